@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key, so keys are recognisable in the Authorization header and in leaks
+const APIKeyPrefix = "wf_"
+
+// Random bytes in an API key's lookup prefix and secret part
+const (
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+)
+
+// APIKeyAuthenticator resolves an API key to the identity it was issued to, returning ErrInvalidCredentials
+// for unknown or revoked keys
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*Identity, error)
+}
+
+// GenerateAPIKey returns a new key in the form wf_<prefix>_<secret>, along with its prefix and hash. Only
+// the prefix and hash are stored, the prefix to find the key and the hash to check it.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	random := make([]byte, apiKeyPrefixBytes+apiKeySecretBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	prefix = hex.EncodeToString(random[:apiKeyPrefixBytes])
+	key = APIKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(random[apiKeyPrefixBytes:])
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// ParseAPIKeyPrefix returns the lookup prefix of an API key, false if key isn't shaped like one
+func ParseAPIKeyPrefix(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != hex.EncodedLen(apiKeyPrefixBytes) || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// HashAPIKey returns the hex SHA-256 of an API key. Keys are long and random, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckAPIKey returns true if key matches the stored hash, in constant time
+func CheckAPIKey(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(hash)) == 1
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPIKeys(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.HasPrefix(key, APIKeyPrefix+prefix+"_") {
+		t.Errorf("Expected the key to start with its prefix, got %s", key)
+	}
+	if parsed, ok := ParseAPIKeyPrefix(key); !ok || parsed != prefix {
+		t.Errorf("Expected prefix %s, got %s", prefix, parsed)
+	}
+	if !CheckAPIKey(key, hash) || CheckAPIKey(key+"x", hash) {
+		t.Errorf("Expected only the key to match its hash")
+	}
+	if strings.Contains(hash, prefix) || len(hash) != 64 {
+		t.Errorf("Expected a hex SHA-256 hash, got %s", hash)
+	}
+
+	for _, malformed := range []string{"", "wf_", "wf_abc_secret", "sk_" + key[3:], APIKeyPrefix + prefix + "_"} {
+		if _, ok := ParseAPIKeyPrefix(malformed); ok {
+			t.Errorf("Expected %q to be rejected", malformed)
+		}
+	}
+
+	other, _, _, _ := GenerateAPIKey()
+	if other == key {
+		t.Errorf("Expected keys to be unique")
+	}
+}
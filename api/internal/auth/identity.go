@@ -0,0 +1,86 @@
+// Package auth authenticates API callers with hashed API keys or JWTs and carries their identity, scopes
+// included, in the request context.
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/models"
+)
+
+// Authentication methods
+const (
+	MethodAPIKey     = "api_key"
+	MethodJWT        = "jwt"
+	MethodAdminToken = "admin_token"
+	// MethodNone identifies callers when authentication is disabled
+	MethodNone = "none"
+)
+
+// JWTSubjectPrefix namespaces the subjects of JWT callers, so a token's sub can't name the admin or an API key
+const JWTSubjectPrefix = "jwt:"
+
+var (
+	// ErrMissingCredentials is returned for requests without an API key or token
+	ErrMissingCredentials = errors.New("missing credentials")
+	// ErrInvalidCredentials is returned for unknown, revoked or expired API keys and invalid tokens
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrUnknownWorkspace is returned when a request selects a workspace that doesn't exist
+	ErrUnknownWorkspace = errors.New("unknown workspace")
+	// ErrWorkspaceDenied is returned when a caller selects a workspace other than its own without the admin scope
+	ErrWorkspaceDenied = errors.New("workspace access denied")
+)
+
+// Identity is the authenticated caller of a request
+type Identity struct {
+	// Subject is who the caller is: jwt:<sub> for JWTs, api-key:<id> for API keys or admin for the admin token
+	Subject string   `json:"subject"`
+	Method  string   `json:"method"`
+	Scopes  []string `json:"scopes"`
+	// KeyID is the ID of the API key, or the kid of the key that signed the JWT
+	KeyID string `json:"keyId,omitempty"`
+	// WorkspaceID is the workspace the caller acts in. Credentials that aren't bound to a workspace leave it
+	// empty until the request's workspace is resolved.
+	WorkspaceID uuid.UUID `json:"workspaceId"`
+}
+
+// HasScope returns true if the caller was granted scope, admins have every scope
+func (id *Identity) HasScope(scope string) bool {
+	if id == nil {
+		return false
+	}
+	return slices.Contains(id.Scopes, scope) || slices.Contains(id.Scopes, models.ScopeAdmin)
+}
+
+// allScopes returns every scope, for callers that can do anything
+func allScopes() []string {
+	return []string{models.ScopeRead, models.ScopeWrite, models.ScopeExecute, models.ScopeAdmin}
+}
+
+// knownScopes returns the scopes that are valid, in order, dropping the rest
+func knownScopes(scopes []string) []string {
+	known := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if models.ValidScopes[scope] && !slices.Contains(known, scope) {
+			known = append(known, scope)
+		}
+	}
+	return known
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context carrying the caller's identity
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the caller's identity, nil for unauthenticated contexts
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supported JWT signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+// DefaultJWTLeeway is the clock skew allowed when checking a token's exp and nbf
+const DefaultJWTLeeway = 30 * time.Second
+
+// JWTConfig configures the claims a JWT must carry besides a valid signature
+type JWTConfig struct {
+	// Issuer is the required iss claim, not checked if empty
+	Issuer string
+	// Audience must be one of the aud claim's values, not checked if empty
+	Audience string
+	Leeway   time.Duration
+}
+
+// JWTVerifier validates HS256 and RS256 JWTs against the keys of a JWKS
+type JWTVerifier struct {
+	keys   []jwk
+	config JWTConfig
+	now    func() time.Time
+}
+
+// jwk is a verification key from a JWKS, either an HMAC secret or an RSA public key
+type jwk struct {
+	id      string
+	alg     string
+	hmacKey []byte
+	rsaKey  *rsa.PublicKey
+}
+
+// jwksDocument is a JSON Web Key Set as defined by RFC 7517
+type jwksDocument struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		Use string `json:"use"`
+		K   string `json:"k"` // oct
+		N   string `json:"n"` // RSA
+		E   string `json:"e"` // RSA
+	} `json:"keys"`
+}
+
+// NewJWTVerifier creates a verifier from a JWKS document. Symmetric keys ("oct") verify HS256 tokens and RSA
+// keys RS256 tokens, keys for other uses or key types are skipped.
+func NewJWTVerifier(jwks []byte, config JWTConfig) (*JWTVerifier, error) {
+	var document jwksDocument
+	if err := json.Unmarshal(jwks, &document); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	verifier := &JWTVerifier{config: config, now: time.Now}
+	for i, key := range document.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		switch key.Kty {
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(key.K, "="))
+			if err != nil || len(secret) == 0 {
+				return nil, fmt.Errorf("JWKS key %d has an invalid k", i)
+			}
+			verifier.keys = append(verifier.keys, jwk{id: key.Kid, alg: AlgHS256, hmacKey: secret})
+		case "RSA":
+			publicKey, err := parseRSAKey(key.N, key.E)
+			if err != nil {
+				return nil, fmt.Errorf("JWKS key %d: %w", i, err)
+			}
+			verifier.keys = append(verifier.keys, jwk{id: key.Kid, alg: AlgRS256, rsaKey: publicKey})
+		default:
+			continue
+		}
+
+		if key.Alg != "" && key.Alg != verifier.keys[len(verifier.keys)-1].alg {
+			return nil, fmt.Errorf("JWKS key %d has kty %s but alg %s", i, key.Kty, key.Alg)
+		}
+	}
+
+	if len(verifier.keys) == 0 {
+		return nil, fmt.Errorf("JWKS has no HS256 or RS256 signing keys")
+	}
+	if verifier.config.Leeway == 0 {
+		verifier.config.Leeway = DefaultJWTLeeway
+	}
+	return verifier, nil
+}
+
+// LoadJWTVerifier creates a verifier from a JWKS file
+func LoadJWTVerifier(path string, config JWTConfig) (*JWTVerifier, error) {
+	jwks, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	return NewJWTVerifier(jwks, config)
+}
+
+// JWTVerifierFromEnv loads the JWKS named by JWT_JWKS_FILE, checking JWT_ISSUER and JWT_AUDIENCE if set.
+// It returns nil if JWT_JWKS_FILE is unset, JWTs are then not accepted.
+func JWTVerifierFromEnv() (*JWTVerifier, error) {
+	path := os.Getenv("JWT_JWKS_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	return LoadJWTVerifier(path, JWTConfig{
+		Issuer:   os.Getenv("JWT_ISSUER"),
+		Audience: os.Getenv("JWT_AUDIENCE"),
+	})
+}
+
+// parseRSAKey builds an RSA public key from its base64url modulus and exponent
+func parseRSAKey(n, e string) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil || len(modulus) == 0 {
+		return nil, fmt.Errorf("invalid RSA modulus")
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, fmt.Errorf("invalid RSA exponent")
+	}
+
+	publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(new(big.Int).SetBytes(exponent).Int64())}
+	if publicKey.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("RSA key must be at least 2048 bits")
+	}
+	return publicKey, nil
+}
+
+// jwtHeader is the JOSE header of a JWT
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims are the claims read from a JWT. Scopes are taken from scope (space separated, RFC 8693) or scp.
+type jwtClaims struct {
+	Subject   string      `json:"sub"`
+	Issuer    string      `json:"iss"`
+	Audience  jwtAudience `json:"aud"`
+	ExpiresAt *float64    `json:"exp"`
+	NotBefore *float64    `json:"nbf"`
+	Scope     string      `json:"scope"`
+	Scp       []string    `json:"scp"`
+	// Workspace is the ID of the workspace the subject acts in, the default workspace if empty
+	Workspace string `json:"workspace"`
+}
+
+// jwtAudience is an aud claim, either a single string or an array of them
+type jwtAudience []string
+
+// UnmarshalJSON accepts a single audience or an array of them
+func (a *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = jwtAudience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("aud must be a string or an array of strings")
+	}
+	*a = multiple
+	return nil
+}
+
+// Verify checks a JWT's signature and claims, returning the identity of its subject. Every failure is an
+// ErrInvalidCredentials.
+func (v *JWTVerifier) Verify(token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: invalid header", ErrInvalidCredentials)
+	}
+	// Only the algorithms configured keys are for are accepted, never "none"
+	if header.Alg != AlgHS256 && header.Alg != AlgRS256 {
+		return nil, fmt.Errorf("%w: unsupported algorithm '%s'", ErrInvalidCredentials, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature encoding", ErrInvalidCredentials)
+	}
+	key, ok := v.verifySignature(header, []byte(parts[0]+"."+parts[1]), signature)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid signature", ErrInvalidCredentials)
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: invalid claims", ErrInvalidCredentials)
+	}
+	if err := v.checkClaims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	scopes := claims.Scp
+	if claims.Scope != "" {
+		scopes = strings.Fields(claims.Scope)
+	}
+
+	var workspaceID uuid.UUID
+	if claims.Workspace != "" {
+		if workspaceID, err = uuid.Parse(claims.Workspace); err != nil {
+			return nil, fmt.Errorf("%w: invalid workspace claim", ErrInvalidCredentials)
+		}
+	}
+
+	return &Identity{
+		Subject:     JWTSubjectPrefix + claims.Subject,
+		Method:      MethodJWT,
+		Scopes:      knownScopes(scopes),
+		KeyID:       key.id,
+		WorkspaceID: workspaceID,
+	}, nil
+}
+
+// verifySignature checks the signature with the keys for the token's algorithm, only the key named by kid
+// if the token has one, returning the key that verified it
+func (v *JWTVerifier) verifySignature(header jwtHeader, signingInput, signature []byte) (*jwk, bool) {
+	digest := sha256.Sum256(signingInput)
+
+	for i := range v.keys {
+		key := &v.keys[i]
+		if key.alg != header.Alg || (header.Kid != "" && key.id != header.Kid) {
+			continue
+		}
+
+		switch key.alg {
+		case AlgHS256:
+			mac := hmac.New(sha256.New, key.hmacKey)
+			mac.Write(signingInput)
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return key, true
+			}
+		case AlgRS256:
+			if rsa.VerifyPKCS1v15(key.rsaKey, crypto.SHA256, digest[:], signature) == nil {
+				return key, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// checkClaims checks the token is current and meant for this API
+func (v *JWTVerifier) checkClaims(claims *jwtClaims) error {
+	now := v.now()
+
+	if claims.Subject == "" {
+		return fmt.Errorf("token has no subject")
+	}
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("token has no expiry")
+	}
+	if now.After(numericDate(*claims.ExpiresAt).Add(v.config.Leeway)) {
+		return fmt.Errorf("token expired")
+	}
+	if claims.NotBefore != nil && now.Before(numericDate(*claims.NotBefore).Add(-v.config.Leeway)) {
+		return fmt.Errorf("token not valid yet")
+	}
+	if v.config.Issuer != "" && claims.Issuer != v.config.Issuer {
+		return fmt.Errorf("unexpected issuer '%s'", claims.Issuer)
+	}
+	if v.config.Audience != "" && !containsAudience(claims.Audience, v.config.Audience) {
+		return fmt.Errorf("token is not for audience '%s'", v.config.Audience)
+	}
+	return nil
+}
+
+// decodeJWTPart decodes a base64url JSON part of a JWT
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// numericDate converts a JWT NumericDate, seconds since the epoch, to a time
+func numericDate(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+func containsAudience(audience jwtAudience, want string) bool {
+	for _, aud := range audience {
+		if aud == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var testHMACSecret = []byte("test-hmac-secret-of-at-least-32-bytes")
+
+// signJWT builds a JWT with the given header and claims, signed with an HMAC secret or RSA private key
+func signJWT(t *testing.T, header, claims map[string]interface{}, key interface{}) string {
+	t.Helper()
+
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("Failed to encode JWT part: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signingInput := encode(header) + "." + encode(claims)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signingInput))
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("Failed to sign JWT: %v", err)
+		}
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func testJWKS(t *testing.T, rsaKey *rsa.PrivateKey) []byte {
+	t.Helper()
+	return []byte(fmt.Sprintf(`{"keys": [
+		{"kty": "oct", "kid": "hmac-1", "alg": "HS256", "k": %q},
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256"}
+	]}`,
+		base64.RawURLEncoding.EncodeToString(testHMACSecret),
+		base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	))
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	verifier, err := NewJWTVerifier(testJWKS(t, rsaKey), JWTConfig{Issuer: "https://issuer.test", Audience: "workflow-api"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	verifier.now = func() time.Time { return now }
+
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		base := map[string]interface{}{
+			"sub":   "alice",
+			"iss":   "https://issuer.test",
+			"aud":   []string{"other", "workflow-api"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": "read execute unknown",
+		}
+		for name, value := range changes {
+			if value == nil {
+				delete(base, name)
+			} else {
+				base[name] = value
+			}
+		}
+		return base
+	}
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	rs256 := map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"HS256", signJWT(t, hs256, claims(nil), testHMACSecret), false},
+		{"RS256", signJWT(t, rs256, claims(nil), rsaKey), false},
+		{"RS256 without kid", signJWT(t, map[string]interface{}{"alg": "RS256"}, claims(nil), rsaKey), false},
+		{"single audience", signJWT(t, hs256, claims(map[string]interface{}{"aud": "workflow-api"}), testHMACSecret), false},
+		{"within leeway", signJWT(t, hs256, claims(map[string]interface{}{"exp": now.Add(-10 * time.Second).Unix()}), testHMACSecret), false},
+		{"expired", signJWT(t, hs256, claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()}), testHMACSecret), true},
+		{"no expiry", signJWT(t, hs256, claims(map[string]interface{}{"exp": nil}), testHMACSecret), true},
+		{"not yet valid", signJWT(t, hs256, claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()}), testHMACSecret), true},
+		{"wrong issuer", signJWT(t, hs256, claims(map[string]interface{}{"iss": "https://evil.test"}), testHMACSecret), true},
+		{"wrong audience", signJWT(t, hs256, claims(map[string]interface{}{"aud": "other"}), testHMACSecret), true},
+		{"no subject", signJWT(t, hs256, claims(map[string]interface{}{"sub": nil}), testHMACSecret), true},
+		{"wrong HMAC secret", signJWT(t, hs256, claims(nil), []byte("another-secret")), true},
+		{"wrong RSA key", signJWT(t, rs256, claims(nil), otherKey), true},
+		{"unknown kid", signJWT(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-2"}, claims(nil), rsaKey), true},
+		{"alg none", signJWT(t, map[string]interface{}{"alg": "none"}, claims(nil), testHMACSecret), true},
+		{"workspace", signJWT(t, hs256, claims(map[string]interface{}{"workspace": "3f0c2d52-8f3e-4e58-9f3c-0d7c1b1a2b3c"}), testHMACSecret), false},
+		{"invalid workspace", signJWT(t, hs256, claims(map[string]interface{}{"workspace": "team-a"}), testHMACSecret), true},
+		{"malformed", "not.a-jwt", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := verifier.Verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if identity.Subject != "jwt:alice" || identity.Method != MethodJWT {
+				t.Errorf("Expected alice authenticated by JWT, got %+v", identity)
+			}
+			if len(identity.Scopes) != 2 || !identity.HasScope("read") || !identity.HasScope("execute") || identity.HasScope("write") {
+				t.Errorf("Expected the read and execute scopes, got %v", identity.Scopes)
+			}
+		})
+	}
+}
+
+func TestJWTVerifier_NamespacesSubjects(t *testing.T) {
+	verifier, err := NewJWTVerifier([]byte(fmt.Sprintf(`{"keys": [{"kty": "oct", "k": %q}]}`,
+		base64.RawURLEncoding.EncodeToString(testHMACSecret))), JWTConfig{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Subjects naming the admin token or an API key stay JWT subjects, sharing none of their roles or keys
+	for _, sub := range []string{"admin", "api-key:" + uuid.NewString(), "anonymous"} {
+		claims := map[string]interface{}{"sub": sub, "exp": time.Now().Add(time.Hour).Unix()}
+		identity, err := verifier.Verify(signJWT(t, map[string]interface{}{"alg": "HS256"}, claims, testHMACSecret))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if identity.Subject != JWTSubjectPrefix+sub {
+			t.Errorf("Expected subject %s, got %s", JWTSubjectPrefix+sub, identity.Subject)
+		}
+	}
+}
+
+func TestJWTVerifier_HMACKeyRejectsRS256(t *testing.T) {
+	// A token claiming RS256 must not be checked against the HMAC key, and vice versa
+	verifier, err := NewJWTVerifier([]byte(fmt.Sprintf(`{"keys": [{"kty": "oct", "k": %q}]}`,
+		base64.RawURLEncoding.EncodeToString(testHMACSecret))), JWTConfig{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	claims := map[string]interface{}{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()}
+	if _, err := verifier.Verify(signJWT(t, map[string]interface{}{"alg": "RS256"}, claims, testHMACSecret)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestNewJWTVerifier_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":        `keys`,
+		"no signing keys": `{"keys": [{"kty": "oct", "use": "enc", "k": "c2VjcmV0"}]}`,
+		"alg mismatch":    `{"keys": [{"kty": "oct", "alg": "RS256", "k": "c2VjcmV0"}]}`,
+		"short RSA key":   `{"keys": [{"kty": "RSA", "n": "AQAB", "e": "AQAB"}]}`,
+	}
+
+	for name, jwks := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewJWTVerifier([]byte(jwks), JWTConfig{}); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
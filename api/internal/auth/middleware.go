@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/tenant"
+)
+
+// Request headers carrying credentials besides Authorization
+const (
+	// APIKeyHeader carries an API key, as an alternative to "Authorization: Bearer wf_..."
+	APIKeyHeader = "X-API-Key"
+	// AdminTokenHeader carries the deployment's ADMIN_TOKEN
+	AdminTokenHeader = "X-Admin-Token"
+	// WorkspaceHeader selects the workspace a request acts in, callers without the admin scope may only
+	// select their own
+	WorkspaceHeader = "X-Workspace-ID"
+)
+
+// WorkspaceChecker checks that workspaces selected with WorkspaceHeader exist
+type WorkspaceChecker interface {
+	WorkspaceExists(ctx context.Context, workspaceID uuid.UUID) (bool, error)
+}
+
+// Config configures how requests are authenticated
+type Config struct {
+	// APIKeys resolves API keys, they are rejected if nil
+	APIKeys APIKeyAuthenticator
+	// JWT verifies bearer tokens that aren't API keys, they are rejected if nil
+	JWT *JWTVerifier
+	// AdminToken authenticates the bootstrap admin with every scope, e.g. to create the first API key.
+	// Not accepted if empty.
+	AdminToken string
+	// Workspaces checks selected workspaces exist, they aren't checked if nil
+	Workspaces WorkspaceChecker
+	// Disabled lets every request through with every scope, for local development only
+	Disabled bool
+}
+
+// ConfigFromEnv reads ADMIN_TOKEN, AUTH_DISABLED and the JWT settings of JWTVerifierFromEnv. API keys
+// are left to the caller.
+func ConfigFromEnv() (Config, error) {
+	verifier, err := JWTVerifierFromEnv()
+	if err != nil {
+		return Config{}, err
+	}
+	return Config{
+		JWT:        verifier,
+		AdminToken: os.Getenv("ADMIN_TOKEN"),
+		Disabled:   os.Getenv("AUTH_DISABLED") == "true",
+	}, nil
+}
+
+// Authenticator authenticates requests with API keys, JWTs or the admin token
+type Authenticator struct {
+	config Config
+}
+
+// NewAuthenticator creates an authenticator
+func NewAuthenticator(config Config) *Authenticator {
+	if config.Disabled {
+		slog.Warn("Authentication is disabled, every request has every scope")
+	}
+	return &Authenticator{config: config}
+}
+
+// Authenticate returns the identity of the request's caller. Credentials are read from X-Admin-Token,
+// X-API-Key or a bearer token in Authorization, which is an API key if it starts with wf_ and a JWT otherwise.
+func (a *Authenticator) Authenticate(r *http.Request) (*Identity, error) {
+	if a.config.Disabled {
+		return &Identity{Subject: "anonymous", Method: MethodNone, Scopes: allScopes()}, nil
+	}
+
+	if token := r.Header.Get(AdminTokenHeader); token != "" {
+		if a.config.AdminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.config.AdminToken)) != 1 {
+			return nil, ErrInvalidCredentials
+		}
+		return &Identity{Subject: "admin", Method: MethodAdminToken, Scopes: allScopes()}, nil
+	}
+
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.authenticateAPIKey(r, key)
+	}
+
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, ErrMissingCredentials
+	}
+	token = strings.TrimSpace(token)
+
+	if strings.HasPrefix(token, APIKeyPrefix) {
+		return a.authenticateAPIKey(r, token)
+	}
+	if a.config.JWT == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return a.config.JWT.Verify(token)
+}
+
+func (a *Authenticator) authenticateAPIKey(r *http.Request, key string) (*Identity, error) {
+	if a.config.APIKeys == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return a.config.APIKeys.AuthenticateAPIKey(r.Context(), key)
+}
+
+// Middleware rejects unauthenticated requests with 401 and puts the caller's identity in the context of the rest
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := a.Authenticate(r)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrMissingCredentials):
+				w.Header().Set("WWW-Authenticate", `Bearer realm="workflow-api"`)
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+			case errors.Is(err, ErrInvalidCredentials):
+				slog.Warn("Rejected credentials", "path", r.URL.Path, "remoteAddr", r.RemoteAddr, "error", err)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="workflow-api", error="invalid_token"`)
+				http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+			default:
+				slog.Error("Failed to authenticate request", "path", r.URL.Path, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		workspaceID, err := a.resolveWorkspace(r, identity)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrUnknownWorkspace):
+				http.Error(w, "Workspace not found", http.StatusNotFound)
+			case errors.Is(err, ErrWorkspaceDenied):
+				slog.Warn("Caller selected another workspace", "path", r.URL.Path, "subject", identity.Subject, "workspaceId", identity.WorkspaceID)
+				http.Error(w, "Workspace access denied", http.StatusForbidden)
+			default:
+				slog.Error("Failed to resolve workspace", "path", r.URL.Path, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}
+			return
+		}
+		scoped := *identity
+		scoped.WorkspaceID = workspaceID
+
+		ctx := tenant.WithWorkspace(WithIdentity(r.Context(), &scoped), workspaceID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// resolveWorkspace returns the workspace a request acts in: the one selected with WorkspaceHeader, else the
+// caller's own, else the default workspace
+func (a *Authenticator) resolveWorkspace(r *http.Request, identity *Identity) (uuid.UUID, error) {
+	own := identity.WorkspaceID
+	if own == uuid.Nil {
+		own = models.DefaultWorkspaceID
+	}
+
+	selected := r.Header.Get(WorkspaceHeader)
+	if selected == "" {
+		return own, nil
+	}
+	workspaceID, err := uuid.Parse(selected)
+	if err != nil {
+		return uuid.Nil, ErrUnknownWorkspace
+	}
+	if workspaceID == own {
+		return own, nil
+	}
+	if !identity.HasScope(models.ScopeAdmin) {
+		return uuid.Nil, ErrWorkspaceDenied
+	}
+
+	if a.config.Workspaces != nil {
+		exists, err := a.config.Workspaces.WorkspaceExists(r.Context(), workspaceID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if !exists {
+			return uuid.Nil, ErrUnknownWorkspace
+		}
+	}
+	return workspaceID, nil
+}
+
+// RequireScope wraps a handler so it is only called for callers granted scope, others get 403
+func RequireScope(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity := IdentityFromContext(r.Context())
+		if !identity.HasScope(scope) {
+			if identity != nil {
+				slog.Warn("Caller lacks scope", "path", r.URL.Path, "subject", identity.Subject, "scope", scope)
+			}
+			http.Error(w, "Missing scope: "+scope, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/tenant"
+)
+
+// mapAPIKeys authenticates the API keys in a map
+type mapAPIKeys map[string]*Identity
+
+func (m mapAPIKeys) AuthenticateAPIKey(ctx context.Context, key string) (*Identity, error) {
+	if key == "wf_broken" {
+		return nil, errors.New("database unavailable")
+	}
+	identity, ok := m[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+	return identity, nil
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	reader := &Identity{Subject: "api-key:1", Method: MethodAPIKey, Scopes: []string{"read"}}
+	authenticator := NewAuthenticator(Config{
+		APIKeys:    mapAPIKeys{"wf_reader": reader},
+		AdminToken: "admin-secret",
+	})
+
+	var seen *Identity
+	handler := authenticator.Middleware(RequireScope("read", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = IdentityFromContext(r.Context())
+	})))
+
+	tests := []struct {
+		name        string
+		header      string
+		value       string
+		wantStatus  int
+		wantSubject string
+	}{
+		{"no credentials", "", "", http.StatusUnauthorized, ""},
+		{"bearer API key", "Authorization", "Bearer wf_reader", http.StatusOK, "api-key:1"},
+		{"API key header", APIKeyHeader, "wf_reader", http.StatusOK, "api-key:1"},
+		{"unknown API key", "Authorization", "Bearer wf_unknown", http.StatusUnauthorized, ""},
+		{"lookup failure", APIKeyHeader, "wf_broken", http.StatusInternalServerError, ""},
+		{"JWT without verifier", "Authorization", "Bearer eyJ.eyJ.sig", http.StatusUnauthorized, ""},
+		{"basic auth", "Authorization", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, ""},
+		{"admin token", AdminTokenHeader, "admin-secret", http.StatusOK, "admin"},
+		{"wrong admin token", AdminTokenHeader, "guess", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/workflows/1", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("Expected a WWW-Authenticate challenge")
+			}
+			if tt.wantSubject != "" && (seen == nil || seen.Subject != tt.wantSubject) {
+				t.Errorf("Expected subject %s in the context, got %+v", tt.wantSubject, seen)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	handler := RequireScope("write", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		identity   *Identity
+		wantStatus int
+	}{
+		{"no identity", nil, http.StatusForbidden},
+		{"missing scope", &Identity{Subject: "alice", Scopes: []string{"read", "execute"}}, http.StatusForbidden},
+		{"granted scope", &Identity{Subject: "alice", Scopes: []string{"write"}}, http.StatusOK},
+		{"admin", &Identity{Subject: "alice", Scopes: []string{"admin"}}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/workflows/1", nil)
+			if tt.identity != nil {
+				req = req.WithContext(WithIdentity(req.Context(), tt.identity))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_Disabled(t *testing.T) {
+	identity, err := NewAuthenticator(Config{Disabled: true}).Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if identity.Method != MethodNone || !identity.HasScope("admin") {
+		t.Errorf("Expected an anonymous caller with every scope, got %+v", identity)
+	}
+}
+
+// workspaceSet checks workspaces exist in a set
+type workspaceSet map[uuid.UUID]bool
+
+func (s workspaceSet) WorkspaceExists(ctx context.Context, workspaceID uuid.UUID) (bool, error) {
+	return s[workspaceID], nil
+}
+
+func TestAuthenticator_Workspace(t *testing.T) {
+	teamA, teamB, missing := uuid.New(), uuid.New(), uuid.New()
+	authenticator := NewAuthenticator(Config{
+		APIKeys: mapAPIKeys{
+			"wf_team_a":  &Identity{Subject: "api-key:1", Method: MethodAPIKey, Scopes: []string{"read"}, WorkspaceID: teamA},
+			"wf_unbound": &Identity{Subject: "api-key:2", Method: MethodAPIKey, Scopes: []string{"read"}},
+		},
+		AdminToken: "admin-secret",
+		Workspaces: workspaceSet{teamA: true, teamB: true},
+	})
+
+	var seen uuid.UUID
+	handler := authenticator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = tenant.WorkspaceFromContext(r.Context())
+		if identity := IdentityFromContext(r.Context()); identity.WorkspaceID != seen {
+			t.Errorf("Expected the identity in workspace %s, got %s", seen, identity.WorkspaceID)
+		}
+	}))
+
+	tests := []struct {
+		name          string
+		apiKey        string
+		workspace     string
+		wantStatus    int
+		wantWorkspace uuid.UUID
+	}{
+		{"bound key", "wf_team_a", "", http.StatusOK, teamA},
+		{"bound key selecting its own", "wf_team_a", teamA.String(), http.StatusOK, teamA},
+		{"bound key selecting another", "wf_team_a", teamB.String(), http.StatusForbidden, uuid.Nil},
+		{"unbound key", "wf_unbound", "", http.StatusOK, models.DefaultWorkspaceID},
+		{"admin", "", "", http.StatusOK, models.DefaultWorkspaceID},
+		{"admin selecting another", "", teamB.String(), http.StatusOK, teamB},
+		{"admin selecting a missing workspace", "", missing.String(), http.StatusNotFound, uuid.Nil},
+		{"malformed workspace", "", "team-b", http.StatusNotFound, uuid.Nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = uuid.Nil
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/workflows", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			} else {
+				req.Header.Set(AdminTokenHeader, "admin-secret")
+			}
+			if tt.workspace != "" {
+				req.Header.Set(WorkspaceHeader, tt.workspace)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if seen != tt.wantWorkspace {
+				t.Errorf("Expected workspace %s, got %s", tt.wantWorkspace, seen)
+			}
+		})
+	}
+}
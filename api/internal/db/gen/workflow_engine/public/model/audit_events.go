@@ -0,0 +1,27 @@
+//
+// Code generated by go-jet DO NOT EDIT.
+//
+// WARNING: Changes to this file may cause incorrect behavior
+// and will be lost if the code is regenerated
+//
+
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type AuditEvents struct {
+	ID           int64 `sql:"primary_key"`
+	WorkspaceID  uuid.UUID
+	OccurredAt   time.Time
+	Actor        string
+	ActorMethod  string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	RequestID    string
+	Before       *string
+	After        *string
+}
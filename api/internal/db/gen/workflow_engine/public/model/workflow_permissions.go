@@ -0,0 +1,22 @@
+//
+// Code generated by go-jet DO NOT EDIT.
+//
+// WARNING: Changes to this file may cause incorrect behavior
+// and will be lost if the code is regenerated
+//
+
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type WorkflowPermissions struct {
+	WorkflowID uuid.UUID `sql:"primary_key"`
+	Subject    string    `sql:"primary_key"`
+	Role       string
+	GrantedBy  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
@@ -13,8 +13,9 @@ import (
 )
 
 type Workflows struct {
-	ID        uuid.UUID `sql:"primary_key"`
-	Name      string
-	CreatedAt *time.Time
-	UpdatedAt *time.Time
+	ID          uuid.UUID `sql:"primary_key"`
+	Name        string
+	CreatedAt   *time.Time
+	UpdatedAt   *time.Time
+	WorkspaceID uuid.UUID
 }
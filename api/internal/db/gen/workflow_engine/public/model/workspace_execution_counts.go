@@ -0,0 +1,19 @@
+//
+// Code generated by go-jet DO NOT EDIT.
+//
+// WARNING: Changes to this file may cause incorrect behavior
+// and will be lost if the code is regenerated
+//
+
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type WorkspaceExecutionCounts struct {
+	WorkspaceID uuid.UUID `sql:"primary_key"`
+	Day         time.Time `sql:"primary_key"`
+	Executions  int32
+}
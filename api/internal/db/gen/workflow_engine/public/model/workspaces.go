@@ -0,0 +1,22 @@
+//
+// Code generated by go-jet DO NOT EDIT.
+//
+// WARNING: Changes to this file may cause incorrect behavior
+// and will be lost if the code is regenerated
+//
+
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Workspaces struct {
+	ID                  uuid.UUID `sql:"primary_key"`
+	Slug                string
+	Name                string
+	DailyExecutionQuota int32
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
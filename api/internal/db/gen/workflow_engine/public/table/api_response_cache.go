@@ -0,0 +1,96 @@
+//
+// Code generated by go-jet DO NOT EDIT.
+//
+// WARNING: Changes to this file may cause incorrect behavior
+// and will be lost if the code is regenerated
+//
+
+package table
+
+import (
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+var APIResponseCache = newAPIResponseCacheTable("public", "api_response_cache", "")
+
+type aPIResponseCacheTable struct {
+	postgres.Table
+
+	// Columns
+	WorkspaceID postgres.ColumnString
+	Key         postgres.ColumnString
+	URL         postgres.ColumnString
+	StatusCode  postgres.ColumnInteger
+	Body        postgres.ColumnString
+	StoredAt    postgres.ColumnTimestampz
+	ExpiresAt   postgres.ColumnTimestampz
+
+	AllColumns     postgres.ColumnList
+	MutableColumns postgres.ColumnList
+	DefaultColumns postgres.ColumnList
+}
+
+type APIResponseCacheTable struct {
+	aPIResponseCacheTable
+
+	EXCLUDED aPIResponseCacheTable
+}
+
+// AS creates new APIResponseCacheTable with assigned alias
+func (a APIResponseCacheTable) AS(alias string) *APIResponseCacheTable {
+	return newAPIResponseCacheTable(a.SchemaName(), a.TableName(), alias)
+}
+
+// Schema creates new APIResponseCacheTable with assigned schema name
+func (a APIResponseCacheTable) FromSchema(schemaName string) *APIResponseCacheTable {
+	return newAPIResponseCacheTable(schemaName, a.TableName(), a.Alias())
+}
+
+// WithPrefix creates new APIResponseCacheTable with assigned table prefix
+func (a APIResponseCacheTable) WithPrefix(prefix string) *APIResponseCacheTable {
+	return newAPIResponseCacheTable(a.SchemaName(), prefix+a.TableName(), a.TableName())
+}
+
+// WithSuffix creates new APIResponseCacheTable with assigned table suffix
+func (a APIResponseCacheTable) WithSuffix(suffix string) *APIResponseCacheTable {
+	return newAPIResponseCacheTable(a.SchemaName(), a.TableName()+suffix, a.TableName())
+}
+
+func newAPIResponseCacheTable(schemaName, tableName, alias string) *APIResponseCacheTable {
+	return &APIResponseCacheTable{
+		aPIResponseCacheTable: newAPIResponseCacheTableImpl(schemaName, tableName, alias),
+		EXCLUDED:              newAPIResponseCacheTableImpl("", "excluded", ""),
+	}
+}
+
+func newAPIResponseCacheTableImpl(schemaName, tableName, alias string) aPIResponseCacheTable {
+	var (
+		WorkspaceIDColumn = postgres.StringColumn("workspace_id")
+		KeyColumn         = postgres.StringColumn("key")
+		URLColumn         = postgres.StringColumn("url")
+		StatusCodeColumn  = postgres.IntegerColumn("status_code")
+		BodyColumn        = postgres.StringColumn("body")
+		StoredAtColumn    = postgres.TimestampzColumn("stored_at")
+		ExpiresAtColumn   = postgres.TimestampzColumn("expires_at")
+		allColumns        = postgres.ColumnList{WorkspaceIDColumn, KeyColumn, URLColumn, StatusCodeColumn, BodyColumn, StoredAtColumn, ExpiresAtColumn}
+		mutableColumns    = postgres.ColumnList{URLColumn, StatusCodeColumn, BodyColumn, StoredAtColumn, ExpiresAtColumn}
+		defaultColumns    = postgres.ColumnList{StoredAtColumn}
+	)
+
+	return aPIResponseCacheTable{
+		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
+
+		//Columns
+		WorkspaceID: WorkspaceIDColumn,
+		Key:         KeyColumn,
+		URL:         URLColumn,
+		StatusCode:  StatusCodeColumn,
+		Body:        BodyColumn,
+		StoredAt:    StoredAtColumn,
+		ExpiresAt:   ExpiresAtColumn,
+
+		AllColumns:     allColumns,
+		MutableColumns: mutableColumns,
+		DefaultColumns: defaultColumns,
+	}
+}
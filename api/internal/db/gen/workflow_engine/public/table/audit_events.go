@@ -0,0 +1,108 @@
+//
+// Code generated by go-jet DO NOT EDIT.
+//
+// WARNING: Changes to this file may cause incorrect behavior
+// and will be lost if the code is regenerated
+//
+
+package table
+
+import (
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+var AuditEvents = newAuditEventsTable("public", "audit_events", "")
+
+type auditEventsTable struct {
+	postgres.Table
+
+	// Columns
+	ID           postgres.ColumnInteger
+	WorkspaceID  postgres.ColumnString
+	OccurredAt   postgres.ColumnTimestampz
+	Actor        postgres.ColumnString
+	ActorMethod  postgres.ColumnString
+	Action       postgres.ColumnString
+	ResourceType postgres.ColumnString
+	ResourceID   postgres.ColumnString
+	RequestID    postgres.ColumnString
+	Before       postgres.ColumnString
+	After        postgres.ColumnString
+
+	AllColumns     postgres.ColumnList
+	MutableColumns postgres.ColumnList
+	DefaultColumns postgres.ColumnList
+}
+
+type AuditEventsTable struct {
+	auditEventsTable
+
+	EXCLUDED auditEventsTable
+}
+
+// AS creates new AuditEventsTable with assigned alias
+func (a AuditEventsTable) AS(alias string) *AuditEventsTable {
+	return newAuditEventsTable(a.SchemaName(), a.TableName(), alias)
+}
+
+// Schema creates new AuditEventsTable with assigned schema name
+func (a AuditEventsTable) FromSchema(schemaName string) *AuditEventsTable {
+	return newAuditEventsTable(schemaName, a.TableName(), a.Alias())
+}
+
+// WithPrefix creates new AuditEventsTable with assigned table prefix
+func (a AuditEventsTable) WithPrefix(prefix string) *AuditEventsTable {
+	return newAuditEventsTable(a.SchemaName(), prefix+a.TableName(), a.TableName())
+}
+
+// WithSuffix creates new AuditEventsTable with assigned table suffix
+func (a AuditEventsTable) WithSuffix(suffix string) *AuditEventsTable {
+	return newAuditEventsTable(a.SchemaName(), a.TableName()+suffix, a.TableName())
+}
+
+func newAuditEventsTable(schemaName, tableName, alias string) *AuditEventsTable {
+	return &AuditEventsTable{
+		auditEventsTable: newAuditEventsTableImpl(schemaName, tableName, alias),
+		EXCLUDED:         newAuditEventsTableImpl("", "excluded", ""),
+	}
+}
+
+func newAuditEventsTableImpl(schemaName, tableName, alias string) auditEventsTable {
+	var (
+		IDColumn           = postgres.IntegerColumn("id")
+		WorkspaceIDColumn  = postgres.StringColumn("workspace_id")
+		OccurredAtColumn   = postgres.TimestampzColumn("occurred_at")
+		ActorColumn        = postgres.StringColumn("actor")
+		ActorMethodColumn  = postgres.StringColumn("actor_method")
+		ActionColumn       = postgres.StringColumn("action")
+		ResourceTypeColumn = postgres.StringColumn("resource_type")
+		ResourceIDColumn   = postgres.StringColumn("resource_id")
+		RequestIDColumn    = postgres.StringColumn("request_id")
+		BeforeColumn       = postgres.StringColumn("before")
+		AfterColumn        = postgres.StringColumn("after")
+		allColumns         = postgres.ColumnList{IDColumn, WorkspaceIDColumn, OccurredAtColumn, ActorColumn, ActorMethodColumn, ActionColumn, ResourceTypeColumn, ResourceIDColumn, RequestIDColumn, BeforeColumn, AfterColumn}
+		mutableColumns     = postgres.ColumnList{WorkspaceIDColumn, OccurredAtColumn, ActorColumn, ActorMethodColumn, ActionColumn, ResourceTypeColumn, ResourceIDColumn, RequestIDColumn, BeforeColumn, AfterColumn}
+		defaultColumns     = postgres.ColumnList{IDColumn, OccurredAtColumn, ActorMethodColumn, ResourceIDColumn, RequestIDColumn}
+	)
+
+	return auditEventsTable{
+		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
+
+		//Columns
+		ID:           IDColumn,
+		WorkspaceID:  WorkspaceIDColumn,
+		OccurredAt:   OccurredAtColumn,
+		Actor:        ActorColumn,
+		ActorMethod:  ActorMethodColumn,
+		Action:       ActionColumn,
+		ResourceType: ResourceTypeColumn,
+		ResourceID:   ResourceIDColumn,
+		RequestID:    RequestIDColumn,
+		Before:       BeforeColumn,
+		After:        AfterColumn,
+
+		AllColumns:     allColumns,
+		MutableColumns: mutableColumns,
+		DefaultColumns: defaultColumns,
+	}
+}
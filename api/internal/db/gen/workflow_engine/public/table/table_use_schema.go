@@ -10,8 +10,16 @@ package table
 // UseSchema sets a new schema name for all generated table SQL builder types. It is recommended to invoke
 // this method only once at the beginning of the program.
 func UseSchema(schema string) {
+	APIKeys = APIKeys.FromSchema(schema)
+	APIResponseCache = APIResponseCache.FromSchema(schema)
+	AuditEvents = AuditEvents.FromSchema(schema)
 	Edges = Edges.FromSchema(schema)
+	ExecutionIdempotencyKeys = ExecutionIdempotencyKeys.FromSchema(schema)
 	Nodes = Nodes.FromSchema(schema)
 	SchemaMigrations = SchemaMigrations.FromSchema(schema)
+	Secrets = Secrets.FromSchema(schema)
+	WorkflowPermissions = WorkflowPermissions.FromSchema(schema)
 	Workflows = Workflows.FromSchema(schema)
+	WorkspaceExecutionCounts = WorkspaceExecutionCounts.FromSchema(schema)
+	Workspaces = Workspaces.FromSchema(schema)
 }
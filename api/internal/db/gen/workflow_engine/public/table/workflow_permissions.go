@@ -0,0 +1,93 @@
+//
+// Code generated by go-jet DO NOT EDIT.
+//
+// WARNING: Changes to this file may cause incorrect behavior
+// and will be lost if the code is regenerated
+//
+
+package table
+
+import (
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+var WorkflowPermissions = newWorkflowPermissionsTable("public", "workflow_permissions", "")
+
+type workflowPermissionsTable struct {
+	postgres.Table
+
+	// Columns
+	WorkflowID postgres.ColumnString
+	Subject    postgres.ColumnString
+	Role       postgres.ColumnString
+	GrantedBy  postgres.ColumnString
+	CreatedAt  postgres.ColumnTimestampz
+	UpdatedAt  postgres.ColumnTimestampz
+
+	AllColumns     postgres.ColumnList
+	MutableColumns postgres.ColumnList
+	DefaultColumns postgres.ColumnList
+}
+
+type WorkflowPermissionsTable struct {
+	workflowPermissionsTable
+
+	EXCLUDED workflowPermissionsTable
+}
+
+// AS creates new WorkflowPermissionsTable with assigned alias
+func (a WorkflowPermissionsTable) AS(alias string) *WorkflowPermissionsTable {
+	return newWorkflowPermissionsTable(a.SchemaName(), a.TableName(), alias)
+}
+
+// Schema creates new WorkflowPermissionsTable with assigned schema name
+func (a WorkflowPermissionsTable) FromSchema(schemaName string) *WorkflowPermissionsTable {
+	return newWorkflowPermissionsTable(schemaName, a.TableName(), a.Alias())
+}
+
+// WithPrefix creates new WorkflowPermissionsTable with assigned table prefix
+func (a WorkflowPermissionsTable) WithPrefix(prefix string) *WorkflowPermissionsTable {
+	return newWorkflowPermissionsTable(a.SchemaName(), prefix+a.TableName(), a.TableName())
+}
+
+// WithSuffix creates new WorkflowPermissionsTable with assigned table suffix
+func (a WorkflowPermissionsTable) WithSuffix(suffix string) *WorkflowPermissionsTable {
+	return newWorkflowPermissionsTable(a.SchemaName(), a.TableName()+suffix, a.TableName())
+}
+
+func newWorkflowPermissionsTable(schemaName, tableName, alias string) *WorkflowPermissionsTable {
+	return &WorkflowPermissionsTable{
+		workflowPermissionsTable: newWorkflowPermissionsTableImpl(schemaName, tableName, alias),
+		EXCLUDED:                 newWorkflowPermissionsTableImpl("", "excluded", ""),
+	}
+}
+
+func newWorkflowPermissionsTableImpl(schemaName, tableName, alias string) workflowPermissionsTable {
+	var (
+		WorkflowIDColumn = postgres.StringColumn("workflow_id")
+		SubjectColumn    = postgres.StringColumn("subject")
+		RoleColumn       = postgres.StringColumn("role")
+		GrantedByColumn  = postgres.StringColumn("granted_by")
+		CreatedAtColumn  = postgres.TimestampzColumn("created_at")
+		UpdatedAtColumn  = postgres.TimestampzColumn("updated_at")
+		allColumns       = postgres.ColumnList{WorkflowIDColumn, SubjectColumn, RoleColumn, GrantedByColumn, CreatedAtColumn, UpdatedAtColumn}
+		mutableColumns   = postgres.ColumnList{RoleColumn, GrantedByColumn, CreatedAtColumn, UpdatedAtColumn}
+		defaultColumns   = postgres.ColumnList{GrantedByColumn, CreatedAtColumn, UpdatedAtColumn}
+	)
+
+	return workflowPermissionsTable{
+		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
+
+		//Columns
+		WorkflowID: WorkflowIDColumn,
+		Subject:    SubjectColumn,
+		Role:       RoleColumn,
+		GrantedBy:  GrantedByColumn,
+		CreatedAt:  CreatedAtColumn,
+		UpdatedAt:  UpdatedAtColumn,
+
+		AllColumns:     allColumns,
+		MutableColumns: mutableColumns,
+		DefaultColumns: defaultColumns,
+	}
+}
@@ -0,0 +1,84 @@
+//
+// Code generated by go-jet DO NOT EDIT.
+//
+// WARNING: Changes to this file may cause incorrect behavior
+// and will be lost if the code is regenerated
+//
+
+package table
+
+import (
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+var WorkspaceExecutionCounts = newWorkspaceExecutionCountsTable("public", "workspace_execution_counts", "")
+
+type workspaceExecutionCountsTable struct {
+	postgres.Table
+
+	// Columns
+	WorkspaceID postgres.ColumnString
+	Day         postgres.ColumnDate
+	Executions  postgres.ColumnInteger
+
+	AllColumns     postgres.ColumnList
+	MutableColumns postgres.ColumnList
+	DefaultColumns postgres.ColumnList
+}
+
+type WorkspaceExecutionCountsTable struct {
+	workspaceExecutionCountsTable
+
+	EXCLUDED workspaceExecutionCountsTable
+}
+
+// AS creates new WorkspaceExecutionCountsTable with assigned alias
+func (a WorkspaceExecutionCountsTable) AS(alias string) *WorkspaceExecutionCountsTable {
+	return newWorkspaceExecutionCountsTable(a.SchemaName(), a.TableName(), alias)
+}
+
+// Schema creates new WorkspaceExecutionCountsTable with assigned schema name
+func (a WorkspaceExecutionCountsTable) FromSchema(schemaName string) *WorkspaceExecutionCountsTable {
+	return newWorkspaceExecutionCountsTable(schemaName, a.TableName(), a.Alias())
+}
+
+// WithPrefix creates new WorkspaceExecutionCountsTable with assigned table prefix
+func (a WorkspaceExecutionCountsTable) WithPrefix(prefix string) *WorkspaceExecutionCountsTable {
+	return newWorkspaceExecutionCountsTable(a.SchemaName(), prefix+a.TableName(), a.TableName())
+}
+
+// WithSuffix creates new WorkspaceExecutionCountsTable with assigned table suffix
+func (a WorkspaceExecutionCountsTable) WithSuffix(suffix string) *WorkspaceExecutionCountsTable {
+	return newWorkspaceExecutionCountsTable(a.SchemaName(), a.TableName()+suffix, a.TableName())
+}
+
+func newWorkspaceExecutionCountsTable(schemaName, tableName, alias string) *WorkspaceExecutionCountsTable {
+	return &WorkspaceExecutionCountsTable{
+		workspaceExecutionCountsTable: newWorkspaceExecutionCountsTableImpl(schemaName, tableName, alias),
+		EXCLUDED:                      newWorkspaceExecutionCountsTableImpl("", "excluded", ""),
+	}
+}
+
+func newWorkspaceExecutionCountsTableImpl(schemaName, tableName, alias string) workspaceExecutionCountsTable {
+	var (
+		WorkspaceIDColumn = postgres.StringColumn("workspace_id")
+		DayColumn         = postgres.DateColumn("day")
+		ExecutionsColumn  = postgres.IntegerColumn("executions")
+		allColumns        = postgres.ColumnList{WorkspaceIDColumn, DayColumn, ExecutionsColumn}
+		mutableColumns    = postgres.ColumnList{ExecutionsColumn}
+		defaultColumns    = postgres.ColumnList{ExecutionsColumn}
+	)
+
+	return workspaceExecutionCountsTable{
+		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
+
+		//Columns
+		WorkspaceID: WorkspaceIDColumn,
+		Day:         DayColumn,
+		Executions:  ExecutionsColumn,
+
+		AllColumns:     allColumns,
+		MutableColumns: mutableColumns,
+		DefaultColumns: defaultColumns,
+	}
+}
@@ -0,0 +1,93 @@
+//
+// Code generated by go-jet DO NOT EDIT.
+//
+// WARNING: Changes to this file may cause incorrect behavior
+// and will be lost if the code is regenerated
+//
+
+package table
+
+import (
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+var Workspaces = newWorkspacesTable("public", "workspaces", "")
+
+type workspacesTable struct {
+	postgres.Table
+
+	// Columns
+	ID                  postgres.ColumnString
+	Slug                postgres.ColumnString
+	Name                postgres.ColumnString
+	DailyExecutionQuota postgres.ColumnInteger
+	CreatedAt           postgres.ColumnTimestampz
+	UpdatedAt           postgres.ColumnTimestampz
+
+	AllColumns     postgres.ColumnList
+	MutableColumns postgres.ColumnList
+	DefaultColumns postgres.ColumnList
+}
+
+type WorkspacesTable struct {
+	workspacesTable
+
+	EXCLUDED workspacesTable
+}
+
+// AS creates new WorkspacesTable with assigned alias
+func (a WorkspacesTable) AS(alias string) *WorkspacesTable {
+	return newWorkspacesTable(a.SchemaName(), a.TableName(), alias)
+}
+
+// Schema creates new WorkspacesTable with assigned schema name
+func (a WorkspacesTable) FromSchema(schemaName string) *WorkspacesTable {
+	return newWorkspacesTable(schemaName, a.TableName(), a.Alias())
+}
+
+// WithPrefix creates new WorkspacesTable with assigned table prefix
+func (a WorkspacesTable) WithPrefix(prefix string) *WorkspacesTable {
+	return newWorkspacesTable(a.SchemaName(), prefix+a.TableName(), a.TableName())
+}
+
+// WithSuffix creates new WorkspacesTable with assigned table suffix
+func (a WorkspacesTable) WithSuffix(suffix string) *WorkspacesTable {
+	return newWorkspacesTable(a.SchemaName(), a.TableName()+suffix, a.TableName())
+}
+
+func newWorkspacesTable(schemaName, tableName, alias string) *WorkspacesTable {
+	return &WorkspacesTable{
+		workspacesTable: newWorkspacesTableImpl(schemaName, tableName, alias),
+		EXCLUDED:        newWorkspacesTableImpl("", "excluded", ""),
+	}
+}
+
+func newWorkspacesTableImpl(schemaName, tableName, alias string) workspacesTable {
+	var (
+		IDColumn                  = postgres.StringColumn("id")
+		SlugColumn                = postgres.StringColumn("slug")
+		NameColumn                = postgres.StringColumn("name")
+		DailyExecutionQuotaColumn = postgres.IntegerColumn("daily_execution_quota")
+		CreatedAtColumn           = postgres.TimestampzColumn("created_at")
+		UpdatedAtColumn           = postgres.TimestampzColumn("updated_at")
+		allColumns                = postgres.ColumnList{IDColumn, SlugColumn, NameColumn, DailyExecutionQuotaColumn, CreatedAtColumn, UpdatedAtColumn}
+		mutableColumns            = postgres.ColumnList{SlugColumn, NameColumn, DailyExecutionQuotaColumn, CreatedAtColumn, UpdatedAtColumn}
+		defaultColumns            = postgres.ColumnList{IDColumn, DailyExecutionQuotaColumn, CreatedAtColumn, UpdatedAtColumn}
+	)
+
+	return workspacesTable{
+		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
+
+		//Columns
+		ID:                  IDColumn,
+		Slug:                SlugColumn,
+		Name:                NameColumn,
+		DailyExecutionQuota: DailyExecutionQuotaColumn,
+		CreatedAt:           CreatedAtColumn,
+		UpdatedAt:           UpdatedAtColumn,
+
+		AllColumns:     allColumns,
+		MutableColumns: mutableColumns,
+		DefaultColumns: defaultColumns,
+	}
+}
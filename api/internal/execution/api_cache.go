@@ -0,0 +1,303 @@
+package execution
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/tenant"
+)
+
+// Cache statuses reported in APICallInfo.CacheStatus and the integration step output
+const (
+	CacheStatusHit    = "hit"
+	CacheStatusMiss   = "miss"
+	CacheStatusBypass = "bypass" // caching was disabled for the call
+)
+
+// DefaultAPICacheTTL is how long responses are cached when the node doesn't set its own TTL
+const DefaultAPICacheTTL = 5 * time.Minute
+
+// DefaultAPICacheSize is the number of responses the in-memory cache holds
+const DefaultAPICacheSize = 1000
+
+// ResponseCache stores API responses by cache key
+type ResponseCache interface {
+	// Get returns the response stored under key, nil if there is none or it has expired
+	Get(ctx context.Context, key string) (*models.CachedAPIResponse, error)
+	Set(ctx context.Context, key string, response *models.CachedAPIResponse) error
+}
+
+// CachingAPIClient wraps an API client, serving repeated calls from a response cache. Responses are cached
+// for the TTL of the call's APICallInfo, or the client's default, capped by the response's Cache-Control.
+type CachingAPIClient struct {
+	apiClient  APIClient
+	cache      ResponseCache
+	defaultTTL time.Duration
+	now        func() time.Time
+}
+
+// NewCachingAPIClient creates a caching API client
+func NewCachingAPIClient(apiClient APIClient, cache ResponseCache, defaultTTL time.Duration) *CachingAPIClient {
+	return &CachingAPIClient{
+		apiClient:  apiClient,
+		cache:      cache,
+		defaultTTL: defaultTTL,
+		now:        time.Now,
+	}
+}
+
+// CallAPI implements APIClient
+func (c *CachingAPIClient) CallAPI(ctx context.Context, url string) (map[string]interface{}, error) {
+	info := apiCallInfoFromContext(ctx)
+	ttl := c.defaultTTL
+	if info.CacheTTL != nil {
+		ttl = *info.CacheTTL
+	}
+	if ttl <= 0 {
+		info.CacheStatus = CacheStatusBypass
+		return c.apiClient.CallAPI(ctx, url)
+	}
+
+	workspaceID, _ := tenant.WorkspaceFromContext(ctx)
+	key := apiCacheKey(workspaceID, url, APIHeadersFromContext(ctx))
+	redactor := RedactorFromContext(ctx)
+	// A failing cache must not fail the call, it's only slower
+	cached, err := c.cache.Get(ctx, key)
+	if err != nil {
+		slog.Warn("Failed to read API response cache", "url", redactor.Redact(url), "error", err)
+	}
+	if cached != nil && cached.Fresh(c.now()) {
+		info.CacheStatus = CacheStatusHit
+		// Nodes may change the response they're handed, which mustn't change it for later hits
+		return copyResponseBody(cached.Body), nil
+	}
+	info.CacheStatus = CacheStatusMiss
+
+	response, err := fetchAPI(ctx, c.apiClient, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl = cacheControlTTL(response.Header, ttl); ttl > 0 {
+		now := c.now()
+		err := c.cache.Set(ctx, key, &models.CachedAPIResponse{
+			// The cache may be persisted, keep the secrets of the call out of it
+			URL:        redactor.Redact(response.URL),
+			StatusCode: response.StatusCode,
+			Body:       copyResponseBody(response.Body),
+			StoredAt:   now,
+			ExpiresAt:  now.Add(ttl),
+		})
+		if err != nil {
+			slog.Warn("Failed to write API response cache", "url", redactor.Redact(url), "error", err)
+		}
+	}
+
+	return response.Body, nil
+}
+
+// apiCacheKey derives the cache key of a call from the workspace making it, its URL and request headers,
+// hashed so secrets in either aren't stored in the clear. Workspaces never share cached responses.
+func apiCacheKey(workspaceID uuid.UUID, url string, header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, http.CanonicalHeaderKey(name))
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	hash.Write([]byte(workspaceID.String() + "\n" + url))
+	for _, name := range names {
+		hash.Write([]byte("\n" + name + ": " + strings.Join(header.Values(name), ", ")))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// copyResponseBody deep copies a decoded JSON response body, so the copy shares no maps or slices with it
+func copyResponseBody(body map[string]interface{}) map[string]interface{} {
+	if body == nil {
+		return nil
+	}
+	return copyJSONValue(body).(map[string]interface{})
+}
+
+func copyJSONValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			copied[key] = copyJSONValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, item := range value {
+			copied[i] = copyJSONValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// cacheControlTTL caps ttl by the response's Cache-Control header. Responses marked no-store, no-cache or
+// private aren't cached at all as the cache is shared and doesn't revalidate.
+func cacheControlTTL(header http.Header, ttl time.Duration) time.Duration {
+	var maxAge, sharedMaxAge *time.Duration
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache", "private":
+			return 0
+		case "max-age", "s-maxage":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil {
+				continue
+			}
+			age := time.Duration(seconds) * time.Second
+			if strings.EqualFold(name, "s-maxage") {
+				sharedMaxAge = &age
+			} else {
+				maxAge = &age
+			}
+		}
+	}
+
+	// s-maxage is meant for shared caches like this one and wins over max-age
+	if sharedMaxAge != nil {
+		maxAge = sharedMaxAge
+	}
+	if maxAge != nil && *maxAge < ttl {
+		return *maxAge
+	}
+	return ttl
+}
+
+// LRUResponseCache is an in-memory response cache evicting the least recently used response when full
+type LRUResponseCache struct {
+	capacity int
+	now      func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // front is the most recently used
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key      string
+	response *models.CachedAPIResponse
+}
+
+// NewLRUResponseCache creates an in-memory response cache holding up to capacity responses
+func NewLRUResponseCache(capacity int) *LRUResponseCache {
+	return &LRUResponseCache{
+		capacity: capacity,
+		now:      time.Now,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get implements ResponseCache
+func (c *LRUResponseCache) Get(ctx context.Context, key string) (*models.CachedAPIResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := element.Value.(*lruEntry)
+	if !entry.response.Fresh(c.now()) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, nil
+	}
+
+	c.order.MoveToFront(element)
+	return entry.response, nil
+}
+
+// Set implements ResponseCache
+func (c *LRUResponseCache) Set(ctx context.Context, key string, response *models.CachedAPIResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*lruEntry).response = response
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, response: response})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+// Len returns the number of responses held, expired ones included until they are next read
+func (c *LRUResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// TieredResponseCache layers response caches, typically a local in-memory cache in front of a shared one.
+// Reads try each tier in turn, skipping tiers that fail, and copy hits into the tiers before it. Writes go to
+// every tier.
+type TieredResponseCache struct {
+	tiers []ResponseCache
+}
+
+// NewTieredResponseCache creates a response cache over the given tiers, fastest first
+func NewTieredResponseCache(tiers ...ResponseCache) *TieredResponseCache {
+	return &TieredResponseCache{tiers: tiers}
+}
+
+// Get implements ResponseCache
+func (c *TieredResponseCache) Get(ctx context.Context, key string) (*models.CachedAPIResponse, error) {
+	for i, tier := range c.tiers {
+		response, err := tier.Get(ctx, key)
+		if err != nil {
+			// A failing tier is a miss, the next tier or the origin can still serve the call
+			slog.WarnContext(ctx, "Failed to read API response cache tier", "tier", i, "error", err)
+			continue
+		}
+		if response == nil {
+			continue
+		}
+
+		for _, faster := range c.tiers[:i] {
+			if err := faster.Set(ctx, key, response); err != nil {
+				slog.WarnContext(ctx, "Failed to copy cached API response into a faster tier", "error", err)
+			}
+		}
+		return response, nil
+	}
+	return nil, nil
+}
+
+// Set implements ResponseCache
+func (c *TieredResponseCache) Set(ctx context.Context, key string, response *models.CachedAPIResponse) error {
+	for _, tier := range c.tiers {
+		if err := tier.Set(ctx, key, response); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/tenant"
+)
+
+// newCountingServer serves a JSON body counting its requests, with the given Cache-Control header
+func newCountingServer(t *testing.T, cacheControl string) (*httptest.Server, *int) {
+	t.Helper()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		fmt.Fprintf(w, `{"request": %d, "language": %q}`, requests, r.Header.Get("Accept-Language"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestCachingAPIClient_HitAndMiss(t *testing.T) {
+	server, requests := newCountingServer(t, "")
+	cache := NewLRUResponseCache(10)
+	client := NewCachingAPIClient(NewHTTPAPIClient(), cache, time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	client.now = func() time.Time { return now }
+	cache.now = client.now
+
+	call := func(ctx context.Context, url string) (map[string]interface{}, string) {
+		t.Helper()
+		info := &APICallInfo{}
+		body, err := client.CallAPI(WithAPICallInfo(ctx, info), url)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return body, info.CacheStatus
+	}
+
+	if _, status := call(context.Background(), server.URL+"/weather?lat=1"); status != CacheStatusMiss {
+		t.Errorf("Expected a miss, got %s", status)
+	}
+	body, status := call(context.Background(), server.URL+"/weather?lat=1")
+	if status != CacheStatusHit || body["request"] != 1.0 {
+		t.Errorf("Expected a hit serving the first response, got %s %v", status, body)
+	}
+
+	// The URL and request headers key the cache
+	if _, status := call(context.Background(), server.URL+"/weather?lat=2"); status != CacheStatusMiss {
+		t.Errorf("Expected another URL to miss, got %s", status)
+	}
+	spanish := WithAPIHeaders(context.Background(), http.Header{"Accept-Language": {"es"}})
+	if body, status := call(spanish, server.URL+"/weather?lat=1"); status != CacheStatusMiss || body["language"] != "es" {
+		t.Errorf("Expected other headers to miss and be sent, got %s %v", status, body)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, status := call(context.Background(), server.URL+"/weather?lat=1"); status != CacheStatusMiss {
+		t.Errorf("Expected an expired response to miss, got %s", status)
+	}
+	if *requests != 4 {
+		t.Errorf("Expected 4 requests to reach the server, got %d", *requests)
+	}
+}
+
+func TestCachingAPIClient_WorkspacesDontShareResponses(t *testing.T) {
+	server, requests := newCountingServer(t, "")
+	client := NewCachingAPIClient(NewHTTPAPIClient(), NewLRUResponseCache(10), time.Minute)
+
+	first := tenant.WithWorkspace(context.Background(), uuid.New())
+	second := tenant.WithWorkspace(context.Background(), uuid.New())
+	for _, ctx := range []context.Context{first, second, first} {
+		if _, err := client.CallAPI(ctx, server.URL); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if *requests != 2 {
+		t.Errorf("Expected each workspace to fetch its own response, got %d requests", *requests)
+	}
+}
+
+func TestCachingAPIClient_HitsDontShareBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"current": {"temperature": 21}, "hourly": [1, 2]}`)
+	}))
+	defer server.Close()
+	client := NewCachingAPIClient(NewHTTPAPIClient(), NewLRUResponseCache(10), time.Minute)
+
+	// A node changing the response it was handed, on the miss and on a hit
+	for i := 0; i < 2; i++ {
+		body, err := client.CallAPI(context.Background(), server.URL)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		body["current"].(map[string]interface{})["temperature"] = 99.0
+		body["hourly"].([]interface{})[0] = 99.0
+	}
+
+	body, err := client.CallAPI(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := body["current"].(map[string]interface{})["temperature"]; got != 21.0 {
+		t.Errorf("Expected the cached temperature 21, got %v", got)
+	}
+	if got := body["hourly"].([]interface{})[0]; got != 1.0 {
+		t.Errorf("Expected the cached hourly value 1, got %v", got)
+	}
+}
+
+func TestCachingAPIClient_PerCallTTL(t *testing.T) {
+	server, requests := newCountingServer(t, "")
+	client := NewCachingAPIClient(NewHTTPAPIClient(), NewLRUResponseCache(10), time.Minute)
+
+	disabled := time.Duration(0)
+	for i := 0; i < 2; i++ {
+		info := &APICallInfo{CacheTTL: &disabled}
+		if _, err := client.CallAPI(WithAPICallInfo(context.Background(), info), server.URL); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if info.CacheStatus != CacheStatusBypass {
+			t.Errorf("Expected a bypass, got %s", info.CacheStatus)
+		}
+	}
+	if *requests != 2 {
+		t.Errorf("Expected uncached calls to reach the server, got %d requests", *requests)
+	}
+}
+
+func TestCachingAPIClient_CacheControl(t *testing.T) {
+	tests := []struct {
+		cacheControl string
+		wantCached   bool
+	}{
+		{cacheControl: "", wantCached: true},
+		{cacheControl: "public, max-age=3600", wantCached: true},
+		{cacheControl: "max-age=0", wantCached: false},
+		{cacheControl: "no-store", wantCached: false},
+		{cacheControl: "no-cache", wantCached: false},
+		{cacheControl: "private, max-age=600", wantCached: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cacheControl, func(t *testing.T) {
+			server, requests := newCountingServer(t, tt.cacheControl)
+			client := NewCachingAPIClient(NewHTTPAPIClient(), NewLRUResponseCache(10), time.Minute)
+
+			for i := 0; i < 2; i++ {
+				if _, err := client.CallAPI(context.Background(), server.URL); err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+			}
+			if cached := *requests == 1; cached != tt.wantCached {
+				t.Errorf("Expected cached %v, got %d requests", tt.wantCached, *requests)
+			}
+		})
+	}
+}
+
+func TestCacheControlTTL(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":                          time.Hour,
+		"max-age=60":                time.Minute,
+		"max-age=7200":              time.Hour, // the node's TTL is the upper bound
+		"max-age=600, s-maxage=120": 2 * time.Minute,
+		"max-age=abc":               time.Hour,
+		"No-Store":                  0,
+	}
+
+	for cacheControl, want := range tests {
+		header := http.Header{}
+		header.Set("Cache-Control", cacheControl)
+		if got := cacheControlTTL(header, time.Hour); got != want {
+			t.Errorf("cacheControlTTL(%q) = %v, want %v", cacheControl, got, want)
+		}
+	}
+}
+
+func TestLRUResponseCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUResponseCache(2)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	response := func(url string) *models.CachedAPIResponse {
+		return &models.CachedAPIResponse{URL: url, ExpiresAt: now.Add(time.Minute)}
+	}
+
+	_ = cache.Set(ctx, "a", response("a"))
+	_ = cache.Set(ctx, "b", response("b"))
+	// Reading a makes b the least recently used
+	if got, _ := cache.Get(ctx, "a"); got == nil {
+		t.Fatal("Expected a to be cached")
+	}
+	_ = cache.Set(ctx, "c", response("c"))
+
+	if got, _ := cache.Get(ctx, "b"); got != nil {
+		t.Error("Expected b to be evicted")
+	}
+	if got, _ := cache.Get(ctx, "a"); got == nil {
+		t.Error("Expected a to be kept")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if got, _ := cache.Get(ctx, "c"); got != nil {
+		t.Error("Expected an expired response not to be returned")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Expected the expired response to be removed, %d left", cache.Len())
+	}
+}
+
+func TestTieredResponseCache(t *testing.T) {
+	ctx := context.Background()
+	local, shared := NewLRUResponseCache(10), NewLRUResponseCache(10)
+	cache := NewTieredResponseCache(local, shared)
+
+	response := &models.CachedAPIResponse{URL: "https://example.com", ExpiresAt: time.Now().Add(time.Minute)}
+	_ = shared.Set(ctx, "key", response)
+
+	if got, err := cache.Get(ctx, "key"); err != nil || got != response {
+		t.Fatalf("Expected the shared response, got %v, %v", got, err)
+	}
+	if got, _ := local.Get(ctx, "key"); got != response {
+		t.Error("Expected a shared hit to be copied into the local tier")
+	}
+
+	_ = cache.Set(ctx, "other", response)
+	if got, _ := shared.Get(ctx, "other"); got == nil {
+		t.Error("Expected writes to reach every tier")
+	}
+}
+
+// failingResponseCache is a cache tier that is unavailable
+type failingResponseCache struct{}
+
+func (failingResponseCache) Get(context.Context, string) (*models.CachedAPIResponse, error) {
+	return nil, errors.New("cache unavailable")
+}
+
+func (failingResponseCache) Set(context.Context, string, *models.CachedAPIResponse) error {
+	return errors.New("cache unavailable")
+}
+
+func TestTieredResponseCache_FailingTier(t *testing.T) {
+	ctx := context.Background()
+	shared := NewLRUResponseCache(10)
+	cache := NewTieredResponseCache(failingResponseCache{}, shared)
+
+	response := &models.CachedAPIResponse{URL: "https://example.com", ExpiresAt: time.Now().Add(time.Minute)}
+	_ = shared.Set(ctx, "key", response)
+
+	if got, err := cache.Get(ctx, "key"); err != nil || got != response {
+		t.Errorf("Expected the failing tier to be skipped, got %v, %v", got, err)
+	}
+	if got, err := cache.Get(ctx, "missing"); err != nil || got != nil {
+		t.Errorf("Expected a miss, got %v, %v", got, err)
+	}
+}
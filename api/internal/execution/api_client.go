@@ -3,15 +3,26 @@ package execution
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"workflow-code-test/api/internal/tracing"
 )
 
 // HTTPAPIClient handles generic HTTP API calls
 type HTTPAPIClient struct {
 	httpClient *http.Client
+	policy     *OutboundPolicy
 }
 
 // NewHTTPAPIClient creates a new HTTP API client
@@ -23,6 +34,14 @@ func NewHTTPAPIClient() *HTTPAPIClient {
 	}
 }
 
+// NewHTTPAPIClientWithPolicy creates an HTTP API client that only calls URLs the outbound policy allows
+func NewHTTPAPIClientWithPolicy(policy OutboundPolicy) *HTTPAPIClient {
+	return &HTTPAPIClient{
+		httpClient: policy.httpClient(10 * time.Second),
+		policy:     &policy,
+	}
+}
+
 // OpenMeteoCurrentWeatherResponse represents the current weather response from Open-Meteo
 type OpenMeteoCurrentWeatherResponse struct {
 	Current struct {
@@ -31,27 +50,119 @@ type OpenMeteoCurrentWeatherResponse struct {
 	} `json:"current_weather"`
 }
 
+// APIResponse is a decoded API response along with the HTTP details callers such as the cache need
+type APIResponse struct {
+	// URL is the final URL of the response, after redirects
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       map[string]interface{}
+}
+
+// APIStatusError is returned when an API responds with a status other than 200 OK
+type APIStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error implements the error interface for APIStatusError
+func (e *APIStatusError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// APIResponseFetcher is implemented by API clients that can return the HTTP details of a response
+type APIResponseFetcher interface {
+	FetchAPI(ctx context.Context, url string) (*APIResponse, error)
+}
+
+// fetchAPI calls client, with the response's HTTP details if it can return them. Wrapping clients call the
+// client they wrap with it, so the details pass through.
+func fetchAPI(ctx context.Context, client APIClient, url string) (*APIResponse, error) {
+	if fetcher, ok := client.(APIResponseFetcher); ok {
+		return fetcher.FetchAPI(ctx, url)
+	}
+
+	body, err := client.CallAPI(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return &APIResponse{URL: url, StatusCode: http.StatusOK, Body: body}, nil
+}
+
 // CallAPI makes a generic API call and returns the raw response
 func (c *HTTPAPIClient) CallAPI(ctx context.Context, url string) (map[string]interface{}, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	response, err := c.FetchAPI(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// FetchAPI makes a generic API call and returns the response with its HTTP details
+func (c *HTTPAPIClient) FetchAPI(ctx context.Context, rawURL string) (*APIResponse, error) {
+	// The URL may hold secrets, so the span only gets it redacted
+	attributes := []attribute.KeyValue{
+		semconv.HTTPRequestMethodGet,
+		semconv.URLFull(RedactorFromContext(ctx).Redact(rawURL)),
+	}
+	if parsed, err := url.Parse(rawURL); err == nil {
+		attributes = append(attributes, semconv.ServerAddress(parsed.Hostname()))
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "GET", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	defer span.End()
+
+	response, err := c.fetch(ctx, rawURL)
+
+	var statusErr *APIStatusError
+	switch {
+	case errors.As(err, &statusErr):
+		span.SetAttributes(semconv.HTTPResponseStatusCode(statusErr.StatusCode))
+	case err == nil:
+		span.SetAttributes(semconv.HTTPResponseStatusCode(response.StatusCode))
+	}
+	if err != nil {
+		tracing.RecordError(span, errors.New(redactTraceText(ctx, err.Error())))
+	}
+	return response, err
+}
+
+// fetch makes the call of FetchAPI, passing the context's trace on to the API in W3C traceparent headers
+func (c *HTTPAPIClient) fetch(ctx context.Context, rawURL string) (*APIResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if c.policy != nil {
+		if err := c.policy.CheckURL(req.URL); err != nil {
+			return nil, redactURLError(ctx, err)
+		}
+	}
+	for name, values := range APIHeadersFromContext(ctx) {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
+		// Surface policy violations as is rather than buried in the client's error
+		var violation *PolicyViolationError
+		if errors.As(err, &violation) {
+			return nil, redactURLError(ctx, violation)
+		}
+		return nil, fmt.Errorf("failed to make API request: %w", redactURLError(ctx, err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return nil, &APIStatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.readBody(resp)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, redactURLError(ctx, err)
 	}
 
 	var result map[string]interface{}
@@ -59,5 +170,94 @@ func (c *HTTPAPIClient) CallAPI(ctx context.Context, url string) (map[string]int
 		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
 	}
 
-	return result, nil
+	return &APIResponse{
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       result,
+	}, nil
+}
+
+// maxErrorBodyBytes is how much of an error response is kept for the error message
+const maxErrorBodyBytes = 4 << 10
+
+// readBody reads the response body, failing if it's larger than the policy allows
+func (c *HTTPAPIClient) readBody(resp *http.Response) ([]byte, error) {
+	if c.policy == nil || c.policy.MaxResponseBytes <= 0 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		return body, nil
+	}
+
+	limit := c.policy.MaxResponseBytes
+	tooLarge := &PolicyViolationError{
+		URL:    resp.Request.URL.String(),
+		Reason: fmt.Sprintf("response is larger than %d bytes", limit),
+	}
+	if resp.ContentLength > limit {
+		return nil, tooLarge
+	}
+	// Read one byte past the limit to tell a body of exactly the limit from a longer one
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, tooLarge
+	}
+	return body, nil
+}
+
+// redactURLError replaces the secrets of the call in the URL an error carries, as the error message may be
+// kept around, e.g. as the last error of a circuit breaker
+func redactURLError(ctx context.Context, err error) error {
+	redactor := RedactorFromContext(ctx)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactor.Redact(urlErr.URL)
+	}
+	var violation *PolicyViolationError
+	if errors.As(err, &violation) {
+		violation.URL = redactor.Redact(violation.URL)
+	}
+	return err
+}
+
+type apiHeadersKey struct{}
+
+// WithAPIHeaders returns a context whose API calls send the given request headers
+func WithAPIHeaders(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, apiHeadersKey{}, header)
+}
+
+// APIHeadersFromContext returns the request headers API calls made with the context send
+func APIHeadersFromContext(ctx context.Context) http.Header {
+	header, _ := ctx.Value(apiHeadersKey{}).(http.Header)
+	return header
+}
+
+// APICallInfo carries per call options to the API client wrappers and collects what they did, so the
+// integration step can report it
+type APICallInfo struct {
+	// CacheTTL overrides how long a cached API client caches the response, zero disables caching
+	CacheTTL *time.Duration
+	// CacheStatus is set by cached API clients to one of the CacheStatus constants
+	CacheStatus string
+}
+
+type apiCallInfoKey struct{}
+
+// WithAPICallInfo returns a context whose API calls read their options from and report to info
+func WithAPICallInfo(ctx context.Context, info *APICallInfo) context.Context {
+	return context.WithValue(ctx, apiCallInfoKey{}, info)
+}
+
+// apiCallInfoFromContext returns the call info of the context, a throwaway one if it has none
+func apiCallInfoFromContext(ctx context.Context) *APICallInfo {
+	if info, ok := ctx.Value(apiCallInfoKey{}).(*APICallInfo); ok {
+		return info
+	}
+	return &APICallInfo{}
 }
@@ -0,0 +1,68 @@
+package execution
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider recording every span for the duration of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestHTTPAPIClient_PropagatesTraceContext(t *testing.T) {
+	recorder := recordSpans(t)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "workflow.node integration")
+	redactor := &Redactor{}
+	redactor.Add("s3cr3t")
+	ctx = WithRedactor(ctx, redactor)
+
+	if _, err := NewHTTPAPIClient().CallAPI(ctx, server.URL+"/forecast?apikey=s3cr3t"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected the call's span and its parent, got %d spans", len(spans))
+	}
+	call := spans[0]
+
+	if call.SpanKind() != trace.SpanKindClient || call.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("Expected a client span under the node's span, got %s under %s", call.SpanKind(), call.Parent().SpanID())
+	}
+	if !strings.Contains(traceparent, call.SpanContext().SpanID().String()) ||
+		!strings.HasPrefix(traceparent, "00-"+call.SpanContext().TraceID().String()) {
+		t.Errorf("Expected the API to get a traceparent of the call's span, got %q", traceparent)
+	}
+	for _, attribute := range call.Attributes() {
+		if strings.Contains(attribute.Value.Emit(), "s3cr3t") {
+			t.Errorf("Expected the secret redacted from the span, got %s=%s", attribute.Key, attribute.Value.Emit())
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package execution
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"workflow-code-test/api/internal/models"
+)
+
+// Cassette modes
+const (
+	// CassetteReplay serves recorded responses only and fails on requests the cassette doesn't have
+	CassetteReplay = "replay"
+	// CassetteRecord calls the real API and records every exchange, replacing the cassette on Save
+	CassetteRecord = "record"
+)
+
+// RedactedValue replaces secrets in recorded cassettes and execution output
+const RedactedValue = models.RedactedValue
+
+// defaultSecretNames are the query parameters, headers and body fields whose values are always redacted,
+// compared case-insensitively
+var defaultSecretNames = []string{
+	"api_key", "apikey", "key", "token", "access_token", "secret", "client_secret", "password", "appid",
+	"authorization", "x-api-key", "cookie", "set-cookie",
+}
+
+// Cassette is a recorded set of API exchanges, stored as a JSON fixture file
+type Cassette struct {
+	Interactions []CassetteInteraction `json:"interactions"`
+}
+
+// CassetteInteraction is a single recorded request and its response
+type CassetteInteraction struct {
+	Request    CassetteRequest  `json:"request"`
+	Response   CassetteResponse `json:"response"`
+	RecordedAt time.Time        `json:"recordedAt"`
+}
+
+// CassetteRequest is a recorded request, with secrets redacted
+type CassetteRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+// CassetteResponse is a recorded response, with secrets redacted
+type CassetteResponse struct {
+	StatusCode int                    `json:"statusCode"`
+	Header     http.Header            `json:"header,omitempty"`
+	Body       map[string]interface{} `json:"body,omitempty"`
+	// Error is the raw body of a non 200 response, replayed as an APIStatusError
+	Error string `json:"error,omitempty"`
+}
+
+// UnmatchedRequestError is returned when a replaying cassette has no recording of a request
+type UnmatchedRequestError struct {
+	Cassette string
+	Method   string
+	URL      string
+	// Recorded lists the requests the cassette does have
+	Recorded []string
+}
+
+// Error implements the error interface for UnmatchedRequestError
+func (e *UnmatchedRequestError) Error() string {
+	message := fmt.Sprintf("cassette %s has no recording of %s %s", e.Cassette, e.Method, e.URL)
+	if len(e.Recorded) == 0 {
+		return message + ", it is empty; record it with the API available"
+	}
+	return message + ", recorded requests: " + strings.Join(e.Recorded, ", ")
+}
+
+// CassetteAPIClient records API exchanges to a JSON fixture once and replays them offline, so tests are
+// deterministic and don't need the network. Requests match on method, host, path and query, the query in
+// any order.
+type CassetteAPIClient struct {
+	path        string
+	mode        string
+	apiClient   APIClient // only called when recording
+	secretNames map[string]bool
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed []bool
+}
+
+// NewCassetteAPIClient opens the cassette at path. Replaying requires the file to exist, recording starts an
+// empty cassette calling apiClient. Extra secret names are redacted along with the defaults.
+func NewCassetteAPIClient(path, mode string, apiClient APIClient, secretNames ...string) (*CassetteAPIClient, error) {
+	c := &CassetteAPIClient{
+		path:        path,
+		mode:        mode,
+		apiClient:   apiClient,
+		secretNames: make(map[string]bool),
+	}
+	for _, name := range append(append([]string{}, defaultSecretNames...), secretNames...) {
+		c.secretNames[strings.ToLower(name)] = true
+	}
+
+	switch mode {
+	case CassetteReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &c.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+		c.replayed = make([]bool, len(c.cassette.Interactions))
+	case CassetteRecord:
+		if apiClient == nil {
+			return nil, fmt.Errorf("recording a cassette requires an API client")
+		}
+	default:
+		return nil, fmt.Errorf("invalid cassette mode '%s', must be one of: %s, %s", mode, CassetteReplay, CassetteRecord)
+	}
+
+	return c, nil
+}
+
+// CallAPI implements APIClient
+func (c *CassetteAPIClient) CallAPI(ctx context.Context, url string) (map[string]interface{}, error) {
+	response, err := c.FetchAPI(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// FetchAPI implements APIResponseFetcher. Besides the values of secret names, the secrets the execution
+// resolved are redacted wherever they appear, whatever they're named.
+func (c *CassetteAPIClient) FetchAPI(ctx context.Context, rawURL string) (*APIResponse, error) {
+	redactor := RedactorFromContext(ctx)
+	request := CassetteRequest{
+		Method: http.MethodGet,
+		URL:    redactor.Redact(c.redactURL(rawURL)),
+		Header: c.redactHeader(APIHeadersFromContext(ctx), redactor),
+	}
+
+	if c.mode == CassetteRecord {
+		return c.record(ctx, rawURL, request)
+	}
+	return c.replay(rawURL, request)
+}
+
+// record calls the real API and appends the exchange to the cassette
+func (c *CassetteAPIClient) record(ctx context.Context, rawURL string, request CassetteRequest) (*APIResponse, error) {
+	response, err := fetchAPI(ctx, c.apiClient, rawURL)
+
+	redactor := RedactorFromContext(ctx)
+	recorded := CassetteResponse{}
+	var statusErr *APIStatusError
+	switch {
+	case errors.As(err, &statusErr):
+		recorded.StatusCode = statusErr.StatusCode
+		recorded.Error = redactor.Redact(statusErr.Body)
+	case err != nil:
+		// Network errors aren't reproducible responses, leave them out of the cassette
+		return nil, err
+	default:
+		recorded.StatusCode = response.StatusCode
+		recorded.Header = c.redactHeader(response.Header, redactor)
+		recorded.Body = c.redactBody(response.Body, redactor).(map[string]interface{})
+	}
+
+	c.mu.Lock()
+	c.cassette.Interactions = append(c.cassette.Interactions, CassetteInteraction{
+		Request:    request,
+		Response:   recorded,
+		RecordedAt: time.Now().UTC(),
+	})
+	c.mu.Unlock()
+
+	return response, err
+}
+
+// replay serves the first unused matching interaction, or the last matching one once all have been used
+func (c *CassetteAPIClient) replay(rawURL string, request CassetteRequest) (*APIResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	match := -1
+	for i, interaction := range c.cassette.Interactions {
+		if !requestsMatch(interaction.Request, request) {
+			continue
+		}
+		match = i
+		if !c.replayed[i] {
+			break
+		}
+	}
+
+	if match < 0 {
+		recorded := make([]string, len(c.cassette.Interactions))
+		for i, interaction := range c.cassette.Interactions {
+			recorded[i] = interaction.Request.Method + " " + interaction.Request.URL
+		}
+		return nil, &UnmatchedRequestError{Cassette: c.path, Method: request.Method, URL: request.URL, Recorded: recorded}
+	}
+	c.replayed[match] = true
+
+	recorded := c.cassette.Interactions[match].Response
+	if recorded.StatusCode != http.StatusOK {
+		return nil, &APIStatusError{StatusCode: recorded.StatusCode, Body: recorded.Error}
+	}
+	return &APIResponse{
+		URL:        rawURL,
+		StatusCode: recorded.StatusCode,
+		Header:     recorded.Header,
+		Body:       recorded.Body,
+	}, nil
+}
+
+// requestsMatch compares the method, host, path and query of two requests
+func requestsMatch(recorded, request CassetteRequest) bool {
+	if !strings.EqualFold(recorded.Method, request.Method) {
+		return false
+	}
+	a, errA := url.Parse(recorded.URL)
+	b, errB := url.Parse(request.URL)
+	if errA != nil || errB != nil {
+		return recorded.URL == request.URL
+	}
+	return strings.EqualFold(a.Host, b.Host) && a.Path == b.Path && reflect.DeepEqual(a.Query(), b.Query())
+}
+
+// Unused returns the recorded requests that were never replayed, so tests can check their cassette is current
+func (c *CassetteAPIClient) Unused() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var unused []string
+	for i, interaction := range c.cassette.Interactions {
+		if i < len(c.replayed) && !c.replayed[i] {
+			unused = append(unused, interaction.Request.Method+" "+interaction.Request.URL)
+		}
+	}
+	return unused
+}
+
+// Save writes the recorded cassette to its file, replaying cassettes are left untouched
+func (c *CassetteAPIClient) Save() error {
+	if c.mode != CassetteRecord {
+		return nil
+	}
+
+	// Leave URLs readable, the encoder would escape every & otherwise
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	c.mu.Lock()
+	err := encoder.Encode(c.cassette)
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, data.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// isSecret returns true if values of the named parameter, header or field must be redacted
+func (c *CassetteAPIClient) isSecret(name string) bool {
+	return c.secretNames[strings.ToLower(name)]
+}
+
+// redactURL replaces secret query parameter values and any user info of a URL
+func (c *CassetteAPIClient) redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if parsed.User != nil {
+		parsed.User = url.User(RedactedValue)
+	}
+
+	query := parsed.Query()
+	redacted := false
+	for name, values := range query {
+		if c.isSecret(name) {
+			for i := range values {
+				values[i] = RedactedValue
+			}
+			redacted = true
+		}
+	}
+	if redacted {
+		parsed.RawQuery = query.Encode()
+	}
+	return parsed.String()
+}
+
+// redactHeader returns a copy of the header with secret values replaced, and the values redactor knows
+// replaced in the others
+func (c *CassetteAPIClient) redactHeader(header http.Header, redactor *Redactor) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	redacted := header.Clone()
+	for name, values := range redacted {
+		for i := range values {
+			if c.isSecret(name) {
+				values[i] = RedactedValue
+			} else {
+				values[i] = redactor.Redact(values[i])
+			}
+		}
+	}
+	return redacted
+}
+
+// redactBody returns a copy of a JSON value with the values of secret fields replaced, and the values
+// redactor knows replaced in other strings, at any depth
+func (c *CassetteAPIClient) redactBody(value interface{}, redactor *Redactor) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for name, field := range v {
+			if c.isSecret(name) {
+				redacted[name] = RedactedValue
+			} else {
+				redacted[name] = c.redactBody(field, redactor)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = c.redactBody(item, redactor)
+		}
+		return redacted
+	case string:
+		return redactor.Redact(v)
+	default:
+		return v
+	}
+}
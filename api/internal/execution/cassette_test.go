@@ -0,0 +1,240 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"workflow-code-test/api/internal/models"
+)
+
+// testCassette replays testdata/cassettes/<name>.json, or records it against the real API when
+// CASSETTE_RECORD=1 is set
+func testCassette(t *testing.T, name string) *CassetteAPIClient {
+	t.Helper()
+	path := filepath.Join("testdata", "cassettes", name+".json")
+
+	if os.Getenv("CASSETTE_RECORD") != "1" {
+		cassette, err := NewCassetteAPIClient(path, CassetteReplay, nil)
+		if err != nil {
+			t.Fatalf("Expected cassette %s to load, got %v (record it with CASSETTE_RECORD=1)", path, err)
+		}
+		t.Cleanup(func() {
+			if unused := cassette.Unused(); len(unused) > 0 {
+				t.Errorf("Expected every recorded request to be replayed, unused: %v", unused)
+			}
+		})
+		return cassette
+	}
+
+	cassette, err := NewCassetteAPIClient(path, CassetteRecord, NewHTTPAPIClient())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	t.Cleanup(func() {
+		if err := cassette.Save(); err != nil {
+			t.Errorf("Expected cassette to be saved, got %v", err)
+		}
+	})
+	return cassette
+}
+
+func TestCassetteAPIClient_RecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/missing" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc123")
+		w.Write([]byte(`{"temperature": 21.5, "account": {"token": "tok-secret", "name": "demo"}}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "weather.json")
+	recorder, err := NewCassetteAPIClient(path, CassetteRecord, NewHTTPAPIClient())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx := WithAPIHeaders(context.Background(), http.Header{"Authorization": []string{"Bearer very-secret"}})
+	body, err := recorder.CallAPI(ctx, server.URL+"/weather?city=Sydney&apikey=very-secret")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if body["temperature"] != 21.5 {
+		t.Errorf("Expected the live response while recording, got %v", body)
+	}
+	if _, err := recorder.CallAPI(ctx, server.URL+"/missing"); err == nil {
+		t.Fatal("Expected the 404 to be returned while recording")
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, secret := range []string{"very-secret", "tok-secret", "abc123"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %q to be redacted from the cassette, got %s", secret, data)
+		}
+	}
+
+	replayer, err := NewCassetteAPIClient(path, CassetteReplay, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Query parameters match in any order, and secrets match whatever their real value
+	body, err = replayer.CallAPI(context.Background(), server.URL+"/weather?apikey=other-secret&city=Sydney")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if body["temperature"] != 21.5 {
+		t.Errorf("Expected temperature 21.5, got %v", body["temperature"])
+	}
+	account, _ := body["account"].(map[string]interface{})
+	if account["token"] != RedactedValue || account["name"] != "demo" {
+		t.Errorf("Expected only the token to be redacted, got %v", account)
+	}
+
+	var statusErr *APIStatusError
+	if _, err := replayer.CallAPI(context.Background(), server.URL+"/missing"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected the recorded 404 to be replayed, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected replaying not to call the API, got %d calls", calls)
+	}
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("Expected every interaction to be replayed, unused: %v", unused)
+	}
+}
+
+func TestCassetteAPIClient_RedactsResolvedSecrets(t *testing.T) {
+	const secret = "s3cr3t-credential"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// An API echoing the credential it was sent, under names the cassette doesn't know as secret
+		credential := r.Header.Get("X-Weather-Credential")
+		w.Header().Set("X-Echo", credential)
+		if r.URL.Path == "/denied" {
+			http.Error(w, "credential "+credential+" is not allowed", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, `{"temperature": 21.5, "echo": %q}`, credential)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "weather.json")
+	recorder, err := NewCassetteAPIClient(path, CassetteRecord, NewHTTPAPIClient())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The secret was resolved from {{secret.WEATHER}} into a custom header and the query
+	redactor := &Redactor{}
+	redactor.Add(secret)
+	ctx := WithAPIHeaders(WithRedactor(context.Background(), redactor), http.Header{"X-Weather-Credential": {secret}})
+	if _, err := recorder.CallAPI(ctx, server.URL+"/weather?credential="+secret); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := recorder.CallAPI(ctx, server.URL+"/denied"); err == nil {
+		t.Fatal("Expected the 403 to be returned while recording")
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Errorf("Expected the resolved secret to be redacted from the cassette, got %s", data)
+	}
+
+	// Replaying with the secret resolved again matches the redacted recording
+	replayer, err := NewCassetteAPIClient(path, CassetteReplay, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := replayer.CallAPI(ctx, server.URL+"/weather?credential="+secret); err != nil {
+		t.Errorf("Expected the redacted recording to match, got %v", err)
+	}
+}
+
+func TestCassetteAPIClient_Unmatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	os.WriteFile(path, []byte(`{"interactions": [{"request": {"method": "GET", "url": "https://example.com/weather?city=Sydney"}, "response": {"statusCode": 200, "body": {}}}]}`), 0o644)
+
+	cassette, err := NewCassetteAPIClient(path, CassetteReplay, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, url := range []string{
+		"https://example.com/weather?city=Melbourne",
+		"https://example.com/weather",
+		"https://example.org/weather?city=Sydney",
+		"https://example.com/forecast?city=Sydney",
+	} {
+		_, err := cassette.CallAPI(context.Background(), url)
+		var unmatched *UnmatchedRequestError
+		if !errors.As(err, &unmatched) {
+			t.Errorf("Expected an UnmatchedRequestError for %s, got %v", url, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), "https://example.com/weather?city=Sydney") {
+			t.Errorf("Expected the error to list the recorded requests, got %v", err)
+		}
+	}
+
+	if unused := cassette.Unused(); len(unused) != 1 {
+		t.Errorf("Expected the recording to be unused, got %v", unused)
+	}
+}
+
+func TestCassetteAPIClient_InvalidMode(t *testing.T) {
+	if _, err := NewCassetteAPIClient("cassette.json", "live", nil); err == nil {
+		t.Error("Expected an invalid mode to be rejected")
+	}
+	if _, err := NewCassetteAPIClient("cassette.json", CassetteRecord, nil); err == nil {
+		t.Error("Expected recording without an API client to be rejected")
+	}
+	if _, err := NewCassetteAPIClient(filepath.Join(t.TempDir(), "missing.json"), CassetteReplay, nil); err == nil {
+		t.Error("Expected replaying a missing cassette to fail")
+	}
+}
+
+func TestIntegrationService_ExecuteIntegration_Cassette(t *testing.T) {
+	service := NewIntegrationServiceWithGeocoder(testCassette(t, "open_meteo_sydney"), NewBundledGazetteer())
+
+	nodeData := models.IntegrationNodeData{
+		Label: "Weather API",
+		Metadata: models.IntegrationNodeMetadata{
+			InputVariables:  []string{"city"},
+			APIEndpoint:     "https://api.open-meteo.com/v1/forecast?latitude={lat}&longitude={lon}&current_weather=true",
+			OutputVariables: []string{"temperature"},
+		},
+	}
+
+	result, err := service.ExecuteIntegration(context.Background(), nodeData, map[string]interface{}{"city": "Sydney"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := result.ProcessedData["temperature"].(float64); !ok {
+		t.Errorf("Expected a temperature, got %v", result.ProcessedData)
+	}
+	if _, ok := result.ProcessedData[WeatherVarHumidity].(float64); !ok {
+		t.Errorf("Expected the humidity, got %v", result.ProcessedData)
+	}
+}
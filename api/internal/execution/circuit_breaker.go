@@ -0,0 +1,201 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	// CircuitClosed lets calls through, counting consecutive failures
+	CircuitClosed = "closed"
+	// CircuitOpen fails calls instantly until the open timeout has passed
+	CircuitOpen = "open"
+	// CircuitHalfOpen lets a limited number of trial calls through to decide whether to close again
+	CircuitHalfOpen = "half-open"
+)
+
+// CircuitBreakerConfig holds the thresholds of a circuit breaker
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before trial calls are let through
+	OpenTimeout time.Duration
+	// HalfOpenMaxCalls is the number of trial calls let through at once while half-open
+	HalfOpenMaxCalls int
+	// SuccessThreshold is the number of successful trial calls that closes the circuit again
+	SuccessThreshold int
+}
+
+// DefaultCircuitBreakerConfig returns the circuit breaker thresholds used for every host by default
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+	}
+}
+
+// ErrCircuitOpen is matched by errors.Is for every CircuitOpenError
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned instantly, without calling the API, while the circuit of its host is open
+type CircuitOpenError struct {
+	Host string
+	// RetryAt is when the circuit lets trial calls through again
+	RetryAt time.Time
+}
+
+// Error implements the error interface for CircuitOpenError
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for %s is open, retry after %s", e.Host, e.RetryAt.Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) true for circuit open errors
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitBreakerState is a snapshot of a circuit breaker, as shown on the admin endpoint
+type CircuitBreakerState struct {
+	Host                string     `json:"host"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+}
+
+// CircuitBreaker stops calling a failing host for a while so callers fail fast instead of waiting out timeouts
+type CircuitBreaker struct {
+	host   string
+	config CircuitBreakerConfig
+	now    func() time.Time
+
+	mu                  sync.Mutex
+	state               string
+	consecutiveFailures int
+	halfOpenCalls       int // trial calls in flight
+	halfOpenSuccesses   int
+	openedAt            time.Time
+	lastError           string
+}
+
+// NewCircuitBreaker creates a closed circuit breaker for a host
+func NewCircuitBreaker(host string, config CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		host:   host,
+		config: config,
+		now:    time.Now,
+		state:  CircuitClosed,
+	}
+}
+
+// Allow returns a CircuitOpenError if the call must not be made. Every allowed call must be followed by
+// a call to Record with its outcome or to Release.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		retryAt := b.openedAt.Add(b.config.OpenTimeout)
+		if b.now().Before(retryAt) {
+			return &CircuitOpenError{Host: b.host, RetryAt: retryAt}
+		}
+		b.state = CircuitHalfOpen
+		b.halfOpenCalls = 0
+		b.halfOpenSuccesses = 0
+	}
+
+	if b.state == CircuitHalfOpen {
+		if b.halfOpenCalls >= b.config.HalfOpenMaxCalls {
+			// Trial calls are still in flight, keep failing fast until they settle the state
+			return &CircuitOpenError{Host: b.host, RetryAt: b.now().Add(b.config.OpenTimeout)}
+		}
+		b.halfOpenCalls++
+	}
+
+	return nil
+}
+
+// Record records the outcome of an allowed call, err is nil for a success
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen {
+		b.halfOpenCalls--
+		if err != nil {
+			b.open(err)
+			return
+		}
+		b.halfOpenSuccesses++
+		if b.halfOpenSuccesses >= b.config.SuccessThreshold {
+			b.state = CircuitClosed
+			b.consecutiveFailures = 0
+		}
+		return
+	}
+
+	if b.state == CircuitOpen {
+		// Calls allowed before the circuit opened finish late, they don't extend the open period
+		if err != nil {
+			b.lastError = err.Error()
+		}
+		return
+	}
+
+	if err == nil {
+		b.consecutiveFailures = 0
+		return
+	}
+	b.consecutiveFailures++
+	b.lastError = err.Error()
+	if b.consecutiveFailures >= b.config.FailureThreshold {
+		b.open(err)
+	}
+}
+
+// Release ends an allowed call without an outcome, e.g. when the caller gave up before the host answered
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen {
+		b.halfOpenCalls--
+	}
+}
+
+// open trips the circuit, the caller holds the lock
+func (b *CircuitBreaker) open(err error) {
+	b.state = CircuitOpen
+	b.openedAt = b.now()
+	b.lastError = err.Error()
+}
+
+// State returns a snapshot of the breaker
+func (b *CircuitBreaker) State() CircuitBreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := CircuitBreakerState{
+		Host:                b.host,
+		State:               b.state,
+		ConsecutiveFailures: b.consecutiveFailures,
+		LastError:           b.lastError,
+	}
+	if b.state != CircuitClosed {
+		openedAt := b.openedAt
+		retryAt := b.openedAt.Add(b.config.OpenTimeout)
+		state.OpenedAt = &openedAt
+		state.RetryAt = &retryAt
+	}
+	// An open circuit whose timeout has passed turns half-open on the next call, show it as such already
+	if b.state == CircuitOpen && !b.now().Before(*state.RetryAt) {
+		state.State = CircuitHalfOpen
+	}
+	return state
+}
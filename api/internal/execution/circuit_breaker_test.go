@@ -0,0 +1,120 @@
+package execution
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	breaker := NewCircuitBreaker("api.example.com", CircuitBreakerConfig{
+		FailureThreshold: 3,
+		OpenTimeout:      time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 2,
+	})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+	failure := errors.New("connection refused")
+
+	call := func(err error) error {
+		t.Helper()
+		if allowErr := breaker.Allow(); allowErr != nil {
+			return allowErr
+		}
+		breaker.Record(err)
+		return nil
+	}
+
+	// A success resets the consecutive failures
+	_ = call(failure)
+	_ = call(failure)
+	_ = call(nil)
+	_ = call(failure)
+	_ = call(failure)
+	if state := breaker.State(); state.State != CircuitClosed || state.ConsecutiveFailures != 2 {
+		t.Fatalf("Expected closed with 2 failures, got %+v", state)
+	}
+
+	_ = call(failure)
+	if state := breaker.State(); state.State != CircuitOpen || state.LastError != "connection refused" {
+		t.Fatalf("Expected open, got %+v", state)
+	}
+
+	err := call(nil)
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected a CircuitOpenError, got %v", err)
+	}
+	if !openErr.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected retry at %v, got %v", now.Add(time.Minute), openErr.RetryAt)
+	}
+
+	// After the timeout one trial call at a time is let through
+	now = now.Add(time.Minute)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Expected a trial call to be allowed, got %v", err)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected a second concurrent trial call to be rejected, got %v", err)
+	}
+	breaker.Record(nil)
+	if state := breaker.State(); state.State != CircuitHalfOpen {
+		t.Fatalf("Expected half-open until 2 trial calls succeed, got %+v", state)
+	}
+
+	// A failed trial call opens the circuit again
+	_ = call(failure)
+	if state := breaker.State(); state.State != CircuitOpen {
+		t.Fatalf("Expected open after a failed trial call, got %+v", state)
+	}
+
+	now = now.Add(time.Minute)
+	_ = call(nil)
+	_ = call(nil)
+	if state := breaker.State(); state.State != CircuitClosed || state.OpenedAt != nil {
+		t.Errorf("Expected closed after 2 successful trial calls, got %+v", state)
+	}
+}
+
+func TestCircuitBreaker_ReleaseFreesTrialCall(t *testing.T) {
+	breaker := NewCircuitBreaker("api.example.com", CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second, HalfOpenMaxCalls: 1, SuccessThreshold: 1})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+
+	_ = breaker.Allow()
+	breaker.Record(errors.New("timeout"))
+	now = now.Add(time.Second)
+
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Expected a trial call, got %v", err)
+	}
+	breaker.Release()
+	if err := breaker.Allow(); err != nil {
+		t.Errorf("Expected a released trial call to free its slot, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_LateFailuresDontExtendOpen(t *testing.T) {
+	breaker := NewCircuitBreaker("api.example.com", CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenMaxCalls: 1, SuccessThreshold: 1})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+	openedAt := now
+
+	// Two calls are in flight when the first failure opens the circuit
+	_ = breaker.Allow()
+	_ = breaker.Allow()
+	breaker.Record(errors.New("timeout"))
+
+	now = now.Add(30 * time.Second)
+	breaker.Record(errors.New("connection reset"))
+	state := breaker.State()
+	if state.State != CircuitOpen || !state.OpenedAt.Equal(openedAt) || state.LastError != "connection reset" {
+		t.Fatalf("Expected the circuit to stay open since %v, got %+v", openedAt, state)
+	}
+
+	now = openedAt.Add(time.Minute)
+	if err := breaker.Allow(); err != nil {
+		t.Errorf("Expected a trial call once the original timeout passed, got %v", err)
+	}
+}
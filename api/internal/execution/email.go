@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
+
+	"workflow-code-test/api/internal/models"
 )
 
 // EmailPayload represents an email that would be sent
@@ -13,60 +16,94 @@ type EmailPayload struct {
 	Subject   string    `json:"subject"`
 	Body      string    `json:"body"`
 	Timestamp time.Time `json:"timestamp"`
+	MessageID string    `json:"messageId"`
+	// DedupeKey identifies the send across retries, empty if it may be repeated
+	DedupeKey string `json:"dedupeKey,omitempty"`
+}
+
+// EmailSendResult is the outcome of a send
+type EmailSendResult struct {
+	MessageID string
+	// Duplicate is true when an email with the same dedupe key was already sent, MessageID is then the
+	// earlier email's
+	Duplicate bool
 }
 
 // InMemoryEmailService tracks email payloads in memory without actually sending them
 type InMemoryEmailService struct {
+	mu         sync.Mutex
 	sentEmails []EmailPayload
+	sentByKey  map[string]string // dedupe key -> message ID
 }
 
 // NewInMemoryEmailService creates a new in-memory email service
 func NewInMemoryEmailService() *InMemoryEmailService {
 	return &InMemoryEmailService{
 		sentEmails: make([]EmailPayload, 0),
+		sentByKey:  make(map[string]string),
 	}
 }
 
-// SendEmail tracks the email payload in memory
-func (s *InMemoryEmailService) SendEmail(ctx context.Context, to, subject, body string) error {
+// SendEmail tracks the email payload in memory. An email with the dedupe key of one already sent isn't sent
+// again, an empty dedupe key never matches.
+func (s *InMemoryEmailService) SendEmail(ctx context.Context, to, subject, body, dedupeKey string) (*EmailSendResult, error) {
 	// Validate email parameters
 	if to == "" {
-		return fmt.Errorf("recipient email is required")
+		return nil, fmt.Errorf("recipient email is required")
 	}
 
 	if subject == "" {
-		return fmt.Errorf("email subject is required")
+		return nil, fmt.Errorf("email subject is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if messageID, ok := s.sentByKey[dedupeKey]; ok && dedupeKey != "" {
+		slog.InfoContext(ctx, "Email already sent, skipping duplicate", "messageId", messageID)
+		return &EmailSendResult{MessageID: messageID, Duplicate: true}, nil
 	}
 
 	// Create email payload
+	now := time.Now()
 	payload := EmailPayload{
 		To:        to,
 		Subject:   subject,
 		Body:      body,
-		Timestamp: time.Now(),
+		Timestamp: now,
+		MessageID: fmt.Sprintf("msg_%d", now.UnixNano()),
+		DedupeKey: dedupeKey,
 	}
 
 	// Store in memory
 	s.sentEmails = append(s.sentEmails, payload)
+	if dedupeKey != "" {
+		s.sentByKey[dedupeKey] = payload.MessageID
+	}
 
-	// Log to avoid unused variable warnings and for visibility
-	slog.Info("Email payload tracked in memory",
-		"to", payload.To,
+	// The recipient and body are personal data, log only enough to trace the send
+	slog.InfoContext(ctx, "Email payload tracked in memory",
+		"to", models.MaskPII(payload.To),
 		"subject", payload.Subject,
-		"body", payload.Body,
+		"bodyLength", len(payload.Body),
 		"timestamp", payload.Timestamp,
 		"totalEmails", len(s.sentEmails),
 	)
 
-	return nil
+	return &EmailSendResult{MessageID: payload.MessageID}, nil
 }
 
 // GetSentEmails returns all tracked email payloads (for testing/debugging)
 func (s *InMemoryEmailService) GetSentEmails() []EmailPayload {
-	return s.sentEmails
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]EmailPayload(nil), s.sentEmails...)
 }
 
 // ClearSentEmails clears all tracked email payloads
 func (s *InMemoryEmailService) ClearSentEmails() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.sentEmails = make([]EmailPayload, 0)
+	s.sentByKey = make(map[string]string)
 }
@@ -0,0 +1,96 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"workflow-code-test/api/internal/models"
+)
+
+func TestInMemoryEmailService_SendEmail_Dedupe(t *testing.T) {
+	service := NewInMemoryEmailService()
+	ctx := context.Background()
+
+	first, err := service.SendEmail(ctx, "alice@example.com", "Weather Alert", "Hot", "key-1:email")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.Duplicate {
+		t.Errorf("Expected the first send not to be a duplicate")
+	}
+
+	retry, err := service.SendEmail(ctx, "alice@example.com", "Weather Alert", "Hot", "key-1:email")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !retry.Duplicate || retry.MessageID != first.MessageID {
+		t.Errorf("Expected a duplicate of message %s, got %+v", first.MessageID, retry)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.SendEmail(ctx, "alice@example.com", "Weather Alert", "Hot", ""); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if sent := service.GetSentEmails(); len(sent) != 3 {
+		t.Errorf("Expected 3 emails, one deduped and two without a key, got %d", len(sent))
+	}
+}
+
+func TestEngine_ExecuteWorkflow_IdempotentEmail(t *testing.T) {
+	engine := NewEngineWithAPIClient(NewMockAPIClient())
+
+	workflow := &models.WorkflowResponse{
+		ID: "test-workflow",
+		Nodes: []models.NodeResponse{
+			{ID: "start", Type: models.NodeTypeStart},
+			{
+				ID:   "form",
+				Type: models.NodeTypeForm,
+				Data: models.FormNodeData{
+					Metadata: models.FormNodeMetadata{
+						InputFields:     models.NewFormFields("email"),
+						OutputVariables: []string{"email"},
+					},
+				},
+			},
+			{ID: "email", Type: models.NodeTypeEmail},
+			{ID: "end", Type: models.NodeTypeEnd},
+		},
+		Edges: []models.EdgeResponse{
+			{ID: "e1", Source: "start", Target: "form"},
+			{ID: "e2", Source: "form", Target: "email"},
+			{ID: "e3", Source: "email", Target: "end"},
+		},
+	}
+	req := &models.ExecutionRequest{FormData: map[string]interface{}{"email": "alice@example.com"}}
+
+	ctx := WithIdempotencyKey(context.Background(), "scoped-key")
+	var statuses []string
+	for i := 0; i < 2; i++ {
+		result, err := engine.ExecuteWorkflow(ctx, workflow, req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.Status != "completed" || len(result.Steps) != 4 {
+			t.Fatalf("Expected a completed execution of 4 steps, got %s: %+v", result.Status, result.Steps)
+		}
+
+		var output struct {
+			DeliveryStatus string `json:"deliveryStatus"`
+		}
+		if err := json.Unmarshal(result.Steps[2].RawOutput, &output); err != nil {
+			t.Fatalf("Expected email output, got %v", err)
+		}
+		statuses = append(statuses, output.DeliveryStatus)
+	}
+
+	if statuses[0] != "sent" || statuses[1] != "duplicate" {
+		t.Errorf("Expected the retry's email to be a duplicate, got %v", statuses)
+	}
+	if sent := engine.emailService.GetSentEmails(); len(sent) != 1 || sent[0].DedupeKey != "scoped-key:email" {
+		t.Errorf("Expected one email deduped by the node's key, got %+v", sent)
+	}
+}
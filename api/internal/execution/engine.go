@@ -3,14 +3,26 @@ package execution
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"workflow-code-test/api/internal/metrics"
 	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/tracing"
 )
 
+// executionRejected is the metrics status of executions whose form data was rejected, which never ran
+const executionRejected = "rejected"
+
 // Engine handles workflow execution logic
 type Engine struct {
 	integrationService *IntegrationService
@@ -41,9 +53,82 @@ func NewEngineWithAPIClient(apiClient APIClient) *Engine {
 	}
 }
 
+// WithSecretResolver makes the engine resolve {{secret.NAME}} references in node metadata with resolver
+func (e *Engine) WithSecretResolver(resolver SecretResolver) *Engine {
+	e.integrationService.secrets = resolver
+	return e
+}
+
 // ExecuteWorkflow executes a workflow in memory
 func (e *Engine) ExecuteWorkflow(ctx context.Context, workflow *models.WorkflowResponse, req *models.ExecutionRequest) (*models.ExecutionResponse, error) {
+	// Collect the secrets nodes resolve and the sensitive form values, so they can be kept out of step
+	// output and errors
+	ctx = WithRedactor(ctx, &Redactor{})
+	ctx = withPIIRedactor(ctx, &PIIRedactor{})
+
+	// Every node gets a child span of the execution's span
+	ctx, span := tracing.Tracer().Start(ctx, "workflow.execute", trace.WithAttributes(
+		attribute.String("workflow.id", workflow.ID),
+		attribute.String("workflow.version", workflow.Version),
+	))
+	defer span.End()
+
+	response, err := e.executeWorkflow(ctx, workflow, req)
+	recordExecutionMetrics(executionMetricsWorkflow(ctx, workflow.ID), response, err)
+
+	// The failed node's span holds the error
+	if err != nil {
+		span.SetAttributes(attribute.String("workflow.execution.status", executionRejected))
+		span.SetStatus(codes.Error, "form data rejected")
+	} else {
+		span.SetAttributes(attribute.String("workflow.execution.status", response.Status))
+		if response.Status == "failed" {
+			span.SetStatus(codes.Error, "execution failed")
+		}
+	}
+	return response, err
+}
+
+// adhocWorkflowLabel labels executions of ad-hoc definitions, whose IDs are chosen by the caller
+const adhocWorkflowLabel = "adhoc"
+
+type storedWorkflowKey struct{}
+
+// WithStoredWorkflow returns a context whose executions run a stored workflow, counted by its ID in the
+// metrics rather than as ad-hoc
+func WithStoredWorkflow(ctx context.Context) context.Context {
+	return context.WithValue(ctx, storedWorkflowKey{}, true)
+}
+
+// executionMetricsWorkflow returns the workflow label an execution is counted under
+func executionMetricsWorkflow(ctx context.Context, workflowID string) string {
+	if stored, _ := ctx.Value(storedWorkflowKey{}).(bool); stored {
+		return workflowID
+	}
+	return adhocWorkflowLabel
+}
+
+// recordExecutionMetrics counts an execution by its final status and records how long each of its nodes took
+func recordExecutionMetrics(workflowID string, response *models.ExecutionResponse, err error) {
+	if err != nil {
+		metrics.Executions.WithLabelValues(workflowID, executionRejected).Inc()
+		return
+	}
+
+	metrics.Executions.WithLabelValues(workflowID, response.Status).Inc()
+	for _, step := range response.Steps {
+		if step.Duration != nil {
+			duration := time.Duration(*step.Duration) * time.Millisecond
+			metrics.NodeDuration.WithLabelValues(step.Type, step.Status).Observe(duration.Seconds())
+		}
+	}
+}
+
+// executeWorkflow runs a workflow from its start node
+func (e *Engine) executeWorkflow(ctx context.Context, workflow *models.WorkflowResponse, req *models.ExecutionRequest) (*models.ExecutionResponse, error) {
 	execCtx := models.NewExecutionContext(workflow.ID, req.FormData)
+	execCtx.WorkflowVersion = workflow.Version
+	execCtx.IdempotencyKey = IdempotencyKeyFromContext(ctx)
 
 	// Store condition data in context for later use
 	if req.Condition != nil {
@@ -83,11 +168,17 @@ func (e *Engine) ExecuteWorkflow(ctx context.Context, workflow *models.WorkflowR
 
 	// Execute workflow starting from start node
 	if err := e.executeNode(ctx, startNode, nodeMap, edgeMap, execCtx); err != nil {
+		// Rejected form data is a problem with the request rather than a failed execution
+		var formErr *models.FormValidationError
+		if errors.As(err, &formErr) {
+			return nil, err
+		}
+
 		return &models.ExecutionResponse{
 			ExecutedAt: time.Now(),
 			Status:     "failed",
 			Steps:      execCtx.Steps,
-			Error:      stringPtr(err.Error()),
+			Error:      stringPtr(redactText(ctx, err.Error())),
 		}, nil
 	}
 
@@ -100,41 +191,91 @@ func (e *Engine) ExecuteWorkflow(ctx context.Context, workflow *models.WorkflowR
 
 // executeNode executes a single node and continues to next nodes
 func (e *Engine) executeNode(ctx context.Context, node *models.NodeResponse, nodeMap map[string]*models.NodeResponse, edgeMap map[string][]models.EdgeResponse, execCtx *models.ExecutionContext) error {
-	stepStart := time.Now()
+	err := e.runNode(ctx, node, execCtx)
+	if err != nil {
+		return e.handleNodeError(ctx, node, err, nodeMap, edgeMap, execCtx)
+	}
 
-	step := models.ExecutionStep{
-		NodeID:      node.ID,
-		Type:        node.Type,
-		Label:       e.getNodeLabel(node),
-		Description: e.getNodeDescription(node),
-		Status:      "running",
+	// Continue to next nodes based on node type and condition results
+	if err := e.continueToNextNodes(ctx, node, nodeMap, edgeMap, execCtx); err != nil {
+		return err
 	}
 
-	var err error
-	var output interface{}
+	return nil
+}
 
-	// Execute node based on type
-	switch node.Type {
-	case models.NodeTypeStart:
-		output, err = e.executeStartNode(ctx, node, execCtx)
+// handleNodeError continues a failed node's execution along its error edges, which see the error in the
+// error and errorKind variables. Without error edges, and for rejected form data, the error fails the
+// execution.
+func (e *Engine) handleNodeError(ctx context.Context, node *models.NodeResponse, err error, nodeMap map[string]*models.NodeResponse, edgeMap map[string][]models.EdgeResponse, execCtx *models.ExecutionContext) error {
+	var formErr *models.FormValidationError
+	if errors.As(err, &formErr) {
+		return err
+	}
 
-	case models.NodeTypeForm:
-		output, err = e.executeFormNode(ctx, node, execCtx)
+	var errorEdges []models.EdgeResponse
+	for _, edge := range edgeMap[node.ID] {
+		if isErrorEdge(edge) {
+			errorEdges = append(errorEdges, edge)
+		}
+	}
+	if len(errorEdges) == 0 {
+		return err
+	}
 
-	case models.NodeTypeIntegration:
-		output, err = e.executeIntegrationNode(ctx, node, execCtx)
+	execCtx.SetVariable("error", redactText(ctx, err.Error()))
+	execCtx.SetVariable("errorKind", errorKind(err))
+	return e.executeNextNodes(ctx, errorEdges, nodeMap, edgeMap, execCtx)
+}
 
-	case models.NodeTypeCondition:
-		output, err = e.executeConditionNode(ctx, node, execCtx)
+// isErrorEdge returns true for edges leaving their node through its error handle
+func isErrorEdge(edge models.EdgeResponse) bool {
+	return edge.SourceHandle != nil && *edge.SourceHandle == models.ErrorHandle
+}
 
-	case models.NodeTypeEmail:
-		output, err = e.executeEmailNode(ctx, node, execCtx)
+// errorKind classifies a node's error, so error edges can tell an open circuit from other failures
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return models.ErrorKindCircuitOpen
+	case errors.Is(err, ErrRateLimited):
+		return models.ErrorKindRateLimited
+	case errors.Is(err, ErrPolicyViolation):
+		return models.ErrorKindBlocked
+	default:
+		return models.ErrorKindFailed
+	}
+}
 
-	case models.NodeTypeEnd:
-		output, err = e.executeEndNode(ctx, node, execCtx)
+// runNode runs a single node in a span of its own and adds its step to the execution
+func (e *Engine) runNode(ctx context.Context, node *models.NodeResponse, execCtx *models.ExecutionContext) error {
+	ctx, span := tracing.Tracer().Start(ctx, "workflow.node "+node.Type, trace.WithAttributes(
+		attribute.String("workflow.node.id", node.ID),
+		attribute.String("workflow.node.type", node.Type),
+	))
+	defer span.End()
 
-	default:
-		err = fmt.Errorf("unsupported node type: %s", node.Type)
+	stepStart := time.Now()
+
+	step := models.ExecutionStep{
+		NodeID: node.ID,
+		Type:   node.Type,
+		Label:  "Unknown",
+		Status: "running",
+	}
+
+	var output interface{}
+
+	// Execute node using its registered node type
+	executor, err := getNodeExecutor(node.Type)
+	if err == nil {
+		description := executor.Describe()
+		step.Label = description.Label
+		step.Description = description.Description
+
+		output, err = executor.Execute(ctx, e, node, execCtx)
+	} else {
+		step.Description = "Unknown node type"
 	}
 
 	// Update step with results
@@ -143,37 +284,44 @@ func (e *Engine) executeNode(ctx context.Context, node *models.NodeResponse, nod
 
 	if err != nil {
 		step.Status = "failed"
-		step.Error = stringPtr(err.Error())
+		step.Error = stringPtr(redactText(ctx, err.Error()))
+		step.ErrorKind = errorKind(err)
 	} else {
 		step.Status = "completed"
 		if output != nil {
 			outputBytes, _ := json.Marshal(output)
-			step.RawOutput = outputBytes
+			outputBytes = []byte(RedactorFromContext(ctx).Redact(string(outputBytes)))
+			// Stored output never holds sensitive values, revealing them only changes this response
+			step.RawOutput = PIIRedactorFromContext(ctx).RedactJSON(outputBytes)
+			if PIIRevealed(ctx) {
+				step.RevealedOutput = outputBytes
+			}
 			// TODO: Set strongly typed output when we have type info
 		}
 	}
 
 	execCtx.AddStep(step)
 
+	span.SetAttributes(attribute.String("workflow.node.status", step.Status))
 	if err != nil {
-		return err
+		tracing.RecordError(span, errors.New(redactTraceText(ctx, err.Error())))
 	}
-
-	// Continue to next nodes based on node type and condition results
-	if err := e.continueToNextNodes(ctx, node, nodeMap, edgeMap, execCtx); err != nil {
-		return err
-	}
-
-	return nil
+	return err
 }
 
 // continueToNextNodes determines which nodes to execute next
 func (e *Engine) continueToNextNodes(ctx context.Context, currentNode *models.NodeResponse, nodeMap map[string]*models.NodeResponse, edgeMap map[string][]models.EdgeResponse, execCtx *models.ExecutionContext) error {
 	edges := edgeMap[currentNode.ID]
 
-	// For non-condition nodes, follow all edges
+	// For non-condition nodes, follow all edges but the error edges
 	if currentNode.Type != models.NodeTypeCondition {
-		return e.executeNextNodes(ctx, edges, nodeMap, edgeMap, execCtx)
+		var next []models.EdgeResponse
+		for _, edge := range edges {
+			if !isErrorEdge(edge) {
+				next = append(next, edge)
+			}
+		}
+		return e.executeNextNodes(ctx, next, nodeMap, edgeMap, execCtx)
 	}
 
 	// Special handling for condition nodes
@@ -268,12 +416,19 @@ func (e *Engine) executeFormNode(ctx context.Context, node *models.NodeResponse,
 		return e.storeAndReturnFormData(execCtx)
 	}
 
-	// Validate form input
-	err = e.validator.ValidateFormData(execCtx.FormData, &formData)
+	// Fill in field defaults, then validate form input against the field definitions
+	execCtx.FormData = e.validator.ApplyDefaults(execCtx.FormData, &formData)
+	err = e.validator.ValidateWorkflowFormData(execCtx.WorkflowID, execCtx.WorkflowVersion, node.ID, execCtx.FormData, &formData)
 	if err != nil {
+		var fieldErrors models.FieldErrors
+		if errors.As(err, &fieldErrors) {
+			return nil, &models.FormValidationError{NodeID: node.ID, Errors: fieldErrors}
+		}
 		return nil, fmt.Errorf("form validation failed: %w", err)
 	}
 
+	registerSensitiveFields(ctx, execCtx.FormData, &formData)
+
 	return e.storeAndReturnFormData(execCtx)
 }
 
@@ -301,21 +456,66 @@ func (e *Engine) executeIntegrationNode(ctx context.Context, node *models.NodeRe
 		return nil, fmt.Errorf("node data is not IntegrationNodeData type")
 	}
 
-	result, err := e.integrationService.ExecuteIntegration(ctx, integrationData, inputVariables)
+	result, err := e.runIntegration(ctx, integrationData, inputVariables)
 	if err != nil {
 		return nil, fmt.Errorf("integration execution failed: %w", err)
 	}
 
 	// Store results in execution context for downstream nodes from strongly typed output
-	if temperature, ok := result.ProcessedData["temperature"]; ok {
-		execCtx.SetVariable("temperature", temperature)
+	output := make(map[string]interface{}, len(result.ProcessedData)+1)
+	for name, value := range result.ProcessedData {
+		execCtx.SetVariable(name, value)
+		output[name] = value
 	}
-	if location, ok := result.ProcessedData["location"]; ok {
-		execCtx.SetVariable("location", location)
+	if result.Cache != "" {
+		output["cache"] = result.Cache
 	}
 
 	// Return the processed data for compatibility with existing interface
-	return result.ProcessedData, nil
+	return output, nil
+}
+
+// runIntegration runs an integration, retrying failures as the node's retry policy says
+func (e *Engine) runIntegration(ctx context.Context, data models.IntegrationNodeData, inputVariables map[string]interface{}) (models.IntegrationExecutionOutput, error) {
+	attempts, backoff := 1, time.Duration(0)
+	if retry := data.Metadata.Retry; retry != nil {
+		attempts = retry.MaxAttempts
+		backoff = time.Duration(retry.BackoffMs) * time.Millisecond
+	}
+
+	for attempt := 1; ; attempt++ {
+		result, err := e.integrationService.ExecuteIntegration(ctx, data, inputVariables)
+		if err == nil || attempt >= attempts || !retryable(ctx, err) {
+			if err != nil && attempt > 1 {
+				err = fmt.Errorf("failed after %d attempts: %w", attempt, err)
+			}
+			return result, err
+		}
+
+		slog.DebugContext(ctx, "Retrying integration", "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// retryable returns false for failures another attempt can't fix: calls refused by an open circuit or the
+// outbound policy, client errors, unknown locations and cancelled executions
+func retryable(ctx context.Context, err error) bool {
+	var statusErr *APIStatusError
+	switch {
+	case ctx.Err() != nil:
+		return false
+	case errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrPolicyViolation), errors.Is(err, ErrLocationNotFound):
+		return false
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	default:
+		return true
+	}
 }
 
 // executeConditionNode executes a condition node with threshold comparison
@@ -343,19 +543,21 @@ func (e *Engine) executeConditionNode(ctx context.Context, node *models.NodeResp
 		return nil, fmt.Errorf("condition threshold must be a number")
 	}
 
-	// Get temperature from context
-	temperatureValue, ok := execCtx.GetVariable("temperature")
+	// Get the compared variable from context, named by the condition expression
+	conditionData, _ := node.Data.(models.ConditionNodeData)
+	variable := conditionVariable(conditionData.Metadata.ConditionExpression)
+	actualValue, ok := execCtx.GetVariable(variable)
 	if !ok {
-		return nil, fmt.Errorf("temperature not found in execution context")
+		return nil, fmt.Errorf("%s not found in execution context", variable)
 	}
 
-	temperature, ok := temperatureValue.(float64)
+	actual, ok := actualValue.(float64)
 	if !ok {
-		return nil, fmt.Errorf("temperature must be a number")
+		return nil, fmt.Errorf("%s must be a number", variable)
 	}
 
 	// Evaluate condition based on frontend operator strings
-	conditionMet, err := e.evaluateCondition(temperature, operator, threshold)
+	conditionMet, err := e.evaluateCondition(actual, operator, threshold)
 	if err != nil {
 		return nil, fmt.Errorf("condition evaluation failed: %w", err)
 	}
@@ -363,12 +565,16 @@ func (e *Engine) executeConditionNode(ctx context.Context, node *models.NodeResp
 	// Store condition result in context for edge routing
 	execCtx.SetVariable("conditionMet", conditionMet)
 
+	unit := unitSymbol(variable, execCtx)
+
 	output := map[string]interface{}{
 		"conditionMet": conditionMet,
 		"operator":     operator,
 		"threshold":    threshold,
-		"actualValue":  temperature,
-		"message":      fmt.Sprintf("Temperature %.1f°C %s %.1f°C - condition %s", temperature, e.getOperatorSymbol(operator), threshold, map[bool]string{true: "met", false: "not met"}[conditionMet]),
+		"variable":     variable,
+		"actualValue":  actual,
+		"message": fmt.Sprintf("%s %.1f%s %s %.1f%s - condition %s", variableLabel(variable), actual, unit, e.getOperatorSymbol(operator), threshold, unit,
+			map[bool]string{true: "met", false: "not met"}[conditionMet]),
 	}
 
 	return output, nil
@@ -396,18 +602,26 @@ func (e *Engine) executeEmailNode(ctx context.Context, node *models.NodeResponse
 
 	// Build email content
 	subject := "Weather Alert"
-	body := fmt.Sprintf("Weather alert for %s! Temperature is %.1f°C!", location, temperature)
+	body := fmt.Sprintf("Weather alert for %s! Temperature is %.1f%s!", location, temperature, unitSymbol("temperature", execCtx))
 	if nameStr, ok := name.(string); ok {
 		body = fmt.Sprintf("Hi %s, %s", nameStr, strings.ToLower(body))
 	}
 
 	fromEmail := "weather-alerts@example.com"
 
-	// Send email (mock implementation)
-	if err := e.emailService.SendEmail(ctx, toEmail, subject, body); err != nil {
+	// Send email (mock implementation), only once per node across retries of an idempotent execution
+	sent, err := e.emailService.SendEmail(ctx, toEmail, subject, body, nodeDedupeKey(execCtx, node))
+	if err != nil {
+		metrics.Emails.WithLabelValues("failed").Inc()
 		return nil, fmt.Errorf("failed to send email: %w", err)
 	}
 
+	deliveryStatus := "sent"
+	if sent.Duplicate {
+		deliveryStatus = "duplicate"
+	}
+	metrics.Emails.WithLabelValues(deliveryStatus).Inc()
+
 	output := map[string]interface{}{
 		"emailDraft": map[string]interface{}{
 			"to":        toEmail,
@@ -416,8 +630,8 @@ func (e *Engine) executeEmailNode(ctx context.Context, node *models.NodeResponse
 			"body":      body,
 			"timestamp": time.Now().Format(time.RFC3339),
 		},
-		"deliveryStatus": "sent",
-		"messageId":      fmt.Sprintf("msg_%d", time.Now().UnixNano()),
+		"deliveryStatus": deliveryStatus,
+		"messageId":      sent.MessageID,
 		"emailSent":      true,
 	}
 
@@ -435,44 +649,6 @@ func (e *Engine) executeEndNode(ctx context.Context, node *models.NodeResponse,
 
 // Helper functions
 
-func (e *Engine) getNodeLabel(node *models.NodeResponse) string {
-	switch node.Type {
-	case models.NodeTypeStart:
-		return "Start"
-	case models.NodeTypeForm:
-		return "User Input"
-	case models.NodeTypeIntegration:
-		return "Weather API"
-	case models.NodeTypeCondition:
-		return "Check Condition"
-	case models.NodeTypeEmail:
-		return "Send Alert"
-	case models.NodeTypeEnd:
-		return "Complete"
-	default:
-		return "Unknown"
-	}
-}
-
-func (e *Engine) getNodeDescription(node *models.NodeResponse) string {
-	switch node.Type {
-	case models.NodeTypeStart:
-		return "Begin weather check workflow"
-	case models.NodeTypeForm:
-		return "Process collected data - name, email, location"
-	case models.NodeTypeIntegration:
-		return "Fetch current temperature"
-	case models.NodeTypeCondition:
-		return "Evaluate temperature threshold"
-	case models.NodeTypeEmail:
-		return "Email weather alert notification"
-	case models.NodeTypeEnd:
-		return "Workflow execution finished"
-	default:
-		return "Unknown node type"
-	}
-}
-
 // evaluateCondition evaluates condition using frontend operator strings
 func (e *Engine) evaluateCondition(temperature float64, operator string, threshold float64) (bool, error) {
 	switch operator {
@@ -491,6 +667,25 @@ func (e *Engine) evaluateCondition(temperature float64, operator string, thresho
 	}
 }
 
+// variableNamePattern matches execution context variable names
+var variableNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// conditionVariable returns the variable a condition expression compares, the identifier it starts with,
+// e.g. "precip_probability_tomorrow {{operator}} {{threshold}}". Expressions not naming one compare temperature.
+func conditionVariable(expression string) string {
+	fields := strings.Fields(expression)
+	if len(fields) == 0 || !variableNamePattern.MatchString(fields[0]) {
+		return "temperature"
+	}
+	return fields[0]
+}
+
+// variableLabel turns a variable name into a label for messages, e.g. max_temp_tomorrow into "Max temp tomorrow"
+func variableLabel(variable string) string {
+	label := strings.ReplaceAll(variable, "_", " ")
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
 // getOperatorSymbol returns the mathematical symbol for the operator
 func (e *Engine) getOperatorSymbol(operator string) string {
 	switch operator {
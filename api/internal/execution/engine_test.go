@@ -2,8 +2,17 @@ package execution
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/codes"
+
+	"workflow-code-test/api/internal/metrics"
 	"workflow-code-test/api/internal/models"
 )
 
@@ -38,7 +47,7 @@ func TestEngine_ExecuteWorkflow(t *testing.T) {
 					Description: "User input form",
 					Metadata: models.FormNodeMetadata{
 						HasHandles:      models.HandleConfig{Source: true, Target: true},
-						InputFields:     []string{"name", "email", "city"},
+						InputFields:     models.NewFormFields("name", "email", "city"),
 						OutputVariables: []string{"name", "email", "city"},
 					},
 				},
@@ -178,7 +187,7 @@ func TestDefaultInputValidator_ValidateFormData(t *testing.T) {
 		Description: "Test form validation",
 		Metadata: models.FormNodeMetadata{
 			HasHandles:      models.HandleConfig{Source: true, Target: true},
-			InputFields:     []string{"name", "email"},
+			InputFields:     models.NewFormFields("name", "email"),
 			OutputVariables: []string{"name", "email"},
 		},
 	}
@@ -239,7 +248,7 @@ func TestEngine_ExecuteWorkflow_APIFailure(t *testing.T) {
 					Description: "User input form",
 					Metadata: models.FormNodeMetadata{
 						HasHandles:      models.HandleConfig{Source: true, Target: true},
-						InputFields:     []string{"name", "email", "city"},
+						InputFields:     models.NewFormFields("name", "email", "city"),
 						OutputVariables: []string{"name", "email", "city"},
 					},
 				},
@@ -271,7 +280,9 @@ func TestEngine_ExecuteWorkflow_APIFailure(t *testing.T) {
 	// Create execution request
 	req := &models.ExecutionRequest{
 		FormData: map[string]interface{}{
-			"city": "Sydney",
+			"name":  "Alice",
+			"email": "alice@example.com",
+			"city":  "Sydney",
 		},
 	}
 
@@ -291,7 +302,654 @@ func TestEngine_ExecuteWorkflow_APIFailure(t *testing.T) {
 	}
 
 	// Should have executed start and form, but failed on integration
-	if len(result.Steps) < 2 {
-		t.Errorf("Expected at least 2 steps, got %d", len(result.Steps))
+	if len(result.Steps) != 3 || result.Steps[2].Status != "failed" {
+		t.Errorf("Expected the integration step to fail after start and form, got %+v", result.Steps)
+	}
+}
+
+// echoNode is an executable node type registered by the tests to exercise the registry
+type echoNode struct{ models.NodeType }
+
+func (echoNode) Execute(ctx context.Context, e *Engine, node *models.NodeResponse, execCtx *models.ExecutionContext) (interface{}, error) {
+	execCtx.SetVariable("echo", "hello")
+	return map[string]interface{}{"echo": "hello"}, nil
+}
+
+func TestEngine_ExecuteWorkflow_RegisteredNodeType(t *testing.T) {
+	RegisterNodeType(echoNode{models.NewNodeType[models.StartNodeData, models.StartExecutionOutput](models.NodeTypeDescription{
+		Type:        "test_echo",
+		Label:       "Echo",
+		Description: "Echo a greeting",
+		Category:    models.NodeCategoryAction,
+	})})
+
+	engine := NewEngineWithAPIClient(NewMockAPIClient())
+
+	workflow := &models.WorkflowResponse{
+		ID: "test-workflow",
+		Nodes: []models.NodeResponse{
+			{ID: "start", Type: models.NodeTypeStart},
+			{ID: "echo", Type: "test_echo"},
+			{ID: "end", Type: models.NodeTypeEnd},
+		},
+		Edges: []models.EdgeResponse{
+			{ID: "e1", Source: "start", Target: "echo"},
+			{ID: "e2", Source: "echo", Target: "end"},
+		},
+	}
+
+	result, err := engine.ExecuteWorkflow(context.Background(), workflow, &models.ExecutionRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Status != "completed" {
+		t.Fatalf("Expected status 'completed', got '%s': %v", result.Status, result.Error)
+	}
+
+	if len(result.Steps) != 3 || result.Steps[1].Label != "Echo" || result.Steps[1].Description != "Echo a greeting" {
+		t.Errorf("Expected the echo step to be described by its node type, got %+v", result.Steps)
+	}
+}
+
+func TestEngine_ExecuteWorkflow_UnknownNodeType(t *testing.T) {
+	engine := NewEngineWithAPIClient(NewMockAPIClient())
+
+	workflow := &models.WorkflowResponse{
+		ID: "test-workflow",
+		Nodes: []models.NodeResponse{
+			{ID: "start", Type: models.NodeTypeStart},
+			{ID: "mystery", Type: "mystery"},
+		},
+		Edges: []models.EdgeResponse{
+			{ID: "e1", Source: "start", Target: "mystery"},
+		},
+	}
+
+	result, err := engine.ExecuteWorkflow(context.Background(), workflow, &models.ExecutionRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error from ExecuteWorkflow, got %v", err)
+	}
+
+	if result.Status != "failed" {
+		t.Errorf("Expected status 'failed', got '%s'", result.Status)
+	}
+
+	if len(result.Steps) != 2 || result.Steps[1].Label != "Unknown" {
+		t.Errorf("Expected unknown node step to be labelled 'Unknown', got %+v", result.Steps)
+	}
+}
+
+func TestDefaultInputValidator_ValidateFormData_FieldDefinitions(t *testing.T) {
+	validator := NewDefaultInputValidator()
+
+	formData := &models.FormNodeData{
+		Label: "Test Form",
+		Metadata: models.FormNodeMetadata{
+			InputFields: []models.FormField{
+				{Name: "name", Type: models.FormFieldTypeText, Required: true, Validation: []string{"min_length:2", "alpha_only"}},
+				{Name: "email", Type: models.FormFieldTypeEmail, Required: true},
+				{Name: "units", Type: models.FormFieldTypeSelect, Options: []string{"metric", "imperial"}, Default: "metric"},
+				{Name: "threshold", Type: models.FormFieldTypeNumber, Validation: []string{"range:-50,60"}},
+				{Name: "startDate", Type: models.FormFieldTypeDate},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		input   map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:  "valid with optional fields omitted",
+			input: map[string]interface{}{"name": "Alice", "email": "alice@example.com"},
+		},
+		{
+			name:  "valid with all fields",
+			input: map[string]interface{}{"name": "Alice", "email": "alice@example.com", "units": "imperial", "threshold": 25.0, "startDate": "2025-01-31"},
+		},
+		{
+			name:    "rule violation",
+			input:   map[string]interface{}{"name": "A1", "email": "alice@example.com"},
+			wantErr: "field 'name': must contain only letters",
+		},
+		{
+			name:    "option not allowed",
+			input:   map[string]interface{}{"name": "Alice", "email": "alice@example.com", "units": "kelvin"},
+			wantErr: "field 'units': must be one of: metric, imperial",
+		},
+		{
+			name:    "number out of range",
+			input:   map[string]interface{}{"name": "Alice", "email": "alice@example.com", "threshold": 100.0},
+			wantErr: "field 'threshold': must be between -50 and 60",
+		},
+		{
+			name:    "wrong type",
+			input:   map[string]interface{}{"name": "Alice", "email": "alice@example.com", "threshold": "hot"},
+			wantErr: "field 'threshold': must be a number",
+		},
+		{
+			name:    "invalid date",
+			input:   map[string]interface{}{"name": "Alice", "email": "alice@example.com", "startDate": "31/01/2025"},
+			wantErr: "field 'startDate': must be a date in YYYY-MM-DD format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateFormData(tt.input, formData)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	withDefaults := validator.ApplyDefaults(map[string]interface{}{"name": "Alice"}, formData)
+	if withDefaults["units"] != "metric" {
+		t.Errorf("Expected units to default to 'metric', got %v", withDefaults["units"])
+	}
+}
+
+func TestEngine_ExecuteWorkflow_FormValidationError(t *testing.T) {
+	engine := NewEngineWithAPIClient(NewMockAPIClient())
+
+	workflow := &models.WorkflowResponse{
+		ID: "test-workflow",
+		Nodes: []models.NodeResponse{
+			{ID: "start", Type: models.NodeTypeStart},
+			{
+				ID:   "form",
+				Type: models.NodeTypeForm,
+				Data: models.FormNodeData{
+					Label: "Form",
+					Metadata: models.FormNodeMetadata{
+						InputFields: models.NewFormFields("name", "email"),
+					},
+				},
+			},
+		},
+		Edges: []models.EdgeResponse{
+			{ID: "e1", Source: "start", Target: "form"},
+		},
+	}
+
+	req := &models.ExecutionRequest{
+		FormData: map[string]interface{}{"email": "not-an-email"},
+	}
+
+	result, err := engine.ExecuteWorkflow(context.Background(), workflow, req)
+	if result != nil {
+		t.Errorf("Expected no execution result, got %+v", result)
+	}
+
+	var formErr *models.FormValidationError
+	if !errors.As(err, &formErr) {
+		t.Fatalf("Expected a form validation error, got %v", err)
+	}
+	if formErr.NodeID != "form" || len(formErr.Errors) != 2 {
+		t.Fatalf("Expected 2 field errors from node form, got %+v", formErr)
+	}
+
+	if got := formErr.Errors[0]; got.Field != "name" || got.Rule != models.FormRuleRequired || got.Value != nil {
+		t.Errorf("Expected missing name to fail the required rule, got %+v", got)
+	}
+	// Legacy email fields are sensitive, so the rejected value is masked
+	if got := formErr.Errors[1]; got.Field != "email" || got.Rule != models.FormRuleEmail || got.Value != models.RedactedValue {
+		t.Errorf("Expected the masked email value, got %+v", got)
+	}
+}
+
+func TestDefaultInputValidator_ValidateFormData_CrossFieldRules(t *testing.T) {
+	validator := NewDefaultInputValidator()
+
+	formData := &models.FormNodeData{
+		Label: "Trip Form",
+		Metadata: models.FormNodeMetadata{
+			InputFields: []models.FormField{
+				{Name: "startDate", Type: models.FormFieldTypeDate, Required: true},
+				{Name: "endDate", Type: models.FormFieldTypeDate, Required: true},
+				{Name: "notifyBy", Type: models.FormFieldTypeSelect, Options: []string{"email", "sms"}},
+				{Name: "email", Type: models.FormFieldTypeEmail},
+				{Name: "phone", Type: models.FormFieldTypeText},
+			},
+			Rules: []models.FormRule{
+				{Rule: models.FormRuleAfter, Fields: []string{"endDate", "startDate"}},
+				{Rule: models.FormRuleRequiredIf, Fields: []string{"phone"}, When: &models.FormRuleCondition{Field: "notifyBy", Equals: "sms"}},
+				{Rule: models.FormRuleAtLeastOne, Fields: []string{"email", "phone"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		input      map[string]interface{}
+		wantField  string
+		wantRule   string
+		wantFields []string
+	}{
+		{
+			name:  "all rules satisfied",
+			input: map[string]interface{}{"startDate": "2025-01-01", "endDate": "2025-01-05", "notifyBy": "sms", "phone": "0400000000"},
+		},
+		{
+			name:       "end date before start date",
+			input:      map[string]interface{}{"startDate": "2025-01-05", "endDate": "2025-01-01", "email": "alice@example.com"},
+			wantField:  "endDate",
+			wantRule:   models.FormRuleAfter,
+			wantFields: []string{"endDate", "startDate"},
+		},
+		{
+			name:       "phone required for sms",
+			input:      map[string]interface{}{"startDate": "2025-01-01", "endDate": "2025-01-05", "notifyBy": "sms", "email": "alice@example.com"},
+			wantField:  "phone",
+			wantRule:   models.FormRuleRequiredIf,
+			wantFields: []string{"phone", "notifyBy"},
+		},
+		{
+			name:       "no contact details",
+			input:      map[string]interface{}{"startDate": "2025-01-01", "endDate": "2025-01-05", "notifyBy": "email"},
+			wantField:  "email",
+			wantRule:   models.FormRuleAtLeastOne,
+			wantFields: []string{"email", "phone"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateFormData(tt.input, formData)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			var fieldErrors models.FieldErrors
+			if !errors.As(err, &fieldErrors) || len(fieldErrors) != 1 {
+				t.Fatalf("Expected a single field error, got %v", err)
+			}
+			got := fieldErrors[0]
+			if got.Field != tt.wantField || got.Rule != tt.wantRule || strings.Join(got.Fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("Expected %s to fail %s involving %v, got %+v", tt.wantField, tt.wantRule, tt.wantFields, got)
+			}
+		})
+	}
+}
+
+func TestDefaultInputValidator_ValidateWorkflowFormData_Cache(t *testing.T) {
+	validator := NewDefaultInputValidator()
+
+	formData := &models.FormNodeData{
+		Label: "Form",
+		Metadata: models.FormNodeMetadata{
+			InputFields: []models.FormField{
+				{Name: "code", Required: true, Validation: []string{`regex:^[A-Z]{3}$`}},
+			},
+		},
+	}
+
+	if err := validator.ValidateWorkflowFormData("wf", "v1", "form", map[string]interface{}{"code": "ABC"}, formData); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	compiled := validator.cache.workflows["wf"].forms["form"]
+
+	err := validator.ValidateWorkflowFormData("wf", "v1", "form", map[string]interface{}{"code": "abc"}, formData)
+	var fieldErrors models.FieldErrors
+	if !errors.As(err, &fieldErrors) || fieldErrors[0].Rule != models.FormRuleRegex {
+		t.Fatalf("Expected a regex field error, got %v", err)
+	}
+	if validator.cache.workflows["wf"].forms["form"] != compiled {
+		t.Error("Expected the compiled form to be reused for the same workflow version")
+	}
+
+	if err := validator.ValidateWorkflowFormData("wf", "v2", "form", map[string]interface{}{"code": "ABC"}, formData); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entry := validator.cache.workflows["wf"]; entry.version != "v2" || entry.forms["form"] == compiled {
+		t.Error("Expected a new workflow version to replace the compiled forms")
+	}
+
+	// Patterns that slipped past save-time validation fail loudly rather than being skipped
+	formData.Metadata.InputFields[0].Validation = []string{`regex:^[A-Z`}
+	err = validator.ValidateWorkflowFormData("wf", "v3", "form", map[string]interface{}{"code": "ABC"}, formData)
+	if err == nil || errors.As(err, &fieldErrors) {
+		t.Errorf("Expected an invalid pattern error, got %v", err)
+	}
+}
+
+func TestDefaultInputValidator_ValidateWorkflowFormData_CacheMiss(t *testing.T) {
+	validator := NewDefaultInputValidator()
+
+	formData := &models.FormNodeData{
+		Label: "Form",
+		Metadata: models.FormNodeMetadata{
+			InputFields: []models.FormField{{Name: "code", Required: true}},
+		},
+	}
+
+	// A stale entry for the same version was compiled before the field had its regex rule
+	if err := validator.ValidateWorkflowFormData("wf", "v1", "form", map[string]interface{}{"code": "abc"}, formData); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	formData.Metadata.InputFields[0].Validation = []string{`regex:^[A-Z]{3}$`}
+
+	if err := validator.ValidateWorkflowFormData("wf", "v1", "form", map[string]interface{}{"code": "ABC"}, formData); err != nil {
+		t.Errorf("Expected the pattern missing from the cache to be compiled, got %v", err)
+	}
+	err := validator.ValidateWorkflowFormData("wf", "v1", "form", map[string]interface{}{"code": "abc"}, formData)
+	var fieldErrors models.FieldErrors
+	if !errors.As(err, &fieldErrors) || fieldErrors[0].Rule != models.FormRuleRegex {
+		t.Errorf("Expected a regex field error, got %v", err)
+	}
+
+	formData.Metadata.InputFields[0].Validation = []string{`regex:^[A-Z`}
+	err = validator.ValidateWorkflowFormData("wf", "v1", "form", map[string]interface{}{"code": "ABC"}, formData)
+	if !errors.As(err, &fieldErrors) || fieldErrors[0].Rule != models.FormRuleRegex {
+		t.Errorf("Expected an uncompilable pattern missing from the cache to reject the value, got %v", err)
+	}
+}
+
+func TestEngine_ExecuteConditionNode_ExpressionVariable(t *testing.T) {
+	engine := NewEngineWithAPIClient(NewMockAPIClient())
+
+	tests := []struct {
+		name        string
+		expression  string
+		variables   map[string]interface{}
+		wantMet     bool
+		wantMessage string
+	}{
+		{
+			name:        "forecast variable",
+			expression:  "precip_probability_tomorrow {{operator}} {{threshold}}",
+			variables:   map[string]interface{}{"precip_probability_tomorrow": 85.0, "temperature": 10.0},
+			wantMet:     true,
+			wantMessage: "Precip probability tomorrow 85.0% > 60.0% - condition met",
+		},
+		{
+			name:        "temperature in fahrenheit",
+			expression:  "max_temp_tomorrow > 60",
+			variables:   map[string]interface{}{"max_temp_tomorrow": 55.0, WeatherVarTemperatureUnit: models.TemperatureUnitFahrenheit},
+			wantMet:     false,
+			wantMessage: "Max temp tomorrow 55.0°F > 60.0°F - condition not met",
+		},
+		{
+			name:        "expression without a variable",
+			expression:  "{{operator}} {{threshold}}",
+			variables:   map[string]interface{}{"temperature": 72.0},
+			wantMet:     true,
+			wantMessage: "Temperature 72.0°C > 60.0°C - condition met",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execCtx := models.NewExecutionContext("test-workflow", nil)
+			execCtx.SetVariable("condition_operator", "greater_than")
+			execCtx.SetVariable("condition_threshold", 60.0)
+			for name, value := range tt.variables {
+				execCtx.SetVariable(name, value)
+			}
+
+			node := &models.NodeResponse{
+				ID:   "condition",
+				Type: models.NodeTypeCondition,
+				Data: models.ConditionNodeData{Metadata: models.ConditionNodeMetadata{ConditionExpression: tt.expression}},
+			}
+			output, err := engine.executeConditionNode(context.Background(), node, execCtx)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			result := output.(map[string]interface{})
+			if result["conditionMet"] != tt.wantMet {
+				t.Errorf("Expected conditionMet %v, got %v", tt.wantMet, result["conditionMet"])
+			}
+			if result["message"] != tt.wantMessage {
+				t.Errorf("Expected message %q, got %q", tt.wantMessage, result["message"])
+			}
+		})
+	}
+}
+
+func TestEngine_ExecuteWorkflow_RecordsMetrics(t *testing.T) {
+	engine := NewEngineWithAPIClient(NewMockAPIClient())
+
+	workflow := &models.WorkflowResponse{
+		ID: "metrics-workflow",
+		Nodes: []models.NodeResponse{
+			{ID: "start", Type: models.NodeTypeStart},
+			{ID: "end", Type: models.NodeTypeEnd},
+		},
+		Edges: []models.EdgeResponse{
+			{ID: "e1", Source: "start", Target: "end"},
+		},
+	}
+
+	if _, err := engine.ExecuteWorkflow(WithStoredWorkflow(context.Background()), workflow, &models.ExecutionRequest{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got := testutil.ToFloat64(metrics.Executions.WithLabelValues("metrics-workflow", "completed")); got != 1 {
+		t.Errorf("Expected 1 completed execution, got %v", got)
+	}
+	// One series per node type and step status, here the start and end nodes
+	if got := testutil.CollectAndCount(metrics.NodeDuration); got < 2 {
+		t.Errorf("Expected the start and end node durations to be observed, got %d series", got)
+	}
+	if got := testutil.ToFloat64(metrics.Executions.WithLabelValues("metrics-workflow", "failed")); got != 0 {
+		t.Errorf("Expected no failed executions, got %v", got)
+	}
+}
+
+func TestEngine_ExecuteWorkflow_CountsUnsavedWorkflowsAsAdhoc(t *testing.T) {
+	engine := NewEngineWithAPIClient(NewMockAPIClient())
+
+	workflow := &models.WorkflowResponse{
+		ID: "unsaved-workflow",
+		Nodes: []models.NodeResponse{
+			{ID: "start", Type: models.NodeTypeStart},
+			{ID: "end", Type: models.NodeTypeEnd},
+		},
+		Edges: []models.EdgeResponse{
+			{ID: "e1", Source: "start", Target: "end"},
+		},
+	}
+
+	before := testutil.ToFloat64(metrics.Executions.WithLabelValues(adhocWorkflowLabel, "completed"))
+	if _, err := engine.ExecuteWorkflow(context.Background(), workflow, &models.ExecutionRequest{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got := testutil.ToFloat64(metrics.Executions.WithLabelValues(adhocWorkflowLabel, "completed")) - before; got != 1 {
+		t.Errorf("Expected 1 completed adhoc execution, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.Executions.WithLabelValues("unsaved-workflow", "completed")); got != 0 {
+		t.Errorf("Expected no series for the unsaved workflow's ID, got %v", got)
+	}
+}
+
+func TestEngine_ExecuteWorkflow_Spans(t *testing.T) {
+	recorder := recordSpans(t)
+	engine := NewEngineWithAPIClient(NewMockAPIClient())
+
+	workflow := &models.WorkflowResponse{
+		ID: "test-workflow",
+		Nodes: []models.NodeResponse{
+			{ID: "start", Type: models.NodeTypeStart},
+			{ID: "mystery", Type: "mystery"},
+		},
+		Edges: []models.EdgeResponse{
+			{ID: "e1", Source: "start", Target: "mystery"},
+		},
+	}
+
+	if _, err := engine.ExecuteWorkflow(context.Background(), workflow, &models.ExecutionRequest{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected an execution span and 2 node spans, got %d", len(spans))
+	}
+	execution := spans[2]
+	if execution.Name() != "workflow.execute" || execution.Status().Code != codes.Error {
+		t.Errorf("Expected a failed workflow.execute span, got %s %v", execution.Name(), execution.Status())
+	}
+
+	expected := []struct {
+		name   string
+		status codes.Code
+	}{
+		{"workflow.node start", codes.Unset},
+		{"workflow.node mystery", codes.Error},
+	}
+	for i, node := range expected {
+		span := spans[i]
+		if span.Name() != node.name || span.Status().Code != node.status {
+			t.Errorf("Expected span %s with status %v, got %s with %v", node.name, node.status, span.Name(), span.Status())
+		}
+		// Node spans are siblings under the execution, however deep the workflow
+		if span.Parent().SpanID() != execution.SpanContext().SpanID() {
+			t.Errorf("Expected %s to be a child of the execution span", span.Name())
+		}
+	}
+}
+
+// weatherWorkflow builds start -> form -> weather -> end, with an error edge from weather to a fallback end
+// node. The weather node calls endpoint, with the given retry policy.
+func weatherWorkflow(endpoint string, retry *models.RetryPolicy) *models.WorkflowResponse {
+	errorHandle := models.ErrorHandle
+	endData := models.EndNodeData{
+		Label:    "End",
+		Metadata: models.EndNodeMetadata{HasHandles: models.HandleConfig{Target: true}},
+	}
+	return &models.WorkflowResponse{
+		ID: "weather-workflow",
+		Nodes: []models.NodeResponse{
+			{ID: "start", Type: models.NodeTypeStart, Data: models.StartNodeData{
+				Label:    "Start",
+				Metadata: models.StartNodeMetadata{HasHandles: models.HandleConfig{Source: true}},
+			}},
+			{ID: "form", Type: models.NodeTypeForm, Data: models.FormNodeData{
+				Label: "Form",
+				Metadata: models.FormNodeMetadata{
+					HasHandles:      models.HandleConfig{Source: true, Target: true},
+					InputFields:     models.NewFormFields("city"),
+					OutputVariables: []string{"city"},
+				},
+			}},
+			{ID: "weather", Type: models.NodeTypeIntegration, Data: models.IntegrationNodeData{
+				Label: "Weather API",
+				Metadata: models.IntegrationNodeMetadata{
+					HasHandles:      models.HandleConfig{Source: true, Target: true},
+					InputVariables:  []string{"city"},
+					APIEndpoint:     endpoint + "?latitude={lat}&longitude={lon}&current_weather=true",
+					Options:         []models.LocationOption{{City: "Sydney", Lat: -33.8688, Lon: 151.2093}},
+					OutputVariables: []string{"temperature"},
+					Retry:           retry,
+				},
+			}},
+			{ID: "done", Type: models.NodeTypeEnd, Data: endData},
+			{ID: "fallback", Type: models.NodeTypeEnd, Data: endData},
+		},
+		Edges: []models.EdgeResponse{
+			{ID: "e1", Source: "start", Target: "form"},
+			{ID: "e2", Source: "form", Target: "weather"},
+			{ID: "e3", Source: "weather", Target: "done"},
+			{ID: "e4", Source: "weather", Target: "fallback", SourceHandle: &errorHandle},
+		},
+	}
+}
+
+// stepNodeIDs returns the IDs of the nodes an execution ran, in order
+func stepNodeIDs(steps []models.ExecutionStep) []string {
+	ids := make([]string, len(steps))
+	for i, step := range steps {
+		ids[i] = step.NodeID
+	}
+	return ids
+}
+
+func TestEngine_ExecuteWorkflow_RetriesFailedCalls(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"current_weather": {"temperature": 21.5}}`))
+	}))
+	defer server.Close()
+
+	engine := NewEngineWithAPIClient(NewHTTPAPIClient())
+	workflow := weatherWorkflow(server.URL, &models.RetryPolicy{MaxAttempts: 3, BackoffMs: 1})
+	req := &models.ExecutionRequest{FormData: map[string]interface{}{"city": "Sydney"}}
+
+	result, err := engine.ExecuteWorkflow(context.Background(), workflow, req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Status != "completed" || requests != 2 {
+		t.Errorf("Expected the execution to complete on the second attempt, got %s after %d requests", result.Status, requests)
+	}
+	// The error edge is only followed when the node fails
+	if got := stepNodeIDs(result.Steps); !reflect.DeepEqual(got, []string{"start", "form", "weather", "done"}) {
+		t.Errorf("Expected the normal edge to be followed, got %v", got)
+	}
+}
+
+func TestEngine_ExecuteWorkflow_OpenCircuitFollowsErrorEdge(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := DefaultGuardConfig()
+	config.CircuitBreaker.FailureThreshold = 1
+	engine := NewEngineWithAPIClient(NewGuardedAPIClient(NewHTTPAPIClient(), config))
+	workflow := weatherWorkflow(server.URL, &models.RetryPolicy{MaxAttempts: 3, BackoffMs: 1})
+	req := &models.ExecutionRequest{FormData: map[string]interface{}{"city": "Sydney"}}
+
+	result, err := engine.ExecuteWorkflow(context.Background(), workflow, req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The first failure opens the circuit, and the retry gives up on the open circuit without a request
+	if requests != 1 {
+		t.Errorf("Expected 1 request before the circuit opened, got %d", requests)
+	}
+	if result.Status != "completed" {
+		t.Errorf("Expected the error edge to complete the execution, got %s: %v", result.Status, result.Error)
+	}
+	if got := stepNodeIDs(result.Steps); !reflect.DeepEqual(got, []string{"start", "form", "weather", "fallback"}) {
+		t.Errorf("Expected only the error edge to be followed, got %v", got)
+	}
+	if step := result.Steps[2]; step.Status != "failed" || step.ErrorKind != models.ErrorKindCircuitOpen {
+		t.Errorf("Expected the weather step to fail with an open circuit, got %s %s", step.Status, step.ErrorKind)
+	}
+
+	// Without an error edge the open circuit fails the execution
+	workflow.Edges = workflow.Edges[:3]
+	result, err = engine.ExecuteWorkflow(context.Background(), workflow, req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Status != "failed" || result.Steps[2].ErrorKind != models.ErrorKindCircuitOpen {
+		t.Errorf("Expected the execution to fail with an open circuit, got %s %+v", result.Status, result.Steps[2])
+	}
+	if requests != 1 {
+		t.Errorf("Expected the open circuit to refuse the call, got %d requests", requests)
 	}
 }
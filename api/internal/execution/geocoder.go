@@ -0,0 +1,322 @@
+package execution
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Geocoder looks up the coordinates of places by name
+type Geocoder interface {
+	// Search returns the places named name, most relevant first
+	Search(ctx context.Context, name string) ([]Location, error)
+}
+
+// Location is a place returned by a Geocoder
+type Location struct {
+	Name        string  `json:"name"`
+	Admin1      string  `json:"admin1,omitempty"` // state, province or region
+	Country     string  `json:"country,omitempty"`
+	CountryCode string  `json:"countryCode,omitempty"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Population  int     `json:"population,omitempty"`
+}
+
+// String returns the qualified name of the location, e.g. "Perth, Western Australia, Australia"
+func (l Location) String() string {
+	parts := []string{l.Name}
+	for _, part := range []string{l.Admin1, l.Country} {
+		if part != "" && !strings.EqualFold(part, l.Name) {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// matches returns true if the qualifier names the location's region, country or country code
+func (l Location) matches(qualifier string) bool {
+	return strings.EqualFold(qualifier, l.Admin1) ||
+		strings.EqualFold(qualifier, l.Country) ||
+		strings.EqualFold(qualifier, l.CountryCode)
+}
+
+// ErrLocationNotFound is returned when no place matches a location query
+var ErrLocationNotFound = errors.New("location not found")
+
+// AmbiguousLocationError is returned when a location query matches several places and
+// none of them is clearly the one meant
+type AmbiguousLocationError struct {
+	Query      string
+	Candidates []Location
+}
+
+// Error implements the error interface for AmbiguousLocationError
+func (e *AmbiguousLocationError) Error() string {
+	names := make([]string, len(e.Candidates))
+	for i, candidate := range e.Candidates {
+		names[i] = "'" + candidate.String() + "'"
+	}
+	return fmt.Sprintf("location '%s' is ambiguous, did you mean one of: %s", e.Query, strings.Join(names, ", "))
+}
+
+// dominantPopulationRatio is how many times larger the most populous match must be than the next
+// for an unqualified query to resolve to it, so "London" means London, England without asking
+const dominantPopulationRatio = 10
+
+// ResolveLocation resolves a location query to a single place. The query is a place name optionally
+// followed by comma separated qualifiers, e.g. "Perth, Scotland" or "Springfield, IL, US".
+func ResolveLocation(ctx context.Context, geocoder Geocoder, query string) (*Location, error) {
+	parts := strings.Split(query, ",")
+	name := strings.TrimSpace(parts[0])
+	if name == "" {
+		return nil, fmt.Errorf("location must not be empty")
+	}
+
+	results, err := geocoder.Search(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up location '%s': %w", query, err)
+	}
+
+	var candidates []Location
+	for _, result := range results {
+		if !strings.EqualFold(result.Name, name) {
+			continue
+		}
+		matchesAll := true
+		for _, qualifier := range parts[1:] {
+			if qualifier = strings.TrimSpace(qualifier); qualifier != "" && !result.matches(qualifier) {
+				matchesAll = false
+				break
+			}
+		}
+		if matchesAll {
+			candidates = append(candidates, result)
+		}
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return nil, fmt.Errorf("%w: '%s'", ErrLocationNotFound, query)
+	case len(candidates) == 1:
+		return &candidates[0], nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Population > candidates[j].Population
+	})
+	if candidates[0].Population >= dominantPopulationRatio*max(candidates[1].Population, 1) {
+		return &candidates[0], nil
+	}
+	return nil, &AmbiguousLocationError{Query: query, Candidates: candidates}
+}
+
+// OpenMeteoGeocodingURL is the endpoint of the Open-Meteo geocoding API
+const OpenMeteoGeocodingURL = "https://geocoding-api.open-meteo.com/v1/search"
+
+// openMeteoSearchCount is the number of results requested per search
+const openMeteoSearchCount = 10
+
+// OpenMeteoGeocoder looks up places with the Open-Meteo geocoding API
+type OpenMeteoGeocoder struct {
+	apiClient APIClient
+	baseURL   string
+}
+
+// NewOpenMeteoGeocoder creates a geocoder calling the Open-Meteo geocoding API through the API client
+func NewOpenMeteoGeocoder(apiClient APIClient) *OpenMeteoGeocoder {
+	return &OpenMeteoGeocoder{
+		apiClient: apiClient,
+		baseURL:   OpenMeteoGeocodingURL,
+	}
+}
+
+// openMeteoGeocodingResponse is the response of the Open-Meteo geocoding API, results is omitted
+// when nothing matches
+type openMeteoGeocodingResponse struct {
+	Results []struct {
+		Name        string  `json:"name"`
+		Latitude    float64 `json:"latitude"`
+		Longitude   float64 `json:"longitude"`
+		CountryCode string  `json:"country_code"`
+		Country     string  `json:"country"`
+		Admin1      string  `json:"admin1"`
+		Population  int     `json:"population"`
+	} `json:"results"`
+}
+
+// Search implements Geocoder
+func (g *OpenMeteoGeocoder) Search(ctx context.Context, name string) ([]Location, error) {
+	searchURL := fmt.Sprintf("%s?name=%s&count=%d&language=en&format=json",
+		g.baseURL, url.QueryEscape(name), openMeteoSearchCount)
+
+	raw, err := g.apiClient.CallAPI(ctx, searchURL)
+	if err != nil {
+		return nil, fmt.Errorf("geocoding request failed: %w", err)
+	}
+
+	// The API client returns generic JSON, round trip it into the typed response
+	body, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read geocoding response: %w", err)
+	}
+	var response openMeteoGeocodingResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse geocoding response: %w", err)
+	}
+
+	locations := make([]Location, len(response.Results))
+	for i, result := range response.Results {
+		locations[i] = Location{
+			Name:        result.Name,
+			Admin1:      result.Admin1,
+			Country:     result.Country,
+			CountryCode: result.CountryCode,
+			Lat:         result.Latitude,
+			Lon:         result.Longitude,
+			Population:  result.Population,
+		}
+	}
+	return locations, nil
+}
+
+//go:embed data/gazetteer.csv
+var bundledGazetteer string
+
+// Gazetteer is an offline geocoder over a fixed list of places
+type Gazetteer struct {
+	locations map[string][]Location // lower cased name -> places, most populous first
+}
+
+// NewGazetteer loads a gazetteer from CSV with the header
+// name,admin1,country,country_code,latitude,longitude,population
+func NewGazetteer(r io.Reader) (*Gazetteer, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 7
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gazetteer: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("gazetteer has no header")
+	}
+
+	g := &Gazetteer{locations: make(map[string][]Location)}
+	// Line numbers are 1-based and the header is line 1
+	for i, record := range records[1:] {
+		lat, err := strconv.ParseFloat(record[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("gazetteer line %d has invalid latitude: %w", i+2, err)
+		}
+		lon, err := strconv.ParseFloat(record[5], 64)
+		if err != nil {
+			return nil, fmt.Errorf("gazetteer line %d has invalid longitude: %w", i+2, err)
+		}
+		population, err := strconv.Atoi(record[6])
+		if err != nil {
+			return nil, fmt.Errorf("gazetteer line %d has invalid population: %w", i+2, err)
+		}
+
+		key := strings.ToLower(record[0])
+		g.locations[key] = append(g.locations[key], Location{
+			Name:        record[0],
+			Admin1:      record[1],
+			Country:     record[2],
+			CountryCode: record[3],
+			Lat:         lat,
+			Lon:         lon,
+			Population:  population,
+		})
+	}
+
+	for _, locations := range g.locations {
+		sort.SliceStable(locations, func(i, j int) bool {
+			return locations[i].Population > locations[j].Population
+		})
+	}
+	return g, nil
+}
+
+// NewBundledGazetteer loads the gazetteer bundled with the API
+func NewBundledGazetteer() *Gazetteer {
+	g, err := NewGazetteer(strings.NewReader(bundledGazetteer))
+	if err != nil {
+		panic(fmt.Sprintf("bundled gazetteer is invalid: %v", err))
+	}
+	return g
+}
+
+// Search implements Geocoder
+func (g *Gazetteer) Search(ctx context.Context, name string) ([]Location, error) {
+	locations := g.locations[strings.ToLower(strings.TrimSpace(name))]
+	return append([]Location(nil), locations...), nil
+}
+
+// DefaultGeocoderCacheTTL is how long resolved places are cached, places rarely move
+const DefaultGeocoderCacheTTL = 24 * time.Hour
+
+// CachingGeocoder caches the search results of another geocoder
+type CachingGeocoder struct {
+	geocoder Geocoder
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]geocoderCacheEntry
+}
+
+type geocoderCacheEntry struct {
+	locations []Location
+	expires   time.Time
+}
+
+// NewCachingGeocoder wraps a geocoder, caching its results for ttl
+func NewCachingGeocoder(geocoder Geocoder, ttl time.Duration) *CachingGeocoder {
+	return &CachingGeocoder{
+		geocoder: geocoder,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]geocoderCacheEntry),
+	}
+}
+
+// Search implements Geocoder. Failed searches aren't cached so they are retried next time.
+func (c *CachingGeocoder) Search(ctx context.Context, name string) ([]Location, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && c.now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.locations, nil
+	}
+	c.mu.Unlock()
+
+	locations, err := c.geocoder.Search(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Drop expired entries while we hold the lock so the cache doesn't grow unbounded over time
+	now := c.now()
+	for cached, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, cached)
+		}
+	}
+	c.entries[key] = geocoderCacheEntry{locations: locations, expires: now.Add(c.ttl)}
+	return locations, nil
+}
@@ -0,0 +1,182 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveLocation(t *testing.T) {
+	gazetteer := NewBundledGazetteer()
+
+	tests := []struct {
+		name        string
+		query       string
+		wantCountry string
+		wantAdmin1  string
+		wantErr     error
+		wantCount   int // candidates of an ambiguous query
+	}{
+		{name: "single match", query: "Berlin", wantCountry: "DE", wantAdmin1: "Berlin"},
+		{name: "case insensitive", query: "  tokyo ", wantCountry: "JP", wantAdmin1: "Tokyo"},
+		{name: "dominant match", query: "London", wantCountry: "GB", wantAdmin1: "England"},
+		{name: "qualified by region", query: "London, Ontario", wantCountry: "CA", wantAdmin1: "Ontario"},
+		{name: "qualified by country code", query: "Perth, GB", wantCountry: "GB", wantAdmin1: "Scotland"},
+		{name: "qualified by region and country", query: "Portland, Maine, United States", wantCountry: "US", wantAdmin1: "Maine"},
+		{name: "not found", query: "Atlantis", wantErr: ErrLocationNotFound},
+		{name: "qualifier excludes all", query: "Berlin, France", wantErr: ErrLocationNotFound},
+		{name: "ambiguous", query: "Springfield", wantCount: 3},
+		{name: "ambiguous within qualifier", query: "Portland, US", wantCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := ResolveLocation(context.Background(), gazetteer, tt.query)
+
+			if tt.wantCount > 0 {
+				var ambiguous *AmbiguousLocationError
+				if !errors.As(err, &ambiguous) {
+					t.Fatalf("Expected AmbiguousLocationError, got %v", err)
+				}
+				if len(ambiguous.Candidates) != tt.wantCount {
+					t.Errorf("Expected %d candidates, got %d", tt.wantCount, len(ambiguous.Candidates))
+				}
+				return
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if location.CountryCode != tt.wantCountry || location.Admin1 != tt.wantAdmin1 {
+				t.Errorf("Expected %s in %s, got %s", tt.wantAdmin1, tt.wantCountry, location)
+			}
+		})
+	}
+}
+
+func TestAmbiguousLocationError_ListsCandidates(t *testing.T) {
+	_, err := ResolveLocation(context.Background(), NewBundledGazetteer(), "Springfield")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	// Candidates are listed most populous first
+	want := "location 'Springfield' is ambiguous, did you mean one of: 'Springfield, Missouri, United States', " +
+		"'Springfield, Massachusetts, United States', 'Springfield, Illinois, United States'"
+	if err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestNewGazetteer_InvalidCSV(t *testing.T) {
+	tests := map[string]string{
+		"wrong column count": "name,admin1,country,country_code,latitude,longitude,population\nSydney,NSW,Australia\n",
+		"invalid latitude":   "name,admin1,country,country_code,latitude,longitude,population\nSydney,NSW,Australia,AU,south,151.2,100\n",
+	}
+
+	for name, csv := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewGazetteer(strings.NewReader(csv)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestOpenMeteoGeocoder_Search(t *testing.T) {
+	mockClient := NewMockAPIClient()
+	mockClient.SetResponse("geocoding-api.open-meteo.com/v1/search?name=Perth", map[string]interface{}{
+		"results": []interface{}{
+			map[string]interface{}{
+				"name": "Perth", "latitude": -31.95224, "longitude": 115.8614,
+				"country_code": "AU", "country": "Australia", "admin1": "Western Australia", "population": 1896548.0,
+			},
+			map[string]interface{}{
+				"name": "Perth", "latitude": 56.39522, "longitude": -3.43139,
+				"country_code": "GB", "country": "United Kingdom", "admin1": "Scotland", "population": 47180.0,
+			},
+		},
+	})
+	mockClient.SetResponse("geocoding-api.open-meteo.com/v1/search?name=Atlantis", map[string]interface{}{
+		"generationtime_ms": 0.5,
+	})
+	geocoder := NewOpenMeteoGeocoder(mockClient)
+
+	locations, err := geocoder.Search(context.Background(), "Perth")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(locations) != 2 {
+		t.Fatalf("Expected 2 locations, got %d", len(locations))
+	}
+	if locations[1].String() != "Perth, Scotland, United Kingdom" || locations[1].Lat != 56.39522 {
+		t.Errorf("Unexpected second location %+v", locations[1])
+	}
+
+	// No results is not an error, the response just omits them
+	locations, err = geocoder.Search(context.Background(), "Atlantis")
+	if err != nil || len(locations) != 0 {
+		t.Errorf("Expected no locations and no error, got %v, %v", locations, err)
+	}
+
+	mockClient.SetError("name=Nowhere", errors.New("network timeout"))
+	if _, err := geocoder.Search(context.Background(), "Nowhere"); err == nil {
+		t.Error("Expected the API error to be returned")
+	}
+}
+
+// countingGeocoder counts searches, failing while err is set
+type countingGeocoder struct {
+	searches int
+	err      error
+}
+
+func (g *countingGeocoder) Search(ctx context.Context, name string) ([]Location, error) {
+	g.searches++
+	if g.err != nil {
+		return nil, g.err
+	}
+	return []Location{{Name: name}}, nil
+}
+
+func TestCachingGeocoder(t *testing.T) {
+	inner := &countingGeocoder{}
+	cache := NewCachingGeocoder(inner, time.Hour)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	for _, name := range []string{"Sydney", "sydney", " SYDNEY "} {
+		if _, err := cache.Search(context.Background(), name); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if inner.searches != 1 {
+		t.Errorf("Expected names differing only in case and spacing to share 1 search, got %d", inner.searches)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := cache.Search(context.Background(), "Sydney"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if inner.searches != 2 {
+		t.Errorf("Expected an expired entry to be searched again, got %d searches", inner.searches)
+	}
+
+	// Failures aren't cached
+	inner.err = errors.New("unavailable")
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Search(context.Background(), "Perth"); err == nil {
+			t.Error("Expected an error")
+		}
+	}
+	if inner.searches != 4 {
+		t.Errorf("Expected failed searches to be retried, got %d searches", inner.searches)
+	}
+}
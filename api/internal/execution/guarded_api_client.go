@@ -0,0 +1,269 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGuardMaxHosts is how many hosts a GuardedAPIClient keeps a circuit breaker and rate limit for by default
+const DefaultGuardMaxHosts = 1000
+
+// GuardConfig configures the circuit breakers and rate limits of a GuardedAPIClient
+type GuardConfig struct {
+	CircuitBreaker CircuitBreakerConfig
+	RateLimit      RateLimitConfig
+	// HostRateLimits overrides RateLimit for specific hosts
+	HostRateLimits map[string]RateLimitConfig
+	// MaxHosts caps the hosts guarded at once, the least recently called one is dropped for a new host.
+	// Integration endpoints are user editable, so the hosts called aren't bounded otherwise.
+	MaxHosts int
+}
+
+// DefaultGuardConfig returns the default circuit breaker and rate limit configuration
+func DefaultGuardConfig() GuardConfig {
+	return GuardConfig{
+		CircuitBreaker: DefaultCircuitBreakerConfig(),
+		RateLimit:      DefaultRateLimitConfig(),
+		MaxHosts:       DefaultGuardMaxHosts,
+	}
+}
+
+// GuardConfigFromEnv returns the default configuration adjusted by the CIRCUIT_BREAKER_* and RATE_LIMIT_*
+// environment variables. RATE_LIMIT_HOSTS overrides the rate of specific hosts as a comma separated list of
+// host=rps:burst, e.g. api.example.com=5:10.
+func GuardConfigFromEnv() (GuardConfig, error) {
+	config := DefaultGuardConfig()
+
+	ints := []struct {
+		name  string
+		value *int
+	}{
+		{"CIRCUIT_BREAKER_FAILURE_THRESHOLD", &config.CircuitBreaker.FailureThreshold},
+		{"CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS", &config.CircuitBreaker.HalfOpenMaxCalls},
+		{"CIRCUIT_BREAKER_SUCCESS_THRESHOLD", &config.CircuitBreaker.SuccessThreshold},
+		{"RATE_LIMIT_BURST", &config.RateLimit.Burst},
+		{"GUARD_MAX_HOSTS", &config.MaxHosts},
+	}
+	for _, setting := range ints {
+		if raw := os.Getenv(setting.name); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value <= 0 {
+				return GuardConfig{}, fmt.Errorf("invalid %s '%s', must be a positive number", setting.name, raw)
+			}
+			*setting.value = value
+		}
+	}
+
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"CIRCUIT_BREAKER_OPEN_TIMEOUT", &config.CircuitBreaker.OpenTimeout},
+		{"RATE_LIMIT_MAX_WAIT", &config.RateLimit.MaxWait},
+	}
+	for _, setting := range durations {
+		if raw := os.Getenv(setting.name); raw != "" {
+			value, err := time.ParseDuration(raw)
+			if err != nil || value < 0 {
+				return GuardConfig{}, fmt.Errorf("invalid %s '%s', must be a duration", setting.name, raw)
+			}
+			*setting.value = value
+		}
+	}
+
+	if raw := os.Getenv("RATE_LIMIT_RPS"); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value <= 0 {
+			return GuardConfig{}, fmt.Errorf("invalid RATE_LIMIT_RPS '%s', must be a positive number", raw)
+		}
+		config.RateLimit.RequestsPerSecond = value
+	}
+
+	if raw := os.Getenv("RATE_LIMIT_HOSTS"); raw != "" {
+		config.HostRateLimits = make(map[string]RateLimitConfig)
+		for _, entry := range splitList(raw) {
+			limit, err := parseHostRateLimit(entry, config.RateLimit)
+			if err != nil {
+				return GuardConfig{}, fmt.Errorf("invalid RATE_LIMIT_HOSTS entry '%s', must be host=rps:burst", entry)
+			}
+			config.HostRateLimits[limit.host] = limit.config
+		}
+	}
+
+	return config, nil
+}
+
+// hostRateLimit is a parsed RATE_LIMIT_HOSTS entry
+type hostRateLimit struct {
+	host   string
+	config RateLimitConfig
+}
+
+// parseHostRateLimit parses a host=rps:burst entry, waiting at most as long as base
+func parseHostRateLimit(entry string, base RateLimitConfig) (hostRateLimit, error) {
+	host, rate, ok := strings.Cut(entry, "=")
+	if !ok || host == "" {
+		return hostRateLimit{}, errors.New("missing host")
+	}
+	rps, burst, ok := strings.Cut(rate, ":")
+	if !ok {
+		return hostRateLimit{}, errors.New("missing burst")
+	}
+
+	limit := hostRateLimit{host: host, config: base}
+	var err error
+	if limit.config.RequestsPerSecond, err = strconv.ParseFloat(rps, 64); err != nil || limit.config.RequestsPerSecond <= 0 {
+		return hostRateLimit{}, errors.New("invalid rate")
+	}
+	if limit.config.Burst, err = strconv.Atoi(burst); err != nil || limit.config.Burst <= 0 {
+		return hostRateLimit{}, errors.New("invalid burst")
+	}
+	return limit, nil
+}
+
+// GuardedAPIClient wraps an API client with a circuit breaker and a token bucket rate limit per host
+type GuardedAPIClient struct {
+	apiClient APIClient
+	config    GuardConfig
+
+	mu     sync.Mutex
+	guards map[string]*hostGuard
+	now    func() time.Time
+}
+
+type hostGuard struct {
+	breaker *CircuitBreaker
+	limiter *TokenBucket
+	// lastUsed is when the host was last called, guarded by the client's mutex
+	lastUsed time.Time
+}
+
+// NewGuardedAPIClient creates a guarded API client
+func NewGuardedAPIClient(apiClient APIClient, config GuardConfig) *GuardedAPIClient {
+	return &GuardedAPIClient{
+		apiClient: apiClient,
+		config:    config,
+		guards:    make(map[string]*hostGuard),
+		now:       time.Now,
+	}
+}
+
+// CallAPI implements APIClient
+func (c *GuardedAPIClient) CallAPI(ctx context.Context, url string) (map[string]interface{}, error) {
+	response, err := c.FetchAPI(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// FetchAPI implements APIResponseFetcher, passing the HTTP details of the wrapped client through
+func (c *GuardedAPIClient) FetchAPI(ctx context.Context, rawURL string) (*APIResponse, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse API URL: %w", err)
+	}
+	guard := c.guard(strings.ToLower(parsed.Host))
+
+	if err := guard.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	if err := guard.limiter.Wait(ctx); err != nil {
+		// The host wasn't called, so the call counts as neither a success nor a failure
+		guard.breaker.Release()
+		return nil, err
+	}
+
+	response, err := fetchAPI(ctx, c.apiClient, rawURL)
+	if err != nil && (ctx.Err() != nil || errors.Is(err, ErrPolicyViolation)) {
+		// The caller gave up or the call was refused, which says nothing about the host's health
+		guard.breaker.Release()
+	} else {
+		guard.breaker.Record(breakerFailure(err))
+	}
+	return response, err
+}
+
+// breakerFailure returns the error if it means the host is unhealthy, nil otherwise. Client errors
+// such as a 404 mean the host is up and answering.
+func breakerFailure(err error) error {
+	if err == nil {
+		return nil
+	}
+	var statusErr *APIStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode < 500 && statusErr.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	return err
+}
+
+// guard returns the breaker and limiter of a host, creating them on first use
+func (c *GuardedAPIClient) guard(host string) *hostGuard {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	guard, ok := c.guards[host]
+	if !ok {
+		if c.config.MaxHosts > 0 && len(c.guards) >= c.config.MaxHosts {
+			c.evictIdle()
+		}
+		limit, ok := c.config.HostRateLimits[host]
+		if !ok {
+			limit = c.config.RateLimit
+		}
+		guard = &hostGuard{
+			breaker: NewCircuitBreaker(host, c.config.CircuitBreaker),
+			limiter: NewTokenBucket(host, limit),
+		}
+		c.guards[host] = guard
+	}
+	guard.lastUsed = c.now()
+	return guard
+}
+
+// evictIdle drops the guard of the least recently called host, preferring hosts whose circuit is closed so a
+// failing host isn't given a fresh circuit by calls to other hosts. The caller must hold the lock.
+func (c *GuardedAPIClient) evictIdle() {
+	var idlest, idlestClosed string
+	for host, guard := range c.guards {
+		if idlest == "" || guard.lastUsed.Before(c.guards[idlest].lastUsed) {
+			idlest = host
+		}
+		if guard.breaker.State().State == CircuitClosed &&
+			(idlestClosed == "" || guard.lastUsed.Before(c.guards[idlestClosed].lastUsed)) {
+			idlestClosed = host
+		}
+	}
+	if idlestClosed != "" {
+		idlest = idlestClosed
+	}
+	delete(c.guards, idlest)
+}
+
+// CircuitBreakerStates returns the state of the circuit breaker of every host guarded, sorted by host
+func (c *GuardedAPIClient) CircuitBreakerStates() []CircuitBreakerState {
+	c.mu.Lock()
+	guards := make([]*hostGuard, 0, len(c.guards))
+	for _, guard := range c.guards {
+		guards = append(guards, guard)
+	}
+	c.mu.Unlock()
+
+	states := make([]CircuitBreakerState, len(guards))
+	for i, guard := range guards {
+		states[i] = guard.breaker.State()
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Host < states[j].Host
+	})
+	return states
+}
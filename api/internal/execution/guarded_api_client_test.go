@@ -0,0 +1,176 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestGuardedAPIClient_OpensCircuitOnServerErrors(t *testing.T) {
+	status := http.StatusInternalServerError
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	config := DefaultGuardConfig()
+	config.CircuitBreaker.FailureThreshold = 2
+	client := NewGuardedAPIClient(NewHTTPAPIClient(), config)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		var statusErr *APIStatusError
+		if _, err := client.CallAPI(ctx, server.URL); !errors.As(err, &statusErr) {
+			t.Fatalf("Expected an APIStatusError, got %v", err)
+		}
+	}
+
+	// The open circuit fails without calling the server
+	start := time.Now()
+	_, err := client.CallAPI(ctx, server.URL+"/other")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if requests != 2 || time.Since(start) > 100*time.Millisecond {
+		t.Errorf("Expected an instant failure without a request, got %d requests", requests)
+	}
+
+	host, _ := url.Parse(server.URL)
+	states := client.CircuitBreakerStates()
+	if len(states) != 1 || states[0].Host != host.Host || states[0].State != CircuitOpen {
+		t.Errorf("Expected the server's circuit to be open, got %+v", states)
+	}
+}
+
+func TestGuardedAPIClient_ClientErrorsKeepCircuitClosed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	config := DefaultGuardConfig()
+	config.CircuitBreaker.FailureThreshold = 1
+	client := NewGuardedAPIClient(NewHTTPAPIClient(), config)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.CallAPI(context.Background(), server.URL); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected 404s not to open the circuit, got %v", err)
+		}
+	}
+	if state := client.CircuitBreakerStates()[0]; state.State != CircuitClosed {
+		t.Errorf("Expected closed, got %+v", state)
+	}
+}
+
+func TestGuardedAPIClient_PerHostGuards(t *testing.T) {
+	mockClient := NewMockAPIClient()
+	mockClient.SetError("down.example.com", errors.New("connection refused"))
+
+	config := DefaultGuardConfig()
+	config.CircuitBreaker.FailureThreshold = 1
+	config.HostRateLimits = map[string]RateLimitConfig{
+		"limited.example.com": {RequestsPerSecond: 1, Burst: 1, MaxWait: 0},
+	}
+	client := NewGuardedAPIClient(mockClient, config)
+	ctx := context.Background()
+
+	_, _ = client.CallAPI(ctx, "https://down.example.com/a")
+	if _, err := client.CallAPI(ctx, "https://down.example.com/b"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the failing host's circuit to be open, got %v", err)
+	}
+	if _, err := client.CallAPI(ctx, "https://up.example.com/a"); err != nil {
+		t.Errorf("Expected other hosts to be unaffected, got %v", err)
+	}
+
+	if _, err := client.CallAPI(ctx, "https://limited.example.com/a"); err != nil {
+		t.Fatalf("Expected the first call within the burst, got %v", err)
+	}
+	if _, err := client.CallAPI(ctx, "https://limited.example.com/a"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected the host's own rate limit to apply, got %v", err)
+	}
+}
+
+func TestGuardedAPIClient_EvictsIdleHosts(t *testing.T) {
+	config := DefaultGuardConfig()
+	config.MaxHosts = 2
+	config.CircuitBreaker.FailureThreshold = 1
+	client := NewGuardedAPIClient(NewMockAPIClient(), config)
+	clock := time.Now()
+	client.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	// failing.example.com's circuit is open, so the idler healthy host is dropped first
+	client.guard("failing.example.com").breaker.Record(errors.New("connection refused"))
+	client.guard("healthy.example.com")
+	client.guard("new.example.com")
+
+	if len(client.guards) != 2 {
+		t.Fatalf("Expected at most 2 hosts guarded, got %d", len(client.guards))
+	}
+	if _, ok := client.guards["healthy.example.com"]; ok {
+		t.Error("Expected the idle host with a closed circuit to be dropped")
+	}
+	if _, ok := client.guards["failing.example.com"]; !ok {
+		t.Error("Expected the host with an open circuit to be kept")
+	}
+
+	// Without closed circuits to drop, the least recently called host goes
+	client.guard("new.example.com").breaker.Record(errors.New("connection refused"))
+	client.guard("failing.example.com")
+	client.guard("another.example.com")
+	if _, ok := client.guards["new.example.com"]; ok || len(client.guards) != 2 {
+		t.Errorf("Expected the least recently called host to be dropped, got %d hosts", len(client.guards))
+	}
+}
+
+func TestGuardConfigFromEnv(t *testing.T) {
+	t.Setenv("CIRCUIT_BREAKER_FAILURE_THRESHOLD", "3")
+	t.Setenv("CIRCUIT_BREAKER_OPEN_TIMEOUT", "1m")
+	t.Setenv("RATE_LIMIT_RPS", "2.5")
+	t.Setenv("RATE_LIMIT_BURST", "4")
+	t.Setenv("RATE_LIMIT_MAX_WAIT", "0s")
+	t.Setenv("RATE_LIMIT_HOSTS", "API.example.com=1:2, other.example.com=50:100")
+
+	config, err := GuardConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if config.CircuitBreaker.FailureThreshold != 3 || config.CircuitBreaker.OpenTimeout != time.Minute {
+		t.Errorf("Expected the circuit breaker settings to be applied, got %+v", config.CircuitBreaker)
+	}
+	if config.CircuitBreaker.SuccessThreshold != DefaultCircuitBreakerConfig().SuccessThreshold {
+		t.Errorf("Expected unset settings to keep their default, got %+v", config.CircuitBreaker)
+	}
+	if config.MaxHosts != DefaultGuardMaxHosts {
+		t.Errorf("Expected the default host cap, got %d", config.MaxHosts)
+	}
+	if config.RateLimit != (RateLimitConfig{RequestsPerSecond: 2.5, Burst: 4}) {
+		t.Errorf("Expected the rate limit to be applied, got %+v", config.RateLimit)
+	}
+	if limit := config.HostRateLimits["api.example.com"]; limit.RequestsPerSecond != 1 || limit.Burst != 2 {
+		t.Errorf("Expected the host's rate limit to be applied, got %+v", config.HostRateLimits)
+	}
+
+	for name, value := range map[string]string{
+		"CIRCUIT_BREAKER_FAILURE_THRESHOLD": "0",
+		"CIRCUIT_BREAKER_OPEN_TIMEOUT":      "soon",
+		"RATE_LIMIT_RPS":                    "-1",
+		"RATE_LIMIT_HOSTS":                  "api.example.com=5",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := GuardConfigFromEnv(); err == nil {
+				t.Errorf("Expected %s=%s to be rejected", name, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package execution
+
+import (
+	"context"
+
+	"workflow-code-test/api/internal/models"
+)
+
+type idempotencyKeyKey struct{}
+
+// WithIdempotencyKey returns a context whose executions hand key to their nodes as
+// models.ExecutionContext.IdempotencyKey. The key must already be scoped to the caller, nodes use it as is.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key of the context's executions, empty if it has none
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyKey{}).(string)
+	return key
+}
+
+// nodeDedupeKey returns the key a node's side effects are deduplicated by across retries of the execution,
+// empty if the execution has no idempotency key
+func nodeDedupeKey(execCtx *models.ExecutionContext, node *models.NodeResponse) string {
+	if execCtx.IdempotencyKey == "" {
+		return ""
+	}
+	return execCtx.IdempotencyKey + ":" + node.ID
+}
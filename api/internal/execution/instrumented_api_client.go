@@ -0,0 +1,89 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"workflow-code-test/api/internal/metrics"
+)
+
+const (
+	// apiCallErrorStatus labels calls that got no response, such as timeouts and refused connections
+	apiCallErrorStatus = "error"
+	// apiCallBlockedStatus labels calls the outbound policy refused to make
+	apiCallBlockedStatus = "blocked"
+	// apiCallOtherHost labels calls to hosts the workflow engine doesn't know, as user-entered URLs could
+	// otherwise create a series per host
+	apiCallOtherHost = "other"
+)
+
+// apiCallHosts are the hosts calls are labelled by, the APIs nodes call out of the box
+var apiCallHosts = map[string]bool{
+	"api.open-meteo.com":           true,
+	"geocoding-api.open-meteo.com": true,
+}
+
+// InstrumentedAPIClient wraps an API client, recording the latency and outcome of every call by host
+type InstrumentedAPIClient struct {
+	apiClient APIClient
+}
+
+// NewInstrumentedAPIClient creates an instrumented API client
+func NewInstrumentedAPIClient(apiClient APIClient) *InstrumentedAPIClient {
+	return &InstrumentedAPIClient{
+		apiClient: apiClient,
+	}
+}
+
+// CallAPI implements APIClient
+func (c *InstrumentedAPIClient) CallAPI(ctx context.Context, url string) (map[string]interface{}, error) {
+	response, err := c.FetchAPI(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// FetchAPI implements APIResponseFetcher, passing the HTTP details of the wrapped client through
+func (c *InstrumentedAPIClient) FetchAPI(ctx context.Context, rawURL string) (*APIResponse, error) {
+	start := time.Now()
+	response, err := fetchAPI(ctx, c.apiClient, rawURL)
+
+	host := apiCallHost(rawURL)
+	metrics.APICallDuration.WithLabelValues(host).Observe(time.Since(start).Seconds())
+	metrics.APICalls.WithLabelValues(host, apiCallStatus(response, err)).Inc()
+
+	return response, err
+}
+
+// apiCallHost returns the host a call went to if it is one of apiCallHosts, "other" if not, the URL itself
+// never as it may carry secrets
+func apiCallHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "invalid"
+	}
+	if host := strings.ToLower(parsed.Host); apiCallHosts[host] {
+		return host
+	}
+	return apiCallOtherHost
+}
+
+// apiCallStatus returns the response status of a call, or why it has none
+func apiCallStatus(response *APIResponse, err error) string {
+	var statusErr *APIStatusError
+	switch {
+	case errors.As(err, &statusErr):
+		return strconv.Itoa(statusErr.StatusCode)
+	case errors.Is(err, ErrPolicyViolation):
+		return apiCallBlockedStatus
+	case err != nil:
+		return apiCallErrorStatus
+	default:
+		return strconv.Itoa(response.StatusCode)
+	}
+}
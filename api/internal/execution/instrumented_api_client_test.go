@@ -0,0 +1,94 @@
+package execution
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"workflow-code-test/api/internal/metrics"
+)
+
+func TestInstrumentedAPIClient_CountsCallsByHostAndStatus(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	// The test server isn't one of the known hosts
+	host := apiCallOtherHost
+
+	client := NewInstrumentedAPIClient(NewHTTPAPIClient())
+	ctx := context.Background()
+	count := func(status string) float64 {
+		return testutil.ToFloat64(metrics.APICalls.WithLabelValues(host, status))
+	}
+	before := map[string]float64{"200": count("200"), "503": count("503"), apiCallErrorStatus: count(apiCallErrorStatus)}
+
+	if _, err := client.CallAPI(ctx, server.URL+"/forecast?apikey=secret"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if _, err := client.CallAPI(ctx, server.URL); err == nil {
+		t.Fatalf("Expected an error for a 503")
+	}
+
+	tests := []struct {
+		status   string
+		expected float64
+	}{
+		{"200", 1},
+		{"503", 1},
+		{apiCallErrorStatus, 0},
+	}
+	for _, tt := range tests {
+		if got := count(tt.status) - before[tt.status]; got != tt.expected {
+			t.Errorf("Expected %v calls with status %s, got %v", tt.expected, tt.status, got)
+		}
+	}
+}
+
+func TestAPICallHost(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://api.open-meteo.com/v1/forecast?latitude=1", "api.open-meteo.com"},
+		{"https://API.Open-Meteo.com/v1/forecast", "api.open-meteo.com"},
+		{"https://geocoding-api.open-meteo.com/v1/search?name=Sydney", "geocoding-api.open-meteo.com"},
+		{"https://user-1234.example.com/hook", apiCallOtherHost},
+		{"https://api.open-meteo.com.attacker.test/", apiCallOtherHost},
+		{"not a url", "invalid"},
+	}
+
+	for _, tt := range tests {
+		if got := apiCallHost(tt.url); got != tt.expected {
+			t.Errorf("Expected %s for %s, got %s", tt.expected, tt.url, got)
+		}
+	}
+}
+
+func TestAPICallStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *APIResponse
+		err      error
+		expected string
+	}{
+		{name: "success", response: &APIResponse{StatusCode: http.StatusOK}, expected: "200"},
+		{name: "status error", err: &APIStatusError{StatusCode: http.StatusNotFound}, expected: "404"},
+		{name: "policy violation", err: &PolicyViolationError{Reason: "host not allowed"}, expected: apiCallBlockedStatus},
+		{name: "no response", err: context.DeadlineExceeded, expected: apiCallErrorStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apiCallStatus(tt.response, tt.err); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
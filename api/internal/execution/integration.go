@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"workflow-code-test/api/internal/models"
 )
@@ -12,32 +15,38 @@ import (
 // IntegrationService handles API integration calls for workflow nodes
 type IntegrationService struct {
 	apiClient APIClient
+	geocoder  Geocoder
+	secrets   SecretResolver
 }
 
-// NewIntegrationService creates a new integration service
+// NewIntegrationService creates a new integration service looking up cities with the Open-Meteo geocoding API
 func NewIntegrationService(apiClient APIClient) *IntegrationService {
+	return NewIntegrationServiceWithGeocoder(apiClient,
+		NewCachingGeocoder(NewOpenMeteoGeocoder(apiClient), DefaultGeocoderCacheTTL))
+}
+
+// NewIntegrationServiceWithGeocoder creates a new integration service with a custom geocoder
+func NewIntegrationServiceWithGeocoder(apiClient APIClient, geocoder Geocoder) *IntegrationService {
 	return &IntegrationService{
 		apiClient: apiClient,
+		geocoder:  geocoder,
 	}
 }
 
-// findCityCoordinates finds the coordinates for a given city from available options
-func (s *IntegrationService) findCityCoordinates(city string, options []models.LocationOption) (*models.LocationOption, error) {
+// findCityCoordinates finds the coordinates for a given city. Cities listed in the node's options use the
+// configured coordinates, any other place is looked up with the geocoder.
+func (s *IntegrationService) findCityCoordinates(ctx context.Context, city string, options []models.LocationOption) (*models.LocationOption, error) {
 	for _, option := range options {
 		if strings.EqualFold(option.City, city) {
 			return &option, nil
 		}
 	}
-	return nil, fmt.Errorf("city '%s' not found in available options: %v", city, s.getCityNames(options))
-}
 
-// getCityNames extracts city names from options for error messages
-func (s *IntegrationService) getCityNames(options []models.LocationOption) []string {
-	names := make([]string, len(options))
-	for i, option := range options {
-		names[i] = option.City
+	location, err := ResolveLocation(ctx, s.geocoder, city)
+	if err != nil {
+		return nil, err
 	}
-	return names
+	return &models.LocationOption{City: location.Name, Lat: location.Lat, Lon: location.Lon}, nil
 }
 
 // buildAPIURL builds the API URL by substituting coordinate placeholders
@@ -100,38 +109,65 @@ func (s *IntegrationService) ExecuteIntegration(ctx context.Context, nodeData mo
 	}
 
 	// Find coordinates for the city
-	coordinates, err := s.findCityCoordinates(city, nodeData.Metadata.Options)
+	coordinates, err := s.findCityCoordinates(ctx, city, nodeData.Metadata.Options)
+	if err != nil {
+		return models.IntegrationExecutionOutput{}, err
+	}
+
+	units, err := resolveWeatherUnits(nodeData.Metadata, inputVariables)
 	if err != nil {
 		return models.IntegrationExecutionOutput{}, err
 	}
 
-	// Build and call API
-	apiURL := s.buildAPIURL(nodeData.Metadata.APIEndpoint, coordinates.Lat, coordinates.Lon)
-	slog.Debug("Making integration API call",
-		"url", apiURL,
-		"city", city,
+	// Build and call API. Secrets are only resolved into the URL for the call itself, so what is logged and
+	// reported keeps the {{secret.NAME}} references.
+	endpoint := withWeatherParams(s.buildAPIURL(nodeData.Metadata.APIEndpoint, coordinates.Lat, coordinates.Lon), units)
+	apiURL, err := resolveSecrets(ctx, s.secrets, endpoint, url.QueryEscape)
+	if err != nil {
+		return models.IntegrationExecutionOutput{}, err
+	}
+	// The city is form input, so only the coordinates it resolved to are logged
+	slog.DebugContext(ctx, "Making integration API call",
+		"url", endpoint,
 		"lat", coordinates.Lat,
 		"lon", coordinates.Lon)
 
-	apiResponse, err := s.apiClient.CallAPI(ctx, apiURL)
+	callInfo := &APICallInfo{}
+	if ttl := nodeData.Metadata.CacheTTLSeconds; ttl != nil {
+		cacheTTL := time.Duration(*ttl) * time.Second
+		callInfo.CacheTTL = &cacheTTL
+	}
+	callCtx := WithAPICallInfo(ctx, callInfo)
+	if len(nodeData.Metadata.Headers) > 0 {
+		header := make(http.Header, len(nodeData.Metadata.Headers))
+		for name, value := range nodeData.Metadata.Headers {
+			resolved, err := resolveSecrets(ctx, s.secrets, value, nil)
+			if err != nil {
+				return models.IntegrationExecutionOutput{}, err
+			}
+			header.Set(name, resolved)
+		}
+		callCtx = WithAPIHeaders(callCtx, header)
+	}
+
+	apiResponse, err := s.apiClient.CallAPI(callCtx, apiURL)
 	if err != nil {
 		return models.IntegrationExecutionOutput{}, fmt.Errorf("API call failed: %w", err)
 	}
 
-	// Extract temperature from response
-	temperature, err := s.extractTemperature(apiResponse)
+	// Extract the weather variables from response
+	processedData, err := s.extractWeather(apiResponse, units)
 	if err != nil {
-		return models.IntegrationExecutionOutput{}, fmt.Errorf("failed to extract temperature: %w", err)
+		return models.IntegrationExecutionOutput{}, fmt.Errorf("failed to extract weather data: %w", err)
 	}
+	processedData["location"] = coordinates.City
 
 	// Return strongly typed response
 	return models.IntegrationExecutionOutput{
-		APIResponse: apiResponse,
-		ProcessedData: map[string]interface{}{
-			"temperature": temperature,
-			"location":    coordinates.City,
-		},
-		EndpointCalled: apiURL,
+		APIResponse:    apiResponse,
+		ProcessedData:  processedData,
+		EndpointCalled: endpoint,
 		StatusCode:     200, // Assume success if no error
+		Cache:          callInfo.CacheStatus,
 	}, nil
 }
@@ -2,7 +2,9 @@ package execution
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"workflow-code-test/api/internal/models"
 )
@@ -73,8 +75,8 @@ func TestIntegrationService_ExecuteIntegration_Success(t *testing.T) {
 }
 
 func TestIntegrationService_ExecuteIntegration_InvalidCity(t *testing.T) {
-	// Create integration service
-	service := NewIntegrationService(NewMockAPIClient())
+	// Create integration service with the offline gazetteer
+	service := NewIntegrationServiceWithGeocoder(NewMockAPIClient(), NewBundledGazetteer())
 
 	// Test data with limited city options
 	nodeData := models.IntegrationNodeData{
@@ -90,18 +92,60 @@ func TestIntegrationService_ExecuteIntegration_InvalidCity(t *testing.T) {
 	}
 
 	inputVariables := map[string]interface{}{
-		"city": "Perth", // Not in available options
+		"city": "Atlantis", // Not in available options nor the gazetteer
 	}
 
 	// Execute integration - should fail
 	_, err := service.ExecuteIntegration(context.Background(), nodeData, inputVariables)
 	if err == nil {
-		t.Error("Expected error for invalid city, got none")
+		t.Fatal("Expected error for invalid city, got none")
 	}
 
-	expectedMsg := "city 'Perth' not found in available options"
-	if err.Error()[:len(expectedMsg)] != expectedMsg {
-		t.Errorf("Expected error message to start with '%s', got '%s'", expectedMsg, err.Error())
+	if !errors.Is(err, ErrLocationNotFound) {
+		t.Errorf("Expected ErrLocationNotFound, got '%s'", err.Error())
+	}
+}
+
+func TestIntegrationService_ExecuteIntegration_GeocodedCity(t *testing.T) {
+	mockClient := NewMockAPIClient()
+	mockClient.SetResponse("latitude=-31.952240", map[string]interface{}{
+		"current_weather": map[string]interface{}{
+			"temperature": 31.2,
+			"time":        "2024-01-01T12:00",
+		},
+	})
+	service := NewIntegrationServiceWithGeocoder(mockClient, NewBundledGazetteer())
+
+	nodeData := models.IntegrationNodeData{
+		Label: "Weather API",
+		Metadata: models.IntegrationNodeMetadata{
+			APIEndpoint: "https://api.open-meteo.com/v1/forecast?latitude={lat}&longitude={lon}&current_weather=true",
+			Options: []models.LocationOption{
+				{City: "Sydney", Lat: -33.8688, Lon: 151.2093},
+			},
+		},
+	}
+
+	// Perth isn't in the node's options, the gazetteer resolves it to the much larger Perth in Australia
+	result, err := service.ExecuteIntegration(context.Background(), nodeData, map[string]interface{}{"city": "perth"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.ProcessedData["temperature"] != 31.2 {
+		t.Errorf("Expected temperature 31.2, got %v", result.ProcessedData["temperature"])
+	}
+	if result.ProcessedData["location"] != "Perth" {
+		t.Errorf("Expected location Perth, got %v", result.ProcessedData["location"])
+	}
+
+	// Ambiguous places list the candidates
+	_, err = service.ExecuteIntegration(context.Background(), nodeData, map[string]interface{}{"city": "Springfield"})
+	var ambiguous *AmbiguousLocationError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Expected AmbiguousLocationError, got %v", err)
+	}
+	if len(ambiguous.Candidates) != 3 {
+		t.Errorf("Expected 3 candidates, got %d", len(ambiguous.Candidates))
 	}
 }
 
@@ -141,3 +185,40 @@ func TestIntegrationService_ExecuteIntegration_APIFailure(t *testing.T) {
 		t.Errorf("Expected error message to start with '%s', got '%s'", expectedMsg, err.Error())
 	}
 }
+
+func TestIntegrationService_ExecuteIntegration_CacheStatus(t *testing.T) {
+	mockClient := NewMockAPIClient()
+	mockClient.SetDefaultWeatherResponse()
+	cachingClient := NewCachingAPIClient(mockClient, NewLRUResponseCache(10), time.Minute)
+	service := NewIntegrationServiceWithGeocoder(cachingClient, NewBundledGazetteer())
+
+	nodeData := models.IntegrationNodeData{
+		Label: "Weather API",
+		Metadata: models.IntegrationNodeMetadata{
+			APIEndpoint: "https://api.open-meteo.com/v1/forecast?latitude={lat}&longitude={lon}&current_weather=true",
+			Options:     []models.LocationOption{{City: "Sydney", Lat: -33.8688, Lon: 151.2093}},
+		},
+	}
+	input := map[string]interface{}{"city": "Sydney"}
+
+	for _, want := range []string{CacheStatusMiss, CacheStatusHit} {
+		result, err := service.ExecuteIntegration(context.Background(), nodeData, input)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.Cache != want {
+			t.Errorf("Expected cache %s, got %s", want, result.Cache)
+		}
+	}
+
+	// A TTL of 0 turns caching off for the node
+	noCache := 0
+	nodeData.Metadata.CacheTTLSeconds = &noCache
+	result, err := service.ExecuteIntegration(context.Background(), nodeData, input)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Cache != CacheStatusBypass {
+		t.Errorf("Expected cache %s, got %s", CacheStatusBypass, result.Cache)
+	}
+}
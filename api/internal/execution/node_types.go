@@ -0,0 +1,83 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+
+	"workflow-code-test/api/internal/models"
+)
+
+// NodeExecutor is a node type the engine can run: it parses, validates and describes its
+// data through models.NodeType and adds the execution logic on top
+type NodeExecutor interface {
+	models.NodeType
+	// Execute runs the node and returns its output, which is stored on the execution step
+	Execute(ctx context.Context, e *Engine, node *models.NodeResponse, execCtx *models.ExecutionContext) (interface{}, error)
+}
+
+// RegisterNodeType registers an executable node type so it can be parsed, validated, listed and executed
+func RegisterNodeType(executor NodeExecutor) {
+	models.RegisterNodeType(executor)
+}
+
+// getNodeExecutor looks up the executor for a node type in the registry
+func getNodeExecutor(nodeType string) (NodeExecutor, error) {
+	registered, ok := models.GetNodeType(nodeType)
+	if !ok {
+		return nil, fmt.Errorf("unsupported node type: %s", nodeType)
+	}
+
+	executor, ok := registered.(NodeExecutor)
+	if !ok {
+		return nil, fmt.Errorf("node type %s is registered but can't be executed", nodeType)
+	}
+
+	return executor, nil
+}
+
+func init() {
+	RegisterNodeType(startNode{models.StartNodeType})
+	RegisterNodeType(formNode{models.FormNodeType})
+	RegisterNodeType(integrationNode{models.IntegrationNodeType})
+	RegisterNodeType(conditionNode{models.ConditionNodeType})
+	RegisterNodeType(emailNode{models.EmailNodeType})
+	RegisterNodeType(endNode{models.EndNodeType})
+}
+
+// Built-in executable node types, delegating to the engine's node implementations
+
+type startNode struct{ models.NodeType }
+
+func (startNode) Execute(ctx context.Context, e *Engine, node *models.NodeResponse, execCtx *models.ExecutionContext) (interface{}, error) {
+	return e.executeStartNode(ctx, node, execCtx)
+}
+
+type formNode struct{ models.NodeType }
+
+func (formNode) Execute(ctx context.Context, e *Engine, node *models.NodeResponse, execCtx *models.ExecutionContext) (interface{}, error) {
+	return e.executeFormNode(ctx, node, execCtx)
+}
+
+type integrationNode struct{ models.NodeType }
+
+func (integrationNode) Execute(ctx context.Context, e *Engine, node *models.NodeResponse, execCtx *models.ExecutionContext) (interface{}, error) {
+	return e.executeIntegrationNode(ctx, node, execCtx)
+}
+
+type conditionNode struct{ models.NodeType }
+
+func (conditionNode) Execute(ctx context.Context, e *Engine, node *models.NodeResponse, execCtx *models.ExecutionContext) (interface{}, error) {
+	return e.executeConditionNode(ctx, node, execCtx)
+}
+
+type emailNode struct{ models.NodeType }
+
+func (emailNode) Execute(ctx context.Context, e *Engine, node *models.NodeResponse, execCtx *models.ExecutionContext) (interface{}, error) {
+	return e.executeEmailNode(ctx, node, execCtx)
+}
+
+type endNode struct{ models.NodeType }
+
+func (endNode) Execute(ctx context.Context, e *Engine, node *models.NodeResponse, execCtx *models.ExecutionContext) (interface{}, error) {
+	return e.executeEndNode(ctx, node, execCtx)
+}
@@ -0,0 +1,291 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultMaxResponseBytes is the largest API response body read by default
+const DefaultMaxResponseBytes = 5 << 20
+
+// DefaultMaxRedirects is the number of redirects followed by default
+const DefaultMaxRedirects = 5
+
+// OutboundPolicy restricts which URLs integration nodes can call, since their endpoints are user editable
+type OutboundPolicy struct {
+	// AllowedSchemes are the URL schemes that can be called
+	AllowedSchemes []string
+	// AllowedHosts are the hosts that can be called, "*.example.com" allows any subdomain. Empty allows any host.
+	AllowedHosts []string
+	// AllowPrivateIPs allows connecting to private, loopback, link-local and other non public addresses
+	AllowPrivateIPs bool
+	// MaxResponseBytes is the largest response body read, larger responses fail
+	MaxResponseBytes int64
+	// MaxRedirects is the number of redirects followed, each of which is checked against the policy too
+	MaxRedirects int
+}
+
+// DefaultOutboundPolicy returns a policy allowing any public HTTP(S) host
+func DefaultOutboundPolicy() OutboundPolicy {
+	return OutboundPolicy{
+		AllowedSchemes:   []string{"https", "http"},
+		MaxResponseBytes: DefaultMaxResponseBytes,
+		MaxRedirects:     DefaultMaxRedirects,
+	}
+}
+
+// OutboundPolicyFromEnv returns the default policy adjusted by the OUTBOUND_* environment variables
+func OutboundPolicyFromEnv() (OutboundPolicy, error) {
+	policy := DefaultOutboundPolicy()
+
+	if hosts := os.Getenv("OUTBOUND_ALLOWED_HOSTS"); hosts != "" {
+		policy.AllowedHosts = splitList(hosts)
+	}
+	if schemes := os.Getenv("OUTBOUND_ALLOWED_SCHEMES"); schemes != "" {
+		policy.AllowedSchemes = splitList(schemes)
+	}
+	if allow := os.Getenv("OUTBOUND_ALLOW_PRIVATE_IPS"); allow != "" {
+		value, err := strconv.ParseBool(allow)
+		if err != nil {
+			return OutboundPolicy{}, fmt.Errorf("invalid OUTBOUND_ALLOW_PRIVATE_IPS: %w", err)
+		}
+		policy.AllowPrivateIPs = value
+	}
+	if size := os.Getenv("OUTBOUND_MAX_RESPONSE_BYTES"); size != "" {
+		value, err := strconv.ParseInt(size, 10, 64)
+		if err != nil || value <= 0 {
+			return OutboundPolicy{}, fmt.Errorf("invalid OUTBOUND_MAX_RESPONSE_BYTES '%s', must be a positive number", size)
+		}
+		policy.MaxResponseBytes = value
+	}
+
+	return policy, nil
+}
+
+// splitList splits a comma separated list, dropping blanks
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ErrPolicyViolation is matched by errors.Is for every PolicyViolationError
+var ErrPolicyViolation = errors.New("outbound policy violation")
+
+// PolicyViolationError is returned when a call is refused by the outbound policy
+type PolicyViolationError struct {
+	URL    string
+	Reason string
+}
+
+// Error implements the error interface for PolicyViolationError
+func (e *PolicyViolationError) Error() string {
+	if e.URL == "" {
+		return fmt.Sprintf("outbound policy violation: %s", e.Reason)
+	}
+	return fmt.Sprintf("outbound policy violation: %s (%s)", e.Reason, e.URL)
+}
+
+// Is makes errors.Is(err, ErrPolicyViolation) true for policy violations
+func (e *PolicyViolationError) Is(target error) bool {
+	return target == ErrPolicyViolation
+}
+
+// CheckURL returns a PolicyViolationError if the scheme or host of the URL isn't allowed
+func (p OutboundPolicy) CheckURL(u *url.URL) error {
+	scheme := strings.ToLower(u.Scheme)
+	if !containsString(p.AllowedSchemes, scheme) {
+		return &PolicyViolationError{URL: u.String(), Reason: fmt.Sprintf("scheme '%s' is not allowed", u.Scheme)}
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return &PolicyViolationError{URL: u.String(), Reason: "URL has no host"}
+	}
+	if len(p.AllowedHosts) > 0 && !p.hostAllowed(host) {
+		return &PolicyViolationError{URL: u.String(), Reason: fmt.Sprintf("host '%s' is not in the allowlist", host)}
+	}
+
+	// Catch IP literals before connecting for a clearer error, resolved hostnames are checked when dialing
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.checkAddr(host, addr)
+	}
+	return nil
+}
+
+// hostAllowed matches a host against the allowlist
+func (p OutboundPolicy) hostAllowed(host string) bool {
+	for _, allowed := range p.AllowedHosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAddr returns a PolicyViolationError if the address host resolved to isn't public and private
+// addresses aren't allowed
+func (p OutboundPolicy) checkAddr(host string, addr netip.Addr) error {
+	if p.AllowPrivateIPs {
+		return nil
+	}
+	addr = addr.Unmap()
+
+	kind := blockedAddrKind(addr)
+	if kind == "" {
+		return nil
+	}
+
+	if host == addr.String() {
+		return &PolicyViolationError{Reason: fmt.Sprintf("%s address %s is blocked", kind, addr)}
+	}
+	return &PolicyViolationError{Reason: fmt.Sprintf("host '%s' resolves to %s address %s, which is blocked", host, kind, addr)}
+}
+
+// blockedAddrKind returns what kind of non public address addr is, empty if it's public
+func blockedAddrKind(addr netip.Addr) string {
+	switch {
+	case addr.IsLoopback():
+		return "loopback"
+	case addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast():
+		return "link-local"
+	case addr.IsPrivate() || sharedAddressSpace.Contains(addr):
+		return "private"
+	case addr.IsUnspecified() || addr.IsMulticast() || addr.IsInterfaceLocalMulticast():
+		return "non-unicast"
+	}
+	for _, prefix := range reservedRanges {
+		if prefix.Contains(addr) {
+			return "reserved"
+		}
+	}
+
+	// Translated IPv6 addresses reach the IPv4 address they embed
+	if embedded, ok := embeddedIPv4(addr); ok {
+		if kind := blockedAddrKind(embedded); kind != "" {
+			return kind
+		}
+	}
+	return ""
+}
+
+// embeddedIPv4 returns the IPv4 address a NAT64 or 6to4 address embeds
+func embeddedIPv4(addr netip.Addr) (netip.Addr, bool) {
+	bytes := addr.As16()
+	switch {
+	case nat64Prefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(bytes[12:16])), true
+	case sixToFourPrefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(bytes[2:6])), true
+	default:
+		return netip.Addr{}, false
+	}
+}
+
+var (
+	// sharedAddressSpace is the carrier-grade NAT range, not public but not covered by IsPrivate
+	sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+	// reservedRanges aren't public, nor covered by the netip predicates
+	reservedRanges = []netip.Prefix{
+		netip.MustParsePrefix("0.0.0.0/8"),     // "this network", which reaches the local host on Linux
+		netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+		netip.MustParsePrefix("240.0.0.0/4"),   // reserved, and the broadcast address
+	}
+	// nat64Prefix is the well-known NAT64 prefix, its addresses end in the IPv4 address they translate to
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	// sixToFourPrefix is the 6to4 prefix, its addresses embed an IPv4 address after the prefix
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+)
+
+// containsString returns true if the list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// httpClient returns an HTTP client enforcing the policy. Addresses are checked as the connection is
+// made, after DNS resolution, so neither a hostname pointing at an internal address nor a redirect to
+// one gets through.
+func (p OutboundPolicy) httpClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialled instead of the host, bypassing the address check
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{
+			Timeout: timeout,
+			// Control sees each resolved address just before connecting to it
+			Control: func(_, resolved string, _ syscall.RawConn) error {
+				ip, _, err := net.SplitHostPort(resolved)
+				if err != nil {
+					return err
+				}
+				addr, err := netip.ParseAddr(ip)
+				if err != nil {
+					return &PolicyViolationError{Reason: fmt.Sprintf("host '%s' resolves to unexpected address %s", host, resolved)}
+				}
+				return p.checkAddr(host, addr)
+			},
+		}
+
+		conn, err := dialer.DialContext(ctx, network, address)
+		// Unwrap violations from the dial error so they read the same as URL checks
+		var violation *PolicyViolationError
+		if errors.As(err, &violation) {
+			return nil, violation
+		}
+		return conn, err
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > p.MaxRedirects {
+				return &PolicyViolationError{URL: req.URL.String(), Reason: fmt.Sprintf("more than %d redirects", p.MaxRedirects)}
+			}
+			if err := p.CheckURL(req.URL); err != nil {
+				return err
+			}
+			dropHeadersAcrossHosts(req, via)
+			return nil
+		},
+	}
+}
+
+// dropHeadersAcrossHosts removes the headers of the original request from a redirect to another host. Go only
+// drops Authorization and Cookie, but node headers may carry resolved secrets under any name.
+func dropHeadersAcrossHosts(req *http.Request, via []*http.Request) {
+	original := via[0]
+	if strings.EqualFold(req.URL.Host, original.URL.Host) {
+		return
+	}
+	for name := range original.Header {
+		req.Header.Del(name)
+	}
+}
@@ -0,0 +1,196 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutboundPolicy_CheckURL(t *testing.T) {
+	policy := DefaultOutboundPolicy()
+	policy.AllowedHosts = []string{"api.open-meteo.com", "*.example.com", "8.8.8.8", "169.254.169.254"}
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://api.open-meteo.com/v1/forecast", true},
+		{"https://API.Open-Meteo.com/v1/forecast", true},
+		{"https://weather.example.com/", true},
+		{"https://example.com/", false},
+		{"https://evil.com/", false},
+		{"ftp://api.open-meteo.com/", false},
+		{"file:///etc/passwd", false},
+		{"http://8.8.8.8/", true},
+		{"http://169.254.169.254/latest/meta-data/", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			err := policy.CheckURL(u)
+			if tt.allowed && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrPolicyViolation) {
+				t.Errorf("Expected a policy violation, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOutboundPolicy_CheckURL_PrivateAddresses(t *testing.T) {
+	policy := DefaultOutboundPolicy()
+
+	for _, host := range []string{"127.0.0.1", "[::1]", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "[fe80::1]", "100.64.0.1", "0.0.0.0", "[::ffff:127.0.0.1]",
+		"0.1.2.3", "198.18.0.1", "255.255.255.255", "[64:ff9b::a9fe:a9fe]", "[2002:7f00:1::]"} {
+		u, _ := url.Parse("http://" + host + "/")
+		if err := policy.CheckURL(u); !errors.Is(err, ErrPolicyViolation) {
+			t.Errorf("Expected %s to be blocked, got %v", host, err)
+		}
+	}
+
+	policy.AllowPrivateIPs = true
+	u, _ := url.Parse("http://10.1.2.3/")
+	if err := policy.CheckURL(u); err != nil {
+		t.Errorf("Expected private addresses to be allowed, got %v", err)
+	}
+}
+
+func TestHTTPAPIClient_Policy_BlocksResolvedAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	// localhost passes the URL check and is only caught once resolved
+	localURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	client := NewHTTPAPIClientWithPolicy(DefaultOutboundPolicy())
+
+	_, err := client.CallAPI(context.Background(), localURL)
+	var violation *PolicyViolationError
+	if !errors.As(err, &violation) {
+		t.Fatalf("Expected a PolicyViolationError, got %v", err)
+	}
+	if !strings.Contains(violation.Error(), "host 'localhost' resolves to loopback address") {
+		t.Errorf("Expected the violation to name the host and address, got %v", violation)
+	}
+
+	policy := DefaultOutboundPolicy()
+	policy.AllowPrivateIPs = true
+	if _, err := NewHTTPAPIClientWithPolicy(policy).CallAPI(context.Background(), localURL); err != nil {
+		t.Errorf("Expected private addresses to be allowed, got %v", err)
+	}
+}
+
+func TestHTTPAPIClient_Policy_Redirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/elsewhere":
+			http.Redirect(w, r, strings.Replace("http://"+r.Host, "127.0.0.1", "localhost", 1)+"/", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	policy := DefaultOutboundPolicy()
+	policy.AllowPrivateIPs = true
+	policy.AllowedHosts = []string{"127.0.0.1"}
+	client := NewHTTPAPIClientWithPolicy(policy)
+
+	for _, path := range []string{"/elsewhere", "/loop"} {
+		if _, err := client.CallAPI(context.Background(), server.URL+path); !errors.Is(err, ErrPolicyViolation) {
+			t.Errorf("Expected the redirect from %s to be refused, got %v", path, err)
+		}
+	}
+
+	// Redirects to a blocked address are refused even when the first host is allowed
+	redirect := DefaultOutboundPolicy().httpClient(time.Second).CheckRedirect
+	req, _ := http.NewRequest("GET", "http://169.254.169.254/latest/meta-data/", nil)
+	if err := redirect(req, []*http.Request{{}}); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("Expected the redirect to the metadata address to be refused, got %v", err)
+	}
+}
+
+func TestHTTPAPIClient_Policy_RedirectDropsHeadersAcrossHosts(t *testing.T) {
+	redirect := DefaultOutboundPolicy().httpClient(time.Second).CheckRedirect
+	original, _ := http.NewRequest("GET", "https://api.example.com/", nil)
+	original.Header.Set("X-Api-Key", "secret")
+
+	for _, tt := range []struct {
+		url      string
+		keepsKey bool
+	}{
+		{"https://api.example.com/v2", true},
+		{"https://other.example.com/", false},
+	} {
+		req, _ := http.NewRequest("GET", tt.url, nil)
+		req.Header.Set("X-Api-Key", "secret")
+		if err := redirect(req, []*http.Request{original}); err != nil {
+			t.Fatalf("Expected the redirect to %s to be followed, got %v", tt.url, err)
+		}
+		if got := req.Header.Get("X-Api-Key") != ""; got != tt.keepsKey {
+			t.Errorf("Expected the redirect to %s to keep the key: %v, got %v", tt.url, tt.keepsKey, got)
+		}
+	}
+}
+
+func TestHTTPAPIClient_Policy_ResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := `{"data": "` + strings.Repeat("x", 100) + `"}`
+		if r.URL.Path == "/chunked" {
+			// Flushing before writing the body leaves the length unknown up front
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	policy := DefaultOutboundPolicy()
+	policy.AllowPrivateIPs = true
+	policy.MaxResponseBytes = 50
+	client := NewHTTPAPIClientWithPolicy(policy)
+
+	for _, path := range []string{"/", "/chunked"} {
+		_, err := client.CallAPI(context.Background(), server.URL+path)
+		if !errors.Is(err, ErrPolicyViolation) || !strings.Contains(err.Error(), "larger than 50 bytes") {
+			t.Errorf("Expected the %s response to be refused as too large, got %v", path, err)
+		}
+	}
+
+	policy.MaxResponseBytes = 1000
+	if _, err := NewHTTPAPIClientWithPolicy(policy).CallAPI(context.Background(), server.URL); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestOutboundPolicyFromEnv(t *testing.T) {
+	t.Setenv("OUTBOUND_ALLOWED_HOSTS", "api.open-meteo.com, *.example.com,")
+	t.Setenv("OUTBOUND_ALLOWED_SCHEMES", "https")
+	t.Setenv("OUTBOUND_ALLOW_PRIVATE_IPS", "true")
+	t.Setenv("OUTBOUND_MAX_RESPONSE_BYTES", "1024")
+
+	policy, err := OutboundPolicyFromEnv()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(policy.AllowedHosts) != 2 || policy.AllowedHosts[1] != "*.example.com" {
+		t.Errorf("Expected 2 allowed hosts, got %v", policy.AllowedHosts)
+	}
+	if len(policy.AllowedSchemes) != 1 || !policy.AllowPrivateIPs || policy.MaxResponseBytes != 1024 {
+		t.Errorf("Expected the environment to be applied, got %+v", policy)
+	}
+
+	t.Setenv("OUTBOUND_MAX_RESPONSE_BYTES", "lots")
+	if _, err := OutboundPolicyFromEnv(); err == nil {
+		t.Error("Expected an invalid response size to be rejected")
+	}
+}
@@ -0,0 +1,230 @@
+package execution
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"workflow-code-test/api/internal/models"
+)
+
+// minPIIMatchLength is the shortest sensitive value replaced wherever it appears in text. Shorter values
+// would mangle unrelated words, so they are only masked under their own field name.
+const minPIIMatchLength = 3
+
+// PIIRedactor collects the values of an execution's sensitive form fields and masks them in its output,
+// errors and logs
+type PIIRedactor struct {
+	mu     sync.Mutex
+	fields map[string]bool
+	values Redactor
+}
+
+// AddField registers the value of a sensitive field. Output under the field's name is masked whole, string
+// values are also masked wherever else they appear, e.g. in an email body.
+func (r *PIIRedactor) AddField(name string, value interface{}) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	if r.fields == nil {
+		r.fields = make(map[string]bool)
+	}
+	r.fields[name] = true
+	r.mu.Unlock()
+
+	if text, ok := value.(string); ok && len(text) >= minPIIMatchLength {
+		r.values.AddMasked(text, models.MaskPII(text))
+	}
+}
+
+// IsField returns true if name is a registered sensitive field
+func (r *PIIRedactor) IsField(name string) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fields[name]
+}
+
+// Redact masks every registered value in text
+func (r *PIIRedactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	return r.values.Redact(text)
+}
+
+// RedactJSON masks registered values in a JSON document, leaving its structure intact. Documents that
+// can't be parsed are redacted as text.
+func (r *PIIRedactor) RedactJSON(data []byte) []byte {
+	if r == nil {
+		return data
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return []byte(r.Redact(string(data)))
+	}
+
+	redacted, err := json.Marshal(r.redactValue("", document))
+	if err != nil {
+		return []byte(r.Redact(string(data)))
+	}
+	return redacted
+}
+
+// redactValue masks value if key is a sensitive field, otherwise the registered values in its strings
+func (r *PIIRedactor) redactValue(key string, value interface{}) interface{} {
+	if key != "" && r.IsField(key) && value != nil {
+		return models.MaskPII(value)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return r.Redact(v)
+	case map[string]interface{}:
+		for name, nested := range v {
+			v[name] = r.redactValue(name, nested)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = r.redactValue("", nested)
+		}
+	}
+	return value
+}
+
+type piiRedactorKey struct{}
+
+// withPIIRedactor returns a context whose sensitive form values are registered with the redactor
+func withPIIRedactor(ctx context.Context, redactor *PIIRedactor) context.Context {
+	return context.WithValue(ctx, piiRedactorKey{}, redactor)
+}
+
+// PIIRedactorFromContext returns the PII redactor of the context, nil if it has none. A nil redactor masks nothing.
+func PIIRedactorFromContext(ctx context.Context) *PIIRedactor {
+	redactor, _ := ctx.Value(piiRedactorKey{}).(*PIIRedactor)
+	return redactor
+}
+
+type piiRevealedKey struct{}
+
+// WithPIIRevealed returns a context whose executions show sensitive values in their response. Only use it
+// for callers authorised to see them, stored output is masked regardless.
+func WithPIIRevealed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, piiRevealedKey{}, true)
+}
+
+// PIIRevealed returns true if the context's executions show sensitive values in their response
+func PIIRevealed(ctx context.Context) bool {
+	revealed, _ := ctx.Value(piiRevealedKey{}).(bool)
+	return revealed
+}
+
+// redactText removes secrets from text, and the execution's sensitive values unless they were revealed
+func redactText(ctx context.Context, text string) string {
+	text = RedactorFromContext(ctx).Redact(text)
+	if !PIIRevealed(ctx) {
+		text = PIIRedactorFromContext(ctx).Redact(text)
+	}
+	return text
+}
+
+// redactTraceText replaces the secrets and personal data in text for spans, which are exported elsewhere and
+// so never hold revealed values
+func redactTraceText(ctx context.Context, text string) string {
+	return PIIRedactorFromContext(ctx).Redact(RedactorFromContext(ctx).Redact(text))
+}
+
+// registerSensitiveFields registers the values of the form's sensitive fields with the context's PII redactor
+func registerSensitiveFields(ctx context.Context, formData map[string]interface{}, nodeData *models.FormNodeData) {
+	redactor := PIIRedactorFromContext(ctx)
+	for _, name := range nodeData.SensitiveFields() {
+		redactor.AddField(name, formData[name])
+	}
+}
+
+// DefaultSensitiveLogKeys are the log attribute keys whose values are always masked
+var DefaultSensitiveLogKeys = []string{"email", "to", "recipient", "body", "formData"}
+
+// RedactingLogHandler masks personal data and secrets in log records before passing them on: attributes
+// under a sensitive key, and the sensitive form values and secrets of the execution logging the record.
+// Execution values are only known to records logged with the execution's context, e.g. by slog.InfoContext.
+type RedactingLogHandler struct {
+	next slog.Handler
+	keys map[string]bool
+}
+
+// NewRedactingLogHandler creates a log handler masking the values of keys, compared case-insensitively
+func NewRedactingLogHandler(next slog.Handler, keys ...string) *RedactingLogHandler {
+	handler := &RedactingLogHandler{next: next, keys: make(map[string]bool, len(keys))}
+	for _, key := range keys {
+		handler.keys[strings.ToLower(key)] = true
+	}
+	return handler
+}
+
+// Enabled implements slog.Handler
+func (h *RedactingLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler
+func (h *RedactingLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	pii, secrets := PIIRedactorFromContext(ctx), RedactorFromContext(ctx)
+
+	redacted := slog.NewRecord(record.Time, record.Level, secrets.Redact(pii.Redact(record.Message)), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr, pii, secrets))
+		return true
+	})
+
+	return h.next.Handle(ctx, redacted)
+}
+
+// WithAttrs implements slog.Handler
+func (h *RedactingLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redactAttr(attr, nil, nil)
+	}
+	return &RedactingLogHandler{next: h.next.WithAttrs(redacted), keys: h.keys}
+}
+
+// WithGroup implements slog.Handler
+func (h *RedactingLogHandler) WithGroup(name string) slog.Handler {
+	return &RedactingLogHandler{next: h.next.WithGroup(name), keys: h.keys}
+}
+
+// redactAttr masks the attribute if its key is sensitive, otherwise the execution values in its text
+func (h *RedactingLogHandler) redactAttr(attr slog.Attr, pii *PIIRedactor, secrets *Redactor) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+
+	switch {
+	case attr.Value.Kind() == slog.KindGroup:
+		group := attr.Value.Group()
+		redacted := make([]any, len(group))
+		for i, nested := range group {
+			redacted[i] = h.redactAttr(nested, pii, secrets)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case h.keys[strings.ToLower(attr.Key)] || pii.IsField(attr.Key):
+		return slog.String(attr.Key, models.MaskPII(attr.Value.Any()))
+	case attr.Value.Kind() == slog.KindString:
+		return slog.String(attr.Key, secrets.Redact(pii.Redact(attr.Value.String())))
+	}
+
+	if err, ok := attr.Value.Any().(error); ok {
+		return slog.String(attr.Key, secrets.Redact(pii.Redact(err.Error())))
+	}
+	return attr
+}
@@ -0,0 +1,170 @@
+package execution
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"workflow-code-test/api/internal/models"
+)
+
+func piiWorkflow() *models.WorkflowResponse {
+	return &models.WorkflowResponse{
+		ID: "pii-workflow",
+		Nodes: []models.NodeResponse{
+			{ID: "start", Type: models.NodeTypeStart, Data: models.StartNodeData{Label: "Start"}},
+			{ID: "form", Type: models.NodeTypeForm, Data: models.FormNodeData{
+				Label: "Form",
+				Metadata: models.FormNodeMetadata{
+					InputFields: []models.FormField{
+						{Name: "name", Required: true, Sensitive: true},
+						{Name: "email", Type: models.FormFieldTypeEmail, Required: true, Sensitive: true},
+						{Name: "city", Required: true},
+					},
+				},
+			}},
+			{ID: "email", Type: models.NodeTypeEmail, Data: models.EmailNodeData{Label: "Send Email"}},
+		},
+		Edges: []models.EdgeResponse{
+			{ID: "e1", Source: "start", Target: "form"},
+			{ID: "e2", Source: "form", Target: "email"},
+		},
+	}
+}
+
+func piiRequest() *models.ExecutionRequest {
+	return &models.ExecutionRequest{FormData: map[string]interface{}{
+		"name":  "Alice",
+		"email": "alice@example.com",
+		"city":  "Sydney",
+	}}
+}
+
+func TestPIIRedactor_RedactJSON(t *testing.T) {
+	redactor := &PIIRedactor{}
+	redactor.AddField("name", "Al")
+	redactor.AddField("email", "alice@example.com")
+	redactor.AddField("age", 42.0)
+
+	redacted := redactor.RedactJSON([]byte(`{"name":"Al","age":42,"count":42,"draft":{"to":"alice@example.com","body":"Hi Al"}}`))
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(redacted, &document); err != nil {
+		t.Fatalf("Expected valid JSON, got %s: %v", redacted, err)
+	}
+	if document["name"] != models.RedactedValue || document["age"] != models.RedactedValue {
+		t.Errorf("Expected sensitive fields to be masked whole, got %s", redacted)
+	}
+	if document["count"] != 42.0 {
+		t.Errorf("Expected other fields to be left alone, got %s", redacted)
+	}
+
+	draft := document["draft"].(map[string]interface{})
+	if draft["to"] != "a***@example.com" {
+		t.Errorf("Expected the email to be masked where it appears, got %v", draft["to"])
+	}
+	// Values too short to match safely are only masked under their own field
+	if draft["body"] != "Hi Al" {
+		t.Errorf("Expected a short value to be left in text, got %v", draft["body"])
+	}
+
+	var none *PIIRedactor
+	if got := string(none.RedactJSON([]byte(`{"name":"Al"}`))); got != `{"name":"Al"}` {
+		t.Errorf("Expected a nil redactor to leave output alone, got %s", got)
+	}
+}
+
+func TestEngine_ExecuteWorkflow_RedactsPII(t *testing.T) {
+	engine := NewEngine()
+
+	result, err := engine.ExecuteWorkflow(context.Background(), piiWorkflow(), piiRequest())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Status != "completed" || len(result.Steps) != 3 {
+		t.Fatalf("Expected 3 completed steps, got %+v", result)
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, output := range []string{string(result.Steps[1].RawOutput), string(result.Steps[2].RawOutput), string(response)} {
+		if strings.Contains(output, "Alice") || strings.Contains(output, "alice@example.com") {
+			t.Errorf("Expected personal data to be masked, got %s", output)
+		}
+	}
+	if !strings.Contains(string(response), `"city":"Sydney"`) || !strings.Contains(string(response), `"to":"a***@example.com"`) {
+		t.Errorf("Expected the masked output in the response, got %s", response)
+	}
+
+	// The email is still sent to the real address
+	if sent := engine.emailService.GetSentEmails(); len(sent) != 1 || sent[0].To != "alice@example.com" {
+		t.Errorf("Expected the email to be sent to alice@example.com, got %+v", sent)
+	}
+}
+
+func TestEngine_ExecuteWorkflow_RevealsPII(t *testing.T) {
+	engine := NewEngine()
+
+	result, err := engine.ExecuteWorkflow(WithPIIRevealed(context.Background()), piiWorkflow(), piiRequest())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	step := result.Steps[1]
+	if strings.Contains(string(step.RawOutput), "Alice") {
+		t.Errorf("Expected stored output to stay masked, got %s", step.RawOutput)
+	}
+
+	response, err := json.Marshal(step)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(string(response), `"name":"Alice"`) {
+		t.Errorf("Expected the revealed output in the response, got %s", response)
+	}
+}
+
+func TestRedactingLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewRedactingLogHandler(slog.NewTextHandler(&buf, nil), DefaultSensitiveLogKeys...))
+
+	pii := &PIIRedactor{}
+	pii.AddField("name", "Alice")
+	secrets := &Redactor{}
+	secrets.Add("tok-123")
+	ctx := WithRedactor(withPIIRedactor(context.Background(), pii), secrets)
+
+	logger.With("email", "bob@example.com").InfoContext(ctx, "Greeting Alice",
+		"to", "alice@example.com",
+		"body", "Hi there",
+		"name", "Al",
+		"note", "sent to Alice with tok-123",
+		"error", errors.New("Alice rejected"),
+		slog.Group("request", "recipient", "carol@example.com"),
+	)
+
+	line := buf.String()
+	for _, leaked := range []string{"Alice", "bob@example.com", "alice@example.com", "Hi there", "name=Al", "tok-123", "carol@example.com"} {
+		if strings.Contains(line, leaked) {
+			t.Errorf("Expected %q to be redacted, got %s", leaked, line)
+		}
+	}
+	for _, masked := range []string{"email=b***@example.com", "to=a***@example.com", "body=REDACTED", "request.recipient=c***@example.com"} {
+		if !strings.Contains(line, masked) {
+			t.Errorf("Expected %q in the log line, got %s", masked, line)
+		}
+	}
+
+	// Without an execution context only the sensitive keys are masked
+	buf.Reset()
+	logger.Info("Greeting Alice", "city", "Sydney")
+	if !strings.Contains(buf.String(), "Greeting Alice") || !strings.Contains(buf.String(), "city=Sydney") {
+		t.Errorf("Expected other values to be left alone, got %s", buf.String())
+	}
+}
@@ -0,0 +1,125 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig holds the token bucket parameters of a rate limit
+type RateLimitConfig struct {
+	// RequestsPerSecond is the rate tokens are added at, the sustained request rate
+	RequestsPerSecond float64
+	// Burst is the bucket size, the number of requests that can be made at once after a quiet period
+	Burst int
+	// MaxWait is how long a call waits for a token before failing with a RateLimitError
+	MaxWait time.Duration
+}
+
+// DefaultRateLimitConfig returns the rate limit used for every host by default
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		RequestsPerSecond: 10,
+		Burst:             20,
+		MaxWait:           2 * time.Second,
+	}
+}
+
+// ErrRateLimited is matched by errors.Is for every RateLimitError
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitError is returned when a call would have to wait longer than the rate limit allows
+type RateLimitError struct {
+	Host string
+	// RetryAfter is how long until a token would be available
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface for RateLimitError
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit for %s exceeded, retry after %s", e.Host, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Is makes errors.Is(err, ErrRateLimited) true for rate limit errors
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// TokenBucket limits the rate of calls to a host
+type TokenBucket struct {
+	host   string
+	config RateLimitConfig
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a full token bucket for a host
+func NewTokenBucket(host string, config RateLimitConfig) *TokenBucket {
+	return &TokenBucket{
+		host:   host,
+		config: config,
+		now:    time.Now,
+		sleep:  sleepContext,
+		tokens: float64(config.Burst),
+	}
+}
+
+// Wait takes a token, waiting for one if the bucket is empty. It fails with a RateLimitError without
+// waiting if the token would take longer than MaxWait to arrive.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.config.RequestsPerSecond
+		if b.tokens > float64(b.config.Burst) {
+			b.tokens = float64(b.config.Burst)
+		}
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.mu.Unlock()
+		return nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / b.config.RequestsPerSecond * float64(time.Second))
+	if wait > b.config.MaxWait {
+		b.mu.Unlock()
+		return &RateLimitError{Host: b.host, RetryAfter: wait}
+	}
+	// Reserve the token now so concurrent callers queue up behind this one
+	b.tokens--
+	b.mu.Unlock()
+
+	if err := b.sleep(ctx, wait); err != nil {
+		// A cancelled caller never makes its call, so give the reserved token back
+		b.mu.Lock()
+		b.tokens++
+		if b.tokens > float64(b.config.Burst) {
+			b.tokens = float64(b.config.Burst)
+		}
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// sleepContext sleeps for d or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
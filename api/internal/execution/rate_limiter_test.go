@@ -0,0 +1,94 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Wait(t *testing.T) {
+	bucket := NewTokenBucket("api.example.com", RateLimitConfig{RequestsPerSecond: 2, Burst: 2, MaxWait: time.Second})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	bucket.now = func() time.Time { return now }
+	var slept []time.Duration
+	bucket.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	ctx := context.Background()
+
+	// The burst is available straight away
+	for i := 0; i < 2; i++ {
+		if err := bucket.Wait(ctx); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if len(slept) != 0 {
+		t.Fatalf("Expected no waiting within the burst, waited %v", slept)
+	}
+
+	// The next tokens arrive every 500ms, queued callers wait in turn
+	_ = bucket.Wait(ctx)
+	_ = bucket.Wait(ctx)
+	if len(slept) != 2 || slept[0] != 500*time.Millisecond || slept[1] != time.Second {
+		t.Errorf("Expected waits of 500ms and 1s, got %v", slept)
+	}
+
+	// Waiting longer than MaxWait fails instantly
+	err := bucket.Wait(ctx)
+	var limitErr *RateLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected a RateLimitError, got %v", err)
+	}
+	if limitErr.RetryAfter != 1500*time.Millisecond {
+		t.Errorf("Expected retry after 1.5s, got %v", limitErr.RetryAfter)
+	}
+
+	// Tokens refill over time up to the burst
+	now = now.Add(time.Hour)
+	slept = nil
+	for i := 0; i < 2; i++ {
+		if err := bucket.Wait(ctx); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if len(slept) != 0 {
+		t.Errorf("Expected a refilled bucket, waited %v", slept)
+	}
+}
+
+func TestTokenBucket_Wait_CancelRefundsToken(t *testing.T) {
+	bucket := NewTokenBucket("api.example.com", RateLimitConfig{RequestsPerSecond: 2, Burst: 1, MaxWait: time.Second})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	bucket.now = func() time.Time { return now }
+	var slept []time.Duration
+	bucket.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return ctx.Err()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_ = bucket.Wait(ctx)
+	cancel()
+	if err := bucket.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	// The cancelled caller's token is available to the next one instead of pushing it further back
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(slept) != 2 || slept[1] != 500*time.Millisecond {
+		t.Errorf("Expected the next caller to wait 500ms, got %v", slept)
+	}
+}
+
+func TestSleepContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := sleepContext(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
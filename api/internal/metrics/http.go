@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+)
+
+// unmatchedRoute labels requests that didn't match a route, so probing random paths can't create new series
+const unmatchedRoute = "unmatched"
+
+// Middleware counts and times requests by their route template, such as /api/v1/workflows/{id}, rather than
+// their path, so workflow IDs don't each become a series
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The writer is wrapped keeping the interfaces it implements, such as http.Flusher for CSV exports
+		captured := httpsnoop.CaptureMetrics(next, w, r)
+
+		route := routeTemplate(r)
+		HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(captured.Code)).Inc()
+		HTTPRequestDuration.WithLabelValues(r.Method, route).Observe(captured.Duration.Seconds())
+	})
+}
+
+// routeTemplate returns the template of the route the request matched
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return unmatchedRoute
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return unmatchedRoute
+	}
+	return template
+}
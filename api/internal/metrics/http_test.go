@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware_LabelsByRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.HandleFunc("/api/v1/workflows/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+
+	route := "/api/v1/workflows/{id}"
+	before := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", route, "404"))
+	for _, id := range []string{"a", "b"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/workflows/"+id, nil))
+	}
+
+	if got := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", route, "404")) - before; got != 2 {
+		t.Errorf("Expected 2 requests counted under the route template, got %v", got)
+	}
+	if got := testutil.CollectAndCount(HTTPRequestDuration, "workflow_http_request_duration_seconds"); got == 0 {
+		t.Errorf("Expected the request duration to be observed")
+	}
+}
+
+func TestMiddleware_DefaultStatus(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	before := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", unmatchedRoute, "200"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/anything", nil))
+
+	if got := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", unmatchedRoute, "200")) - before; got != 1 {
+		t.Errorf("Expected a request without a route counted as %s with status 200, got %v", unmatchedRoute, got)
+	}
+}
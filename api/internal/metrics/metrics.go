@@ -0,0 +1,110 @@
+// Package metrics holds the Prometheus metrics of the API and the workflow engine, served by Handler in the
+// Prometheus text format.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the name of every metric of the API
+const namespace = "workflow"
+
+// durationBuckets are the histogram buckets, in seconds, of requests, node runs and API calls
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+var (
+	// HTTPRequests counts API requests by method, route template and response status
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "API requests by method, route and response status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes how long API requests take by method and route template
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "How long API requests take by method and route.",
+		Buckets:   durationBuckets,
+	}, []string{"method", "route"})
+
+	// Executions counts workflow executions by workflow ID and final status, ad-hoc executions under "adhoc"
+	// as their IDs and definitions are chosen by the caller
+	Executions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "executions_total",
+		Help:      "Workflow executions by stored workflow (adhoc for ad-hoc definitions) and final status (completed, failed or rejected).",
+	}, []string{"workflow_id", "status"})
+
+	// NodeDuration observes how long nodes take to run by node type and step status
+	NodeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "node_duration_seconds",
+		Help:      "How long nodes take to run by node type and step status.",
+		Buckets:   durationBuckets,
+	}, []string{"node_type", "status"})
+
+	// APICalls counts calls to external APIs by host and response status, "error" if there was no response.
+	// Hosts other than the ones nodes call out of the box are counted as "other".
+	APICalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_calls_total",
+		Help:      "External API calls by host (other for unknown hosts) and response status, error if the call got no response.",
+	}, []string{"host", "status"})
+
+	// APICallDuration observes how long calls to external APIs take by host
+	APICallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_call_duration_seconds",
+		Help:      "How long external API calls take by host.",
+		Buckets:   durationBuckets,
+	}, []string{"host"})
+
+	// Emails counts emails sent by email nodes by delivery status
+	Emails = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "emails_total",
+		Help:      "Emails sent by email nodes by delivery status (sent, duplicate or failed).",
+	}, []string{"status"})
+)
+
+// registry holds the API's metrics along with the Go runtime and process metrics
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		Executions,
+		NodeDuration,
+		APICalls,
+		APICallDuration,
+		Emails,
+	)
+}
+
+// Register adds collectors, such as database pool stats, to the served metrics. Registering a collector
+// again is a no-op.
+func Register(collectors ...prometheus.Collector) error {
+	for _, collector := range collectors {
+		if err := registry.Register(collector); err != nil {
+			var registered prometheus.AlreadyRegisteredError
+			if !errors.As(err, &registered) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
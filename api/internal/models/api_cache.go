@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// CachedAPIResponse is an integration API response held by a response cache
+type CachedAPIResponse struct {
+	URL        string                 `json:"url"`
+	StatusCode int                    `json:"statusCode"`
+	Body       map[string]interface{} `json:"body"`
+	StoredAt   time.Time              `json:"storedAt"`
+	ExpiresAt  time.Time              `json:"expiresAt"`
+}
+
+// Fresh returns true if the response can still be served at the given time
+func (r *CachedAPIResponse) Fresh(now time.Time) bool {
+	return now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes an API key or token grants its caller
+const (
+	// ScopeRead allows loading workflows, node types and secret descriptions
+	ScopeRead = "read"
+	// ScopeWrite allows saving workflows and managing secrets
+	ScopeWrite = "write"
+	// ScopeExecute allows executing workflows
+	ScopeExecute = "execute"
+	// ScopeAdmin allows everything, including the admin endpoints, API keys and revealing personal data
+	ScopeAdmin = "admin"
+)
+
+// ValidScopes contains all grantable scopes as a set for O(1) lookups
+var ValidScopes = map[string]bool{
+	ScopeRead:    true,
+	ScopeWrite:   true,
+	ScopeExecute: true,
+	ScopeAdmin:   true,
+}
+
+// MaxAPIKeyNameLength is the longest API key name accepted
+const MaxAPIKeyNameLength = 255
+
+// APIKey describes an issued API key. The key itself is only returned once, when it is created.
+type APIKey struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Prefix string    `json:"prefix"`
+	Scopes []string  `json:"scopes"`
+	// WorkspaceID is the workspace callers using the key act in
+	WorkspaceID uuid.UUID `json:"workspaceId"`
+	// CreatedBy is the subject of the caller that created the key
+	CreatedBy  string     `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Revoked returns true if the key can no longer be used
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// HashedAPIKey is an API key as stored, with the hash of the key
+type HashedAPIKey struct {
+	APIKey
+	KeyHash string
+}
+
+// CreatedAPIKey is the response to creating an API key, the only time the key is returned
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyRequest is the payload for creating an API key
+type APIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// Validate checks the request names the key and grants known scopes
+func (r *APIKeyRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("API key name is required")
+	}
+	if len(r.Name) > MaxAPIKeyNameLength {
+		return fmt.Errorf("API key name must be at most %d characters", MaxAPIKeyNameLength)
+	}
+	if len(r.Scopes) == 0 {
+		return fmt.Errorf("API key must have at least one scope")
+	}
+	for _, scope := range r.Scopes {
+		if !ValidScopes[scope] {
+			return fmt.Errorf("invalid scope '%s', must be one of: %s, %s, %s, %s", scope, ScopeRead, ScopeWrite, ScopeExecute, ScopeAdmin)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestAPIKeyRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request APIKeyRequest
+		wantErr bool
+	}{
+		{"valid", APIKeyRequest{Name: "ci", Scopes: []string{ScopeRead, ScopeExecute}}, false},
+		{"missing name", APIKeyRequest{Name: " ", Scopes: []string{ScopeRead}}, true},
+		{"no scopes", APIKeyRequest{Name: "ci"}, true},
+		{"unknown scope", APIKeyRequest{Name: "ci", Scopes: []string{ScopeRead, "delete"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr && err == nil {
+				t.Errorf("Expected an error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audited actions, named <resource>.<verb>
+const (
+	AuditWorkflowCreate   = "workflow.create"
+	AuditWorkflowUpdate   = "workflow.update"
+	AuditWorkflowExecute  = "workflow.execute"
+	AuditPermissionSet    = "permission.set"
+	AuditPermissionDelete = "permission.delete"
+	AuditSecretCreate     = "secret.create"
+	AuditSecretUpdate     = "secret.update"
+	AuditSecretDelete     = "secret.delete"
+	AuditSecretAccess     = "secret.access"
+	AuditSecretRotate     = "secret.rotate"
+	AuditAPIKeyCreate     = "api_key.create"
+	AuditAPIKeyRevoke     = "api_key.revoke"
+)
+
+// Types of audited resources
+const (
+	AuditResourceWorkflow = "workflow"
+	AuditResourceSecret   = "secret"
+	AuditResourceAPIKey   = "api_key"
+)
+
+const (
+	// DefaultAuditLimit is how many events a page of the audit log has unless the caller asks for another size
+	DefaultAuditLimit = 100
+	// MaxAuditLimit is the largest page of the audit log a caller can ask for
+	MaxAuditLimit = 1000
+)
+
+// AuditEvent records who did what to which resource. Events are append-only, they're never changed or removed.
+type AuditEvent struct {
+	ID          int64     `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspaceId"`
+	OccurredAt  time.Time `json:"occurredAt"`
+	// Actor is the subject of the caller, ActorMethod how it authenticated
+	Actor        string `json:"actor"`
+	ActorMethod  string `json:"actorMethod"`
+	Action       string `json:"action"`
+	ResourceType string `json:"resourceType"`
+	ResourceID   string `json:"resourceId"`
+	// RequestID is the X-Request-ID of the request that caused the event
+	RequestID string `json:"requestId"`
+	// Before and After summarize the resource around the change, never including secret values
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// AuditCSVHeader names the columns of AuditEvent.CSVRecord
+var AuditCSVHeader = []string{"id", "occurred_at", "actor", "actor_method", "action", "resource_type", "resource_id", "request_id", "before", "after"}
+
+// CSVRecord returns the event as a CSV row, in the order of AuditCSVHeader. Cells are escaped so spreadsheets
+// don't evaluate caller controlled values like the actor or request ID as formulas.
+func (e *AuditEvent) CSVRecord() []string {
+	return []string{
+		strconv.FormatInt(e.ID, 10),
+		e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		csvCell(e.Actor),
+		csvCell(e.ActorMethod),
+		csvCell(e.Action),
+		csvCell(e.ResourceType),
+		csvCell(e.ResourceID),
+		csvCell(e.RequestID),
+		csvCell(string(e.Before)),
+		csvCell(string(e.After)),
+	}
+}
+
+// csvCell prefixes a value spreadsheets would read as a formula with a quote, so it's shown as text
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// AuditChange is what a service records about an action, the caller, request and workspace are taken from
+// the context
+type AuditChange struct {
+	Action       string
+	ResourceType string
+	ResourceID   string
+	// Before and After are marshalled to JSON, nil when there is no resource before or after the change
+	Before any
+	After  any
+}
+
+// WorkflowAuditSummary summarizes a stored workflow
+type WorkflowAuditSummary struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Nodes   int    `json:"nodes"`
+	Edges   int    `json:"edges"`
+}
+
+// ExecutionAuditSummary summarizes the outcome of an execution
+type ExecutionAuditSummary struct {
+	Status string `json:"status"`
+	Steps  int    `json:"steps"`
+	// Stored is false for ad-hoc executions, including ones reusing a saved workflow's ID
+	Stored bool `json:"stored"`
+}
+
+// PermissionAuditSummary is the role a subject has on a workflow
+type PermissionAuditSummary struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+}
+
+// SecretAuditSummary describes a secret, never its value
+type SecretAuditSummary struct {
+	Description string `json:"description"`
+	KeyID       string `json:"keyId"`
+	// ValueChanged is true when an update replaced the value
+	ValueChanged bool `json:"valueChanged,omitempty"`
+}
+
+// SecretRotationAuditSummary is the outcome of rewrapping a workspace's secrets
+type SecretRotationAuditSummary struct {
+	Rotated int    `json:"rotated"`
+	KeyID   string `json:"keyId"`
+}
+
+// APIKeyAuditSummary describes an API key, never the key itself
+type APIKeyAuditSummary struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// AuditFilter selects events from the audit log. Empty fields match every event.
+type AuditFilter struct {
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	RequestID    string
+	Since        *time.Time
+	Until        *time.Time
+	// BeforeID pages through the log, newest first, returning only events older than the given event
+	BeforeID int64
+	Limit    int
+}
+
+// AuditFilterFromQuery parses an audit filter from query parameters: actor, action, resourceType, resourceId,
+// requestId, since and until (RFC 3339), before (an event ID) and limit
+func AuditFilterFromQuery(query url.Values) (*AuditFilter, error) {
+	filter := &AuditFilter{
+		Actor:        query.Get("actor"),
+		Action:       query.Get("action"),
+		ResourceType: query.Get("resourceType"),
+		ResourceID:   query.Get("resourceId"),
+		RequestID:    query.Get("requestId"),
+		Limit:        DefaultAuditLimit,
+	}
+
+	for param, dest := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an RFC 3339 time, e.g. 2024-03-05T08:30:00Z", param)
+		}
+		*dest = &t
+	}
+
+	if value := query.Get("before"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("before must be an audit event ID")
+		}
+		filter.BeforeID = id
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxAuditLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", MaxAuditLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if filter.Since != nil && filter.Until != nil && filter.Until.Before(*filter.Since) {
+		return nil, fmt.Errorf("until must not be before since")
+	}
+	return filter, nil
+}
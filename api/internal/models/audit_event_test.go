@@ -0,0 +1,132 @@
+package models
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAuditFilterFromQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+		check   func(t *testing.T, filter *AuditFilter)
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			check: func(t *testing.T, filter *AuditFilter) {
+				if filter.Limit != DefaultAuditLimit {
+					t.Errorf("Expected limit %d, got %d", DefaultAuditLimit, filter.Limit)
+				}
+				if filter.Since != nil || filter.Until != nil || filter.BeforeID != 0 {
+					t.Errorf("Expected no time range or cursor, got %+v", filter)
+				}
+			},
+		},
+		{
+			name:  "every filter",
+			query: "actor=alice&action=workflow.update&resourceType=workflow&resourceId=abc&requestId=req-1&since=2024-03-05T00:00:00Z&until=2024-03-06T00:00:00Z&before=42&limit=10",
+			check: func(t *testing.T, filter *AuditFilter) {
+				if filter.Actor != "alice" || filter.Action != "workflow.update" || filter.ResourceType != "workflow" ||
+					filter.ResourceID != "abc" || filter.RequestID != "req-1" {
+					t.Errorf("Expected the string filters to be parsed, got %+v", filter)
+				}
+				if filter.Since == nil || !filter.Since.Equal(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("Expected since 2024-03-05, got %v", filter.Since)
+				}
+				if filter.Until == nil || !filter.Until.Equal(time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("Expected until 2024-03-06, got %v", filter.Until)
+				}
+				if filter.BeforeID != 42 {
+					t.Errorf("Expected before 42, got %d", filter.BeforeID)
+				}
+				if filter.Limit != 10 {
+					t.Errorf("Expected limit 10, got %d", filter.Limit)
+				}
+			},
+		},
+		{name: "invalid since", query: "since=yesterday", wantErr: true},
+		{name: "until before since", query: "since=2024-03-06T00:00:00Z&until=2024-03-05T00:00:00Z", wantErr: true},
+		{name: "invalid cursor", query: "before=abc", wantErr: true},
+		{name: "zero limit", query: "limit=0", wantErr: true},
+		{name: "limit too large", query: "limit=1001", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("Failed to parse query: %v", err)
+			}
+
+			filter, err := AuditFilterFromQuery(query)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			tt.check(t, filter)
+		})
+	}
+}
+
+func TestAuditEvent_CSVRecord(t *testing.T) {
+	event := AuditEvent{
+		ID:           7,
+		OccurredAt:   time.Date(2024, 3, 5, 8, 30, 0, 0, time.FixedZone("AEST", 10*60*60)),
+		Actor:        "alice",
+		ActorMethod:  "jwt",
+		Action:       AuditWorkflowUpdate,
+		ResourceType: AuditResourceWorkflow,
+		ResourceID:   "abc",
+		RequestID:    "req-1",
+		After:        json.RawMessage(`{"name":"Weather"}`),
+	}
+
+	record := event.CSVRecord()
+	if len(record) != len(AuditCSVHeader) {
+		t.Fatalf("Expected %d columns, got %d", len(AuditCSVHeader), len(record))
+	}
+
+	expected := []string{"7", "2024-03-04T22:30:00Z", "alice", "jwt", "workflow.update", "workflow", "abc", "req-1", "", `{"name":"Weather"}`}
+	for i := range expected {
+		if record[i] != expected[i] {
+			t.Errorf("Expected %s %q, got %q", AuditCSVHeader[i], expected[i], record[i])
+		}
+	}
+}
+
+func TestAuditEvent_CSVRecord_EscapesFormulas(t *testing.T) {
+	event := AuditEvent{
+		ID:          8,
+		Actor:       `=HYPERLINK("https://evil.example","x")`,
+		ActorMethod: "jwt",
+		Action:      AuditWorkflowExecute,
+		ResourceID:  "@SUM(A1)",
+		RequestID:   "+1-2",
+		Before:      json.RawMessage("-1"),
+		After:       json.RawMessage("\t=1"),
+	}
+
+	record := event.CSVRecord()
+	expected := map[int]string{
+		2: `'=HYPERLINK("https://evil.example","x")`,
+		3: "jwt",
+		6: "'@SUM(A1)",
+		7: "'+1-2",
+		8: "'-1",
+		9: "'\t=1",
+	}
+	for i, want := range expected {
+		if record[i] != want {
+			t.Errorf("Expected %s %q, got %q", AuditCSVHeader[i], want, record[i])
+		}
+	}
+}
@@ -94,6 +94,22 @@ func (e *Edge) ToResponse() EdgeResponse {
 	return response
 }
 
+// ToRequest converts an EdgeResponse to EdgeRequest format, e.g. for validating a stored workflow
+func (e *EdgeResponse) ToRequest() EdgeRequest {
+	return EdgeRequest{
+		ID:           e.ID,
+		Source:       e.Source,
+		Target:       e.Target,
+		Type:         e.Type,
+		Animated:     e.Animated,
+		Style:        e.Style,
+		Label:        e.Label,
+		LabelStyle:   e.LabelStyle,
+		SourceHandle: e.SourceHandle,
+		TargetHandle: e.TargetHandle,
+	}
+}
+
 // EdgeRequest represents an edge as sent from the frontend
 type EdgeRequest struct {
 	ID           string      `json:"id"`
@@ -136,6 +152,22 @@ func (er *EdgeRequest) ToEdge() *Edge {
 	return edge
 }
 
+// ToResponse converts an EdgeRequest to EdgeResponse format, e.g. for ad-hoc execution
+func (er *EdgeRequest) ToResponse() EdgeResponse {
+	return EdgeResponse{
+		ID:           er.ID,
+		Source:       er.Source,
+		Target:       er.Target,
+		Type:         er.Type,
+		Animated:     er.Animated,
+		Style:        er.Style,
+		Label:        er.Label,
+		LabelStyle:   er.LabelStyle,
+		SourceHandle: er.SourceHandle,
+		TargetHandle: er.TargetHandle,
+	}
+}
+
 // Validate checks if the edge has a valid type
 func (e *Edge) Validate() error {
 	if e.Type != nil {
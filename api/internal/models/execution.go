@@ -6,12 +6,66 @@ import (
 	"time"
 )
 
+// Execution modes
+const (
+	// ExecutionModeStored executes the workflow definition persisted in the database
+	ExecutionModeStored = "stored"
+	// ExecutionModeAdHoc executes the definition sent with the request without saving it
+	ExecutionModeAdHoc = "adhoc"
+)
+
+// ValidExecutionModes contains all allowed execution modes as a set for O(1) lookups
+var ValidExecutionModes = map[string]bool{
+	ExecutionModeStored: true,
+	ExecutionModeAdHoc:  true,
+}
+
 // ExecutionRequest represents the request payload for workflow execution
 type ExecutionRequest struct {
+	Mode      string                 `json:"mode,omitempty"` // defaults to ExecutionModeStored
 	FormData  map[string]interface{} `json:"formData"`
 	Condition map[string]interface{} `json:"condition"`
-	Nodes     []NodeRequest          `json:"nodes,omitempty"`
-	Edges     []EdgeRequest          `json:"edges,omitempty"`
+	Nodes     []NodeRequest          `json:"nodes,omitempty"` // only used in ExecutionModeAdHoc
+	Edges     []EdgeRequest          `json:"edges,omitempty"` // only used in ExecutionModeAdHoc
+	// IdempotencyKey comes from the Idempotency-Key header, executions with the same key run only once
+	IdempotencyKey string `json:"-"`
+}
+
+// GetMode returns the requested execution mode, defaulting to ExecutionModeStored
+func (er *ExecutionRequest) GetMode() string {
+	if er.Mode == "" {
+		return ExecutionModeStored
+	}
+	return er.Mode
+}
+
+// Validate checks that the execution mode is known and consistent with the request payload
+func (er *ExecutionRequest) Validate() error {
+	mode := er.GetMode()
+	if !ValidExecutionModes[mode] {
+		return fmt.Errorf("invalid execution mode '%s', must be one of: %s, %s", mode, ExecutionModeStored, ExecutionModeAdHoc)
+	}
+
+	hasDefinition := len(er.Nodes) > 0 || len(er.Edges) > 0
+
+	if mode == ExecutionModeStored && hasDefinition {
+		return fmt.Errorf("nodes and edges are only accepted in '%s' mode, save the workflow before executing it", ExecutionModeAdHoc)
+	}
+
+	if mode == ExecutionModeAdHoc && len(er.Nodes) == 0 {
+		return fmt.Errorf("'%s' mode requires the workflow nodes and edges", ExecutionModeAdHoc)
+	}
+
+	return nil
+}
+
+// ToWorkflowRequest builds the ad-hoc workflow definition carried by the request
+func (er *ExecutionRequest) ToWorkflowRequest(workflowID string) *WorkflowRequest {
+	return &WorkflowRequest{
+		ID:    workflowID,
+		Nodes: er.Nodes,
+		Edges: er.Edges,
+	}
 }
 
 // ExecutionResponse represents the complete execution result
@@ -20,8 +74,22 @@ type ExecutionResponse struct {
 	Status     string          `json:"status"`
 	Steps      []ExecutionStep `json:"steps"`
 	Error      *string         `json:"error,omitempty"`
+	// Replayed is true when the response is the stored result of an earlier request with the same idempotency key
+	Replayed bool `json:"-"`
 }
 
+// Error kinds of failed steps, error edges see them in the errorKind variable
+const (
+	// ErrorKindCircuitOpen is a call refused instantly as the circuit breaker of its host is open
+	ErrorKindCircuitOpen = "circuit_open"
+	// ErrorKindRateLimited is a call that would have waited too long for the rate limit of its host
+	ErrorKindRateLimited = "rate_limited"
+	// ErrorKindBlocked is a call the outbound policy refused
+	ErrorKindBlocked = "blocked"
+	// ErrorKindFailed is any other failure
+	ErrorKindFailed = "failed"
+)
+
 // ExecutionStep represents a single step in the workflow execution
 type ExecutionStep struct {
 	NodeID      string          `json:"nodeId"`
@@ -30,18 +98,68 @@ type ExecutionStep struct {
 	Description string          `json:"description"`
 	Status      string          `json:"status"`
 	Output      ExecutionOutput `json:"output,omitempty"` // Strongly typed output
-	RawOutput   json.RawMessage `json:"-"`                // For database storage
-	Error       *string         `json:"error,omitempty"`
-	Duration    *int64          `json:"duration,omitempty"` // milliseconds
+	RawOutput   json.RawMessage `json:"-"`                // For database storage, sensitive values masked
+	// RevealedOutput is the output with sensitive values left in, only set when an admin reveals them.
+	// It is never stored.
+	RevealedOutput json.RawMessage `json:"-"`
+	Error          *string         `json:"error,omitempty"`
+	// ErrorKind classifies the error of a failed step, one of the ErrorKind constants
+	ErrorKind string `json:"errorKind,omitempty"`
+	Duration  *int64 `json:"duration,omitempty"` // milliseconds
+}
+
+// MarshalJSON writes the strongly typed output if the step has one, otherwise the revealed or stored
+// raw output, so responses only show sensitive values when they were revealed
+func (step ExecutionStep) MarshalJSON() ([]byte, error) {
+	// The alias drops this method, its Output is shadowed by the one below
+	type executionStep ExecutionStep
+	response := struct {
+		executionStep
+		Output interface{} `json:"output,omitempty"`
+	}{executionStep: executionStep(step)}
+
+	switch {
+	case step.Output != nil:
+		response.Output = step.Output
+	case len(step.RevealedOutput) > 0:
+		response.Output = step.RevealedOutput
+	case len(step.RawOutput) > 0:
+		response.Output = step.RawOutput
+	}
+
+	return json.Marshal(response)
+}
+
+// UnmarshalJSON reads a step written by MarshalJSON, keeping its output as raw output, e.g. to replay a
+// stored execution response
+func (step *ExecutionStep) UnmarshalJSON(data []byte) error {
+	type executionStep ExecutionStep
+	var decoded struct {
+		executionStep
+		Output json.RawMessage `json:"output,omitempty"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*step = ExecutionStep(decoded.executionStep)
+	step.RawOutput = decoded.Output
+	return nil
 }
 
 // ExecutionContext holds the runtime state during workflow execution
 type ExecutionContext struct {
 	WorkflowID string
-	FormData   map[string]interface{}
-	Variables  map[string]interface{}
-	Steps      []ExecutionStep
-	StartTime  time.Time
+	// WorkflowVersion is the saved revision being executed, empty for ad-hoc definitions
+	WorkflowVersion string
+	FormData        map[string]interface{}
+	Variables       map[string]interface{}
+	Steps           []ExecutionStep
+	StartTime       time.Time
+
+	// IdempotencyKey identifies the execution request across retries, scoped to its workspace and caller, so
+	// nodes with side effects can dedupe them. Empty when the request had no Idempotency-Key.
+	IdempotencyKey string
 }
 
 // NewExecutionContext creates a new execution context
@@ -74,17 +192,6 @@ func (ctx *ExecutionContext) GetVariable(key string) (interface{}, bool) {
 // Legacy data structures - these are replaced by the strongly typed versions in node_data.go
 // Keeping for backward compatibility during migration
 
-// FormField represents a single form field configuration (legacy)
-type FormField struct {
-	Name        string   `json:"name"`
-	Type        string   `json:"type"`
-	Label       string   `json:"label"`
-	Required    bool     `json:"required"`
-	Options     []string `json:"options,omitempty"`    // for select fields
-	Validation  string   `json:"validation,omitempty"` // validation rules
-	Placeholder string   `json:"placeholder,omitempty"`
-}
-
 // CityOption represents a city with its coordinates (legacy)
 type CityOption struct {
 	City string  `json:"city"`
@@ -42,6 +42,8 @@ type IntegrationExecutionOutput struct {
 	ProcessedData  map[string]interface{} `json:"processedData"`
 	EndpointCalled string                 `json:"endpointCalled"`
 	StatusCode     int                    `json:"statusCode"`
+	// Cache is hit, miss or bypass when the API client caches responses
+	Cache string `json:"cache,omitempty"`
 }
 
 func (o IntegrationExecutionOutput) GetOutputType() string { return NodeTypeIntegration }
@@ -106,31 +108,16 @@ type ExecutionOutputUnion struct {
 
 // UnmarshalJSON implements custom unmarshaling for ExecutionOutputUnion
 func (eou *ExecutionOutputUnion) UnmarshalJSON(data []byte) error {
-	// Try each type until one works
-	types := []struct {
-		outputType string
-		target     ExecutionOutput
-	}{
-		{NodeTypeStart, &StartExecutionOutput{}},
-		{NodeTypeForm, &FormExecutionOutput{}},
-		{NodeTypeIntegration, &IntegrationExecutionOutput{}},
-		{NodeTypeCondition, &ConditionExecutionOutput{}},
-		{NodeTypeEmail, &EmailExecutionOutput{}},
-		{NodeTypeEnd, &EndExecutionOutput{}},
-	}
-
+	// Try each registered type until one works
 	var lastErr error
-	for _, t := range types {
-		if err := json.Unmarshal(data, t.target); err == nil {
-			// Validate that this is the correct type
-			if err := t.target.Validate(); err == nil {
-				eou.Type = t.outputType
-				eou.Output = t.target
-				return nil
-			}
-		} else {
-			lastErr = err
+	for _, nodeType := range RegisteredNodeTypes() {
+		parsed, err := nodeType.ParseOutput(data)
+		if err == nil {
+			eou.Type = nodeType.Describe().Type
+			eou.Output = parsed
+			return nil
 		}
+		lastErr = err
 	}
 
 	return fmt.Errorf("failed to unmarshal execution output into any known type: %v", lastErr)
@@ -144,52 +131,11 @@ func (eou ExecutionOutputUnion) MarshalJSON() ([]byte, error) {
 	return json.Marshal(eou.Output)
 }
 
-// ParseExecutionOutput parses raw JSON into the appropriate strongly typed ExecutionOutput
+// ParseExecutionOutput parses raw JSON into the strongly typed ExecutionOutput of the registered node type
 func ParseExecutionOutput(nodeType string, rawData []byte) (ExecutionOutput, error) {
-	switch nodeType {
-	case NodeTypeStart:
-		var output StartExecutionOutput
-		if err := json.Unmarshal(rawData, &output); err != nil {
-			return nil, fmt.Errorf("failed to parse start execution output: %w", err)
-		}
-		return output, output.Validate()
-
-	case NodeTypeForm:
-		var output FormExecutionOutput
-		if err := json.Unmarshal(rawData, &output); err != nil {
-			return nil, fmt.Errorf("failed to parse form execution output: %w", err)
-		}
-		return output, output.Validate()
-
-	case NodeTypeIntegration:
-		var output IntegrationExecutionOutput
-		if err := json.Unmarshal(rawData, &output); err != nil {
-			return nil, fmt.Errorf("failed to parse integration execution output: %w", err)
-		}
-		return output, output.Validate()
-
-	case NodeTypeCondition:
-		var output ConditionExecutionOutput
-		if err := json.Unmarshal(rawData, &output); err != nil {
-			return nil, fmt.Errorf("failed to parse condition execution output: %w", err)
-		}
-		return output, output.Validate()
-
-	case NodeTypeEmail:
-		var output EmailExecutionOutput
-		if err := json.Unmarshal(rawData, &output); err != nil {
-			return nil, fmt.Errorf("failed to parse email execution output: %w", err)
-		}
-		return output, output.Validate()
-
-	case NodeTypeEnd:
-		var output EndExecutionOutput
-		if err := json.Unmarshal(rawData, &output); err != nil {
-			return nil, fmt.Errorf("failed to parse end execution output: %w", err)
-		}
-		return output, output.Validate()
-
-	default:
+	registered, ok := GetNodeType(nodeType)
+	if !ok {
 		return nil, fmt.Errorf("unknown node type for execution output: %s", nodeType)
 	}
+	return registered.ParseOutput(rawData)
 }
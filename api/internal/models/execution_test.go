@@ -0,0 +1,172 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExecutionRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		request     ExecutionRequest
+		expectError bool
+	}{
+		{
+			name:        "default mode without definition",
+			request:     ExecutionRequest{},
+			expectError: false,
+		},
+		{
+			name:        "stored mode without definition",
+			request:     ExecutionRequest{Mode: ExecutionModeStored},
+			expectError: false,
+		},
+		{
+			name: "stored mode with definition",
+			request: ExecutionRequest{
+				Mode:  ExecutionModeStored,
+				Nodes: []NodeRequest{{ID: "start-1", Type: NodeTypeStart}},
+			},
+			expectError: true,
+		},
+		{
+			name: "default mode with definition",
+			request: ExecutionRequest{
+				Nodes: []NodeRequest{{ID: "start-1", Type: NodeTypeStart}},
+			},
+			expectError: true,
+		},
+		{
+			name: "adhoc mode with definition",
+			request: ExecutionRequest{
+				Mode:  ExecutionModeAdHoc,
+				Nodes: []NodeRequest{{ID: "start-1", Type: NodeTypeStart}},
+			},
+			expectError: false,
+		},
+		{
+			name:        "adhoc mode without definition",
+			request:     ExecutionRequest{Mode: ExecutionModeAdHoc},
+			expectError: true,
+		},
+		{
+			name:        "unknown mode",
+			request:     ExecutionRequest{Mode: "draft"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.expectError && err == nil {
+				t.Error("expected error, got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWorkflowRequest_ToResponse(t *testing.T) {
+	sourceHandle := "true"
+	request := WorkflowRequest{
+		ID: "test-workflow",
+		Nodes: []NodeRequest{
+			{ID: "start-1", Type: NodeTypeStart, Position: Position{X: 10, Y: 20}, Data: StartNodeData{Label: "Start"}},
+			{ID: "end-1", Type: NodeTypeEnd},
+		},
+		Edges: []EdgeRequest{
+			{ID: "edge-1", Source: "start-1", Target: "end-1", SourceHandle: &sourceHandle},
+		},
+	}
+
+	response := request.ToResponse()
+
+	if response.ID != "test-workflow" {
+		t.Errorf("expected ID test-workflow, got %s", response.ID)
+	}
+	if len(response.Nodes) != 2 || len(response.Edges) != 1 {
+		t.Fatalf("expected 2 nodes and 1 edge, got %d nodes and %d edges", len(response.Nodes), len(response.Edges))
+	}
+	if response.Nodes[0].Position.X != 10 || response.Nodes[0].Data == nil {
+		t.Errorf("expected node position and data to be carried over, got %+v", response.Nodes[0])
+	}
+	if response.Edges[0].SourceHandle == nil || *response.Edges[0].SourceHandle != "true" {
+		t.Errorf("expected source handle to be carried over, got %v", response.Edges[0].SourceHandle)
+	}
+}
+
+func TestValidationErrors_Error(t *testing.T) {
+	errs := ValidationErrors{
+		{Field: "nodes", Message: "workflow must have exactly one start node"},
+		{Field: "nodes", Message: "workflow must have at least one end node"},
+	}
+
+	expected := "workflow must have exactly one start node; workflow must have at least one end node"
+	if errs.Error() != expected {
+		t.Errorf("expected %s, got %s", expected, errs.Error())
+	}
+}
+
+func TestExecutionStep_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		step ExecutionStep
+		want string // empty if the step has no output
+	}{
+		{"no output", ExecutionStep{NodeID: "a"}, ""},
+		{"stored output", ExecutionStep{RawOutput: []byte(`{"name":"REDACTED"}`)}, `"output":{"name":"REDACTED"}`},
+		{"revealed output", ExecutionStep{RawOutput: []byte(`{"name":"REDACTED"}`), RevealedOutput: []byte(`{"name":"Alice"}`)}, `"output":{"name":"Alice"}`},
+		{"typed output", ExecutionStep{Output: EndExecutionOutput{Message: "done"}, RawOutput: []byte(`{}`)}, `"output":{"message":"done"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.step)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if tt.want == "" {
+				if strings.Contains(string(data), `"output"`) {
+					t.Errorf("Expected no output, got %s", data)
+				}
+				return
+			}
+			if !strings.Contains(string(data), tt.want) {
+				t.Errorf("Expected %s in %s", tt.want, data)
+			}
+		})
+	}
+}
+
+func TestExecutionStep_UnmarshalJSON(t *testing.T) {
+	duration := int64(12)
+	original := ExecutionStep{NodeID: "email-1", Type: NodeTypeEmail, Status: "completed", RawOutput: []byte(`{"emailSent":true}`), Duration: &duration}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var step ExecutionStep
+	if err := json.Unmarshal(data, &step); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if step.NodeID != "email-1" || step.Status != "completed" || step.Duration == nil || *step.Duration != 12 {
+		t.Errorf("Expected the step fields to round trip, got %+v", step)
+	}
+	if string(step.RawOutput) != `{"emailSent":true}` {
+		t.Errorf("Expected raw output %s, got %s", `{"emailSent":true}`, step.RawOutput)
+	}
+
+	replayed, err := json.Marshal(step)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(replayed) != string(data) {
+		t.Errorf("Expected %s, got %s", data, replayed)
+	}
+}
@@ -0,0 +1,207 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// Form field types
+const (
+	FormFieldTypeText   = "text"
+	FormFieldTypeEmail  = "email"
+	FormFieldTypeNumber = "number"
+	FormFieldTypeSelect = "select"
+	FormFieldTypeDate   = "date"
+)
+
+// ValidFormFieldTypes contains all allowed form field types as a set for O(1) lookups
+var ValidFormFieldTypes = map[string]bool{
+	FormFieldTypeText:   true,
+	FormFieldTypeEmail:  true,
+	FormFieldTypeNumber: true,
+	FormFieldTypeSelect: true,
+	FormFieldTypeDate:   true,
+}
+
+// FormFieldDateLayout is the format date field values are expected in
+const FormFieldDateLayout = "2006-01-02"
+
+// formFieldRules lists the validation rules a form field can declare. Rules ending in ':' take an argument,
+// e.g. "min_length:3", "range:1,10" or "regex:^[A-Z]{3}$".
+var formFieldRules = []string{
+	"min_length:",
+	"max_length:",
+	"regex:",
+	"min:",
+	"max:",
+	"range:",
+	"no_spaces",
+	"alpha_only",
+	"alphanumeric",
+}
+
+// Limits on regex rule patterns, so a form node can't make validation slow or memory hungry
+const (
+	// MaxFormPatternLength is the maximum length of a regex rule pattern in bytes
+	MaxFormPatternLength = 256
+	// MaxFormPatternComplexity is the maximum number of instructions a compiled pattern may have
+	MaxFormPatternComplexity = 2000
+)
+
+// FormField is the definition of a single form input field
+type FormField struct {
+	Name string `json:"name"`
+	// Type is one of the FormFieldType constants, text if empty
+	Type     string   `json:"type,omitempty"`
+	Label    string   `json:"label,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Options  []string `json:"options,omitempty"` // for select fields
+	// Validation lists the rules the value must pass, see formFieldRules
+	Validation  []string    `json:"validation,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	HelpText    string      `json:"helpText,omitempty"`
+	Placeholder string      `json:"placeholder,omitempty"`
+	// Sensitive marks personal data, masked in logs, stored execution output and API responses
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// RedactedValue replaces secrets and personal data that must not be shown
+const RedactedValue = "REDACTED"
+
+// MaskPII masks the value of a sensitive field. Email addresses keep their first character and domain so
+// they can still be told apart, e.g. a***@example.com, anything else is replaced whole.
+func MaskPII(value interface{}) string {
+	if text, ok := value.(string); ok {
+		if at := strings.LastIndex(text, "@"); at > 0 && at < len(text)-1 {
+			_, size := utf8.DecodeRuneInString(text)
+			return text[:size] + "***" + text[at:]
+		}
+	}
+	return RedactedValue
+}
+
+// NewFormFields creates required field definitions from plain field names, the format form nodes
+// used before they carried full definitions. A field named email is an email field, others are text.
+// Fields named name or email hold personal data, so they are sensitive.
+func NewFormFields(names ...string) []FormField {
+	fields := make([]FormField, len(names))
+	for i, name := range names {
+		fields[i] = FormField{Name: name, Type: FormFieldTypeText, Required: true}
+		if name == "email" {
+			fields[i].Type = FormFieldTypeEmail
+		}
+		fields[i].Sensitive = name == "name" || name == "email"
+	}
+	return fields
+}
+
+// UnmarshalJSON accepts either a full field definition or, for backward compatibility, a plain field name
+func (f *FormField) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		var name string
+		if err := json.Unmarshal(trimmed, &name); err != nil {
+			return err
+		}
+		*f = NewFormFields(name)[0]
+		return nil
+	}
+
+	// The alias drops this method so the definition is decoded normally
+	type formField FormField
+	var field formField
+	if err := json.Unmarshal(data, &field); err != nil {
+		return err
+	}
+	*f = FormField(field)
+	return nil
+}
+
+// JSONSchema allows a field to be either a plain field name or a full definition
+func (FormField) JSONSchema() *Schema {
+	return &Schema{
+		AnyOf: []*Schema{
+			{Type: SchemaType{"string"}},
+			structSchema(reflect.TypeOf(FormField{})),
+		},
+	}
+}
+
+// GetType returns the field type, defaulting to text
+func (f FormField) GetType() string {
+	if f.Type == "" {
+		return FormFieldTypeText
+	}
+	return f.Type
+}
+
+// Validate checks that the field definition itself is usable
+func (f FormField) Validate() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return fmt.Errorf("field must have a name")
+	}
+
+	if !ValidFormFieldTypes[f.GetType()] {
+		return fmt.Errorf("field '%s' has invalid type '%s', must be one of: text, email, number, select, date", f.Name, f.Type)
+	}
+
+	if f.GetType() == FormFieldTypeSelect && len(f.Options) == 0 {
+		return fmt.Errorf("select field '%s' must have options", f.Name)
+	}
+
+	for _, rule := range f.Validation {
+		if !isKnownFormFieldRule(rule) {
+			return fmt.Errorf("field '%s' has unknown validation rule '%s'", f.Name, rule)
+		}
+		if pattern, ok := strings.CutPrefix(rule, "regex:"); ok {
+			if _, err := CompileFormPattern(pattern); err != nil {
+				return fmt.Errorf("field '%s' has an invalid regex rule: %w", f.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isKnownFormFieldRule returns true if the rule is one of formFieldRules with an argument where one is expected
+func isKnownFormFieldRule(rule string) bool {
+	for _, known := range formFieldRules {
+		if strings.HasSuffix(known, ":") {
+			if strings.HasPrefix(rule, known) && len(rule) > len(known) {
+				return true
+			}
+		} else if rule == known {
+			return true
+		}
+	}
+	return false
+}
+
+// CompileFormPattern compiles the pattern of a regex rule, rejecting patterns that are invalid,
+// too long or too complex
+func CompileFormPattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > MaxFormPatternLength {
+		return nil, fmt.Errorf("pattern is %d characters long, the maximum is %d", len(pattern), MaxFormPatternLength)
+	}
+
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	// Counted repetitions like (a{100}){100} expand when compiled, so measure the compiled program
+	program, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if len(program.Inst) > MaxFormPatternComplexity {
+		return nil, fmt.Errorf("pattern is too complex")
+	}
+
+	return regexp.Compile(pattern)
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFormField_UnmarshalJSON(t *testing.T) {
+	var metadata FormNodeMetadata
+	err := json.Unmarshal([]byte(`{
+		"hasHandles": {"source": true, "target": true},
+		"inputFields": [
+			"name",
+			"email",
+			{"name": "units", "type": "select", "options": ["metric", "imperial"], "default": "metric", "helpText": "Temperature units"}
+		],
+		"outputVariables": ["name", "email", "units"]
+	}`), &metadata)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	fields := metadata.InputFields
+	if len(fields) != 3 {
+		t.Fatalf("expected 3 fields, got %d", len(fields))
+	}
+	if fields[0].Name != "name" || fields[0].GetType() != FormFieldTypeText || !fields[0].Required || !fields[0].Sensitive {
+		t.Errorf("expected legacy name field to be sensitive required text, got %+v", fields[0])
+	}
+	if fields[1].GetType() != FormFieldTypeEmail || !fields[1].Required || !fields[1].Sensitive {
+		t.Errorf("expected legacy email field to be sensitive required email, got %+v", fields[1])
+	}
+	if fields[2].GetType() != FormFieldTypeSelect || fields[2].Required || fields[2].Sensitive || fields[2].Default != "metric" || fields[2].HelpText != "Temperature units" {
+		t.Errorf("expected full select definition, got %+v", fields[2])
+	}
+}
+
+func TestFormNodeData_Validate_FieldDefinitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []FormField
+		wantErr bool
+	}{
+		{"legacy names", NewFormFields("name", "email"), false},
+		{"unknown type", []FormField{{Name: "age", Type: "integer"}}, true},
+		{"select without options", []FormField{{Name: "units", Type: FormFieldTypeSelect}}, true},
+		{"unknown rule", []FormField{{Name: "name", Validation: []string{"shouting"}}}, true},
+		{"rule without argument", []FormField{{Name: "name", Validation: []string{"min_length:"}}}, true},
+		{"duplicate names", []FormField{{Name: "name"}, {Name: "name"}}, true},
+		{"missing name", []FormField{{Type: FormFieldTypeText}}, true},
+		{"valid rules", []FormField{{Name: "code", Validation: []string{"min_length:3", "no_spaces"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := FormNodeData{Metadata: FormNodeMetadata{InputFields: tt.fields}}
+			err := data.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("expected error, got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFormFieldSchema(t *testing.T) {
+	schema := FormNodeType.Describe().DataSchema
+
+	valid := `{"label":"Form","description":"","metadata":{"hasHandles":{"source":true,"target":true},"inputFields":["name",{"name":"units","type":"select","options":["metric"]}],"outputVariables":[]}}`
+	if violations := schema.ValidateJSON([]byte(valid)); len(violations) != 0 {
+		t.Errorf("expected mixed legacy and full fields to be valid, got %+v", violations)
+	}
+
+	invalid := `{"label":"Form","description":"","metadata":{"hasHandles":{"source":true,"target":true},"inputFields":[{"name":"units","required":"yes"},3],"outputVariables":[]}}`
+	violations := schema.ValidateJSON([]byte(invalid))
+	if len(violations) != 2 || violations[0].Path != "/metadata/inputFields/0/required" || violations[1].Path != "/metadata/inputFields/1" {
+		t.Errorf("expected violations for the field definition and the number, got %+v", violations)
+	}
+}
+
+func TestFormNodeData_Validate_Rules(t *testing.T) {
+	fields := []FormField{
+		{Name: "startDate", Type: FormFieldTypeDate},
+		{Name: "endDate", Type: FormFieldTypeDate},
+		{Name: "notifyBy", Type: FormFieldTypeSelect, Options: []string{"email", "sms"}},
+		{Name: "phone"},
+	}
+
+	tests := []struct {
+		name    string
+		rule    FormRule
+		wantErr bool
+	}{
+		{"date comparison", FormRule{Rule: FormRuleAfter, Fields: []string{"endDate", "startDate"}}, false},
+		{"comparison of text", FormRule{Rule: FormRuleBefore, Fields: []string{"phone", "startDate"}}, true},
+		{"comparison of one field", FormRule{Rule: FormRuleAfter, Fields: []string{"endDate"}}, true},
+		{"conditional requirement", FormRule{Rule: FormRuleRequiredIf, Fields: []string{"phone"}, When: &FormRuleCondition{Field: "notifyBy", Equals: "sms"}}, false},
+		{"requirement without condition", FormRule{Rule: FormRuleRequiredIf, Fields: []string{"phone"}}, true},
+		{"unknown condition field", FormRule{Rule: FormRuleRequiredIf, Fields: []string{"phone"}, When: &FormRuleCondition{Field: "channel", Equals: "sms"}}, true},
+		{"at least one of a single field", FormRule{Rule: FormRuleAtLeastOne, Fields: []string{"phone"}}, true},
+		{"unknown rule", FormRule{Rule: "unique", Fields: []string{"phone"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := FormNodeData{Metadata: FormNodeMetadata{InputFields: fields, Rules: []FormRule{tt.rule}}}
+			err := data.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("expected error, got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCompileFormPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr string
+	}{
+		{"valid", `^[A-Z]{3}-\d+$`, ""},
+		{"invalid syntax", `^[A-Z`, "invalid pattern"},
+		{"too long", strings.Repeat("a", MaxFormPatternLength+1), "maximum is 256"},
+		{"too complex", `^[a-z]{900}[0-9]{900}[A-Z]{900}$`, "too complex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regex, err := CompileFormPattern(tt.pattern)
+			if tt.wantErr == "" {
+				if err != nil || regex == nil {
+					t.Errorf("expected pattern to compile, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNodeRequest_UnmarshalJSON_InvalidRegexRule(t *testing.T) {
+	var node NodeRequest
+	err := json.Unmarshal([]byte(`{"id":"signup","type":"form","data":{"label":"Form","description":"","metadata":{"hasHandles":{"source":true,"target":true},"inputFields":[{"name":"code","validation":["regex:^[A-Z"]}],"outputVariables":[]}}}`), &node)
+
+	var validationErrors ValidationErrors
+	if !errors.As(err, &validationErrors) || len(validationErrors) != 1 {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if got := validationErrors[0]; got.NodeID != "signup" || got.Code != CodeInvalidNodeData || !strings.Contains(got.Message, "invalid regex rule") {
+		t.Errorf("expected the invalid regex to be reported for node signup, got %+v", got)
+	}
+}
+
+func TestMaskPII(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"alice@example.com", "a***@example.com"},
+		{"émile@example.com", "é***@example.com"},
+		{"Alice", RedactedValue},
+		{"@example.com", RedactedValue},
+		{"alice@", RedactedValue},
+		{42.0, RedactedValue},
+	}
+
+	for _, tt := range tests {
+		if got := MaskPII(tt.value); got != tt.want {
+			t.Errorf("MaskPII(%v): expected %q, got %q", tt.value, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Cross-field form rule types. They double as the FormRule codes of the field errors they produce.
+const (
+	// FormRuleAfter requires the first field to be after the second, e.g. endDate after startDate
+	FormRuleAfter = "after"
+	// FormRuleBefore requires the first field to be before the second
+	FormRuleBefore = "before"
+	// FormRuleRequiredIf requires all fields to be set when the rule's condition holds
+	FormRuleRequiredIf = "required_if"
+	// FormRuleAtLeastOne requires at least one of the fields to be set
+	FormRuleAtLeastOne = "at_least_one"
+)
+
+// FormRule is a validation rule spanning several form fields, declared on the form node
+type FormRule struct {
+	Rule   string   `json:"rule"`
+	Fields []string `json:"fields"`
+	// When makes the rule conditional, it is required for required_if and optional otherwise
+	When *FormRuleCondition `json:"when,omitempty"`
+}
+
+// FormRuleCondition holds when a field equals the given value
+type FormRuleCondition struct {
+	Field  string      `json:"field"`
+	Equals interface{} `json:"equals"`
+}
+
+// InvolvedFields returns every field the rule reads, including the condition field
+func (r FormRule) InvolvedFields() []string {
+	fields := append([]string{}, r.Fields...)
+	if r.When != nil {
+		fields = append(fields, r.When.Field)
+	}
+	return fields
+}
+
+// Validate checks that the rule is well formed and only references the given input fields
+func (r FormRule) Validate(fields map[string]FormField) error {
+	for _, name := range r.InvolvedFields() {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("rule '%s' references unknown field '%s'", r.Rule, name)
+		}
+	}
+
+	switch r.Rule {
+	case FormRuleAfter, FormRuleBefore:
+		if len(r.Fields) != 2 {
+			return fmt.Errorf("rule '%s' must reference exactly 2 fields", r.Rule)
+		}
+		first, second := fields[r.Fields[0]].GetType(), fields[r.Fields[1]].GetType()
+		if first != second || (first != FormFieldTypeDate && first != FormFieldTypeNumber) {
+			return fmt.Errorf("rule '%s' must compare two date or two number fields, got %s and %s", r.Rule, first, second)
+		}
+	case FormRuleRequiredIf:
+		if len(r.Fields) == 0 {
+			return fmt.Errorf("rule '%s' must reference at least 1 field", r.Rule)
+		}
+		if r.When == nil {
+			return fmt.Errorf("rule '%s' must have a when condition", r.Rule)
+		}
+	case FormRuleAtLeastOne:
+		if len(r.Fields) < 2 {
+			return fmt.Errorf("rule '%s' must reference at least 2 fields", r.Rule)
+		}
+	default:
+		return fmt.Errorf("invalid rule '%s', must be one of: %s", r.Rule,
+			strings.Join([]string{FormRuleAfter, FormRuleBefore, FormRuleRequiredIf, FormRuleAtLeastOne}, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Form validation rule codes. They identify why a field was rejected and key the translated messages.
+const (
+	FormRuleRequired     = "required"
+	FormRuleType         = "type"
+	FormRuleEmail        = "email"
+	FormRuleDate         = "date"
+	FormRuleOptions      = "options"
+	FormRuleMinLength    = "min_length"
+	FormRuleMaxLength    = "max_length"
+	FormRuleRegex        = "regex"
+	FormRuleNoSpaces     = "no_spaces"
+	FormRuleAlphaOnly    = "alpha_only"
+	FormRuleAlphanumeric = "alphanumeric"
+	FormRuleMin          = "min"
+	FormRuleMax          = "max"
+	FormRuleRange        = "range"
+)
+
+// DefaultLocale is the locale form validation messages are written in when no translation is requested
+const DefaultLocale = "en"
+
+// formRuleMessages holds the message templates per locale and rule code. {name} placeholders are
+// replaced with the FieldError params of the same name.
+var formRuleMessages = map[string]map[string]string{
+	"en": {
+		FormRuleRequired:     "is required",
+		FormRuleType:         "must be a {type}",
+		FormRuleEmail:        "must be a valid email address",
+		FormRuleDate:         "must be a date in YYYY-MM-DD format",
+		FormRuleOptions:      "must be one of: {options}",
+		FormRuleMinLength:    "must be at least {min} characters",
+		FormRuleMaxLength:    "must be at most {max} characters",
+		FormRuleRegex:        "does not match required pattern",
+		FormRuleNoSpaces:     "cannot contain spaces",
+		FormRuleAlphaOnly:    "must contain only letters",
+		FormRuleAlphanumeric: "must contain only letters and numbers",
+		FormRuleMin:          "must be at least {min}",
+		FormRuleMax:          "must be at most {max}",
+		FormRuleRange:        "must be between {min} and {max}",
+		FormRuleAfter:        "must be after {other}",
+		FormRuleBefore:       "must be before {other}",
+		FormRuleRequiredIf:   "is required when {when} is {equals}",
+		FormRuleAtLeastOne:   "at least one of {fields} is required",
+	},
+	"es": {
+		FormRuleRequired:     "es obligatorio",
+		FormRuleType:         "debe ser de tipo {type}",
+		FormRuleEmail:        "debe ser una dirección de correo válida",
+		FormRuleDate:         "debe ser una fecha en formato AAAA-MM-DD",
+		FormRuleOptions:      "debe ser uno de: {options}",
+		FormRuleMinLength:    "debe tener al menos {min} caracteres",
+		FormRuleMaxLength:    "debe tener como máximo {max} caracteres",
+		FormRuleRegex:        "no coincide con el patrón requerido",
+		FormRuleNoSpaces:     "no puede contener espacios",
+		FormRuleAlphaOnly:    "solo puede contener letras",
+		FormRuleAlphanumeric: "solo puede contener letras y números",
+		FormRuleMin:          "debe ser al menos {min}",
+		FormRuleMax:          "debe ser como máximo {max}",
+		FormRuleRange:        "debe estar entre {min} y {max}",
+		FormRuleAfter:        "debe ser posterior a {other}",
+		FormRuleBefore:       "debe ser anterior a {other}",
+		FormRuleRequiredIf:   "es obligatorio cuando {when} es {equals}",
+		FormRuleAtLeastOne:   "se requiere al menos uno de {fields}",
+	},
+}
+
+// SupportsLocale returns true if form validation messages are translated into the locale
+func SupportsLocale(locale string) bool {
+	_, ok := formRuleMessages[locale]
+	return ok
+}
+
+// FormRuleMessage renders the message of a rule in the given locale, falling back to DefaultLocale
+func FormRuleMessage(locale, rule string, params map[string]interface{}) string {
+	template, ok := formRuleMessages[locale][rule]
+	if !ok {
+		template, ok = formRuleMessages[DefaultLocale][rule]
+	}
+	if !ok {
+		return rule
+	}
+
+	for name, value := range params {
+		template = strings.ReplaceAll(template, "{"+name+"}", formatParam(value))
+	}
+	return template
+}
+
+// formatParam formats a message parameter, printing whole numbers without decimals
+func formatParam(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// FieldError describes why a single form field value was rejected
+type FieldError struct {
+	Field string `json:"field"`
+	// Fields lists every field involved in a cross-field rule, Field included
+	Fields []string `json:"fields,omitempty"`
+	// Rule is one of the FormRule codes
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	// Value is the rejected value, null if it was missing
+	Value  interface{}            `json:"value"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// NewFieldError creates a field error with its message in DefaultLocale
+func NewFieldError(field, rule string, value interface{}, params map[string]interface{}) FieldError {
+	return FieldError{
+		Field:   field,
+		Rule:    rule,
+		Message: FormRuleMessage(DefaultLocale, rule, params),
+		Value:   value,
+		Params:  params,
+	}
+}
+
+// Error implements the error interface for FieldError
+func (fe FieldError) Error() string {
+	if fe.Rule == FormRuleRequired {
+		return fmt.Sprintf("field '%s' is required", fe.Field)
+	}
+	return fmt.Sprintf("field '%s': %s", fe.Field, fe.Message)
+}
+
+// Localize returns the field error with its message translated into the locale
+func (fe FieldError) Localize(locale string) FieldError {
+	fe.Message = FormRuleMessage(locale, fe.Rule, fe.Params)
+	return fe
+}
+
+// FieldErrors groups the errors of every rejected form field
+type FieldErrors []FieldError
+
+// Error implements the error interface for FieldErrors
+func (fe FieldErrors) Error() string {
+	messages := make([]string, len(fe))
+	for i, err := range fe {
+		messages[i] = err.Error()
+	}
+	return "validation errors: " + strings.Join(messages, "; ")
+}
+
+// Localize returns the field errors with their messages translated into the locale
+func (fe FieldErrors) Localize(locale string) FieldErrors {
+	localized := make(FieldErrors, len(fe))
+	for i, err := range fe {
+		localized[i] = err.Localize(locale)
+	}
+	return localized
+}
+
+// FormValidationError is returned when the form data of an execution request is rejected by a form node
+type FormValidationError struct {
+	NodeID string
+	Errors FieldErrors
+}
+
+// Error implements the error interface for FormValidationError
+func (e *FormValidationError) Error() string {
+	return fmt.Sprintf("form validation failed for node %s: %s", e.NodeID, e.Errors.Error())
+}
+
+// Unwrap returns the field errors
+func (e *FormValidationError) Unwrap() error {
+	return e.Errors
+}
@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestFieldError_Localize(t *testing.T) {
+	fieldErr := NewFieldError("threshold", FormRuleRange, 100.0, map[string]interface{}{"min": -50.0, "max": 60.0})
+
+	if fieldErr.Message != "must be between -50 and 60" {
+		t.Errorf("expected english message, got %q", fieldErr.Message)
+	}
+	if fieldErr.Error() != "field 'threshold': must be between -50 and 60" {
+		t.Errorf("unexpected error string %q", fieldErr.Error())
+	}
+
+	if got := fieldErr.Localize("es").Message; got != "debe estar entre -50 y 60" {
+		t.Errorf("expected spanish message, got %q", got)
+	}
+	if got := fieldErr.Localize("xx").Message; got != fieldErr.Message {
+		t.Errorf("expected unknown locales to fall back to english, got %q", got)
+	}
+}
+
+func TestFormRuleMessages_Complete(t *testing.T) {
+	for locale, messages := range formRuleMessages {
+		for rule := range formRuleMessages[DefaultLocale] {
+			if _, ok := messages[rule]; !ok {
+				t.Errorf("locale %s is missing a message for rule %s", locale, rule)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxIdempotencyKeyLength is the longest idempotency key accepted
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyRecord is an execution requested with an idempotency key, running until its response is stored
+type IdempotencyRecord struct {
+	Key string
+	// Fingerprint identifies the execution request, see ExecutionFingerprint
+	Fingerprint string
+	WorkflowID  uuid.UUID
+	// Response is the JSON execution response, empty while the execution is running
+	Response  json.RawMessage
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// Reservation identifies the reservation running the execution, a key taken over after the lock timeout
+	// gets a new one
+	Reservation uuid.UUID
+}
+
+// Completed returns true once the execution's response is stored
+func (r *IdempotencyRecord) Completed() bool {
+	return len(r.Response) > 0
+}
+
+// ValidateIdempotencyKey checks an Idempotency-Key header value is 1 to MaxIdempotencyKeyLength visible ASCII
+// characters
+func ValidateIdempotencyKey(key string) error {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return fmt.Errorf("idempotency key must be 1 to %d characters", MaxIdempotencyKeyLength)
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '!' || key[i] > '~' {
+			return fmt.Errorf("idempotency key must only contain visible ASCII characters")
+		}
+	}
+	return nil
+}
+
+// ExecutionFingerprint returns a SHA-256 hash of an execution request, so an idempotency key reused for a
+// different request can be told apart from a retry
+func ExecutionFingerprint(workflowID string, req *ExecutionRequest) (string, error) {
+	// Maps marshal with sorted keys, so equal requests always hash the same
+	document, err := json.Marshal(struct {
+		WorkflowID string            `json:"workflowId"`
+		Request    *ExecutionRequest `json:"request"`
+	}{workflowID, req})
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint execution request: %w", err)
+	}
+
+	sum := sha256.Sum256(document)
+	return hex.EncodeToString(sum[:]), nil
+}
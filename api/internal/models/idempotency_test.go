@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"uuid", "0f8fad5b-d9cb-469f-a165-70867728950e", false},
+		{"punctuation", "retry:42/a_b", false},
+		{"empty", "", true},
+		{"too long", strings.Repeat("k", MaxIdempotencyKeyLength+1), true},
+		{"space", "retry 42", true},
+		{"non ascii", "clé", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIdempotencyKey(tt.key)
+			if tt.wantErr && err == nil {
+				t.Errorf("Expected an error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExecutionFingerprint(t *testing.T) {
+	request := func(email string) *ExecutionRequest {
+		return &ExecutionRequest{
+			FormData:       map[string]interface{}{"email": email, "name": "Alice"},
+			IdempotencyKey: "ignored",
+		}
+	}
+
+	first, err := ExecutionFingerprint("wf-1", request("alice@example.com"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	retry, _ := ExecutionFingerprint("wf-1", &ExecutionRequest{
+		FormData:       map[string]interface{}{"name": "Alice", "email": "alice@example.com"},
+		IdempotencyKey: "other",
+	})
+	if first != retry {
+		t.Errorf("Expected equal requests to have the same fingerprint, got %s and %s", first, retry)
+	}
+
+	otherBody, _ := ExecutionFingerprint("wf-1", request("bob@example.com"))
+	otherWorkflow, _ := ExecutionFingerprint("wf-2", request("alice@example.com"))
+	if first == otherBody || first == otherWorkflow {
+		t.Errorf("Expected different requests to have different fingerprints")
+	}
+}
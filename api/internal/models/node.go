@@ -8,7 +8,7 @@ import (
 	"github.com/google/uuid"
 )
 
-// Valid node types
+// Built-in node types, see RegisterNodeType for adding more
 const (
 	NodeTypeStart       = "start"
 	NodeTypeForm        = "form"
@@ -18,16 +18,6 @@ const (
 	NodeTypeEnd         = "end"
 )
 
-// ValidNodeTypes contains all allowed node types as a set for O(1) lookups
-var ValidNodeTypes = map[string]bool{
-	NodeTypeStart:       true,
-	NodeTypeForm:        true,
-	NodeTypeIntegration: true,
-	NodeTypeCondition:   true,
-	NodeTypeEmail:       true,
-	NodeTypeEnd:         true,
-}
-
 // Node represents a workflow node with its position and data
 type Node struct {
 	ID         string          `json:"id" db:"id"`
@@ -68,6 +58,16 @@ func (n *Node) ToResponse() NodeResponse {
 	}
 }
 
+// ToRequest converts a NodeResponse to NodeRequest format, e.g. for validating a stored workflow
+func (n *NodeResponse) ToRequest() NodeRequest {
+	return NodeRequest{
+		ID:       n.ID,
+		Type:     n.Type,
+		Position: n.Position,
+		Data:     n.Data,
+	}
+}
+
 // NodeRequest represents a node as sent from the frontend
 type NodeRequest struct {
 	ID       string          `json:"id"`
@@ -97,11 +97,32 @@ func (nr *NodeRequest) UnmarshalJSON(data []byte) error {
 	nr.Position = temp.Position
 	nr.RawData = temp.Data
 
-	// Parse the strongly typed data using the node type
+	// Parse the strongly typed data using the node type, rejecting data that doesn't match its schema
 	if len(temp.Data) > 0 {
+		if errs := validateNodeDataSchema(temp.ID, temp.Type, temp.Data); len(errs) > 0 {
+			return errs
+		}
+
+		if err := ValidateNodeType(temp.Type); err != nil {
+			return ValidationErrors{{
+				Field:    "nodes",
+				Message:  fmt.Sprintf("invalid node %s: %v", temp.ID, err),
+				Code:     CodeInvalidNodeType,
+				Severity: SeverityError,
+				NodeID:   temp.ID,
+			}}
+		}
+
+		// The data matches the schema, so a failure here comes from the node type's own validation
 		parsedData, err := ParseNodeData(temp.Type, temp.Data)
 		if err != nil {
-			return fmt.Errorf("failed to parse node data for type %s: %w", temp.Type, err)
+			return ValidationErrors{{
+				Field:    "nodes",
+				Message:  fmt.Sprintf("invalid data for node %s: %v", temp.ID, err),
+				Code:     CodeInvalidNodeData,
+				Severity: SeverityError,
+				NodeID:   temp.ID,
+			}}
 		}
 		nr.Data = parsedData
 	}
@@ -109,6 +130,47 @@ func (nr *NodeRequest) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// validateNodeDataSchema checks raw node data against the data schema of its node type, returning
+// one validation error per violation. Unknown node types are left for ParseNodeData to report.
+func validateNodeDataSchema(nodeID, nodeType string, rawData []byte) ValidationErrors {
+	registered, ok := GetNodeType(nodeType)
+	if !ok {
+		return nil
+	}
+
+	schema := registered.Describe().DataSchema
+	if schema == nil {
+		return nil
+	}
+
+	var errs ValidationErrors
+	for _, violation := range schema.ValidateJSON(rawData) {
+		path := violation.Path
+		if path == "" {
+			path = "/"
+		}
+		errs = append(errs, ValidationError{
+			Field:    "nodes",
+			Message:  fmt.Sprintf("node %s has invalid data at %s: %s", nodeID, path, violation.Message),
+			Code:     CodeSchemaViolation,
+			Severity: SeverityError,
+			NodeID:   nodeID,
+			Path:     violation.Path,
+		})
+	}
+	return errs
+}
+
+// ToResponse converts a NodeRequest to NodeResponse format, e.g. for ad-hoc execution
+func (nr *NodeRequest) ToResponse() NodeResponse {
+	return NodeResponse{
+		ID:       nr.ID,
+		Type:     nr.Type,
+		Position: nr.Position,
+		Data:     nr.Data,
+	}
+}
+
 // ToNode converts a NodeRequest to a Node for database storage
 func (nr *NodeRequest) ToNode() (*Node, error) {
 	// Marshal the strongly typed data to JSON for database storage
@@ -137,19 +199,13 @@ func (nr *NodeRequest) Validate() error {
 	return ValidateNodeType(nr.Type)
 }
 
-// ValidateNodeType checks if the given type is a valid node type
+// ValidateNodeType checks if the given type is a registered node type
 func ValidateNodeType(nodeType string) error {
-	if ValidNodeTypes[nodeType] {
+	if _, ok := GetNodeType(nodeType); ok {
 		return nil
 	}
 
-	// Get valid types for error message
-	validTypes := make([]string, 0, len(ValidNodeTypes))
-	for nodeType := range ValidNodeTypes {
-		validTypes = append(validTypes, nodeType)
-	}
-
-	return fmt.Errorf("invalid node type '%s', must be one of: %v", nodeType, validTypes)
+	return fmt.Errorf("invalid node type '%s', must be one of: %v", nodeType, registeredNodeTypeNames())
 }
 
 // IsStartNode returns true if this is a start node
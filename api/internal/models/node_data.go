@@ -11,6 +11,60 @@ type NodeData interface {
 	GetNodeType() string
 	// Validate performs validation specific to this node type
 	Validate() error
+	// GetHandles returns the connection points the node exposes
+	GetHandles() Handles
+	// GetVariables returns the variables the node reads and writes, used for dataflow analysis
+	GetVariables() NodeVariables
+}
+
+// Variable types that can optionally be declared in a node's variableTypes metadata
+const (
+	VariableTypeString = "string"
+	VariableTypeNumber = "number"
+	VariableTypeBool   = "bool"
+	VariableTypeList   = "list"
+)
+
+// ValidVariableTypes contains all allowed variable types as a set for O(1) lookups
+var ValidVariableTypes = map[string]bool{
+	VariableTypeString: true,
+	VariableTypeNumber: true,
+	VariableTypeBool:   true,
+	VariableTypeList:   true,
+}
+
+// NodeVariables describes the variables a node consumes from and produces into the execution context
+type NodeVariables struct {
+	Inputs  []string
+	Outputs []string
+	// Types optionally declares the type of inputs (expected) and outputs (produced) by variable name
+	Types map[string]string
+}
+
+// ErrorHandle is the source handle of error edges, followed instead of the node's other edges when it fails
+const ErrorHandle = "error"
+
+// Handles describes the connection points of a node, used to validate edges
+type Handles struct {
+	Source bool
+	Target bool
+	// SourceHandles lists named source handles (e.g. condition branches); empty means unnamed
+	SourceHandles []string
+	// Error is true if the node can be left through ErrorHandle when it fails
+	Error bool
+}
+
+// HasSourceHandle returns true if the node exposes the given named source handle
+func (h Handles) HasSourceHandle(handle string) bool {
+	if handle == ErrorHandle {
+		return h.Error
+	}
+	for _, sourceHandle := range h.SourceHandles {
+		if sourceHandle == handle {
+			return true
+		}
+	}
+	return false
 }
 
 // StartNodeData represents data for start nodes
@@ -21,12 +75,17 @@ type StartNodeData struct {
 }
 
 type StartNodeMetadata struct {
-	HasHandles      HandleConfig `json:"hasHandles"`
-	OutputVariables []string     `json:"outputVariables,omitempty"`
+	HasHandles      HandleConfig      `json:"hasHandles"`
+	OutputVariables []string          `json:"outputVariables,omitempty"`
+	VariableTypes   map[string]string `json:"variableTypes,omitempty"`
 }
 
 func (d StartNodeData) GetNodeType() string { return NodeTypeStart }
 func (d StartNodeData) Validate() error     { return nil }
+func (d StartNodeData) GetHandles() Handles { return d.Metadata.HasHandles.toHandles() }
+func (d StartNodeData) GetVariables() NodeVariables {
+	return NodeVariables{Outputs: d.Metadata.OutputVariables, Types: d.Metadata.VariableTypes}
+}
 
 // FormNodeData represents data for form nodes
 type FormNodeData struct {
@@ -36,19 +95,54 @@ type FormNodeData struct {
 }
 
 type FormNodeMetadata struct {
-	HasHandles      HandleConfig `json:"hasHandles"`
-	InputFields     []string     `json:"inputFields"`
-	OutputVariables []string     `json:"outputVariables"`
+	HasHandles      HandleConfig      `json:"hasHandles"`
+	InputFields     []FormField       `json:"inputFields"`
+	Rules           []FormRule        `json:"rules,omitempty"` // cross-field rules
+	OutputVariables []string          `json:"outputVariables"`
+	VariableTypes   map[string]string `json:"variableTypes,omitempty"`
 }
 
 func (d FormNodeData) GetNodeType() string { return NodeTypeForm }
+func (d FormNodeData) GetHandles() Handles { return d.Metadata.HasHandles.toHandles() }
+func (d FormNodeData) GetVariables() NodeVariables {
+	// Input fields are collected from the user, not read from upstream nodes
+	return NodeVariables{Outputs: d.Metadata.OutputVariables, Types: d.Metadata.VariableTypes}
+}
 func (d FormNodeData) Validate() error {
 	if len(d.Metadata.InputFields) == 0 {
 		return fmt.Errorf("form node must have at least one input field")
 	}
+
+	fields := make(map[string]FormField, len(d.Metadata.InputFields))
+	for _, field := range d.Metadata.InputFields {
+		if err := field.Validate(); err != nil {
+			return fmt.Errorf("form node has an invalid input field: %w", err)
+		}
+		if _, exists := fields[field.Name]; exists {
+			return fmt.Errorf("form node has duplicate input field '%s'", field.Name)
+		}
+		fields[field.Name] = field
+	}
+
+	for _, rule := range d.Metadata.Rules {
+		if err := rule.Validate(fields); err != nil {
+			return fmt.Errorf("form node has an invalid rule: %w", err)
+		}
+	}
 	return nil
 }
 
+// SensitiveFields returns the names of the input fields holding personal data
+func (d FormNodeData) SensitiveFields() []string {
+	var names []string
+	for _, field := range d.Metadata.InputFields {
+		if field.Sensitive {
+			names = append(names, field.Name)
+		}
+	}
+	return names
+}
+
 // IntegrationNodeData represents data for integration nodes
 type IntegrationNodeData struct {
 	Label       string                  `json:"label"`
@@ -57,11 +151,56 @@ type IntegrationNodeData struct {
 }
 
 type IntegrationNodeMetadata struct {
-	HasHandles      HandleConfig     `json:"hasHandles"`
-	InputVariables  []string         `json:"inputVariables"`
-	APIEndpoint     string           `json:"apiEndpoint"`
-	Options         []LocationOption `json:"options"`
-	OutputVariables []string         `json:"outputVariables"`
+	HasHandles      HandleConfig      `json:"hasHandles"`
+	InputVariables  []string          `json:"inputVariables"`
+	APIEndpoint     string            `json:"apiEndpoint"`
+	Options         []LocationOption  `json:"options"`
+	OutputVariables []string          `json:"outputVariables"`
+	VariableTypes   map[string]string `json:"variableTypes,omitempty"`
+	// TemperatureUnit and WindSpeedUnit select the units of weather values, celsius and kmh if empty
+	TemperatureUnit string `json:"temperatureUnit,omitempty"`
+	WindSpeedUnit   string `json:"windSpeedUnit,omitempty"`
+	// Headers are sent with the API request and vary its cached response
+	Headers map[string]string `json:"headers,omitempty"`
+	// CacheTTLSeconds is how long API responses are cached, the API default if unset and uncached if 0
+	CacheTTLSeconds *int `json:"cacheTtlSeconds,omitempty"`
+	// Retry retries failed API calls, they aren't retried if unset
+	Retry *RetryPolicy `json:"retry,omitempty"`
+}
+
+// Retry policy limits, so a node can't hold an execution for long
+const (
+	MaxRetryAttempts  = 5
+	MaxRetryBackoffMs = 10000
+)
+
+// RetryPolicy retries the API call of an integration node. Calls refused by an open circuit breaker or the
+// outbound policy aren't retried, they would only be refused again.
+type RetryPolicy struct {
+	// MaxAttempts is how many times the call is made at most, the first attempt included
+	MaxAttempts int `json:"maxAttempts"`
+	// BackoffMs is the wait before the second attempt, doubling before every further attempt
+	BackoffMs int `json:"backoffMs,omitempty"`
+}
+
+// Weather units, named as the Open-Meteo API names them
+const (
+	TemperatureUnitCelsius    = "celsius"
+	TemperatureUnitFahrenheit = "fahrenheit"
+	WindSpeedUnitKmh          = "kmh"
+	WindSpeedUnitMph          = "mph"
+)
+
+// ValidTemperatureUnits contains all allowed temperature units as a set for O(1) lookups
+var ValidTemperatureUnits = map[string]bool{
+	TemperatureUnitCelsius:    true,
+	TemperatureUnitFahrenheit: true,
+}
+
+// ValidWindSpeedUnits contains all allowed wind speed units as a set for O(1) lookups
+var ValidWindSpeedUnits = map[string]bool{
+	WindSpeedUnitKmh: true,
+	WindSpeedUnitMph: true,
 }
 
 type LocationOption struct {
@@ -71,10 +210,36 @@ type LocationOption struct {
 }
 
 func (d IntegrationNodeData) GetNodeType() string { return NodeTypeIntegration }
+func (d IntegrationNodeData) GetHandles() Handles {
+	// A failed call can be handled by error edges
+	handles := d.Metadata.HasHandles.toHandles()
+	handles.Error = handles.Source
+	return handles
+}
+func (d IntegrationNodeData) GetVariables() NodeVariables {
+	return NodeVariables{Inputs: d.Metadata.InputVariables, Outputs: d.Metadata.OutputVariables, Types: d.Metadata.VariableTypes}
+}
 func (d IntegrationNodeData) Validate() error {
 	if d.Metadata.APIEndpoint == "" {
 		return fmt.Errorf("integration node must have an API endpoint")
 	}
+	if unit := d.Metadata.TemperatureUnit; unit != "" && !ValidTemperatureUnits[unit] {
+		return fmt.Errorf("invalid temperature unit '%s', must be one of: %s, %s", unit, TemperatureUnitCelsius, TemperatureUnitFahrenheit)
+	}
+	if unit := d.Metadata.WindSpeedUnit; unit != "" && !ValidWindSpeedUnits[unit] {
+		return fmt.Errorf("invalid wind speed unit '%s', must be one of: %s, %s", unit, WindSpeedUnitKmh, WindSpeedUnitMph)
+	}
+	if ttl := d.Metadata.CacheTTLSeconds; ttl != nil && *ttl < 0 {
+		return fmt.Errorf("cache TTL must not be negative, got %d", *ttl)
+	}
+	if retry := d.Metadata.Retry; retry != nil {
+		if retry.MaxAttempts < 1 || retry.MaxAttempts > MaxRetryAttempts {
+			return fmt.Errorf("retry attempts must be between 1 and %d, got %d", MaxRetryAttempts, retry.MaxAttempts)
+		}
+		if retry.BackoffMs < 0 || retry.BackoffMs > MaxRetryBackoffMs {
+			return fmt.Errorf("retry backoff must be between 0 and %dms, got %d", MaxRetryBackoffMs, retry.BackoffMs)
+		}
+	}
 	return nil
 }
 
@@ -87,8 +252,10 @@ type ConditionNodeData struct {
 
 type ConditionNodeMetadata struct {
 	HasHandles          HandleConfigWithBranches `json:"hasHandles"`
+	InputVariables      []string                 `json:"inputVariables,omitempty"`
 	ConditionExpression string                   `json:"conditionExpression"`
 	OutputVariables     []string                 `json:"outputVariables"`
+	VariableTypes       map[string]string        `json:"variableTypes,omitempty"`
 }
 
 type HandleConfigWithBranches struct {
@@ -97,6 +264,16 @@ type HandleConfigWithBranches struct {
 }
 
 func (d ConditionNodeData) GetNodeType() string { return NodeTypeCondition }
+func (d ConditionNodeData) GetHandles() Handles {
+	return Handles{
+		Source:        len(d.Metadata.HasHandles.Source) > 0,
+		Target:        d.Metadata.HasHandles.Target,
+		SourceHandles: d.Metadata.HasHandles.Source,
+	}
+}
+func (d ConditionNodeData) GetVariables() NodeVariables {
+	return NodeVariables{Inputs: d.Metadata.InputVariables, Outputs: d.Metadata.OutputVariables, Types: d.Metadata.VariableTypes}
+}
 func (d ConditionNodeData) Validate() error {
 	if d.Metadata.ConditionExpression == "" {
 		return fmt.Errorf("condition node must have a condition expression")
@@ -112,10 +289,11 @@ type EmailNodeData struct {
 }
 
 type EmailNodeMetadata struct {
-	HasHandles      HandleConfig  `json:"hasHandles"`
-	InputVariables  []string      `json:"inputVariables"`
-	EmailTemplate   EmailTemplate `json:"emailTemplate"`
-	OutputVariables []string      `json:"outputVariables"`
+	HasHandles      HandleConfig      `json:"hasHandles"`
+	InputVariables  []string          `json:"inputVariables"`
+	EmailTemplate   EmailTemplate     `json:"emailTemplate"`
+	OutputVariables []string          `json:"outputVariables"`
+	VariableTypes   map[string]string `json:"variableTypes,omitempty"`
 }
 
 type EmailTemplate struct {
@@ -124,6 +302,10 @@ type EmailTemplate struct {
 }
 
 func (d EmailNodeData) GetNodeType() string { return NodeTypeEmail }
+func (d EmailNodeData) GetHandles() Handles { return d.Metadata.HasHandles.toHandles() }
+func (d EmailNodeData) GetVariables() NodeVariables {
+	return NodeVariables{Inputs: d.Metadata.InputVariables, Outputs: d.Metadata.OutputVariables, Types: d.Metadata.VariableTypes}
+}
 func (d EmailNodeData) Validate() error {
 	if d.Metadata.EmailTemplate.Subject == "" {
 		return fmt.Errorf("email node must have a subject")
@@ -142,12 +324,17 @@ type EndNodeData struct {
 }
 
 type EndNodeMetadata struct {
-	HasHandles     HandleConfig `json:"hasHandles"`
-	InputVariables []string     `json:"inputVariables,omitempty"`
+	HasHandles     HandleConfig      `json:"hasHandles"`
+	InputVariables []string          `json:"inputVariables,omitempty"`
+	VariableTypes  map[string]string `json:"variableTypes,omitempty"`
 }
 
 func (d EndNodeData) GetNodeType() string { return NodeTypeEnd }
 func (d EndNodeData) Validate() error     { return nil }
+func (d EndNodeData) GetHandles() Handles { return d.Metadata.HasHandles.toHandles() }
+func (d EndNodeData) GetVariables() NodeVariables {
+	return NodeVariables{Inputs: d.Metadata.InputVariables, Types: d.Metadata.VariableTypes}
+}
 
 // HandleConfig represents the standard handle configuration
 type HandleConfig struct {
@@ -155,6 +342,10 @@ type HandleConfig struct {
 	Target bool `json:"target"`
 }
 
+func (h HandleConfig) toHandles() Handles {
+	return Handles{Source: h.Source, Target: h.Target}
+}
+
 // NodeDataUnion represents a union type for all possible node data
 type NodeDataUnion struct {
 	Type string `json:"-"` // Set during unmarshaling
@@ -166,31 +357,16 @@ func (ndu *NodeDataUnion) UnmarshalJSON(data []byte) error {
 	// First, extract just the type information if available
 	// We need to determine the type from context since it's not in the data itself
 
-	// Try each type until one works
-	types := []struct {
-		nodeType string
-		target   NodeData
-	}{
-		{NodeTypeStart, &StartNodeData{}},
-		{NodeTypeForm, &FormNodeData{}},
-		{NodeTypeIntegration, &IntegrationNodeData{}},
-		{NodeTypeCondition, &ConditionNodeData{}},
-		{NodeTypeEmail, &EmailNodeData{}},
-		{NodeTypeEnd, &EndNodeData{}},
-	}
-
+	// Try each registered type until one works
 	var lastErr error
-	for _, t := range types {
-		if err := json.Unmarshal(data, t.target); err == nil {
-			// Validate that this is the correct type
-			if err := t.target.Validate(); err == nil {
-				ndu.Type = t.nodeType
-				ndu.Data = t.target
-				return nil
-			}
-		} else {
-			lastErr = err
+	for _, nodeType := range RegisteredNodeTypes() {
+		parsed, err := nodeType.ParseData(data)
+		if err == nil {
+			ndu.Type = nodeType.Describe().Type
+			ndu.Data = parsed
+			return nil
 		}
+		lastErr = err
 	}
 
 	return fmt.Errorf("failed to unmarshal node data into any known type: %v", lastErr)
@@ -204,52 +380,11 @@ func (ndu NodeDataUnion) MarshalJSON() ([]byte, error) {
 	return json.Marshal(ndu.Data)
 }
 
-// ParseNodeData parses raw JSON into the appropriate strongly typed NodeData
+// ParseNodeData parses raw JSON into the strongly typed NodeData of the registered node type
 func ParseNodeData(nodeType string, rawData []byte) (NodeData, error) {
-	switch nodeType {
-	case NodeTypeStart:
-		var data StartNodeData
-		if err := json.Unmarshal(rawData, &data); err != nil {
-			return nil, fmt.Errorf("failed to parse start node data: %w", err)
-		}
-		return data, data.Validate()
-
-	case NodeTypeForm:
-		var data FormNodeData
-		if err := json.Unmarshal(rawData, &data); err != nil {
-			return nil, fmt.Errorf("failed to parse form node data: %w", err)
-		}
-		return data, data.Validate()
-
-	case NodeTypeIntegration:
-		var data IntegrationNodeData
-		if err := json.Unmarshal(rawData, &data); err != nil {
-			return nil, fmt.Errorf("failed to parse integration node data: %w", err)
-		}
-		return data, data.Validate()
-
-	case NodeTypeCondition:
-		var data ConditionNodeData
-		if err := json.Unmarshal(rawData, &data); err != nil {
-			return nil, fmt.Errorf("failed to parse condition node data: %w", err)
-		}
-		return data, data.Validate()
-
-	case NodeTypeEmail:
-		var data EmailNodeData
-		if err := json.Unmarshal(rawData, &data); err != nil {
-			return nil, fmt.Errorf("failed to parse email node data: %w", err)
-		}
-		return data, data.Validate()
-
-	case NodeTypeEnd:
-		var data EndNodeData
-		if err := json.Unmarshal(rawData, &data); err != nil {
-			return nil, fmt.Errorf("failed to parse end node data: %w", err)
-		}
-		return data, data.Validate()
-
-	default:
+	registered, ok := GetNodeType(nodeType)
+	if !ok {
 		return nil, fmt.Errorf("unknown node type: %s", nodeType)
 	}
+	return registered.ParseData(rawData)
 }
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// NodeType describes a kind of node the workflow engine supports. Adding a node type means
+// implementing this interface (usually through NewNodeType) and registering it with RegisterNodeType.
+type NodeType interface {
+	// Describe returns the palette information and data schema of the node type
+	Describe() NodeTypeDescription
+	// ParseData parses and validates raw node data
+	ParseData(rawData []byte) (NodeData, error)
+	// ParseOutput parses and validates raw execution output
+	ParseOutput(rawOutput []byte) (ExecutionOutput, error)
+}
+
+// NodeTypeDescription is what the editor palette needs to know about a node type
+type NodeTypeDescription struct {
+	Type        string  `json:"type"`
+	Label       string  `json:"label"`
+	Description string  `json:"description"`
+	Category    string  `json:"category"`
+	DataSchema  *Schema `json:"dataSchema"`
+}
+
+// Node type categories used to group the editor palette
+const (
+	NodeCategoryControl     = "control"
+	NodeCategoryInput       = "input"
+	NodeCategoryIntegration = "integration"
+	NodeCategoryLogic       = "logic"
+	NodeCategoryAction      = "action"
+)
+
+// typedNodeType is a NodeType backed by a NodeData struct D and an ExecutionOutput struct O
+type typedNodeType[D NodeData, O ExecutionOutput] struct {
+	description NodeTypeDescription
+}
+
+// NewNodeType creates a NodeType that parses node data into D and execution output into O.
+// The data schema of the description is generated from D.
+func NewNodeType[D NodeData, O ExecutionOutput](description NodeTypeDescription) NodeType {
+	description.DataSchema = SchemaFor(reflect.TypeOf((*D)(nil)).Elem())
+	return typedNodeType[D, O]{description: description}
+}
+
+func (t typedNodeType[D, O]) Describe() NodeTypeDescription { return t.description }
+
+func (t typedNodeType[D, O]) ParseData(rawData []byte) (NodeData, error) {
+	var data D
+	if err := json.Unmarshal(rawData, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse %s node data: %w", t.description.Type, err)
+	}
+	return data, data.Validate()
+}
+
+func (t typedNodeType[D, O]) ParseOutput(rawOutput []byte) (ExecutionOutput, error) {
+	var output O
+	if err := json.Unmarshal(rawOutput, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse %s execution output: %w", t.description.Type, err)
+	}
+	return output, output.Validate()
+}
+
+// Built-in node types. The execution package registers executable versions of these at startup.
+var (
+	StartNodeType = NewNodeType[StartNodeData, StartExecutionOutput](NodeTypeDescription{
+		Type:        NodeTypeStart,
+		Label:       "Start",
+		Description: "Begin weather check workflow",
+		Category:    NodeCategoryControl,
+	})
+	FormNodeType = NewNodeType[FormNodeData, FormExecutionOutput](NodeTypeDescription{
+		Type:        NodeTypeForm,
+		Label:       "User Input",
+		Description: "Process collected data - name, email, location",
+		Category:    NodeCategoryInput,
+	})
+	IntegrationNodeType = NewNodeType[IntegrationNodeData, IntegrationExecutionOutput](NodeTypeDescription{
+		Type:        NodeTypeIntegration,
+		Label:       "Weather API",
+		Description: "Fetch current temperature",
+		Category:    NodeCategoryIntegration,
+	})
+	ConditionNodeType = NewNodeType[ConditionNodeData, ConditionExecutionOutput](NodeTypeDescription{
+		Type:        NodeTypeCondition,
+		Label:       "Check Condition",
+		Description: "Evaluate temperature threshold",
+		Category:    NodeCategoryLogic,
+	})
+	EmailNodeType = NewNodeType[EmailNodeData, EmailExecutionOutput](NodeTypeDescription{
+		Type:        NodeTypeEmail,
+		Label:       "Send Alert",
+		Description: "Email weather alert notification",
+		Category:    NodeCategoryAction,
+	})
+	EndNodeType = NewNodeType[EndNodeData, EndExecutionOutput](NodeTypeDescription{
+		Type:        NodeTypeEnd,
+		Label:       "Complete",
+		Description: "Workflow execution finished",
+		Category:    NodeCategoryControl,
+	})
+)
+
+// nodeTypeRegistry holds the registered node types, keyed by type name
+var nodeTypeRegistry = struct {
+	sync.RWMutex
+	types map[string]NodeType
+	order []string
+}{
+	types: make(map[string]NodeType),
+}
+
+func init() {
+	for _, nodeType := range []NodeType{
+		StartNodeType,
+		FormNodeType,
+		IntegrationNodeType,
+		ConditionNodeType,
+		EmailNodeType,
+		EndNodeType,
+	} {
+		RegisterNodeType(nodeType)
+	}
+}
+
+// RegisterNodeType adds a node type to the registry, replacing any node type with the same name
+func RegisterNodeType(nodeType NodeType) {
+	name := nodeType.Describe().Type
+
+	nodeTypeRegistry.Lock()
+	defer nodeTypeRegistry.Unlock()
+
+	if _, exists := nodeTypeRegistry.types[name]; !exists {
+		nodeTypeRegistry.order = append(nodeTypeRegistry.order, name)
+	}
+	nodeTypeRegistry.types[name] = nodeType
+}
+
+// GetNodeType looks up a registered node type by name
+func GetNodeType(name string) (NodeType, bool) {
+	nodeTypeRegistry.RLock()
+	defer nodeTypeRegistry.RUnlock()
+
+	nodeType, ok := nodeTypeRegistry.types[name]
+	return nodeType, ok
+}
+
+// RegisteredNodeTypes returns all registered node types in registration order
+func RegisteredNodeTypes() []NodeType {
+	nodeTypeRegistry.RLock()
+	defer nodeTypeRegistry.RUnlock()
+
+	nodeTypes := make([]NodeType, len(nodeTypeRegistry.order))
+	for i, name := range nodeTypeRegistry.order {
+		nodeTypes[i] = nodeTypeRegistry.types[name]
+	}
+	return nodeTypes
+}
+
+// NodeDataSchema returns the data schema of a registered node type as a standalone JSON Schema document
+func NodeDataSchema(name string) (*Schema, bool) {
+	nodeType, ok := GetNodeType(name)
+	if !ok {
+		return nil, false
+	}
+
+	description := nodeType.Describe()
+	if description.DataSchema == nil {
+		return nil, false
+	}
+
+	document := *description.DataSchema
+	document.Dialect = JSONSchemaDialect
+	document.ID = fmt.Sprintf("node-types/%s/schema", name)
+	return &document, true
+}
+
+// registeredNodeTypeNames returns the names of all registered node types, sorted for error messages
+func registeredNodeTypeNames() []string {
+	nodeTypeRegistry.RLock()
+	defer nodeTypeRegistry.RUnlock()
+
+	names := make([]string, len(nodeTypeRegistry.order))
+	copy(names, nodeTypeRegistry.order)
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+// delayNodeData is a node data type registered by the tests to exercise the registry
+type delayNodeData struct {
+	Label    string            `json:"label"`
+	Metadata delayNodeMetadata `json:"metadata"`
+}
+
+type delayNodeMetadata struct {
+	HasHandles HandleConfig `json:"hasHandles"`
+	Seconds    int          `json:"seconds"`
+	Reason     string       `json:"reason,omitempty"`
+}
+
+func (d delayNodeData) GetNodeType() string         { return "test_delay" }
+func (d delayNodeData) Validate() error             { return nil }
+func (d delayNodeData) GetHandles() Handles         { return d.Metadata.HasHandles.toHandles() }
+func (d delayNodeData) GetVariables() NodeVariables { return NodeVariables{} }
+
+func TestRegisterNodeType(t *testing.T) {
+	RegisterNodeType(NewNodeType[delayNodeData, EndExecutionOutput](NodeTypeDescription{
+		Type:     "test_delay",
+		Label:    "Delay",
+		Category: NodeCategoryLogic,
+	}))
+
+	if err := ValidateNodeType("test_delay"); err != nil {
+		t.Fatalf("expected registered node type to be valid, got %v", err)
+	}
+
+	data, err := ParseNodeData("test_delay", []byte(`{"label":"Wait","metadata":{"hasHandles":{"source":true,"target":true},"seconds":5}}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if delay, ok := data.(delayNodeData); !ok || delay.Metadata.Seconds != 5 {
+		t.Errorf("expected delayNodeData with 5 seconds, got %#v", data)
+	}
+
+	nodeType, ok := GetNodeType("test_delay")
+	if !ok {
+		t.Fatal("expected node type to be found")
+	}
+
+	schema := nodeType.Describe().DataSchema
+	metadata := schema.Properties["metadata"]
+	if metadata == nil || !reflect.DeepEqual(metadata.Properties["seconds"].Type, SchemaType{"integer"}) {
+		t.Fatalf("expected metadata.seconds to be an integer in schema, got %+v", metadata)
+	}
+	if len(metadata.Required) != 2 || metadata.Required[0] != "hasHandles" || metadata.Required[1] != "seconds" {
+		t.Errorf("expected hasHandles and seconds to be required, got %v", metadata.Required)
+	}
+}
+
+func TestParseNodeData_BuiltInTypes(t *testing.T) {
+	tests := []struct {
+		nodeType string
+		rawData  string
+		wantErr  bool
+	}{
+		{NodeTypeStart, `{"label":"Start","metadata":{"hasHandles":{"source":true,"target":false}}}`, false},
+		{NodeTypeForm, `{"label":"Form","metadata":{"inputFields":["name"]}}`, false},
+		{NodeTypeForm, `{"label":"Form","metadata":{"inputFields":[]}}`, true},
+		{NodeTypeCondition, `{"label":"Condition","metadata":{"conditionExpression":"temperature > 25"}}`, false},
+		{"unknown", `{}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nodeType, func(t *testing.T) {
+			data, err := ParseNodeData(tt.nodeType, []byte(tt.rawData))
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if data.GetNodeType() != tt.nodeType {
+				t.Errorf("expected %s node data, got %s", tt.nodeType, data.GetNodeType())
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaDialect is the JSON Schema version generated schemas conform to
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document describing the shape of a Go type, e.g. a node data struct
+type Schema struct {
+	Dialect    string             `json:"$schema,omitempty"`
+	ID         string             `json:"$id,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	Type       SchemaType         `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	AnyOf      []*Schema          `json:"anyOf,omitempty"`
+	// AdditionalProperties is either a *Schema for map values or false for closed structs
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+}
+
+// SchemaType is the list of JSON types a value may have. It marshals to a plain string when
+// there is only one type, as is conventional in JSON Schema.
+type SchemaType []string
+
+// MarshalJSON implements custom marshaling for SchemaType
+func (st SchemaType) MarshalJSON() ([]byte, error) {
+	if len(st) == 1 {
+		return json.Marshal(st[0])
+	}
+	return json.Marshal([]string(st))
+}
+
+// Allows returns true if the given JSON type is one of the schema types
+func (st SchemaType) Allows(jsonType string) bool {
+	for _, t := range st {
+		if t == jsonType || (t == "number" && jsonType == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaProvider is implemented by types whose JSON form differs from their Go struct, e.g. because
+// of a custom UnmarshalJSON, so they can describe their own schema
+type schemaProvider interface {
+	JSONSchema() *Schema
+}
+
+var schemaProviderType = reflect.TypeOf((*schemaProvider)(nil)).Elem()
+
+// SchemaFor generates a JSON Schema from a Go type, following its json struct tags.
+// Structs are titled with their Go type name, fields without omitempty are required, structs don't allow unknown properties, and slices
+// and maps may be null as that is how encoding/json marshals them when nil.
+// interface{} values produce an empty (any) schema, and types implementing JSONSchema() provide their own.
+func SchemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Implements(schemaProviderType) {
+		return reflect.Zero(t).Interface().(schemaProvider).JSONSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: SchemaType{"string"}}
+	case reflect.Bool:
+		return &Schema{Type: SchemaType{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: SchemaType{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: SchemaType{"number"}}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: SchemaType{"array", "null"}, Items: SchemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: SchemaType{"object", "null"}, AdditionalProperties: SchemaFor(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// structSchema generates the schema of a struct from its exported, json-tagged fields
+func structSchema(t reflect.Type) *Schema {
+	schema := &Schema{
+		Title:                t.Name(),
+		Type:                 SchemaType{"object"},
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		schema.Properties[name] = SchemaFor(field.Type)
+		if !omitEmpty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+// jsonFieldName returns the JSON name of a struct field and whether it is optional or skipped
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, false
+}
+
+// SchemaViolation describes where and why a JSON document doesn't match a schema
+type SchemaViolation struct {
+	// Path is a JSON Pointer (RFC 6901) to the offending value, e.g. /metadata/inputFields/0
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ValidateJSON checks a raw JSON document against the schema and returns every violation found
+func (s *Schema) ValidateJSON(rawData []byte) []SchemaViolation {
+	decoder := json.NewDecoder(strings.NewReader(string(rawData)))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []SchemaViolation{{Path: "", Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+
+	return s.validateValue("", value)
+}
+
+// validateValue recursively validates a decoded JSON value, accumulating the JSON Pointer path
+func (s *Schema) validateValue(path string, value interface{}) []SchemaViolation {
+	jsonType := jsonTypeOf(value)
+
+	if len(s.AnyOf) > 0 {
+		return s.validateAnyOf(path, value, jsonType)
+	}
+
+	if len(s.Type) == 0 {
+		return nil
+	}
+
+	if !s.Type.Allows(jsonType) {
+		return []SchemaViolation{{
+			Path:    path,
+			Message: fmt.Sprintf("expected %s, got %s", strings.Join(s.Type, " or "), jsonType),
+		}}
+	}
+
+	var violations []SchemaViolation
+
+	switch v := value.(type) {
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				violations = append(violations, s.Items.validateValue(path+"/"+strconv.Itoa(i), item)...)
+			}
+		}
+
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				violations = append(violations, SchemaViolation{
+					Path:    path + "/" + escapeJSONPointer(name),
+					Message: "required property is missing",
+				})
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			keyPath := path + "/" + escapeJSONPointer(key)
+			if property, ok := s.Properties[key]; ok {
+				violations = append(violations, property.validateValue(keyPath, v[key])...)
+				continue
+			}
+
+			switch additional := s.AdditionalProperties.(type) {
+			case *Schema:
+				violations = append(violations, additional.validateValue(keyPath, v[key])...)
+			case bool:
+				if !additional {
+					violations = append(violations, SchemaViolation{
+						Path:    keyPath,
+						Message: "unknown property",
+					})
+				}
+			}
+		}
+	}
+
+	return violations
+}
+
+// validateAnyOf accepts the value if any alternative matches. Otherwise it reports the violations of
+// the first alternative of the right type, which is the closest match, or a type mismatch.
+func (s *Schema) validateAnyOf(path string, value interface{}, jsonType string) []SchemaViolation {
+	var closest []SchemaViolation
+	var expected []string
+
+	for _, alternative := range s.AnyOf {
+		violations := alternative.validateValue(path, value)
+		if len(violations) == 0 {
+			return nil
+		}
+		if closest == nil && alternative.Type.Allows(jsonType) {
+			closest = violations
+		}
+		expected = append(expected, alternative.Type...)
+	}
+
+	if closest != nil {
+		return closest
+	}
+	return []SchemaViolation{{
+		Path:    path,
+		Message: fmt.Sprintf("expected %s, got %s", strings.Join(expected, " or "), jsonType),
+	}}
+}
+
+// jsonTypeOf returns the JSON Schema type name of a value decoded with json.Decoder.UseNumber
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// escapeJSONPointer escapes a property name for use as a JSON Pointer reference token
+func escapeJSONPointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemaFor(t *testing.T) {
+	schema := SchemaFor(reflect.TypeOf(IntegrationNodeData{}))
+
+	if schema.Title != "IntegrationNodeData" {
+		t.Errorf("expected struct title, got %q", schema.Title)
+	}
+	if schema.AdditionalProperties != false {
+		t.Errorf("expected structs to reject unknown properties, got %v", schema.AdditionalProperties)
+	}
+
+	metadata := schema.Properties["metadata"]
+	options := metadata.Properties["options"]
+	if !reflect.DeepEqual(options.Type, SchemaType{"array", "null"}) {
+		t.Errorf("expected options to be a nullable array, got %v", options.Type)
+	}
+	if !reflect.DeepEqual(options.Items.Properties["lat"].Type, SchemaType{"number"}) {
+		t.Errorf("expected option lat to be a number, got %v", options.Items.Properties["lat"].Type)
+	}
+
+	variableTypes := metadata.Properties["variableTypes"]
+	if valueSchema, ok := variableTypes.AdditionalProperties.(*Schema); !ok || !reflect.DeepEqual(valueSchema.Type, SchemaType{"string"}) {
+		t.Errorf("expected variableTypes to be a map of strings, got %+v", variableTypes.AdditionalProperties)
+	}
+
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		t.Fatalf("failed to marshal schema: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"type":["array","null"]`) {
+		t.Errorf("expected multiple types to marshal as a list, got %s", encoded)
+	}
+}
+
+func TestSchema_ValidateJSON(t *testing.T) {
+	schema := FormNodeType.Describe().DataSchema
+
+	tests := []struct {
+		name      string
+		rawData   string
+		wantPaths []string
+	}{
+		{
+			name:    "valid data",
+			rawData: `{"label":"Form","description":"","metadata":{"hasHandles":{"source":true,"target":true},"inputFields":["name"],"outputVariables":null}}`,
+		},
+		{
+			name:      "unknown property",
+			rawData:   `{"label":"Form","description":"","colour":"red","metadata":{"hasHandles":{"source":true,"target":true},"inputFields":["name"],"outputVariables":[]}}`,
+			wantPaths: []string{"/colour"},
+		},
+		{
+			name:      "wrong types",
+			rawData:   `{"label":1,"description":"","metadata":{"hasHandles":{"source":"yes","target":true},"inputFields":["name",2],"outputVariables":[]}}`,
+			wantPaths: []string{"/label", "/metadata/hasHandles/source", "/metadata/inputFields/1"},
+		},
+		{
+			name:      "missing required property",
+			rawData:   `{"label":"Form","description":"","metadata":{"inputFields":["name"],"outputVariables":[]}}`,
+			wantPaths: []string{"/metadata/hasHandles"},
+		},
+		{
+			name:      "not an object",
+			rawData:   `[]`,
+			wantPaths: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := schema.ValidateJSON([]byte(tt.rawData))
+
+			var paths []string
+			for _, violation := range violations {
+				paths = append(paths, violation.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("expected violations at %v, got %+v", tt.wantPaths, violations)
+			}
+		})
+	}
+}
+
+func TestNodeRequest_UnmarshalJSON_SchemaViolation(t *testing.T) {
+	var node NodeRequest
+	err := json.Unmarshal([]byte(`{"id":"email","type":"email","data":{"label":"Email","description":"","metadata":{"hasHandles":{"source":true,"target":true},"inputVariables":[],"emailTemplate":{"subject":"Hi","body":"Hello","cc":"x"},"outputVariables":[]}}}`), &node)
+
+	var validationErrors ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+	if len(validationErrors) != 1 {
+		t.Fatalf("expected 1 validation error, got %v", validationErrors)
+	}
+
+	got := validationErrors[0]
+	if got.Code != CodeSchemaViolation || got.NodeID != "email" || got.Path != "/metadata/emailTemplate/cc" {
+		t.Errorf("unexpected validation error: %+v", got)
+	}
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// MaxSecretValueLength is the longest secret value accepted, in bytes
+const MaxSecretValueLength = 8 << 10
+
+// SecretNamePattern matches valid secret names, e.g. WEATHER_API_KEY
+var SecretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,127}$`)
+
+// SecretReferencePattern matches {{secret.NAME}} references in node metadata, capturing the name
+var SecretReferencePattern = regexp.MustCompile(`\{\{\s*secret\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Secret describes a stored secret. Its value is never part of it, it's only decrypted at execution time.
+type Secret struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// KeyID names the master key the secret is currently encrypted with
+	KeyID     string    `json:"keyId"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// EncryptedSecret is a secret as stored, its value encrypted under its wrapped data key
+type EncryptedSecret struct {
+	Secret
+	Ciphertext []byte
+	WrappedKey []byte
+	// WorkspaceBound is true if the workspace is part of the associated data the value was sealed with,
+	// false for values sealed with only the secret's name
+	WorkspaceBound bool
+}
+
+// SecretRequest is the payload for creating or updating a secret
+type SecretRequest struct {
+	Name        string  `json:"name"`
+	Value       *string `json:"value"`
+	Description *string `json:"description"`
+}
+
+// Validate checks the request creates a secret with a valid name and value
+func (r *SecretRequest) Validate() error {
+	if !SecretNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("secret name '%s' must start with a letter or underscore and contain only letters, digits and underscores", r.Name)
+	}
+	if r.Value == nil {
+		return fmt.Errorf("secret value is required")
+	}
+	return r.ValidateUpdate()
+}
+
+// ValidateUpdate checks the request's changes to an existing secret, where every field is optional
+func (r *SecretRequest) ValidateUpdate() error {
+	if r.Value != nil {
+		if *r.Value == "" {
+			return fmt.Errorf("secret value must not be empty")
+		}
+		if len(*r.Value) > MaxSecretValueLength {
+			return fmt.Errorf("secret value must be at most %d bytes", MaxSecretValueLength)
+		}
+	}
+	return nil
+}
+
+// SecretReferences returns the names of the secrets referenced in text, in order of first use
+func SecretReferences(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range SecretReferencePattern.FindAllStringSubmatch(text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
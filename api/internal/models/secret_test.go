@@ -0,0 +1,49 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSecretRequest_Validate(t *testing.T) {
+	value := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		request SecretRequest
+		error   string
+	}{
+		{"valid", SecretRequest{Name: "WEATHER_API_KEY", Value: value("abc")}, ""},
+		{"lowercase", SecretRequest{Name: "_token2", Value: value("abc")}, ""},
+		{"invalid name", SecretRequest{Name: "weather-key", Value: value("abc")}, "must start with a letter"},
+		{"empty name", SecretRequest{Name: "", Value: value("abc")}, "must start with a letter"},
+		{"missing value", SecretRequest{Name: "KEY"}, "value is required"},
+		{"empty value", SecretRequest{Name: "KEY", Value: value("")}, "must not be empty"},
+		{"long value", SecretRequest{Name: "KEY", Value: value(strings.Repeat("x", MaxSecretValueLength+1))}, "at most"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.error == "" && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.error != "" && (err == nil || !strings.Contains(err.Error(), tt.error)) {
+				t.Errorf("Expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestSecretReferences(t *testing.T) {
+	got := SecretReferences("https://api.example.com/?key={{secret.API_KEY}}&other={{ secret.OTHER }}&again={{secret.API_KEY}}&not={{secrets.X}}")
+	want := []string{"API_KEY", "OTHER"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if refs := SecretReferences("https://api.open-meteo.com/v1/forecast?latitude={lat}"); len(refs) != 0 {
+		t.Errorf("Expected no references, got %v", refs)
+	}
+}
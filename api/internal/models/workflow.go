@@ -14,12 +14,22 @@ type Workflow struct {
 	UpdatedAt time.Time `json:"-" db:"updated_at"`
 }
 
+// Version identifies the saved revision of the workflow, derived from when it was last updated
+func (w *Workflow) Version() string {
+	if w.UpdatedAt.IsZero() {
+		return ""
+	}
+	return w.UpdatedAt.UTC().Format(time.RFC3339Nano)
+}
+
 // WorkflowResponse represents a complete workflow as returned to the frontend
 type WorkflowResponse struct {
-	ID    string         `json:"id"`
-	Name  string         `json:"name,omitempty"`
-	Nodes []NodeResponse `json:"nodes"`
-	Edges []EdgeResponse `json:"edges"`
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Version changes every time the workflow is saved, empty for definitions that aren't stored
+	Version string         `json:"version,omitempty"`
+	Nodes   []NodeResponse `json:"nodes"`
+	Edges   []EdgeResponse `json:"edges"`
 }
 
 // WorkflowRequest represents the workflow data sent from the frontend
@@ -29,3 +39,43 @@ type WorkflowRequest struct {
 	Nodes []NodeRequest `json:"nodes"`
 	Edges []EdgeRequest `json:"edges"`
 }
+
+// ToResponse converts a WorkflowRequest into the WorkflowResponse shape used by the execution engine
+func (wr *WorkflowRequest) ToResponse() *WorkflowResponse {
+	nodes := make([]NodeResponse, len(wr.Nodes))
+	for i, node := range wr.Nodes {
+		nodes[i] = node.ToResponse()
+	}
+
+	edges := make([]EdgeResponse, len(wr.Edges))
+	for i, edge := range wr.Edges {
+		edges[i] = edge.ToResponse()
+	}
+
+	return &WorkflowResponse{
+		ID:    wr.ID,
+		Name:  wr.Name,
+		Nodes: nodes,
+		Edges: edges,
+	}
+}
+
+// ToRequest converts a WorkflowResponse into the WorkflowRequest shape used by validation
+func (w *WorkflowResponse) ToRequest() *WorkflowRequest {
+	nodes := make([]NodeRequest, len(w.Nodes))
+	for i, node := range w.Nodes {
+		nodes[i] = node.ToRequest()
+	}
+
+	edges := make([]EdgeRequest, len(w.Edges))
+	for i, edge := range w.Edges {
+		edges[i] = edge.ToRequest()
+	}
+
+	return &WorkflowRequest{
+		ID:    w.ID,
+		Name:  w.Name,
+		Nodes: nodes,
+		Edges: edges,
+	}
+}
@@ -0,0 +1,237 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Dataflow validation error codes
+const (
+	CodeUnavailableVariable  = "unavailable_variable"
+	CodeVariableTypeMismatch = "variable_type_mismatch"
+	CodeInvalidVariableType  = "invalid_variable_type"
+)
+
+// variableTypeConflict marks a variable whose type differs between incoming paths
+const variableTypeConflict = "conflicting"
+
+// availableVariables maps the variables guaranteed to be set at a point in the graph to their
+// declared type, or an empty string if no type was declared
+type availableVariables map[string]string
+
+// intersect keeps only the variables present in both sets, merging their types
+func (av availableVariables) intersect(other availableVariables) availableVariables {
+	result := make(availableVariables)
+	for name, varType := range av {
+		otherType, ok := other[name]
+		if !ok {
+			continue
+		}
+		switch {
+		case varType == otherType:
+			result[name] = varType
+		case varType == "" || otherType == "":
+			// An untyped producer on one path means the type can't be guaranteed either way
+			result[name] = ""
+		default:
+			result[name] = variableTypeConflict
+		}
+	}
+	return result
+}
+
+// equals returns true if both sets contain the same variables with the same types
+func (av availableVariables) equals(other availableVariables) bool {
+	if len(av) != len(other) {
+		return false
+	}
+	for name, varType := range av {
+		if otherType, ok := other[name]; !ok || otherType != varType {
+			return false
+		}
+	}
+	return true
+}
+
+// predecessor is the source of an edge into a node, and whether the edge is only followed when the source fails
+type predecessor struct {
+	nodeID  string
+	onError bool
+}
+
+// errorVariables are set by the engine when it follows a failed node's error edge
+var errorVariables = availableVariables{"error": VariableTypeString, "errorKind": VariableTypeString}
+
+// lintDataflow walks every path from the start node and reports inputs that aren't guaranteed to
+// be produced upstream on all paths, and inputs whose declared type doesn't match what is produced.
+//
+// It is a forward "must be defined" analysis: the variables available when entering a node are the
+// intersection of the variables available when leaving each of its predecessors, iterated to a fixpoint
+// so that cycles are handled. A node left through an error edge produced none of its outputs, only the
+// variables describing its error.
+func (wr *WorkflowRequest) lintDataflow() ValidationErrors {
+	var errs ValidationErrors
+
+	// Declared types must be known, regardless of reachability
+	for _, node := range wr.Nodes {
+		if node.Data == nil {
+			continue
+		}
+		types := node.Data.GetVariables().Types
+		for _, name := range sortedKeys(types) {
+			if varType := types[name]; !ValidVariableTypes[varType] {
+				errs = append(errs, ValidationError{
+					Field:    "nodes",
+					Message:  fmt.Sprintf("node %s declares variable '%s' with unknown type '%s', must be one of: string, number, bool, list", node.ID, name, varType),
+					Code:     CodeInvalidVariableType,
+					Severity: SeverityError,
+					NodeID:   node.ID,
+				})
+			}
+		}
+	}
+
+	startNodeID := wr.startNodeID()
+	if startNodeID == "" {
+		return errs
+	}
+
+	reachable := wr.reachableFrom(startNodeID)
+
+	nodes := make(map[string]*NodeRequest, len(wr.Nodes))
+	order := make([]string, 0, len(wr.Nodes))
+	for i := range wr.Nodes {
+		node := &wr.Nodes[i]
+		if reachable[node.ID] && nodes[node.ID] == nil {
+			nodes[node.ID] = node
+			order = append(order, node.ID)
+		}
+	}
+
+	predecessors := make(map[string][]predecessor)
+	for _, edge := range wr.Edges {
+		if nodes[edge.Source] != nil && nodes[edge.Target] != nil {
+			onError := edge.SourceHandle != nil && *edge.SourceHandle == ErrorHandle
+			predecessors[edge.Target] = append(predecessors[edge.Target], predecessor{nodeID: edge.Source, onError: onError})
+		}
+	}
+
+	// Nodes without an entry in exit haven't been evaluated yet and act as "everything available".
+	// errorExit holds what is available when a node is left through its error edges.
+	exit := make(map[string]availableVariables, len(nodes))
+	errorExit := make(map[string]availableVariables, len(nodes))
+
+	entryOf := func(nodeID string) (availableVariables, bool) {
+		if nodeID == startNodeID {
+			return availableVariables{}, true
+		}
+		var entry availableVariables
+		for _, predecessor := range predecessors[nodeID] {
+			exits := exit
+			if predecessor.onError {
+				exits = errorExit
+			}
+			predecessorExit, ok := exits[predecessor.nodeID]
+			if !ok {
+				continue
+			}
+			if entry == nil {
+				entry = predecessorExit
+			} else {
+				entry = entry.intersect(predecessorExit)
+			}
+		}
+		return entry, entry != nil
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, nodeID := range order {
+			entry, ok := entryOf(nodeID)
+			if !ok {
+				continue
+			}
+
+			nodeExit := make(availableVariables, len(entry))
+			for name, varType := range entry {
+				nodeExit[name] = varType
+			}
+			if data := nodes[nodeID].Data; data != nil {
+				variables := data.GetVariables()
+				for _, name := range variables.Outputs {
+					nodeExit[name] = variables.Types[name]
+				}
+			}
+
+			if previous, ok := exit[nodeID]; !ok || !previous.equals(nodeExit) {
+				exit[nodeID] = nodeExit
+				changed = true
+			}
+
+			nodeErrorExit := make(availableVariables, len(entry)+len(errorVariables))
+			for name, varType := range entry {
+				nodeErrorExit[name] = varType
+			}
+			for name, varType := range errorVariables {
+				nodeErrorExit[name] = varType
+			}
+			if previous, ok := errorExit[nodeID]; !ok || !previous.equals(nodeErrorExit) {
+				errorExit[nodeID] = nodeErrorExit
+				changed = true
+			}
+		}
+	}
+
+	for _, nodeID := range order {
+		node := nodes[nodeID]
+		if node.Data == nil {
+			continue
+		}
+
+		entry, _ := entryOf(nodeID)
+		variables := node.Data.GetVariables()
+
+		for _, name := range variables.Inputs {
+			availableType, ok := entry[name]
+			if !ok {
+				errs = append(errs, ValidationError{
+					Field:    "nodes",
+					Message:  fmt.Sprintf("node %s reads variable '%s' which is not produced upstream on every path from the start node", node.ID, name),
+					Code:     CodeUnavailableVariable,
+					Severity: SeverityError,
+					NodeID:   node.ID,
+				})
+				continue
+			}
+
+			expectedType := variables.Types[name]
+			if expectedType == "" || availableType == "" || availableType == expectedType {
+				continue
+			}
+
+			message := fmt.Sprintf("node %s expects variable '%s' to be %s but it is produced as %s", node.ID, name, expectedType, availableType)
+			if availableType == variableTypeConflict {
+				message = fmt.Sprintf("node %s expects variable '%s' to be %s but upstream paths produce it with different types", node.ID, name, expectedType)
+			}
+			errs = append(errs, ValidationError{
+				Field:    "nodes",
+				Message:  message,
+				Code:     CodeVariableTypeMismatch,
+				Severity: SeverityError,
+				NodeID:   node.ID,
+			})
+		}
+	}
+
+	return errs
+}
+
+// sortedKeys returns the keys of a string map in a deterministic order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
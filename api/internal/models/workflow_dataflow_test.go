@@ -0,0 +1,181 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestWorkflowRequest_lintDataflow(t *testing.T) {
+	trueHandle := "true"
+	falseHandle := "false"
+	errorHandle := ErrorHandle
+
+	start := NodeRequest{ID: "start", Type: NodeTypeStart, Data: StartNodeData{Metadata: StartNodeMetadata{HasHandles: HandleConfig{Source: true}}}}
+	end := NodeRequest{ID: "end", Type: NodeTypeEnd, Data: EndNodeData{Metadata: EndNodeMetadata{HasHandles: HandleConfig{Target: true}}}}
+	form := NodeRequest{ID: "form", Type: NodeTypeForm, Data: FormNodeData{Metadata: FormNodeMetadata{
+		HasHandles:      HandleConfig{Source: true, Target: true},
+		InputFields:     NewFormFields("name", "email", "city"),
+		OutputVariables: []string{"name", "email", "city"},
+	}}}
+	weather := NodeRequest{ID: "weather", Type: NodeTypeIntegration, Data: IntegrationNodeData{Metadata: IntegrationNodeMetadata{
+		HasHandles:      HandleConfig{Source: true, Target: true},
+		InputVariables:  []string{"city"},
+		APIEndpoint:     "https://api.open-meteo.com/v1/forecast?latitude={lat}&longitude={lon}&current_weather=true",
+		OutputVariables: []string{"temperature"},
+		VariableTypes:   map[string]string{"city": VariableTypeString, "temperature": VariableTypeNumber},
+	}}}
+	condition := NodeRequest{ID: "condition", Type: NodeTypeCondition, Data: ConditionNodeData{Metadata: ConditionNodeMetadata{
+		HasHandles:          HandleConfigWithBranches{Source: []string{"true", "false"}, Target: true},
+		InputVariables:      []string{"temperature"},
+		ConditionExpression: "temperature > 25",
+		OutputVariables:     []string{"conditionMet"},
+		VariableTypes:       map[string]string{"temperature": VariableTypeNumber, "conditionMet": VariableTypeBool},
+	}}}
+	emailNode := func(id string, inputs []string, types map[string]string) NodeRequest {
+		return NodeRequest{ID: id, Type: NodeTypeEmail, Data: EmailNodeData{Metadata: EmailNodeMetadata{
+			HasHandles:     HandleConfig{Source: true, Target: true},
+			InputVariables: inputs,
+			EmailTemplate:  EmailTemplate{Subject: "Weather Alert", Body: "Temperature is {{temperature}}"},
+			VariableTypes:  types,
+		}}}
+	}
+
+	tests := []struct {
+		name          string
+		workflow      WorkflowRequest
+		expectedCodes []string
+		expectedNodes []string
+	}{
+		{
+			name: "all inputs produced upstream",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{start, form, weather, condition, emailNode("email", []string{"name", "email", "temperature"}, nil), end},
+				Edges: []EdgeRequest{
+					{ID: "e1", Source: "start", Target: "form"},
+					{ID: "e2", Source: "form", Target: "weather"},
+					{ID: "e3", Source: "weather", Target: "condition"},
+					{ID: "e4", Source: "condition", Target: "email", SourceHandle: &trueHandle},
+					{ID: "e5", Source: "condition", Target: "end", SourceHandle: &falseHandle},
+					{ID: "e6", Source: "email", Target: "end"},
+				},
+			},
+		},
+		{
+			name: "input never produced",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{start, weather, end},
+				Edges: []EdgeRequest{
+					{ID: "e1", Source: "start", Target: "weather"},
+					{ID: "e2", Source: "weather", Target: "end"},
+				},
+			},
+			expectedCodes: []string{CodeUnavailableVariable},
+			expectedNodes: []string{"weather"},
+		},
+		{
+			name: "input produced on only one branch",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{start, form, weather, condition, emailNode("email", []string{"temperature"}, nil), end},
+				Edges: []EdgeRequest{
+					{ID: "e1", Source: "start", Target: "form"},
+					{ID: "e2", Source: "form", Target: "weather"},
+					{ID: "e3", Source: "weather", Target: "condition"},
+					{ID: "e4", Source: "condition", Target: "email", SourceHandle: &trueHandle},
+					// The false branch skips the weather lookup on its way to the email
+					{ID: "e5", Source: "form", Target: "email"},
+					{ID: "e6", Source: "email", Target: "end"},
+				},
+			},
+			expectedCodes: []string{CodeUnavailableVariable},
+			expectedNodes: []string{"email"},
+		},
+		{
+			name: "declared type mismatch",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{start, form, weather, emailNode("email", []string{"temperature"}, map[string]string{"temperature": VariableTypeString}), end},
+				Edges: []EdgeRequest{
+					{ID: "e1", Source: "start", Target: "form"},
+					{ID: "e2", Source: "form", Target: "weather"},
+					{ID: "e3", Source: "weather", Target: "email"},
+					{ID: "e4", Source: "email", Target: "end"},
+				},
+			},
+			expectedCodes: []string{CodeVariableTypeMismatch},
+			expectedNodes: []string{"email"},
+		},
+		{
+			name: "unknown declared type",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{start, form, emailNode("email", []string{"name"}, map[string]string{"name": "text"}), end},
+				Edges: []EdgeRequest{
+					{ID: "e1", Source: "start", Target: "form"},
+					{ID: "e2", Source: "form", Target: "email"},
+					{ID: "e3", Source: "email", Target: "end"},
+				},
+			},
+			expectedCodes: []string{CodeInvalidVariableType},
+			expectedNodes: []string{"email"},
+		},
+		{
+			name: "cycle keeps variables produced before the loop",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{start, form, weather, condition, end},
+				Edges: []EdgeRequest{
+					{ID: "e1", Source: "start", Target: "form"},
+					{ID: "e2", Source: "form", Target: "weather"},
+					{ID: "e3", Source: "weather", Target: "condition"},
+					{ID: "e4", Source: "condition", Target: "weather", SourceHandle: &falseHandle},
+					{ID: "e5", Source: "condition", Target: "end", SourceHandle: &trueHandle},
+				},
+			},
+		},
+		{
+			name: "error handler reads the error variables",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{start, form, weather, condition,
+					emailNode("handler", []string{"name", "error", "errorKind"}, map[string]string{"error": VariableTypeString}), end},
+				Edges: []EdgeRequest{
+					{ID: "e1", Source: "start", Target: "form"},
+					{ID: "e2", Source: "form", Target: "weather"},
+					{ID: "e3", Source: "weather", Target: "condition"},
+					{ID: "e4", Source: "weather", Target: "handler", SourceHandle: &errorHandle},
+					{ID: "e5", Source: "condition", Target: "end", SourceHandle: &trueHandle},
+					{ID: "e6", Source: "handler", Target: "end"},
+				},
+			},
+		},
+		{
+			name: "error handler reads the failed node's output",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{start, form, weather, emailNode("handler", []string{"temperature"}, nil), end},
+				Edges: []EdgeRequest{
+					{ID: "e1", Source: "start", Target: "form"},
+					{ID: "e2", Source: "form", Target: "weather"},
+					{ID: "e3", Source: "weather", Target: "end"},
+					{ID: "e4", Source: "weather", Target: "handler", SourceHandle: &errorHandle},
+					{ID: "e5", Source: "handler", Target: "end"},
+				},
+			},
+			expectedCodes: []string{CodeUnavailableVariable},
+			expectedNodes: []string{"handler"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.workflow.lintDataflow()
+
+			if len(errs) != len(tt.expectedCodes) {
+				t.Fatalf("expected %d errors, got %d: %+v", len(tt.expectedCodes), len(errs), errs)
+			}
+
+			for i := range tt.expectedCodes {
+				if errs[i].Code != tt.expectedCodes[i] {
+					t.Errorf("expected code %s, got %s", tt.expectedCodes[i], errs[i].Code)
+				}
+				if errs[i].NodeID != tt.expectedNodes[i] {
+					t.Errorf("expected node %s, got %s", tt.expectedNodes[i], errs[i].NodeID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles a caller can have on a workflow, each allowing everything the roles below it allow
+const (
+	// RoleOwner allows everything, including sharing the workflow
+	RoleOwner = "owner"
+	// RoleEditor allows saving the workflow
+	RoleEditor = "editor"
+	// RoleExecutor allows executing the workflow
+	RoleExecutor = "executor"
+	// RoleViewer allows loading the workflow
+	RoleViewer = "viewer"
+)
+
+// roleRanks orders the roles, higher ranks allow more. Unknown roles rank 0 and allow nothing.
+var roleRanks = map[string]int{
+	RoleViewer:   1,
+	RoleExecutor: 2,
+	RoleEditor:   3,
+	RoleOwner:    4,
+}
+
+// ValidRole returns true if role is one of the workflow roles
+func ValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// RoleAllows returns true if role allows what the required role allows
+func RoleAllows(role, required string) bool {
+	return ValidRole(role) && roleRanks[role] >= roleRanks[required]
+}
+
+// MaxPermissionSubjectLength is the longest subject a workflow can be shared with
+const MaxPermissionSubjectLength = 255
+
+// WorkflowPermission grants a caller, identified by its subject, a role on a workflow
+type WorkflowPermission struct {
+	WorkflowID uuid.UUID `json:"workflowId"`
+	Subject    string    `json:"subject"`
+	Role       string    `json:"role"`
+	// GrantedBy is the subject of the caller that shared the workflow
+	GrantedBy string    `json:"grantedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// WorkflowPermissionRequest is the payload for sharing a workflow with a subject
+type WorkflowPermissionRequest struct {
+	Role string `json:"role"`
+}
+
+// Validate checks the request grants a known role
+func (r *WorkflowPermissionRequest) Validate() error {
+	if !ValidRole(r.Role) {
+		return fmt.Errorf("role '%s' must be one of owner, editor, executor, viewer", r.Role)
+	}
+	return nil
+}
+
+// ValidatePermissionSubject checks a subject can be granted a role
+func ValidatePermissionSubject(subject string) error {
+	if strings.TrimSpace(subject) == "" {
+		return fmt.Errorf("subject is required")
+	}
+	if len(subject) > MaxPermissionSubjectLength {
+		return fmt.Errorf("subject must be at most %d characters", MaxPermissionSubjectLength)
+	}
+	return nil
+}
+
+// WorkflowSummary lists a workflow without its definition, with the caller's role on it
+type WorkflowSummary struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Version string    `json:"version,omitempty"`
+	// Role is empty for admins listing workflows they weren't granted a role on
+	Role string `json:"role,omitempty"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		want     bool
+	}{
+		{RoleOwner, RoleOwner, true},
+		{RoleOwner, RoleViewer, true},
+		{RoleEditor, RoleExecutor, true},
+		{RoleEditor, RoleOwner, false},
+		{RoleExecutor, RoleExecutor, true},
+		{RoleExecutor, RoleEditor, false},
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleExecutor, false},
+		{"", RoleViewer, false},
+		{"admin", RoleViewer, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role+"/"+tt.required, func(t *testing.T) {
+			if got := RoleAllows(tt.role, tt.required); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWorkflowPermissionRequest_Validate(t *testing.T) {
+	for _, role := range []string{RoleOwner, RoleEditor, RoleExecutor, RoleViewer} {
+		request := WorkflowPermissionRequest{Role: role}
+		if err := request.Validate(); err != nil {
+			t.Errorf("Expected role %s to be valid, got %v", role, err)
+		}
+	}
+
+	for _, role := range []string{"", "admin", "Owner"} {
+		request := WorkflowPermissionRequest{Role: role}
+		if err := request.Validate(); err == nil {
+			t.Errorf("Expected role %q to be rejected", role)
+		}
+	}
+}
+
+func TestValidatePermissionSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		wantErr bool
+	}{
+		{"JWT subject", "alice", false},
+		{"API key", "api-key:3f0c2d52-8f3e-4e58-9f3c-0d7c1b1a2b3c", false},
+		{"blank", "  ", true},
+		{"too long", strings.Repeat("a", MaxPermissionSubjectLength+1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePermissionSubject(tt.subject)
+			if tt.wantErr && err == nil {
+				t.Errorf("Expected an error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
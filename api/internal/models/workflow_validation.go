@@ -1,9 +1,46 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
+// Validation severities
+const (
+	// SeverityError blocks saving and executing the workflow
+	SeverityError = "error"
+	// SeverityWarning is reported to the editor but doesn't block anything
+	SeverityWarning = "warning"
+)
+
+// Validation error codes, stable identifiers the frontend can switch on
+const (
+	CodeMissingStartNode   = "missing_start_node"
+	CodeMissingEndNode     = "missing_end_node"
+	CodeUnreachableEndNode = "unreachable_end_node"
+	CodeUnreachableNode    = "unreachable_node"
+	CodeOrphanNode         = "orphan_node"
+	CodeDuplicateNodeID    = "duplicate_node_id"
+	CodeDuplicateEdgeID    = "duplicate_edge_id"
+	CodeDanglingEdge       = "dangling_edge"
+	CodeInvalidHandle      = "invalid_handle"
+	CodeMissingNodeData    = "missing_node_data"
+	CodeInvalidNodeData    = "invalid_node_data"
+	CodeInvalidNodeType    = "invalid_node_type"
+	CodeInvalidEdgeType    = "invalid_edge_type"
+	CodeSchemaViolation    = "schema_violation"
+)
+
 // ValidationError represents a workflow validation error
 type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+	Code     string `json:"code,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	NodeID   string `json:"nodeId,omitempty"`
+	EdgeID   string `json:"edgeId,omitempty"`
+	// Path is a JSON Pointer into the node data for schema violations, e.g. /metadata/inputFields/0
+	Path string `json:"path,omitempty"`
 }
 
 // Error implements the error interface for ValidationError
@@ -11,6 +48,34 @@ func (ve ValidationError) Error() string {
 	return ve.Message
 }
 
+// IsBlocking returns true if the error should prevent the workflow from being saved or executed
+func (ve ValidationError) IsBlocking() bool {
+	return ve.Severity != SeverityWarning
+}
+
+// ValidationErrors groups multiple validation errors so they can be returned as a single error
+type ValidationErrors []ValidationError
+
+// Error implements the error interface for ValidationErrors
+func (ve ValidationErrors) Error() string {
+	messages := make([]string, len(ve))
+	for i, err := range ve {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Blocking returns only the errors that prevent the workflow from being saved or executed
+func (ve ValidationErrors) Blocking() ValidationErrors {
+	var blocking ValidationErrors
+	for _, err := range ve {
+		if err.IsBlocking() {
+			blocking = append(blocking, err)
+		}
+	}
+	return blocking
+}
+
 // ValidateWorkflow validates that a workflow has the required start and end nodes
 // and that there's a valid path from start to end following the edges
 func (wr *WorkflowRequest) ValidateWorkflow() []ValidationError {
@@ -19,24 +84,31 @@ func (wr *WorkflowRequest) ValidateWorkflow() []ValidationError {
 	// Check for start and end nodes
 	if !wr.hasStartNode() {
 		errors = append(errors, ValidationError{
-			Field:   "nodes",
-			Message: "workflow must have exactly one start node",
+			Field:    "nodes",
+			Message:  "workflow must have exactly one start node",
+			Code:     CodeMissingStartNode,
+			Severity: SeverityError,
 		})
 	}
 
 	if !wr.hasEndNode() {
 		errors = append(errors, ValidationError{
-			Field:   "nodes",
-			Message: "workflow must have at least one end node",
+			Field:    "nodes",
+			Message:  "workflow must have at least one end node",
+			Code:     CodeMissingEndNode,
+			Severity: SeverityError,
 		})
 	}
 
 	// Check path connectivity if we have both start and end nodes
 	if wr.hasStartNode() && wr.hasEndNode() {
-		if !wr.hasValidPath() {
+		for _, endNodeID := range wr.unreachableEndNodes() {
 			errors = append(errors, ValidationError{
-				Field:   "edges",
-				Message: "no valid path exists from start node to all end nodes",
+				Field:    "edges",
+				Message:  fmt.Sprintf("no valid path exists from start node to end node %s", endNodeID),
+				Code:     CodeUnreachableEndNode,
+				Severity: SeverityError,
+				NodeID:   endNodeID,
 			})
 		}
 	}
@@ -44,6 +116,227 @@ func (wr *WorkflowRequest) ValidateWorkflow() []ValidationError {
 	return errors
 }
 
+// Lint runs the complete validation pipeline: the structural checks of ValidateWorkflow
+// plus per node and edge checks and dataflow analysis. The result includes warnings, use Blocking to filter them out.
+func (wr *WorkflowRequest) Lint() ValidationErrors {
+	errs := ValidationErrors(wr.ValidateWorkflow())
+	errs = append(errs, wr.lintNodes()...)
+	errs = append(errs, wr.lintEdges()...)
+	errs = append(errs, wr.lintConnectivity()...)
+	errs = append(errs, wr.lintDataflow()...)
+	return errs
+}
+
+// lintNodes checks node IDs, types and data
+func (wr *WorkflowRequest) lintNodes() ValidationErrors {
+	var errs ValidationErrors
+	seen := make(map[string]bool, len(wr.Nodes))
+
+	for _, node := range wr.Nodes {
+		if seen[node.ID] {
+			errs = append(errs, ValidationError{
+				Field:    "nodes",
+				Message:  fmt.Sprintf("duplicate node ID %s", node.ID),
+				Code:     CodeDuplicateNodeID,
+				Severity: SeverityError,
+				NodeID:   node.ID,
+			})
+		}
+		seen[node.ID] = true
+
+		if err := node.Validate(); err != nil {
+			errs = append(errs, ValidationError{
+				Field:    "nodes",
+				Message:  fmt.Sprintf("invalid node %s: %v", node.ID, err),
+				Code:     CodeInvalidNodeType,
+				Severity: SeverityError,
+				NodeID:   node.ID,
+			})
+			continue
+		}
+
+		if node.Data == nil {
+			errs = append(errs, ValidationError{
+				Field:    "nodes",
+				Message:  fmt.Sprintf("node %s has no data", node.ID),
+				Code:     CodeMissingNodeData,
+				Severity: SeverityError,
+				NodeID:   node.ID,
+			})
+			continue
+		}
+
+		if node.Data.GetNodeType() != node.Type {
+			errs = append(errs, ValidationError{
+				Field:    "nodes",
+				Message:  fmt.Sprintf("node %s data type mismatch: expected %s, got %s", node.ID, node.Type, node.Data.GetNodeType()),
+				Code:     CodeInvalidNodeData,
+				Severity: SeverityError,
+				NodeID:   node.ID,
+			})
+			continue
+		}
+
+		if err := node.Data.Validate(); err != nil {
+			errs = append(errs, ValidationError{
+				Field:    "nodes",
+				Message:  fmt.Sprintf("invalid data for node %s: %v", node.ID, err),
+				Code:     CodeInvalidNodeData,
+				Severity: SeverityError,
+				NodeID:   node.ID,
+			})
+		}
+	}
+
+	return errs
+}
+
+// lintEdges checks edge IDs, types, endpoints and handles
+func (wr *WorkflowRequest) lintEdges() ValidationErrors {
+	var errs ValidationErrors
+
+	nodes := make(map[string]*NodeRequest, len(wr.Nodes))
+	for i := range wr.Nodes {
+		nodes[wr.Nodes[i].ID] = &wr.Nodes[i]
+	}
+
+	seen := make(map[string]bool, len(wr.Edges))
+	for _, edge := range wr.Edges {
+		if seen[edge.ID] {
+			errs = append(errs, ValidationError{
+				Field:    "edges",
+				Message:  fmt.Sprintf("duplicate edge ID %s", edge.ID),
+				Code:     CodeDuplicateEdgeID,
+				Severity: SeverityError,
+				EdgeID:   edge.ID,
+			})
+		}
+		seen[edge.ID] = true
+
+		if err := edge.Validate(); err != nil {
+			errs = append(errs, ValidationError{
+				Field:    "edges",
+				Message:  fmt.Sprintf("invalid edge %s: %v", edge.ID, err),
+				Code:     CodeInvalidEdgeType,
+				Severity: SeverityError,
+				EdgeID:   edge.ID,
+			})
+		}
+
+		source, target := nodes[edge.Source], nodes[edge.Target]
+		if source == nil || target == nil {
+			missing := edge.Source
+			if source != nil {
+				missing = edge.Target
+			}
+			errs = append(errs, ValidationError{
+				Field:    "edges",
+				Message:  fmt.Sprintf("edge %s references unknown node %s", edge.ID, missing),
+				Code:     CodeDanglingEdge,
+				Severity: SeverityError,
+				EdgeID:   edge.ID,
+				NodeID:   missing,
+			})
+			continue
+		}
+
+		errs = append(errs, lintEdgeHandles(edge, source, target)...)
+	}
+
+	return errs
+}
+
+// lintEdgeHandles checks that an edge connects handles its source and target nodes actually expose
+func lintEdgeHandles(edge EdgeRequest, source, target *NodeRequest) ValidationErrors {
+	var errs ValidationErrors
+
+	if source.Data != nil {
+		handles := source.Data.GetHandles()
+		switch {
+		case !handles.Source:
+			errs = append(errs, ValidationError{
+				Field:    "edges",
+				Message:  fmt.Sprintf("edge %s starts at node %s which has no source handle", edge.ID, source.ID),
+				Code:     CodeInvalidHandle,
+				Severity: SeverityError,
+				EdgeID:   edge.ID,
+				NodeID:   source.ID,
+			})
+		case edge.SourceHandle != nil && !handles.HasSourceHandle(*edge.SourceHandle):
+			errs = append(errs, ValidationError{
+				Field:    "edges",
+				Message:  fmt.Sprintf("edge %s uses unknown source handle '%s' of node %s", edge.ID, *edge.SourceHandle, source.ID),
+				Code:     CodeInvalidHandle,
+				Severity: SeverityError,
+				EdgeID:   edge.ID,
+				NodeID:   source.ID,
+			})
+		case edge.SourceHandle == nil && len(handles.SourceHandles) > 0:
+			errs = append(errs, ValidationError{
+				Field:    "edges",
+				Message:  fmt.Sprintf("edge %s leaves node %s without a source handle, it is only followed when the condition is met", edge.ID, source.ID),
+				Code:     CodeInvalidHandle,
+				Severity: SeverityWarning,
+				EdgeID:   edge.ID,
+				NodeID:   source.ID,
+			})
+		}
+	}
+
+	if target.Data != nil && !target.Data.GetHandles().Target {
+		errs = append(errs, ValidationError{
+			Field:    "edges",
+			Message:  fmt.Sprintf("edge %s ends at node %s which has no target handle", edge.ID, target.ID),
+			Code:     CodeInvalidHandle,
+			Severity: SeverityError,
+			EdgeID:   edge.ID,
+			NodeID:   target.ID,
+		})
+	}
+
+	return errs
+}
+
+// lintConnectivity warns about nodes that can never run
+func (wr *WorkflowRequest) lintConnectivity() ValidationErrors {
+	var errs ValidationErrors
+
+	connected := make(map[string]bool)
+	for _, edge := range wr.Edges {
+		connected[edge.Source] = true
+		connected[edge.Target] = true
+	}
+
+	var reachable map[string]bool
+	if startNodeID := wr.startNodeID(); startNodeID != "" {
+		reachable = wr.reachableFrom(startNodeID)
+	}
+
+	for _, node := range wr.Nodes {
+		switch {
+		case !connected[node.ID] && len(wr.Nodes) > 1:
+			errs = append(errs, ValidationError{
+				Field:    "nodes",
+				Message:  fmt.Sprintf("node %s is not connected to any other node", node.ID),
+				Code:     CodeOrphanNode,
+				Severity: SeverityWarning,
+				NodeID:   node.ID,
+			})
+		case reachable != nil && !reachable[node.ID] && node.Type != NodeTypeEnd:
+			// Unreachable end nodes are already reported as errors by ValidateWorkflow
+			errs = append(errs, ValidationError{
+				Field:    "nodes",
+				Message:  fmt.Sprintf("node %s can't be reached from the start node", node.ID),
+				Code:     CodeUnreachableNode,
+				Severity: SeverityWarning,
+				NodeID:   node.ID,
+			})
+		}
+	}
+
+	return errs
+}
+
 // hasStartNode checks if there's exactly one start node
 func (wr *WorkflowRequest) hasStartNode() bool {
 	startCount := 0
@@ -65,43 +358,44 @@ func (wr *WorkflowRequest) hasEndNode() bool {
 	return false
 }
 
-// hasValidPath performs a BFS to check if there's a path from start to all end nodes
-func (wr *WorkflowRequest) hasValidPath() bool {
-	// Build adjacency list from edges
-	graph := make(map[string][]string)
-	for _, edge := range wr.Edges {
-		graph[edge.Source] = append(graph[edge.Source], edge.Target)
+// unreachableEndNodes returns the IDs of end nodes that can't be reached from the start node
+func (wr *WorkflowRequest) unreachableEndNodes() []string {
+	startNodeID := wr.startNodeID()
+	if startNodeID == "" {
+		return nil
 	}
 
-	// Find start node ID
-	var startNodeID string
+	visited := wr.reachableFrom(startNodeID)
+
+	var unreachable []string
 	for _, node := range wr.Nodes {
-		if node.Type == NodeTypeStart {
-			startNodeID = node.ID
-			break
+		if node.Type == NodeTypeEnd && !visited[node.ID] {
+			unreachable = append(unreachable, node.ID)
 		}
 	}
+	return unreachable
+}
 
-	if startNodeID == "" {
-		return false
-	}
-
-	// Get all end node IDs
-	endNodeIDs := make(map[string]bool)
+// startNodeID returns the ID of the first start node, or an empty string if there is none
+func (wr *WorkflowRequest) startNodeID() string {
 	for _, node := range wr.Nodes {
-		if node.Type == NodeTypeEnd {
-			endNodeIDs[node.ID] = true
+		if node.Type == NodeTypeStart {
+			return node.ID
 		}
 	}
+	return ""
+}
 
-	if len(endNodeIDs) == 0 {
-		return false
+// reachableFrom performs a BFS over the edges and returns every node ID reachable from the given node
+func (wr *WorkflowRequest) reachableFrom(nodeID string) map[string]bool {
+	// Build adjacency list from edges
+	graph := make(map[string][]string)
+	for _, edge := range wr.Edges {
+		graph[edge.Source] = append(graph[edge.Source], edge.Target)
 	}
 
-	// BFS to find all reachable nodes from start
-	visited := make(map[string]bool)
-	queue := []string{startNodeID}
-	visited[startNodeID] = true
+	visited := map[string]bool{nodeID: true}
+	queue := []string{nodeID}
 
 	for len(queue) > 0 {
 		current := queue[0]
@@ -116,12 +410,5 @@ func (wr *WorkflowRequest) hasValidPath() bool {
 		}
 	}
 
-	// Check if all end nodes are reachable
-	for endNodeID := range endNodeIDs {
-		if !visited[endNodeID] {
-			return false
-		}
-	}
-
-	return true
+	return visited
 }
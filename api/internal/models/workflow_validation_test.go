@@ -1,6 +1,7 @@
 package models
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -276,11 +277,11 @@ func TestWorkflowRequest_hasEndNode(t *testing.T) {
 	}
 }
 
-func TestWorkflowRequest_hasValidPath(t *testing.T) {
+func TestWorkflowRequest_unreachableEndNodes(t *testing.T) {
 	tests := []struct {
 		name     string
 		workflow WorkflowRequest
-		expected bool
+		expected []string
 	}{
 		{
 			name: "simple valid path",
@@ -293,7 +294,7 @@ func TestWorkflowRequest_hasValidPath(t *testing.T) {
 					{ID: "edge-1", Source: "start-1", Target: "end-1"},
 				},
 			},
-			expected: true,
+			expected: nil,
 		},
 		{
 			name: "complex valid path",
@@ -310,7 +311,7 @@ func TestWorkflowRequest_hasValidPath(t *testing.T) {
 					{ID: "edge-3", Source: "condition-1", Target: "end-1"},
 				},
 			},
-			expected: true,
+			expected: nil,
 		},
 		{
 			name: "branching path to multiple end nodes",
@@ -327,7 +328,7 @@ func TestWorkflowRequest_hasValidPath(t *testing.T) {
 					{ID: "edge-3", Source: "condition-1", Target: "end-2"},
 				},
 			},
-			expected: true,
+			expected: nil,
 		},
 		{
 			name: "no path to end",
@@ -342,7 +343,7 @@ func TestWorkflowRequest_hasValidPath(t *testing.T) {
 					// Missing edge from form-1 to end-1
 				},
 			},
-			expected: false,
+			expected: []string{"end-1"},
 		},
 		{
 			name: "cycle but no path to end",
@@ -360,7 +361,7 @@ func TestWorkflowRequest_hasValidPath(t *testing.T) {
 					// No path to end-1
 				},
 			},
-			expected: false,
+			expected: []string{"end-1"},
 		},
 		{
 			name: "multiple end nodes but not all reachable",
@@ -377,14 +378,14 @@ func TestWorkflowRequest_hasValidPath(t *testing.T) {
 					// Missing edge to end-2, so end-2 is not reachable
 				},
 			},
-			expected: false,
+			expected: []string{"end-2"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := tt.workflow.hasValidPath()
-			if result != tt.expected {
+			result := tt.workflow.unreachableEndNodes()
+			if !reflect.DeepEqual(result, tt.expected) {
 				t.Errorf("expected %v, got %v", tt.expected, result)
 			}
 		})
@@ -402,3 +403,165 @@ func TestValidationError_Error(t *testing.T) {
 		t.Errorf("expected %s, got %s", expected, err.Error())
 	}
 }
+
+func TestWorkflowRequest_Lint(t *testing.T) {
+	trueHandle := "true"
+	maybeHandle := "maybe"
+	errorHandle := ErrorHandle
+
+	startData := StartNodeData{Metadata: StartNodeMetadata{HasHandles: HandleConfig{Source: true}}}
+	endData := EndNodeData{Metadata: EndNodeMetadata{HasHandles: HandleConfig{Target: true}}}
+	conditionData := ConditionNodeData{Metadata: ConditionNodeMetadata{
+		HasHandles:          HandleConfigWithBranches{Source: []string{"true", "false"}, Target: true},
+		ConditionExpression: "temperature > 25",
+	}}
+	integrationData := IntegrationNodeData{Metadata: IntegrationNodeMetadata{
+		HasHandles:  HandleConfig{Source: true, Target: true},
+		APIEndpoint: "https://api.open-meteo.com/v1/forecast",
+	}}
+
+	tests := []struct {
+		name          string
+		workflow      WorkflowRequest
+		expectedCodes []string
+		blocking      int
+	}{
+		{
+			name: "valid workflow",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{
+					{ID: "start-1", Type: NodeTypeStart, Data: startData},
+					{ID: "condition-1", Type: NodeTypeCondition, Data: conditionData},
+					{ID: "end-1", Type: NodeTypeEnd, Data: endData},
+				},
+				Edges: []EdgeRequest{
+					{ID: "edge-1", Source: "start-1", Target: "condition-1"},
+					{ID: "edge-2", Source: "condition-1", Target: "end-1", SourceHandle: &trueHandle},
+				},
+			},
+		},
+		{
+			name: "duplicate IDs",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{
+					{ID: "start-1", Type: NodeTypeStart, Data: startData},
+					{ID: "end-1", Type: NodeTypeEnd, Data: endData},
+					{ID: "end-1", Type: NodeTypeEnd, Data: endData},
+				},
+				Edges: []EdgeRequest{
+					{ID: "edge-1", Source: "start-1", Target: "end-1"},
+					{ID: "edge-1", Source: "start-1", Target: "end-1"},
+				},
+			},
+			expectedCodes: []string{CodeDuplicateNodeID, CodeDuplicateEdgeID},
+			blocking:      2,
+		},
+		{
+			name: "dangling edge",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{
+					{ID: "start-1", Type: NodeTypeStart, Data: startData},
+					{ID: "end-1", Type: NodeTypeEnd, Data: endData},
+				},
+				Edges: []EdgeRequest{
+					{ID: "edge-1", Source: "start-1", Target: "end-1"},
+					{ID: "edge-2", Source: "start-1", Target: "ghost-1"},
+				},
+			},
+			expectedCodes: []string{CodeDanglingEdge},
+			blocking:      1,
+		},
+		{
+			name: "invalid handles",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{
+					{ID: "start-1", Type: NodeTypeStart, Data: startData},
+					{ID: "condition-1", Type: NodeTypeCondition, Data: conditionData},
+					{ID: "end-1", Type: NodeTypeEnd, Data: endData},
+				},
+				Edges: []EdgeRequest{
+					{ID: "edge-1", Source: "start-1", Target: "condition-1"},
+					{ID: "edge-2", Source: "condition-1", Target: "end-1", SourceHandle: &maybeHandle},
+					{ID: "edge-3", Source: "end-1", Target: "start-1"},
+				},
+			},
+			// end-1 has no source handle and start-1 has no target handle
+			expectedCodes: []string{CodeInvalidHandle, CodeInvalidHandle, CodeInvalidHandle},
+			blocking:      3,
+		},
+		{
+			name: "error edges",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{
+					{ID: "start-1", Type: NodeTypeStart, Data: startData},
+					{ID: "integration-1", Type: NodeTypeIntegration, Data: integrationData},
+					{ID: "condition-1", Type: NodeTypeCondition, Data: conditionData},
+					{ID: "end-1", Type: NodeTypeEnd, Data: endData},
+				},
+				Edges: []EdgeRequest{
+					{ID: "edge-1", Source: "start-1", Target: "integration-1"},
+					{ID: "edge-2", Source: "integration-1", Target: "condition-1"},
+					{ID: "edge-3", Source: "integration-1", Target: "end-1", SourceHandle: &errorHandle},
+					{ID: "edge-4", Source: "condition-1", Target: "end-1", SourceHandle: &trueHandle},
+					// Only integration nodes can fail over to error edges
+					{ID: "edge-5", Source: "condition-1", Target: "end-1", SourceHandle: &errorHandle},
+				},
+			},
+			expectedCodes: []string{CodeInvalidHandle},
+			blocking:      1,
+		},
+		{
+			name: "missing node data and orphan node",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{
+					{ID: "start-1", Type: NodeTypeStart, Data: startData},
+					{ID: "end-1", Type: NodeTypeEnd},
+					{ID: "email-1", Type: NodeTypeEmail, Data: EmailNodeData{}},
+				},
+				Edges: []EdgeRequest{
+					{ID: "edge-1", Source: "start-1", Target: "end-1"},
+				},
+			},
+			expectedCodes: []string{CodeMissingNodeData, CodeInvalidNodeData, CodeOrphanNode},
+			blocking:      2,
+		},
+		{
+			name: "unreachable end and node",
+			workflow: WorkflowRequest{
+				Nodes: []NodeRequest{
+					{ID: "start-1", Type: NodeTypeStart, Data: startData},
+					{ID: "condition-1", Type: NodeTypeCondition, Data: conditionData},
+					{ID: "end-1", Type: NodeTypeEnd, Data: endData},
+				},
+				Edges: []EdgeRequest{
+					{ID: "edge-1", Source: "condition-1", Target: "end-1", SourceHandle: &trueHandle},
+				},
+			},
+			expectedCodes: []string{CodeUnreachableEndNode, CodeOrphanNode, CodeUnreachableNode},
+			blocking:      1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.workflow.Lint()
+
+			if len(errs) != len(tt.expectedCodes) {
+				t.Fatalf("expected %d errors, got %d: %+v", len(tt.expectedCodes), len(errs), errs)
+			}
+
+			for i, expectedCode := range tt.expectedCodes {
+				if errs[i].Code != expectedCode {
+					t.Errorf("expected error %d to have code %s, got %s", i, expectedCode, errs[i].Code)
+				}
+				if errs[i].NodeID == "" && errs[i].EdgeID == "" {
+					t.Errorf("expected error %d to reference a node or edge, got %+v", i, errs[i])
+				}
+			}
+
+			if blocking := len(errs.Blocking()); blocking != tt.blocking {
+				t.Errorf("expected %d blocking errors, got %d", tt.blocking, blocking)
+			}
+		})
+	}
+}
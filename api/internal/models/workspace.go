@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultWorkspaceID is the workspace of callers whose credentials don't name one, and of everything stored
+// before workspaces existed
+var DefaultWorkspaceID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// WorkspaceSlugPattern matches valid workspace slugs, e.g. data-team
+var WorkspaceSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// MaxWorkspaceNameLength is the longest workspace name accepted
+const MaxWorkspaceNameLength = 255
+
+// Workspace is a tenant of the deployment. Workflows, secrets and API keys belong to exactly one.
+type Workspace struct {
+	ID   uuid.UUID `json:"id"`
+	Slug string    `json:"slug"`
+	Name string    `json:"name"`
+	// DailyExecutionQuota is how many executions the workspace may run per UTC day, 0 for no limit
+	DailyExecutionQuota int       `json:"dailyExecutionQuota"`
+	CreatedAt           time.Time `json:"createdAt"`
+	UpdatedAt           time.Time `json:"updatedAt"`
+}
+
+// WorkspaceUsage is a workspace with the executions it ran today
+type WorkspaceUsage struct {
+	Workspace
+	ExecutionsToday int `json:"executionsToday"`
+	// QuotaResetsAt is when today's executions stop counting, the next UTC midnight
+	QuotaResetsAt time.Time `json:"quotaResetsAt"`
+}
+
+// WorkspaceRequest is the payload for creating or updating a workspace
+type WorkspaceRequest struct {
+	Slug                string `json:"slug"`
+	Name                string `json:"name"`
+	DailyExecutionQuota *int   `json:"dailyExecutionQuota"`
+}
+
+// Validate checks the request creates a workspace with a valid slug and name
+func (r *WorkspaceRequest) Validate() error {
+	if !WorkspaceSlugPattern.MatchString(r.Slug) {
+		return fmt.Errorf("workspace slug '%s' must contain only lowercase letters, digits and dashes", r.Slug)
+	}
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("workspace name is required")
+	}
+	return r.ValidateUpdate()
+}
+
+// ValidateUpdate checks the request's changes to an existing workspace, where every field is optional.
+// Slugs can't be changed.
+func (r *WorkspaceRequest) ValidateUpdate() error {
+	if len(r.Name) > MaxWorkspaceNameLength {
+		return fmt.Errorf("workspace name must be at most %d characters", MaxWorkspaceNameLength)
+	}
+	if r.DailyExecutionQuota != nil && *r.DailyExecutionQuota < 0 {
+		return fmt.Errorf("daily execution quota must not be negative")
+	}
+	return nil
+}
+
+// QuotaDay returns the UTC day executions at t count against
+func QuotaDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
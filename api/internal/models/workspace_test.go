@@ -0,0 +1,47 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkspaceRequest_Validate(t *testing.T) {
+	quota := func(n int) *int { return &n }
+
+	tests := []struct {
+		name    string
+		request WorkspaceRequest
+		wantErr bool
+	}{
+		{"valid", WorkspaceRequest{Slug: "data-team", Name: "Data team", DailyExecutionQuota: quota(1000)}, false},
+		{"no quota", WorkspaceRequest{Slug: "ops", Name: "Ops"}, false},
+		{"uppercase slug", WorkspaceRequest{Slug: "Data", Name: "Data"}, true},
+		{"leading dash", WorkspaceRequest{Slug: "-data", Name: "Data"}, true},
+		{"missing name", WorkspaceRequest{Slug: "data", Name: " "}, true},
+		{"long name", WorkspaceRequest{Slug: "data", Name: strings.Repeat("a", MaxWorkspaceNameLength+1)}, true},
+		{"negative quota", WorkspaceRequest{Slug: "data", Name: "Data", DailyExecutionQuota: quota(-1)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr && err == nil {
+				t.Errorf("Expected an error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestQuotaDay(t *testing.T) {
+	sydney := time.FixedZone("AEST", 10*60*60)
+	got := QuotaDay(time.Date(2024, 3, 5, 8, 30, 0, 0, sydney))
+
+	want := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/db/gen/workflow_engine/public/model"
+	. "workflow-code-test/api/internal/db/gen/workflow_engine/public/table"
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/tenant"
+)
+
+// APIKeyRepository stores hashed API keys. Keys are managed within the context's workspace, but looked up
+// across workspaces as requests are authenticated before their workspace is known.
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+// ListAPIKeys returns every API key of the context's workspace, revoked ones included, newest first
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	workspaceID, err := tenant.Workspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := postgres.SELECT(
+		APIKeys.AllColumns,
+	).FROM(
+		APIKeys,
+	).WHERE(
+		APIKeys.WorkspaceID.EQ(postgres.UUID(workspaceID)),
+	).ORDER_BY(
+		APIKeys.CreatedAt.DESC(),
+	)
+
+	var dest []model.APIKeys
+	if err := stmt.QueryContext(ctx, r.db, &dest); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	keys := make([]models.APIKey, len(dest))
+	for i, row := range dest {
+		keys[i] = apiKeyFromModel(row).APIKey
+	}
+	return keys, nil
+}
+
+// GetAPIKeyByPrefix returns the API key with the given lookup prefix and its hash, nil if there is none
+func (r *APIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.HashedAPIKey, error) {
+	stmt := postgres.SELECT(
+		APIKeys.AllColumns,
+	).FROM(
+		APIKeys,
+	).WHERE(
+		APIKeys.Prefix.EQ(postgres.String(prefix)),
+	)
+
+	var dest model.APIKeys
+	if err := stmt.QueryContext(ctx, r.db, &dest); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	key := apiKeyFromModel(dest)
+	return &key, nil
+}
+
+// CreateAPIKey inserts a new API key in its workspace
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *models.HashedAPIKey) error {
+	stmt := APIKeys.INSERT(
+		APIKeys.ID,
+		APIKeys.Name,
+		APIKeys.Prefix,
+		APIKeys.KeyHash,
+		APIKeys.Scopes,
+		APIKeys.CreatedBy,
+		APIKeys.CreatedAt,
+		APIKeys.WorkspaceID,
+	).VALUES(
+		key.ID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		strings.Join(key.Scopes, ","),
+		key.CreatedBy,
+		key.CreatedAt,
+		key.WorkspaceID,
+	)
+
+	if _, err := stmt.ExecContext(ctx, r.db); err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+	return nil
+}
+
+// RevokeAPIKey marks an API key of the context's workspace revoked, returning false if it doesn't exist or
+// was already revoked
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (bool, error) {
+	workspaceID, err := tenant.Workspace(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	stmt := APIKeys.UPDATE(
+		APIKeys.RevokedAt,
+	).SET(
+		time.Now(),
+	).WHERE(
+		APIKeys.ID.EQ(postgres.UUID(id)).
+			AND(APIKeys.WorkspaceID.EQ(postgres.UUID(workspaceID))).
+			AND(APIKeys.RevokedAt.IS_NULL()),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// TouchAPIKey records that an API key was just used
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	stmt := APIKeys.UPDATE(
+		APIKeys.LastUsedAt,
+	).SET(
+		time.Now(),
+	).WHERE(
+		APIKeys.ID.EQ(postgres.UUID(id)),
+	)
+
+	if _, err := stmt.ExecContext(ctx, r.db); err != nil {
+		return fmt.Errorf("failed to update API key last use: %w", err)
+	}
+	return nil
+}
+
+func apiKeyFromModel(row model.APIKeys) models.HashedAPIKey {
+	return models.HashedAPIKey{
+		APIKey: models.APIKey{
+			ID:          row.ID,
+			Name:        row.Name,
+			Prefix:      row.Prefix,
+			Scopes:      strings.Split(row.Scopes, ","),
+			WorkspaceID: row.WorkspaceID,
+			CreatedBy:   row.CreatedBy,
+			CreatedAt:   row.CreatedAt,
+			LastUsedAt:  row.LastUsedAt,
+			RevokedAt:   row.RevokedAt,
+		},
+		KeyHash: row.KeyHash,
+	}
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/db/gen/workflow_engine/public/model"
+	. "workflow-code-test/api/internal/db/gen/workflow_engine/public/table"
+	"workflow-code-test/api/internal/models"
+)
+
+// apiResponseCachePurgeInterval is how often Set removes expired responses
+const apiResponseCachePurgeInterval = 10 * time.Minute
+
+// APIResponseCacheRepository stores cached integration API responses in Postgres, so every API replica
+// shares the same cache. Responses are scoped to the context's workspace.
+type APIResponseCacheRepository struct {
+	db *sql.DB
+
+	lastPurge sync.Map // workspace ID -> unix seconds
+}
+
+func NewAPIResponseCacheRepository(db *sql.DB) *APIResponseCacheRepository {
+	return &APIResponseCacheRepository{
+		db: db,
+	}
+}
+
+// Get returns the cached response stored under key, nil if there is none or it has expired
+func (r *APIResponseCacheRepository) Get(ctx context.Context, key string) (*models.CachedAPIResponse, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	stmt := postgres.SELECT(
+		APIResponseCache.AllColumns,
+	).FROM(
+		APIResponseCache,
+	).WHERE(
+		APIResponseCache.WorkspaceID.EQ(postgres.UUID(workspaceID)).
+			AND(APIResponseCache.Key.EQ(postgres.String(key))).
+			AND(APIResponseCache.ExpiresAt.GT(postgres.TimestampzT(time.Now()))),
+	)
+
+	var dest model.APIResponseCache
+	err = stmt.QueryContext(ctx, db, &dest)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get cached response: %w", err)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(dest.Body), &body); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cached response body: %w", err)
+	}
+
+	return &models.CachedAPIResponse{
+		URL:        dest.URL,
+		StatusCode: int(dest.StatusCode),
+		Body:       body,
+		StoredAt:   dest.StoredAt,
+		ExpiresAt:  dest.ExpiresAt,
+	}, nil
+}
+
+// Set stores a response under key, replacing any previous one
+func (r *APIResponseCacheRepository) Set(ctx context.Context, key string, response *models.CachedAPIResponse) error {
+	body, err := json.Marshal(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response body: %w", err)
+	}
+
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	stmt := APIResponseCache.INSERT(
+		APIResponseCache.AllColumns,
+	).VALUES(
+		workspaceID,
+		key,
+		response.URL,
+		response.StatusCode,
+		string(body),
+		response.StoredAt,
+		response.ExpiresAt,
+	).ON_CONFLICT(APIResponseCache.WorkspaceID, APIResponseCache.Key).DO_UPDATE(
+		postgres.SET(
+			APIResponseCache.URL.SET(APIResponseCache.EXCLUDED.URL),
+			APIResponseCache.StatusCode.SET(APIResponseCache.EXCLUDED.StatusCode),
+			APIResponseCache.Body.SET(APIResponseCache.EXCLUDED.Body),
+			APIResponseCache.StoredAt.SET(APIResponseCache.EXCLUDED.StoredAt),
+			APIResponseCache.ExpiresAt.SET(APIResponseCache.EXCLUDED.ExpiresAt),
+		),
+	)
+
+	if _, err := stmt.ExecContext(ctx, db); err != nil {
+		return fmt.Errorf("failed to cache response: %w", err)
+	}
+
+	return r.purgeExpired(ctx, db, workspaceID, time.Now())
+}
+
+// purgeExpired removes a workspace's expired responses now and then. They're never read again, but would
+// keep the table growing.
+func (r *APIResponseCacheRepository) purgeExpired(ctx context.Context, db tenantDB, workspaceID uuid.UUID, now time.Time) error {
+	last, loaded := r.lastPurge.Load(workspaceID)
+	if loaded && now.Unix()-last.(int64) < int64(apiResponseCachePurgeInterval.Seconds()) {
+		return nil
+	}
+	if loaded && !r.lastPurge.CompareAndSwap(workspaceID, last, now.Unix()) {
+		return nil
+	}
+	if !loaded {
+		if _, raced := r.lastPurge.LoadOrStore(workspaceID, now.Unix()); raced {
+			return nil
+		}
+	}
+
+	stmt := APIResponseCache.DELETE().WHERE(
+		APIResponseCache.WorkspaceID.EQ(postgres.UUID(workspaceID)).
+			AND(APIResponseCache.ExpiresAt.LT_EQ(postgres.TimestampzT(now))),
+	)
+	if _, err := stmt.ExecContext(ctx, db); err != nil {
+		return fmt.Errorf("failed to delete expired responses: %w", err)
+	}
+	return nil
+}
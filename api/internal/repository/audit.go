@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+
+	"workflow-code-test/api/internal/db/gen/workflow_engine/public/model"
+	. "workflow-code-test/api/internal/db/gen/workflow_engine/public/table"
+	"workflow-code-test/api/internal/models"
+)
+
+// AuditRepository appends to and reads the audit log. There are deliberately no methods to change or remove
+// events, the table rejects that too.
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+// AppendEvent writes an event to the audit log of the context's workspace, filling in its ID, workspace and
+// time
+func (r *AuditRepository) AppendEvent(ctx context.Context, event *models.AuditEvent) error {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	stmt := AuditEvents.INSERT(
+		AuditEvents.WorkspaceID,
+		AuditEvents.Actor,
+		AuditEvents.ActorMethod,
+		AuditEvents.Action,
+		AuditEvents.ResourceType,
+		AuditEvents.ResourceID,
+		AuditEvents.RequestID,
+		AuditEvents.Before,
+		AuditEvents.After,
+	).VALUES(
+		workspaceID,
+		event.Actor,
+		event.ActorMethod,
+		event.Action,
+		event.ResourceType,
+		event.ResourceID,
+		event.RequestID,
+		jsonbOrNull(event.Before),
+		jsonbOrNull(event.After),
+	).RETURNING(
+		AuditEvents.AllColumns,
+	)
+
+	var dest model.AuditEvents
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil {
+		return fmt.Errorf("failed to append audit event: %w", err)
+	}
+
+	*event = auditEventFromModel(dest)
+	return nil
+}
+
+// ListEvents returns the events of the context's workspace matching filter, newest first
+func (r *AuditRepository) ListEvents(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEvent, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	condition := AuditEvents.WorkspaceID.EQ(postgres.UUID(workspaceID))
+	for _, match := range []struct {
+		column postgres.ColumnString
+		value  string
+	}{
+		{AuditEvents.Actor, filter.Actor},
+		{AuditEvents.Action, filter.Action},
+		{AuditEvents.ResourceType, filter.ResourceType},
+		{AuditEvents.ResourceID, filter.ResourceID},
+		{AuditEvents.RequestID, filter.RequestID},
+	} {
+		if match.value != "" {
+			condition = condition.AND(match.column.EQ(postgres.String(match.value)))
+		}
+	}
+	if filter.Since != nil {
+		condition = condition.AND(AuditEvents.OccurredAt.GT_EQ(postgres.TimestampzT(*filter.Since)))
+	}
+	if filter.Until != nil {
+		condition = condition.AND(AuditEvents.OccurredAt.LT(postgres.TimestampzT(*filter.Until)))
+	}
+	if filter.BeforeID > 0 {
+		condition = condition.AND(AuditEvents.ID.LT(postgres.Int(filter.BeforeID)))
+	}
+
+	stmt := postgres.SELECT(
+		AuditEvents.AllColumns,
+	).FROM(
+		AuditEvents,
+	).WHERE(
+		condition,
+	).ORDER_BY(
+		AuditEvents.ID.DESC(),
+	).LIMIT(
+		int64(filter.Limit),
+	)
+
+	var dest []model.AuditEvents
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	events := make([]models.AuditEvent, len(dest))
+	for i, row := range dest {
+		events[i] = auditEventFromModel(row)
+	}
+	return events, nil
+}
+
+// jsonbOrNull returns a JSON document as a JSONB parameter, nil for SQL NULL
+func jsonbOrNull(document []byte) *string {
+	if len(document) == 0 {
+		return nil
+	}
+	value := string(document)
+	return &value
+}
+
+func auditEventFromModel(row model.AuditEvents) models.AuditEvent {
+	event := models.AuditEvent{
+		ID:           row.ID,
+		WorkspaceID:  row.WorkspaceID,
+		OccurredAt:   row.OccurredAt,
+		Actor:        row.Actor,
+		ActorMethod:  row.ActorMethod,
+		Action:       row.Action,
+		ResourceType: row.ResourceType,
+		ResourceID:   row.ResourceID,
+		RequestID:    row.RequestID,
+	}
+	if row.Before != nil {
+		event.Before = []byte(*row.Before)
+	}
+	if row.After != nil {
+		event.After = []byte(*row.After)
+	}
+	return event
+}
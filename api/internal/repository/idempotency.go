@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/db/gen/workflow_engine/public/model"
+	. "workflow-code-test/api/internal/db/gen/workflow_engine/public/table"
+	"workflow-code-test/api/internal/models"
+)
+
+// idempotencyPurgeInterval is how often Reserve removes expired idempotency keys
+const idempotencyPurgeInterval = 10 * time.Minute
+
+// IdempotencyRepository stores executions requested with an idempotency key, scoped to the context's
+// workspace and the caller's subject
+type IdempotencyRepository struct {
+	db *sql.DB
+
+	lastPurge sync.Map // workspace ID -> unix seconds
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+// Reserve claims a key for a running execution until lockedUntil under the record's reservation, returning
+// false if the key is already claimed or holds a response that hasn't expired
+func (r *IdempotencyRepository) Reserve(ctx context.Context, subject string, record *models.IdempotencyRecord, now, lockedUntil time.Time) (bool, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	if err := r.purgeExpired(ctx, db, workspaceID, now); err != nil {
+		return false, err
+	}
+
+	// Expired keys are taken over in the same statement, so only one of several concurrent requests wins
+	stmt := ExecutionIdempotencyKeys.INSERT(
+		ExecutionIdempotencyKeys.WorkspaceID,
+		ExecutionIdempotencyKeys.Subject,
+		ExecutionIdempotencyKeys.Key,
+		ExecutionIdempotencyKeys.Fingerprint,
+		ExecutionIdempotencyKeys.WorkflowID,
+		ExecutionIdempotencyKeys.CreatedAt,
+		ExecutionIdempotencyKeys.ExpiresAt,
+		ExecutionIdempotencyKeys.Reservation,
+	).VALUES(
+		workspaceID,
+		subject,
+		record.Key,
+		record.Fingerprint,
+		record.WorkflowID,
+		now,
+		lockedUntil,
+		record.Reservation,
+	).ON_CONFLICT(ExecutionIdempotencyKeys.WorkspaceID, ExecutionIdempotencyKeys.Subject, ExecutionIdempotencyKeys.Key).DO_UPDATE(
+		postgres.SET(
+			ExecutionIdempotencyKeys.Fingerprint.SET(ExecutionIdempotencyKeys.EXCLUDED.Fingerprint),
+			ExecutionIdempotencyKeys.WorkflowID.SET(ExecutionIdempotencyKeys.EXCLUDED.WorkflowID),
+			ExecutionIdempotencyKeys.Response.SET(postgres.StringExp(postgres.NULL)),
+			ExecutionIdempotencyKeys.CreatedAt.SET(ExecutionIdempotencyKeys.EXCLUDED.CreatedAt),
+			ExecutionIdempotencyKeys.ExpiresAt.SET(ExecutionIdempotencyKeys.EXCLUDED.ExpiresAt),
+			ExecutionIdempotencyKeys.Reservation.SET(ExecutionIdempotencyKeys.EXCLUDED.Reservation),
+		).WHERE(
+			ExecutionIdempotencyKeys.ExpiresAt.LT_EQ(postgres.TimestampzT(now)),
+		),
+	)
+
+	result, err := stmt.ExecContext(ctx, db)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// Get returns the execution stored under a key, nil if there is none or it expired before now
+func (r *IdempotencyRepository) Get(ctx context.Context, subject, key string, now time.Time) (*models.IdempotencyRecord, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	stmt := postgres.SELECT(
+		ExecutionIdempotencyKeys.AllColumns,
+	).FROM(
+		ExecutionIdempotencyKeys,
+	).WHERE(
+		ExecutionIdempotencyKeys.WorkspaceID.EQ(postgres.UUID(workspaceID)).
+			AND(ExecutionIdempotencyKeys.Subject.EQ(postgres.String(subject))).
+			AND(ExecutionIdempotencyKeys.Key.EQ(postgres.String(key))).
+			AND(ExecutionIdempotencyKeys.ExpiresAt.GT(postgres.TimestampzT(now))),
+	)
+
+	var dest model.ExecutionIdempotencyKeys
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	record := &models.IdempotencyRecord{
+		Key:         dest.Key,
+		Fingerprint: dest.Fingerprint,
+		WorkflowID:  dest.WorkflowID,
+		CreatedAt:   dest.CreatedAt,
+		ExpiresAt:   dest.ExpiresAt,
+		Reservation: dest.Reservation,
+	}
+	if dest.Response != nil {
+		record.Response = []byte(*dest.Response)
+	}
+	return record, nil
+}
+
+// Complete stores the response of a reserved key's execution, replayed until expiresAt. Nothing is stored if
+// the key was taken over by another reservation in the meantime.
+func (r *IdempotencyRepository) Complete(ctx context.Context, subject, key string, reservation uuid.UUID, response []byte, expiresAt time.Time) error {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	stmt := ExecutionIdempotencyKeys.UPDATE(
+		ExecutionIdempotencyKeys.Response,
+		ExecutionIdempotencyKeys.ExpiresAt,
+	).SET(
+		string(response),
+		expiresAt,
+	).WHERE(
+		idempotencyReservationIs(workspaceID, subject, key, reservation),
+	)
+
+	if _, err := stmt.ExecContext(ctx, db); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release removes a reserved key whose execution didn't produce a response, so a retry runs it again. A key
+// taken over by another reservation is left alone.
+func (r *IdempotencyRepository) Release(ctx context.Context, subject, key string, reservation uuid.UUID) error {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	stmt := ExecutionIdempotencyKeys.DELETE().WHERE(
+		idempotencyReservationIs(workspaceID, subject, key, reservation),
+	)
+
+	if _, err := stmt.ExecContext(ctx, db); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// purgeExpired removes a workspace's expired keys now and then, so the table doesn't keep growing
+func (r *IdempotencyRepository) purgeExpired(ctx context.Context, db tenantDB, workspaceID uuid.UUID, now time.Time) error {
+	last, loaded := r.lastPurge.Load(workspaceID)
+	if loaded && now.Unix()-last.(int64) < int64(idempotencyPurgeInterval.Seconds()) {
+		return nil
+	}
+	if loaded && !r.lastPurge.CompareAndSwap(workspaceID, last, now.Unix()) {
+		return nil
+	}
+	if !loaded {
+		if _, raced := r.lastPurge.LoadOrStore(workspaceID, now.Unix()); raced {
+			return nil
+		}
+	}
+
+	stmt := ExecutionIdempotencyKeys.DELETE().WHERE(
+		ExecutionIdempotencyKeys.WorkspaceID.EQ(postgres.UUID(workspaceID)).
+			AND(ExecutionIdempotencyKeys.ExpiresAt.LT_EQ(postgres.TimestampzT(now))),
+	)
+	if _, err := stmt.ExecContext(ctx, db); err != nil {
+		return fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return nil
+}
+
+// idempotencyReservationIs matches a key while it is still held, without a response, by the given reservation
+func idempotencyReservationIs(workspaceID uuid.UUID, subject, key string, reservation uuid.UUID) postgres.BoolExpression {
+	return ExecutionIdempotencyKeys.WorkspaceID.EQ(postgres.UUID(workspaceID)).
+		AND(ExecutionIdempotencyKeys.Subject.EQ(postgres.String(subject))).
+		AND(ExecutionIdempotencyKeys.Key.EQ(postgres.String(key))).
+		AND(ExecutionIdempotencyKeys.Reservation.EQ(postgres.UUID(reservation))).
+		AND(ExecutionIdempotencyKeys.Response.IS_NULL())
+}
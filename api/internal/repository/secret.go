@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/lib/pq"
+
+	"workflow-code-test/api/internal/db/gen/workflow_engine/public/model"
+	. "workflow-code-test/api/internal/db/gen/workflow_engine/public/table"
+	"workflow-code-test/api/internal/models"
+)
+
+// ErrSecretExists is returned when creating a secret whose name is taken
+var ErrSecretExists = errors.New("secret already exists")
+
+// SecretRepository stores encrypted secrets, every query is scoped to the context's workspace
+type SecretRepository struct {
+	db *sql.DB
+}
+
+func NewSecretRepository(db *sql.DB) *SecretRepository {
+	return &SecretRepository{
+		db: db,
+	}
+}
+
+// ListSecrets returns every secret without its encrypted value, sorted by name
+func (r *SecretRepository) ListSecrets(ctx context.Context) ([]models.Secret, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	stmt := postgres.SELECT(
+		Secrets.Name,
+		Secrets.Description,
+		Secrets.KeyID,
+		Secrets.CreatedAt,
+		Secrets.UpdatedAt,
+	).FROM(
+		Secrets,
+	).WHERE(
+		Secrets.WorkspaceID.EQ(postgres.UUID(workspaceID)),
+	).ORDER_BY(
+		Secrets.Name.ASC(),
+	)
+
+	var dest []model.Secrets
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	secrets := make([]models.Secret, len(dest))
+	for i, row := range dest {
+		secrets[i] = secretFromModel(row)
+	}
+	return secrets, nil
+}
+
+// GetSecret returns a secret with its encrypted value, nil if there is none by that name
+func (r *SecretRepository) GetSecret(ctx context.Context, name string) (*models.EncryptedSecret, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	stmt := postgres.SELECT(
+		Secrets.AllColumns,
+	).FROM(
+		Secrets,
+	).WHERE(
+		Secrets.WorkspaceID.EQ(postgres.UUID(workspaceID)).
+			AND(Secrets.Name.EQ(postgres.String(name))),
+	)
+
+	var dest model.Secrets
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	return encryptedSecretFromModel(dest)
+}
+
+// ListEncryptedSecrets returns every secret not encrypted with the given master key or not bound to its
+// workspace, with its encrypted value
+func (r *SecretRepository) ListEncryptedSecrets(ctx context.Context, excludeKeyID string) ([]models.EncryptedSecret, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	stmt := postgres.SELECT(
+		Secrets.AllColumns,
+	).FROM(
+		Secrets,
+	).WHERE(
+		Secrets.WorkspaceID.EQ(postgres.UUID(workspaceID)).
+			AND(Secrets.KeyID.NOT_EQ(postgres.String(excludeKeyID)).OR(Secrets.WorkspaceBound.IS_FALSE())),
+	).ORDER_BY(
+		Secrets.Name.ASC(),
+	)
+
+	var dest []model.Secrets
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	secrets := make([]models.EncryptedSecret, 0, len(dest))
+	for _, row := range dest {
+		secret, err := encryptedSecretFromModel(row)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, *secret)
+	}
+	return secrets, nil
+}
+
+// CreateSecret inserts a new secret, returning ErrSecretExists if the name is taken
+func (r *SecretRepository) CreateSecret(ctx context.Context, secret *models.EncryptedSecret) error {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	now := time.Now()
+	stmt := Secrets.INSERT(
+		Secrets.AllColumns,
+	).VALUES(
+		secret.Name,
+		secret.Description,
+		base64.StdEncoding.EncodeToString(secret.Ciphertext),
+		base64.StdEncoding.EncodeToString(secret.WrappedKey),
+		secret.KeyID,
+		now,
+		now,
+		workspaceID,
+		secret.WorkspaceBound,
+	)
+
+	if _, err := stmt.ExecContext(ctx, db); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+			return ErrSecretExists
+		}
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+	return nil
+}
+
+// UpdateSecret replaces the description and encrypted value of a secret, returning false if it doesn't exist
+func (r *SecretRepository) UpdateSecret(ctx context.Context, secret *models.EncryptedSecret) (bool, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	stmt := Secrets.UPDATE(
+		Secrets.Description,
+		Secrets.Ciphertext,
+		Secrets.WrappedKey,
+		Secrets.KeyID,
+		Secrets.WorkspaceBound,
+		Secrets.UpdatedAt,
+	).SET(
+		secret.Description,
+		base64.StdEncoding.EncodeToString(secret.Ciphertext),
+		base64.StdEncoding.EncodeToString(secret.WrappedKey),
+		secret.KeyID,
+		secret.WorkspaceBound,
+		time.Now(),
+	).WHERE(
+		Secrets.WorkspaceID.EQ(postgres.UUID(workspaceID)).
+			AND(Secrets.Name.EQ(postgres.String(secret.Name))),
+	)
+
+	result, err := stmt.ExecContext(ctx, db)
+	if err != nil {
+		return false, fmt.Errorf("failed to update secret: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// RewrapSecret stores a secret's encrypted value rewrapped with another master key or resealed bound to its
+// workspace, leaving updated_at alone as the value didn't change
+func (r *SecretRepository) RewrapSecret(ctx context.Context, secret *models.EncryptedSecret) error {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	stmt := Secrets.UPDATE(
+		Secrets.Ciphertext,
+		Secrets.WrappedKey,
+		Secrets.KeyID,
+		Secrets.WorkspaceBound,
+	).SET(
+		base64.StdEncoding.EncodeToString(secret.Ciphertext),
+		base64.StdEncoding.EncodeToString(secret.WrappedKey),
+		secret.KeyID,
+		secret.WorkspaceBound,
+	).WHERE(
+		Secrets.WorkspaceID.EQ(postgres.UUID(workspaceID)).
+			AND(Secrets.Name.EQ(postgres.String(secret.Name))),
+	)
+
+	if _, err := stmt.ExecContext(ctx, db); err != nil {
+		return fmt.Errorf("failed to rewrap secret: %w", err)
+	}
+	return nil
+}
+
+// DeleteSecret removes a secret, returning false if it doesn't exist
+func (r *SecretRepository) DeleteSecret(ctx context.Context, name string) (bool, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	stmt := Secrets.DELETE().WHERE(
+		Secrets.WorkspaceID.EQ(postgres.UUID(workspaceID)).
+			AND(Secrets.Name.EQ(postgres.String(name))),
+	)
+
+	result, err := stmt.ExecContext(ctx, db)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete secret: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func secretFromModel(row model.Secrets) models.Secret {
+	return models.Secret{
+		Name:        row.Name,
+		Description: row.Description,
+		KeyID:       row.KeyID,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+func encryptedSecretFromModel(row model.Secrets) (*models.EncryptedSecret, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(row.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ciphertext of secret %s: %w", row.Name, err)
+	}
+	wrappedKey, err := base64.StdEncoding.DecodeString(row.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode wrapped key of secret %s: %w", row.Name, err)
+	}
+
+	return &models.EncryptedSecret{
+		Secret:         secretFromModel(row),
+		Ciphertext:     ciphertext,
+		WrappedKey:     wrappedKey,
+		WorkspaceBound: row.WorkspaceBound,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/tenant"
+)
+
+// rowLevelSecurity is whether connections are scoped to the context's workspace for Postgres row level
+// security, see EnableRowLevelSecurity
+var rowLevelSecurity atomic.Bool
+
+// EnableRowLevelSecurity makes repositories set app.workspace_id on every connection they run tenant scoped
+// queries on, which the workspace_isolation policies filter rows with. The policies only apply to database
+// roles that don't own the tables.
+func EnableRowLevelSecurity(enabled bool) {
+	rowLevelSecurity.Store(enabled)
+}
+
+// tenantDB is what tenant scoped queries and transactions run on, the pool or a single connection
+type tenantDB interface {
+	qrm.Queryable
+	qrm.Executable
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// tenantConn returns the workspace the context acts in and what to query it on. With row level security
+// enabled that's a connection with app.workspace_id set, which release returns to the pool; release must
+// always be called.
+func tenantConn(ctx context.Context, db *sql.DB) (tenantDB, uuid.UUID, func(), error) {
+	workspaceID, err := tenant.Workspace(ctx)
+	if err != nil {
+		return nil, uuid.Nil, nil, err
+	}
+	if !rowLevelSecurity.Load() {
+		return db, workspaceID, func() {}, nil
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, uuid.Nil, nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT set_config('app.workspace_id', $1, false)", workspaceID.String()); err != nil {
+		conn.Close()
+		return nil, uuid.Nil, nil, fmt.Errorf("failed to set workspace: %w", err)
+	}
+
+	release := func() {
+		// Pooled connections must not keep the workspace for queries that aren't tenant scoped
+		if _, err := conn.ExecContext(context.Background(), "SELECT set_config('app.workspace_id', '', false)"); err != nil {
+			slog.Warn("Failed to reset connection workspace, discarding it", "error", err)
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return conn, workspaceID, release, nil
+}
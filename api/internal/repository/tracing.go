@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"workflow-code-test/api/internal/tracing"
+)
+
+// repositoryPackage is the import path of this package, to find the repository method that ran a query by
+var repositoryPackage = reflect.TypeOf(WorkflowRepository{}).PkgPath()
+
+// EnableQueryTracing makes every jet query report a span, named after the repository method that ran it,
+// under the span of the request or execution it ran for
+func EnableQueryTracing() {
+	postgres.SetQueryLogger(traceQuery)
+}
+
+// traceQuery records a finished query as a span. Jet reports queries once they're done, so the span is
+// started back dated by the query's duration. Only the statement is recorded, never its arguments.
+func traceQuery(ctx context.Context, info postgres.QueryInfo) {
+	// Queries outside a traced request, such as the cache purge, aren't worth a trace of their own
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return
+	}
+
+	end := time.Now()
+	method := queryCaller()
+	query, _ := info.Statement.Sql()
+
+	_, span := tracing.Tracer().Start(ctx, "db "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(end.Add(-info.Duration)),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBQueryText(query),
+			semconv.DBResponseReturnedRows(int(info.RowsProcessed)),
+			semconv.CodeFunctionName(method),
+		),
+	)
+	// A missing row is an answer rather than a failed query
+	if !errors.Is(info.Err, qrm.ErrNoRows) {
+		tracing.RecordError(span, info.Err)
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+// queryCaller returns the repository method running the current query, such as
+// WorkflowRepository.GetWorkflow, "query" if it wasn't run by a repository
+func queryCaller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, repositoryPackage+"."); ok && !strings.HasPrefix(name, "traceQuery") {
+			// (*WorkflowRepository).GetWorkflow.func1 -> WorkflowRepository.GetWorkflow
+			name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+			if parts := strings.SplitN(name, ".", 3); len(parts) >= 2 {
+				return parts[0] + "." + parts[1]
+			}
+			return name
+		}
+		if !more {
+			return "query"
+		}
+	}
+}
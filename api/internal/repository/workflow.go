@@ -3,9 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
 	"github.com/google/uuid"
 
 	"workflow-code-test/api/internal/db/gen/workflow_engine/public/model"
@@ -13,6 +15,11 @@ import (
 	"workflow-code-test/api/internal/models"
 )
 
+// ErrWorkflowIDTaken is returned when saving a workflow whose ID is used by a workflow of another workspace
+var ErrWorkflowIDTaken = errors.New("workflow ID is taken")
+
+// WorkflowRepository stores workflows with their nodes, edges and permissions. Every query is scoped to the
+// context's workspace.
 type WorkflowRepository struct {
 	db *sql.DB
 }
@@ -25,6 +32,12 @@ func NewWorkflowRepository(db *sql.DB) *WorkflowRepository {
 
 // GetWorkflow retrieves a workflow by ID
 func (r *WorkflowRepository) GetWorkflow(ctx context.Context, workflowID uuid.UUID) (*models.Workflow, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	stmt := postgres.SELECT(
 		Workflows.ID,
 		Workflows.Name,
@@ -33,11 +46,12 @@ func (r *WorkflowRepository) GetWorkflow(ctx context.Context, workflowID uuid.UU
 	).FROM(
 		Workflows,
 	).WHERE(
-		Workflows.ID.EQ(postgres.UUID(workflowID)),
+		Workflows.ID.EQ(postgres.UUID(workflowID)).
+			AND(Workflows.WorkspaceID.EQ(postgres.UUID(workspaceID))),
 	)
 
 	var dest model.Workflows
-	err := stmt.QueryContext(ctx, r.db, &dest)
+	err = stmt.QueryContext(ctx, db, &dest)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("workflow not found: %s", workflowID)
@@ -62,6 +76,12 @@ func (r *WorkflowRepository) GetWorkflow(ctx context.Context, workflowID uuid.UU
 
 // GetNodesByWorkflow retrieves all nodes for a given workflow
 func (r *WorkflowRepository) GetNodesByWorkflow(ctx context.Context, workflowID uuid.UUID) ([]models.Node, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	stmt := postgres.SELECT(
 		Nodes.ID,
 		Nodes.Type,
@@ -74,13 +94,14 @@ func (r *WorkflowRepository) GetNodesByWorkflow(ctx context.Context, workflowID
 	).FROM(
 		Nodes,
 	).WHERE(
-		Nodes.WorkflowID.EQ(postgres.UUID(workflowID)),
+		Nodes.WorkflowID.EQ(postgres.UUID(workflowID)).
+			AND(workflowInWorkspace(Nodes.WorkflowID, workspaceID)),
 	).ORDER_BY(
 		Nodes.CreatedAt.ASC(),
 	)
 
 	var dbNodes []model.Nodes
-	err := stmt.QueryContext(ctx, r.db, &dbNodes)
+	err = stmt.QueryContext(ctx, db, &dbNodes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query nodes: %w", err)
 	}
@@ -118,6 +139,12 @@ func (r *WorkflowRepository) GetNodesByWorkflow(ctx context.Context, workflowID
 
 // GetEdgesByWorkflow retrieves all edges for a given workflow
 func (r *WorkflowRepository) GetEdgesByWorkflow(ctx context.Context, workflowID uuid.UUID) ([]models.Edge, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	stmt := postgres.SELECT(
 		Edges.ID,
 		Edges.Source,
@@ -137,13 +164,14 @@ func (r *WorkflowRepository) GetEdgesByWorkflow(ctx context.Context, workflowID
 	).FROM(
 		Edges,
 	).WHERE(
-		Edges.WorkflowID.EQ(postgres.UUID(workflowID)),
+		Edges.WorkflowID.EQ(postgres.UUID(workflowID)).
+			AND(workflowInWorkspace(Edges.WorkflowID, workspaceID)),
 	).ORDER_BY(
 		Edges.CreatedAt.ASC(),
 	)
 
 	var dbEdges []model.Edges
-	err := stmt.QueryContext(ctx, r.db, &dbEdges)
+	err = stmt.QueryContext(ctx, db, &dbEdges)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query edges: %w", err)
 	}
@@ -199,36 +227,71 @@ func (r *WorkflowRepository) GetEdgesByWorkflow(ctx context.Context, workflowID
 	return edges, nil
 }
 
-// SaveWorkflow creates or updates a workflow and its associated nodes and edges
-func (r *WorkflowRepository) SaveWorkflow(ctx context.Context, workflow *models.Workflow, nodes []models.Node, edges []models.Edge) error {
+// SaveWorkflow creates or updates a workflow and its associated nodes and edges, returning true if it was
+// created. A new workflow is owned by subject, when set. An existing one is locked until the transaction ends
+// and, when authorizeUpdate is set, only updated if it accepts subject's role on the workflow (empty if it has
+// none), so concurrent saves can't both create it. Returns ErrWorkflowIDTaken if another workspace has a
+// workflow with the same ID.
+func (r *WorkflowRepository) SaveWorkflow(ctx context.Context, workflow *models.Workflow, nodes []models.Node, edges []models.Edge, subject string, authorizeUpdate func(role string) error) (bool, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
 	// Start a database transaction
-	tx, err := r.db.BeginTx(ctx, nil)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Insert or update workflow using UPSERT
-	workflowStmt := Workflows.INSERT(
+	// Whether the workflow is new is decided by the insert, a concurrent insert of the same ID waits for this
+	// transaction and then finds the workflow existing
+	insertStmt := Workflows.INSERT(
 		Workflows.ID,
 		Workflows.Name,
 		Workflows.CreatedAt,
 		Workflows.UpdatedAt,
+		Workflows.WorkspaceID,
 	).VALUES(
 		workflow.ID,
 		workflow.Name,
 		postgres.NOW(),
 		postgres.NOW(),
-	).ON_CONFLICT(Workflows.ID).DO_UPDATE(
-		postgres.SET(
-			Workflows.Name.SET(postgres.String(workflow.Name)),
-			Workflows.UpdatedAt.SET(postgres.NOW()),
-		),
-	)
+		workspaceID,
+	).ON_CONFLICT(Workflows.ID).DO_NOTHING()
 
-	_, err = workflowStmt.ExecContext(ctx, tx)
+	result, err := insertStmt.ExecContext(ctx, tx)
 	if err != nil {
-		return fmt.Errorf("failed to save workflow: %w", err)
+		return false, fmt.Errorf("failed to save workflow: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to save workflow: %w", err)
+	}
+	created := rows > 0
+
+	if created {
+		if subject != "" {
+			ownerStmt := WorkflowPermissions.INSERT(
+				WorkflowPermissions.WorkflowID,
+				WorkflowPermissions.Subject,
+				WorkflowPermissions.Role,
+				WorkflowPermissions.GrantedBy,
+			).VALUES(
+				workflow.ID,
+				subject,
+				models.RoleOwner,
+				subject,
+			).ON_CONFLICT(WorkflowPermissions.WorkflowID, WorkflowPermissions.Subject).DO_NOTHING()
+
+			if _, err := ownerStmt.ExecContext(ctx, tx); err != nil {
+				return false, fmt.Errorf("failed to grant workflow owner: %w", err)
+			}
+		}
+	} else if err := updateWorkflow(ctx, tx, workflow, workspaceID, subject, authorizeUpdate); err != nil {
+		return false, err
 	}
 
 	deleteEdgesStmt := Edges.DELETE().WHERE(
@@ -236,7 +299,7 @@ func (r *WorkflowRepository) SaveWorkflow(ctx context.Context, workflow *models.
 	)
 	_, err = deleteEdgesStmt.ExecContext(ctx, tx)
 	if err != nil {
-		return fmt.Errorf("failed to delete existing edges: %w", err)
+		return false, fmt.Errorf("failed to delete existing edges: %w", err)
 	}
 
 	deleteNodesStmt := Nodes.DELETE().WHERE(
@@ -244,7 +307,7 @@ func (r *WorkflowRepository) SaveWorkflow(ctx context.Context, workflow *models.
 	)
 	_, err = deleteNodesStmt.ExecContext(ctx, tx)
 	if err != nil {
-		return fmt.Errorf("failed to delete existing nodes: %w", err)
+		return false, fmt.Errorf("failed to delete existing nodes: %w", err)
 	}
 
 	if len(nodes) > 0 {
@@ -264,7 +327,7 @@ func (r *WorkflowRepository) SaveWorkflow(ctx context.Context, workflow *models.
 		for _, node := range nodes {
 			// Ensure RawData is up to date
 			if err := node.UpdateRawDataFromData(); err != nil {
-				return fmt.Errorf("failed to update raw data for node %s: %w", node.ID, err)
+				return false, fmt.Errorf("failed to update raw data for node %s: %w", node.ID, err)
 			}
 
 			insertNodesStmt = insertNodesStmt.VALUES(
@@ -281,7 +344,7 @@ func (r *WorkflowRepository) SaveWorkflow(ctx context.Context, workflow *models.
 
 		_, err = insertNodesStmt.ExecContext(ctx, tx)
 		if err != nil {
-			return fmt.Errorf("failed to insert nodes: %w", err)
+			return false, fmt.Errorf("failed to insert nodes: %w", err)
 		}
 	}
 
@@ -329,9 +392,86 @@ func (r *WorkflowRepository) SaveWorkflow(ctx context.Context, workflow *models.
 
 		_, err = insertEdgesStmt.ExecContext(ctx, tx)
 		if err != nil {
-			return fmt.Errorf("failed to insert edges: %w", err)
+			return false, fmt.Errorf("failed to insert edges: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit workflow: %w", err)
+	}
+	return created, nil
+}
+
+// updateWorkflow renames an existing workflow within a save, after locking it and checking the saving subject
+// may update it
+func updateWorkflow(ctx context.Context, tx *sql.Tx, workflow *models.Workflow, workspaceID uuid.UUID, subject string, authorizeUpdate func(role string) error) error {
+	lockStmt := postgres.SELECT(
+		Workflows.WorkspaceID,
+	).FROM(
+		Workflows,
+	).WHERE(
+		Workflows.ID.EQ(postgres.UUID(workflow.ID)),
+	).FOR(
+		postgres.UPDATE(),
+	)
+
+	var existing model.Workflows
+	if err := lockStmt.QueryContext(ctx, tx, &existing); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			// Row level security hides workflows of other workspaces
+			return ErrWorkflowIDTaken
+		}
+		return fmt.Errorf("failed to lock workflow: %w", err)
+	}
+	if existing.WorkspaceID != workspaceID {
+		// A workflow of another workspace is never overwritten
+		return ErrWorkflowIDTaken
+	}
+
+	if authorizeUpdate != nil {
+		roleStmt := postgres.SELECT(
+			WorkflowPermissions.AllColumns,
+		).FROM(
+			WorkflowPermissions,
+		).WHERE(
+			WorkflowPermissions.WorkflowID.EQ(postgres.UUID(workflow.ID)).
+				AND(WorkflowPermissions.Subject.EQ(postgres.String(subject))),
+		)
+
+		var permission model.WorkflowPermissions
+		if err := roleStmt.QueryContext(ctx, tx, &permission); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+			return fmt.Errorf("failed to get workflow role: %w", err)
+		}
+		if err := authorizeUpdate(permission.Role); err != nil {
+			return err
 		}
 	}
 
-	return tx.Commit()
+	updateStmt := Workflows.UPDATE(
+		Workflows.Name,
+		Workflows.UpdatedAt,
+	).SET(
+		postgres.String(workflow.Name),
+		postgres.NOW(),
+	).WHERE(
+		Workflows.ID.EQ(postgres.UUID(workflow.ID)),
+	)
+	if _, err := updateStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to save workflow: %w", err)
+	}
+	return nil
+}
+
+// workflowInWorkspace returns a condition matching rows whose workflow belongs to the workspace
+func workflowInWorkspace(workflowID postgres.ColumnString, workspaceID uuid.UUID) postgres.BoolExpression {
+	return postgres.EXISTS(
+		postgres.SELECT(
+			Workflows.ID,
+		).FROM(
+			Workflows,
+		).WHERE(
+			Workflows.ID.EQ(workflowID).
+				AND(Workflows.WorkspaceID.EQ(postgres.UUID(workspaceID))),
+		),
+	)
 }
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/db/gen/workflow_engine/public/model"
+	. "workflow-code-test/api/internal/db/gen/workflow_engine/public/table"
+	"workflow-code-test/api/internal/models"
+)
+
+// WorkflowExists returns true if a workflow with the given ID is stored
+func (r *WorkflowRepository) WorkflowExists(ctx context.Context, workflowID uuid.UUID) (bool, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	stmt := postgres.SELECT(
+		Workflows.ID,
+	).FROM(
+		Workflows,
+	).WHERE(
+		Workflows.ID.EQ(postgres.UUID(workflowID)).
+			AND(Workflows.WorkspaceID.EQ(postgres.UUID(workspaceID))),
+	)
+
+	var dest model.Workflows
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check workflow: %w", err)
+	}
+	return true, nil
+}
+
+// ListWorkflows returns the workflows subject has a role on, with that role, sorted by name. With all set
+// every workflow is returned, those subject has no role on with an empty role.
+func (r *WorkflowRepository) ListWorkflows(ctx context.Context, subject string, all bool) ([]models.WorkflowSummary, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	permissionJoin := WorkflowPermissions.WorkflowID.EQ(Workflows.ID).
+		AND(WorkflowPermissions.Subject.EQ(postgres.String(subject)))
+
+	from := Workflows.INNER_JOIN(WorkflowPermissions, permissionJoin)
+	if all {
+		from = Workflows.LEFT_JOIN(WorkflowPermissions, permissionJoin)
+	}
+
+	stmt := postgres.SELECT(
+		Workflows.ID,
+		Workflows.Name,
+		Workflows.UpdatedAt,
+		WorkflowPermissions.AllColumns,
+	).FROM(
+		from,
+	).WHERE(
+		Workflows.WorkspaceID.EQ(postgres.UUID(workspaceID)),
+	).ORDER_BY(
+		Workflows.Name.ASC(),
+		Workflows.ID.ASC(),
+	)
+
+	var dest []struct {
+		model.Workflows
+		Permission *model.WorkflowPermissions
+	}
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+
+	workflows := make([]models.WorkflowSummary, len(dest))
+	for i, row := range dest {
+		workflow := models.Workflow{ID: row.ID, Name: row.Name}
+		if row.UpdatedAt != nil {
+			workflow.UpdatedAt = *row.UpdatedAt
+		}
+		workflows[i] = models.WorkflowSummary{
+			ID:      workflow.ID,
+			Name:    workflow.Name,
+			Version: workflow.Version(),
+		}
+		if row.Permission != nil {
+			workflows[i].Role = row.Permission.Role
+		}
+	}
+	return workflows, nil
+}
+
+// GetRole returns the role subject has on a workflow, empty if it has none
+func (r *WorkflowRepository) GetRole(ctx context.Context, workflowID uuid.UUID, subject string) (string, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	stmt := postgres.SELECT(
+		WorkflowPermissions.AllColumns,
+	).FROM(
+		WorkflowPermissions,
+	).WHERE(
+		WorkflowPermissions.WorkflowID.EQ(postgres.UUID(workflowID)).
+			AND(WorkflowPermissions.Subject.EQ(postgres.String(subject))).
+			AND(workflowInWorkspace(WorkflowPermissions.WorkflowID, workspaceID)),
+	)
+
+	var dest model.WorkflowPermissions
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get workflow role: %w", err)
+	}
+	return dest.Role, nil
+}
+
+// ListPermissions returns every role granted on a workflow, sorted by subject
+func (r *WorkflowRepository) ListPermissions(ctx context.Context, workflowID uuid.UUID) ([]models.WorkflowPermission, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	stmt := postgres.SELECT(
+		WorkflowPermissions.AllColumns,
+	).FROM(
+		WorkflowPermissions,
+	).WHERE(
+		WorkflowPermissions.WorkflowID.EQ(postgres.UUID(workflowID)).
+			AND(workflowInWorkspace(WorkflowPermissions.WorkflowID, workspaceID)),
+	).ORDER_BY(
+		WorkflowPermissions.Subject.ASC(),
+	)
+
+	var dest []model.WorkflowPermissions
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, fmt.Errorf("failed to list workflow permissions: %w", err)
+	}
+
+	permissions := make([]models.WorkflowPermission, len(dest))
+	for i, row := range dest {
+		permissions[i] = workflowPermissionFromModel(row)
+	}
+	return permissions, nil
+}
+
+// SetPermission grants a subject a role on a workflow, replacing the role it had. Callers must have checked
+// the workflow is in the context's workspace.
+func (r *WorkflowRepository) SetPermission(ctx context.Context, permission *models.WorkflowPermission) error {
+	db, _, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	stmt := WorkflowPermissions.INSERT(
+		WorkflowPermissions.WorkflowID,
+		WorkflowPermissions.Subject,
+		WorkflowPermissions.Role,
+		WorkflowPermissions.GrantedBy,
+	).VALUES(
+		permission.WorkflowID,
+		permission.Subject,
+		permission.Role,
+		permission.GrantedBy,
+	).ON_CONFLICT(WorkflowPermissions.WorkflowID, WorkflowPermissions.Subject).DO_UPDATE(
+		postgres.SET(
+			WorkflowPermissions.Role.SET(WorkflowPermissions.EXCLUDED.Role),
+			WorkflowPermissions.GrantedBy.SET(WorkflowPermissions.EXCLUDED.GrantedBy),
+		),
+	).RETURNING(
+		WorkflowPermissions.AllColumns,
+	)
+
+	var dest model.WorkflowPermissions
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil {
+		return fmt.Errorf("failed to set workflow permission: %w", err)
+	}
+
+	*permission = workflowPermissionFromModel(dest)
+	return nil
+}
+
+// DeletePermission removes a subject's role on a workflow, returning false if it had none
+func (r *WorkflowRepository) DeletePermission(ctx context.Context, workflowID uuid.UUID, subject string) (bool, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	stmt := WorkflowPermissions.DELETE().WHERE(
+		WorkflowPermissions.WorkflowID.EQ(postgres.UUID(workflowID)).
+			AND(WorkflowPermissions.Subject.EQ(postgres.String(subject))).
+			AND(workflowInWorkspace(WorkflowPermissions.WorkflowID, workspaceID)),
+	)
+
+	result, err := stmt.ExecContext(ctx, db)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete workflow permission: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func workflowPermissionFromModel(row model.WorkflowPermissions) models.WorkflowPermission {
+	return models.WorkflowPermission{
+		WorkflowID: row.WorkflowID,
+		Subject:    row.Subject,
+		Role:       row.Role,
+		GrantedBy:  row.GrantedBy,
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+	}
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"workflow-code-test/api/internal/db/gen/workflow_engine/public/model"
+	. "workflow-code-test/api/internal/db/gen/workflow_engine/public/table"
+	"workflow-code-test/api/internal/models"
+)
+
+// ErrWorkspaceExists is returned when creating a workspace whose slug is taken
+var ErrWorkspaceExists = errors.New("workspace already exists")
+
+// WorkspaceRepository stores workspaces and counts their executions per day
+type WorkspaceRepository struct {
+	db *sql.DB
+}
+
+func NewWorkspaceRepository(db *sql.DB) *WorkspaceRepository {
+	return &WorkspaceRepository{
+		db: db,
+	}
+}
+
+// ListWorkspaces returns every workspace, sorted by slug
+func (r *WorkspaceRepository) ListWorkspaces(ctx context.Context) ([]models.Workspace, error) {
+	stmt := postgres.SELECT(
+		Workspaces.AllColumns,
+	).FROM(
+		Workspaces,
+	).ORDER_BY(
+		Workspaces.Slug.ASC(),
+	)
+
+	var dest []model.Workspaces
+	if err := stmt.QueryContext(ctx, r.db, &dest); err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	workspaces := make([]models.Workspace, len(dest))
+	for i, row := range dest {
+		workspaces[i] = workspaceFromModel(row)
+	}
+	return workspaces, nil
+}
+
+// GetWorkspace returns a workspace, nil if there is none with that ID
+func (r *WorkspaceRepository) GetWorkspace(ctx context.Context, id uuid.UUID) (*models.Workspace, error) {
+	stmt := postgres.SELECT(
+		Workspaces.AllColumns,
+	).FROM(
+		Workspaces,
+	).WHERE(
+		Workspaces.ID.EQ(postgres.UUID(id)),
+	)
+
+	var dest model.Workspaces
+	if err := stmt.QueryContext(ctx, r.db, &dest); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+
+	workspace := workspaceFromModel(dest)
+	return &workspace, nil
+}
+
+// CreateWorkspace inserts a new workspace, returning ErrWorkspaceExists if the slug is taken
+func (r *WorkspaceRepository) CreateWorkspace(ctx context.Context, workspace *models.Workspace) error {
+	stmt := Workspaces.INSERT(
+		Workspaces.ID,
+		Workspaces.Slug,
+		Workspaces.Name,
+		Workspaces.DailyExecutionQuota,
+	).VALUES(
+		workspace.ID,
+		workspace.Slug,
+		workspace.Name,
+		workspace.DailyExecutionQuota,
+	)
+
+	if _, err := stmt.ExecContext(ctx, r.db); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+			return ErrWorkspaceExists
+		}
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return nil
+}
+
+// UpdateWorkspace replaces the name and quota of a workspace, returning false if it doesn't exist
+func (r *WorkspaceRepository) UpdateWorkspace(ctx context.Context, workspace *models.Workspace) (bool, error) {
+	stmt := Workspaces.UPDATE(
+		Workspaces.Name,
+		Workspaces.DailyExecutionQuota,
+	).SET(
+		workspace.Name,
+		workspace.DailyExecutionQuota,
+	).WHERE(
+		Workspaces.ID.EQ(postgres.UUID(workspace.ID)),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db)
+	if err != nil {
+		return false, fmt.Errorf("failed to update workspace: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// CountExecution counts an execution of the context's workspace on day, unless that would exceed quota.
+// Returns the day's executions and whether this one was counted. A quota of 0 counts every execution.
+func (r *WorkspaceRepository) CountExecution(ctx context.Context, day time.Time, quota int) (int, bool, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return 0, false, err
+	}
+	defer release()
+
+	// Counted and checked in one statement, so concurrent executions can't overshoot the quota
+	increment := postgres.SET(
+		WorkspaceExecutionCounts.Executions.SET(WorkspaceExecutionCounts.Executions.ADD(postgres.Int(1))),
+	)
+	if quota > 0 {
+		increment = increment.WHERE(WorkspaceExecutionCounts.Executions.LT(postgres.Int(int64(quota))))
+	}
+
+	stmt := WorkspaceExecutionCounts.INSERT(
+		WorkspaceExecutionCounts.WorkspaceID,
+		WorkspaceExecutionCounts.Day,
+		WorkspaceExecutionCounts.Executions,
+	).VALUES(
+		workspaceID,
+		postgres.DateT(day),
+		1,
+	).ON_CONFLICT(WorkspaceExecutionCounts.WorkspaceID, WorkspaceExecutionCounts.Day).DO_UPDATE(
+		increment,
+	).RETURNING(
+		WorkspaceExecutionCounts.AllColumns,
+	)
+
+	var dest model.WorkspaceExecutionCounts
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return quota, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to count execution: %w", err)
+	}
+	return int(dest.Executions), true, nil
+}
+
+// GetExecutionCount returns how many executions the context's workspace ran on day
+func (r *WorkspaceRepository) GetExecutionCount(ctx context.Context, day time.Time) (int, error) {
+	db, workspaceID, release, err := tenantConn(ctx, r.db)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	stmt := postgres.SELECT(
+		WorkspaceExecutionCounts.AllColumns,
+	).FROM(
+		WorkspaceExecutionCounts,
+	).WHERE(
+		WorkspaceExecutionCounts.WorkspaceID.EQ(postgres.UUID(workspaceID)).
+			AND(WorkspaceExecutionCounts.Day.EQ(postgres.DateT(day))),
+	)
+
+	var dest model.WorkspaceExecutionCounts
+	if err := stmt.QueryContext(ctx, db, &dest); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get execution count: %w", err)
+	}
+	return int(dest.Executions), nil
+}
+
+func workspaceFromModel(row model.Workspaces) models.Workspace {
+	return models.Workspace{
+		ID:                  row.ID,
+		Slug:                row.Slug,
+		Name:                row.Name,
+		DailyExecutionQuota: int(row.DailyExecutionQuota),
+		CreatedAt:           row.CreatedAt,
+		UpdatedAt:           row.UpdatedAt,
+	}
+}
@@ -0,0 +1,51 @@
+// Package requestid gives every API request an ID, taken from the X-Request-ID header when the caller or a
+// proxy sent one, so audit events and logs can be traced back to the request that caused them.
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// Header is the request and response header carrying the request ID
+const Header = "X-Request-ID"
+
+// MaxLength is the longest request ID accepted from callers
+const MaxLength = 128
+
+// validID matches request IDs accepted from callers, anything else is replaced with a generated ID
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:/+=-]+$`)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the given request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the request ID carried by the context, empty if it has none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Valid returns true if a caller supplied request ID can be used as is
+func Valid(id string) bool {
+	return len(id) <= MaxLength && validID.MatchString(id)
+}
+
+// Middleware puts the request ID in the request context and echoes it in the response, generating one when
+// the request has none or an invalid one
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !Valid(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), id)))
+	})
+}
@@ -0,0 +1,58 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "caller ID is kept", header: "req-123", expected: "req-123"},
+		{name: "missing ID is generated"},
+		{name: "invalid ID is replaced", header: "bad id\n"},
+		{name: "overlong ID is replaced", header: strings.Repeat("a", MaxLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tt.expected != "" && got != tt.expected {
+				t.Errorf("Expected request ID %q, got %q", tt.expected, got)
+			}
+			if tt.expected == "" {
+				if _, err := uuid.Parse(got); err != nil {
+					t.Errorf("Expected a generated UUID, got %q", got)
+				}
+			}
+			if header := rec.Header().Get(Header); header != got {
+				t.Errorf("Expected response header %q, got %q", got, header)
+			}
+		})
+	}
+}
+
+func TestFromContext_Empty(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if id := FromContext(req.Context()); id != "" {
+		t.Errorf("Expected no request ID, got %q", id)
+	}
+}
@@ -0,0 +1,202 @@
+// Package secrets encrypts stored secrets with envelope encryption: every value is encrypted with its own
+// random data key, and the data key is encrypted ("wrapped") with a master key from the environment. Rotating
+// the master key only re-wraps the small data keys, the values themselves are never re-encrypted.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MasterKeySize is the size of master and data keys, AES-256
+const MasterKeySize = 32
+
+// ErrUnknownKey is returned when a secret was wrapped with a master key the keyring doesn't have
+var ErrUnknownKey = errors.New("unknown master key")
+
+// Envelope is an encrypted value along with its wrapped data key
+type Envelope struct {
+	// KeyID names the master key the data key is wrapped with
+	KeyID string
+	// WrappedKey is the data key encrypted with the master key, nonce first
+	WrappedKey []byte
+	// Ciphertext is the value encrypted with the data key, nonce first
+	Ciphertext []byte
+}
+
+// Keyring holds the master keys. New values are always wrapped with the primary key, older keys are kept
+// to open values wrapped before a rotation.
+type Keyring struct {
+	primary string
+	keys    map[string][]byte
+}
+
+// NewKeyring creates a keyring from master keys by ID, primary naming the one new values are wrapped with
+func NewKeyring(primary string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("primary master key '%s' not found", primary)
+	}
+	for id, key := range keys {
+		if id == "" || strings.ContainsAny(id, ":,") {
+			return nil, fmt.Errorf("invalid master key ID '%s'", id)
+		}
+		if len(key) != MasterKeySize {
+			return nil, fmt.Errorf("master key '%s' must be %d bytes, got %d", id, MasterKeySize, len(key))
+		}
+	}
+	return &Keyring{primary: primary, keys: keys}, nil
+}
+
+// ParseKeyring parses master keys written as comma separated "id:base64key" pairs, the first being primary
+func ParseKeyring(spec string) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	var primary string
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid master key entry, expected 'id:base64key'")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key '%s': %w", id, err)
+		}
+		if _, ok := keys[id]; ok {
+			return nil, fmt.Errorf("duplicate master key '%s'", id)
+		}
+		keys[id] = key
+		if primary == "" {
+			primary = id
+		}
+	}
+	if primary == "" {
+		return nil, fmt.Errorf("no master keys given")
+	}
+	return NewKeyring(primary, keys)
+}
+
+// KeyringFromEnv parses the SECRETS_MASTER_KEYS environment variable, nil if it isn't set
+func KeyringFromEnv() (*Keyring, error) {
+	spec := os.Getenv("SECRETS_MASTER_KEYS")
+	if spec == "" {
+		return nil, nil
+	}
+	keyring, err := ParseKeyring(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SECRETS_MASTER_KEYS: %w", err)
+	}
+	return keyring, nil
+}
+
+// PrimaryKeyID returns the ID of the master key new values are wrapped with
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// Seal encrypts a value under a fresh data key. The associated data, e.g. the secret's name, must be given
+// again to open it, so an envelope can't be swapped onto another secret.
+func (k *Keyring) Seal(plaintext, associatedData []byte) (*Envelope, error) {
+	dataKey := make([]byte, MasterKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	ciphertext, err := seal(dataKey, plaintext, associatedData)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := seal(k.keys[k.primary], dataKey, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{KeyID: k.primary, WrappedKey: wrappedKey, Ciphertext: ciphertext}, nil
+}
+
+// Open decrypts an envelope sealed with the same associated data
+func (k *Keyring) Open(envelope *Envelope, associatedData []byte) ([]byte, error) {
+	dataKey, err := k.unwrap(envelope, associatedData)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(dataKey, envelope.Ciphertext, associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Rewrap re-encrypts the data key of an envelope with the primary master key, returning false if it
+// already was. The value's ciphertext is left as is.
+func (k *Keyring) Rewrap(envelope *Envelope, associatedData []byte) (*Envelope, bool, error) {
+	if envelope.KeyID == k.primary {
+		return envelope, false, nil
+	}
+
+	dataKey, err := k.unwrap(envelope, associatedData)
+	if err != nil {
+		return nil, false, err
+	}
+	wrappedKey, err := seal(k.keys[k.primary], dataKey, associatedData)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &Envelope{KeyID: k.primary, WrappedKey: wrappedKey, Ciphertext: envelope.Ciphertext}, true, nil
+}
+
+// unwrap decrypts the data key of an envelope
+func (k *Keyring) unwrap(envelope *Envelope, associatedData []byte) ([]byte, error) {
+	masterKey, ok := k.keys[envelope.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownKey, envelope.KeyID)
+	}
+	dataKey, err := open(masterKey, envelope.WrappedKey, associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// seal encrypts with AES-GCM under a random nonce, which is prepended to the ciphertext
+func seal(key, plaintext, associatedData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// open decrypts what seal encrypted
+func open(key, sealed, associatedData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, associatedData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
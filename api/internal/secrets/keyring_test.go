@@ -0,0 +1,114 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, MasterKeySize)
+}
+
+func TestKeyring_SealAndOpen(t *testing.T) {
+	keyring, err := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	envelope, err := keyring.Seal([]byte("api-key-123"), []byte("WEATHER_KEY"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if envelope.KeyID != "k1" {
+		t.Errorf("Expected key ID k1, got %s", envelope.KeyID)
+	}
+	if bytes.Contains(envelope.Ciphertext, []byte("api-key-123")) {
+		t.Error("Expected the ciphertext not to contain the value")
+	}
+
+	plaintext, err := keyring.Open(envelope, []byte("WEATHER_KEY"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(plaintext) != "api-key-123" {
+		t.Errorf("Expected api-key-123, got %s", plaintext)
+	}
+
+	// The envelope is bound to its associated data
+	if _, err := keyring.Open(envelope, []byte("OTHER_KEY")); err == nil {
+		t.Error("Expected opening with other associated data to fail")
+	}
+
+	// Every value gets its own data key
+	other, _ := keyring.Seal([]byte("api-key-123"), []byte("WEATHER_KEY"))
+	if bytes.Equal(other.WrappedKey, envelope.WrappedKey) || bytes.Equal(other.Ciphertext, envelope.Ciphertext) {
+		t.Error("Expected sealing the same value twice to give different envelopes")
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old, _ := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	envelope, _ := old.Seal([]byte("s3cret"), []byte("NAME"))
+
+	rotated, err := NewKeyring("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Values wrapped before the rotation can still be opened
+	if plaintext, err := rotated.Open(envelope, []byte("NAME")); err != nil || string(plaintext) != "s3cret" {
+		t.Fatalf("Expected s3cret, got %s (%v)", plaintext, err)
+	}
+
+	rewrapped, changed, err := rotated.Rewrap(envelope, []byte("NAME"))
+	if err != nil || !changed {
+		t.Fatalf("Expected the envelope to be rewrapped, got %v (%v)", changed, err)
+	}
+	if rewrapped.KeyID != "k2" || !bytes.Equal(rewrapped.Ciphertext, envelope.Ciphertext) {
+		t.Errorf("Expected only the data key to be rewrapped with k2, got key %s", rewrapped.KeyID)
+	}
+	if _, changed, _ := rotated.Rewrap(rewrapped, []byte("NAME")); changed {
+		t.Error("Expected an envelope wrapped with the primary key to be left alone")
+	}
+
+	// Once the old key is retired, only rewrapped values can be opened
+	retired, _ := NewKeyring("k2", map[string][]byte{"k2": testKey(2)})
+	if plaintext, err := retired.Open(rewrapped, []byte("NAME")); err != nil || string(plaintext) != "s3cret" {
+		t.Errorf("Expected s3cret, got %s (%v)", plaintext, err)
+	}
+	if _, err := retired.Open(envelope, []byte("NAME")); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+
+	keyring, err := ParseKeyring("2024-06:" + k2 + ", 2024-01:" + k1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if keyring.PrimaryKeyID() != "2024-06" {
+		t.Errorf("Expected the first key to be primary, got %s", keyring.PrimaryKeyID())
+	}
+
+	tests := []struct {
+		spec  string
+		error string
+	}{
+		{"", "no master keys"},
+		{k1, "expected 'id:base64key'"},
+		{"k1:not base64", "invalid master key 'k1'"},
+		{"k1:" + base64.StdEncoding.EncodeToString([]byte("short")), "must be 32 bytes"},
+		{"k1:" + k1 + ",k1:" + k2, "duplicate master key"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseKeyring(tt.spec); err == nil || !strings.Contains(err.Error(), tt.error) {
+			t.Errorf("Expected error containing %q for %q, got %v", tt.error, tt.spec, err)
+		}
+	}
+}
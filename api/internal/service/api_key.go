@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/auth"
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/repository"
+	"workflow-code-test/api/internal/tenant"
+)
+
+// ErrAPIKeyNotFound is returned for API keys that don't exist or are already revoked
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// APIKeyService issues, revokes and checks API keys. Keys are only stored hashed.
+type APIKeyService struct {
+	repo  *repository.APIKeyRepository
+	audit AuditRecorder
+}
+
+// NewAPIKeyService creates an API key service
+func NewAPIKeyService(repo *repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		repo: repo,
+	}
+}
+
+// WithAuditLog records issued and revoked API keys in the audit log
+func (s *APIKeyService) WithAuditLog(audit AuditRecorder) *APIKeyService {
+	s.audit = audit
+	return s
+}
+
+// ListAPIKeys returns every API key, without the keys themselves
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx)
+}
+
+// CreateAPIKey issues a new API key on behalf of the context's caller, in the context's workspace. The returned
+// key is never shown again.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, req *models.APIKeyRequest) (*models.CreatedAPIKey, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	workspaceID, err := tenant.Workspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := &models.HashedAPIKey{
+		APIKey: models.APIKey{
+			ID:          uuid.New(),
+			Name:        req.Name,
+			Prefix:      prefix,
+			Scopes:      req.Scopes,
+			WorkspaceID: workspaceID,
+			CreatedAt:   time.Now(),
+		},
+		KeyHash: hash,
+	}
+	if identity := auth.IdentityFromContext(ctx); identity != nil {
+		apiKey.CreatedBy = identity.Subject
+	}
+
+	if err := s.repo.CreateAPIKey(ctx, apiKey); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Created API key", "id", apiKey.ID, "name", apiKey.Name, "scopes", apiKey.Scopes, "workspaceId", workspaceID, "createdBy", apiKey.CreatedBy)
+	recordAudit(ctx, s.audit, models.AuditChange{
+		Action:       models.AuditAPIKeyCreate,
+		ResourceType: models.AuditResourceAPIKey,
+		ResourceID:   apiKey.ID.String(),
+		After:        models.APIKeyAuditSummary{Name: apiKey.Name, Scopes: apiKey.Scopes},
+	})
+	return &models.CreatedAPIKey{APIKey: apiKey.APIKey, Key: key}, nil
+}
+
+// RevokeAPIKey revokes an API key, it is rejected from then on
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	revoked, err := s.repo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+
+	slog.Info("Revoked API key", "id", id)
+	recordAudit(ctx, s.audit, models.AuditChange{
+		Action:       models.AuditAPIKeyRevoke,
+		ResourceType: models.AuditResourceAPIKey,
+		ResourceID:   id.String(),
+	})
+	return nil
+}
+
+// AuthenticateAPIKey resolves an API key to its caller, implementing auth.APIKeyAuthenticator
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*auth.Identity, error) {
+	prefix, ok := auth.ParseAPIKeyPrefix(key)
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed API key", auth.ErrInvalidCredentials)
+	}
+
+	apiKey, err := s.repo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil || !auth.CheckAPIKey(key, apiKey.KeyHash) {
+		return nil, fmt.Errorf("%w: unknown API key", auth.ErrInvalidCredentials)
+	}
+	if apiKey.Revoked() {
+		return nil, fmt.Errorf("%w: API key %s is revoked", auth.ErrInvalidCredentials, apiKey.ID)
+	}
+
+	// Last use is informational, a failure to record it shouldn't fail the request
+	if err := s.repo.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		slog.Warn("Failed to record API key use", "id", apiKey.ID, "error", err)
+	}
+
+	// Key names aren't unique, so the key is identified by its ID
+	return &auth.Identity{
+		Subject:     "api-key:" + apiKey.ID.String(),
+		Method:      auth.MethodAPIKey,
+		Scopes:      apiKey.Scopes,
+		KeyID:       apiKey.ID.String(),
+		WorkspaceID: apiKey.WorkspaceID,
+	}, nil
+}
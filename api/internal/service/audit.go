@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"workflow-code-test/api/internal/auth"
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/repository"
+	"workflow-code-test/api/internal/requestid"
+)
+
+// auditExportPageSize is how many events ExportEvents reads from the database at a time
+const auditExportPageSize = models.MaxAuditLimit
+
+// AuditRecorder records what callers change and run
+type AuditRecorder interface {
+	// Record appends a change to the audit log, attributed to the context's caller and request
+	Record(ctx context.Context, change models.AuditChange)
+}
+
+// AuditService writes and reads the audit log of the context's workspace
+type AuditService struct {
+	repo *repository.AuditRepository
+}
+
+// NewAuditService creates an audit service
+func NewAuditService(repo *repository.AuditRepository) *AuditService {
+	return &AuditService{
+		repo: repo,
+	}
+}
+
+// Record appends a change to the audit log, implementing AuditRecorder. The change itself has already been
+// made by the time it is recorded, so a failure to record it is logged rather than failing the request.
+func (s *AuditService) Record(ctx context.Context, change models.AuditChange) {
+	event := &models.AuditEvent{
+		Action:       change.Action,
+		ResourceType: change.ResourceType,
+		ResourceID:   change.ResourceID,
+		RequestID:    requestid.FromContext(ctx),
+		Before:       auditSummary(change.Before),
+		After:        auditSummary(change.After),
+	}
+	if identity := auth.IdentityFromContext(ctx); identity != nil {
+		event.Actor = identity.Subject
+		event.ActorMethod = identity.Method
+	}
+
+	// Recorded even if the caller went away right after the change was made
+	if err := s.repo.AppendEvent(context.WithoutCancel(ctx), event); err != nil {
+		slog.Error("Failed to record audit event", "action", change.Action, "resourceType", change.ResourceType,
+			"resourceId", change.ResourceID, "actor", event.Actor, "error", err)
+	}
+}
+
+// ListEvents returns a page of the audit log matching filter, newest first
+func (s *AuditService) ListEvents(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEvent, error) {
+	return s.repo.ListEvents(ctx, filter)
+}
+
+// ExportEvents passes every event matching filter to write, newest first, ignoring the filter's limit
+func (s *AuditService) ExportEvents(ctx context.Context, filter *models.AuditFilter, write func(*models.AuditEvent) error) error {
+	page := *filter
+	page.Limit = auditExportPageSize
+	for {
+		events, err := s.repo.ListEvents(ctx, &page)
+		if err != nil {
+			return err
+		}
+		for i := range events {
+			if err := write(&events[i]); err != nil {
+				return err
+			}
+		}
+		if len(events) < page.Limit {
+			return nil
+		}
+		page.BeforeID = events[len(events)-1].ID
+	}
+}
+
+// auditSummary marshals a before or after summary, nil stays nil
+func auditSummary(summary any) json.RawMessage {
+	if summary == nil {
+		return nil
+	}
+	document, err := json.Marshal(summary)
+	if err != nil {
+		slog.Error("Failed to marshal audit summary", "error", err)
+		return nil
+	}
+	return document
+}
+
+// recordAudit records a change if the service was given an audit recorder
+func recordAudit(ctx context.Context, recorder AuditRecorder, change models.AuditChange) {
+	if recorder != nil {
+		recorder.Record(ctx, change)
+	}
+}
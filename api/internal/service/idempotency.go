@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/auth"
+	"workflow-code-test/api/internal/execution"
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/tenant"
+)
+
+var (
+	// ErrIdempotencyKeyInUse is returned when an execution with the same idempotency key is still running
+	ErrIdempotencyKeyInUse = errors.New("an execution with this idempotency key is still running")
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent with a different execution request
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different execution request")
+	// ErrIdempotencyKeyRevealed is returned for idempotent executions revealing personal data, which is never stored
+	ErrIdempotencyKeyRevealed = errors.New("idempotency keys can't be used when revealing personal data")
+)
+
+// IdempotencyConfig configures how executions with an idempotency key are deduplicated
+type IdempotencyConfig struct {
+	// Window is how long a response is replayed for
+	Window time.Duration
+	// Wait is how long a duplicate of a running execution waits for its response before getting
+	// ErrIdempotencyKeyInUse, 0 to fail straight away
+	Wait time.Duration
+	// LockTimeout is how long a running execution holds its key, after which a retry may run it again. It
+	// only matters if the API stopped before the execution finished.
+	LockTimeout time.Duration
+	// PollInterval is how often a waiting duplicate checks for the response
+	PollInterval time.Duration
+}
+
+// DefaultIdempotencyConfig returns the default idempotency configuration
+func DefaultIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		Window:       24 * time.Hour,
+		Wait:         10 * time.Second,
+		LockTimeout:  5 * time.Minute,
+		PollInterval: 250 * time.Millisecond,
+	}
+}
+
+// IdempotencyConfigFromEnv returns the default configuration adjusted by IDEMPOTENCY_WINDOW and
+// IDEMPOTENCY_WAIT, both Go durations such as 24h or 10s
+func IdempotencyConfigFromEnv() (IdempotencyConfig, error) {
+	config := DefaultIdempotencyConfig()
+
+	if window := os.Getenv("IDEMPOTENCY_WINDOW"); window != "" {
+		value, err := time.ParseDuration(window)
+		if err != nil || value <= 0 {
+			return IdempotencyConfig{}, fmt.Errorf("invalid IDEMPOTENCY_WINDOW '%s', must be a positive duration", window)
+		}
+		config.Window = value
+	}
+	if wait := os.Getenv("IDEMPOTENCY_WAIT"); wait != "" {
+		value, err := time.ParseDuration(wait)
+		if err != nil || value < 0 {
+			return IdempotencyConfig{}, fmt.Errorf("invalid IDEMPOTENCY_WAIT '%s', must be a duration", wait)
+		}
+		config.Wait = value
+	}
+
+	return config, nil
+}
+
+// ExecutionIdempotency runs executions requested with the same idempotency key only once
+type ExecutionIdempotency interface {
+	// Once runs execute unless an execution with the same key ran already, replaying its response instead
+	Once(ctx context.Context, key, workflowID string, req *models.ExecutionRequest, execute func(context.Context) (*models.ExecutionResponse, error)) (*models.ExecutionResponse, error)
+}
+
+// IdempotencyStore stores executions requested with an idempotency key, implemented by
+// repository.IdempotencyRepository
+type IdempotencyStore interface {
+	// Reserve claims a key under the record's reservation, returning false if it is claimed or completed already
+	Reserve(ctx context.Context, subject string, record *models.IdempotencyRecord, now, lockedUntil time.Time) (bool, error)
+	// Get returns the execution stored under a key, nil if there is none or it expired before now
+	Get(ctx context.Context, subject, key string, now time.Time) (*models.IdempotencyRecord, error)
+	// Complete stores the response of a key still held by the reservation
+	Complete(ctx context.Context, subject, key string, reservation uuid.UUID, response []byte, expiresAt time.Time) error
+	// Release removes a key still held by the reservation without a response
+	Release(ctx context.Context, subject, key string, reservation uuid.UUID) error
+}
+
+// IdempotencyService stores the responses of executions with an idempotency key, scoped to the context's
+// workspace and caller, and replays them for retries
+type IdempotencyService struct {
+	repo   IdempotencyStore
+	config IdempotencyConfig
+	now    func() time.Time
+}
+
+// NewIdempotencyService creates an idempotency service
+func NewIdempotencyService(repo IdempotencyStore, config IdempotencyConfig) *IdempotencyService {
+	return &IdempotencyService{
+		repo:   repo,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Once runs execute unless an execution with the same key ran already, implementing ExecutionIdempotency.
+// Only responses are replayed, an execution that fails with an error releases its key so a retry runs it
+// again. The execution isn't cancelled when the caller gives up, so its retry can replay it.
+func (s *IdempotencyService) Once(ctx context.Context, key, workflowID string, req *models.ExecutionRequest, execute func(context.Context) (*models.ExecutionResponse, error)) (*models.ExecutionResponse, error) {
+	// Revealed responses hold personal data, which is never stored
+	if execution.PIIRevealed(ctx) {
+		return nil, ErrIdempotencyKeyRevealed
+	}
+
+	fingerprint, err := models.ExecutionFingerprint(workflowID, req)
+	if err != nil {
+		return nil, err
+	}
+	parsedWorkflowID, err := uuid.Parse(workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workflow ID: %w", err)
+	}
+	subject := ""
+	if identity := auth.IdentityFromContext(ctx); identity != nil {
+		subject = identity.Subject
+	}
+	scopedKey, err := s.scopedKey(ctx, subject, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// The reservation token keeps this request from completing or releasing a key a retry took over after the
+	// lock timeout
+	record := &models.IdempotencyRecord{Key: key, Fingerprint: fingerprint, WorkflowID: parsedWorkflowID, Reservation: uuid.New()}
+	deadline := s.now().Add(s.config.Wait)
+	for {
+		now := s.now()
+		reserved, err := s.repo.Reserve(ctx, subject, record, now, now.Add(s.config.LockTimeout))
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return s.run(context.WithoutCancel(ctx), subject, record, scopedKey, execute)
+		}
+
+		existing, err := s.repo.Get(ctx, subject, key, now)
+		if err != nil {
+			return nil, err
+		}
+		// Expired in between, try to reserve it again
+		if existing == nil {
+			continue
+		}
+		if existing.Fingerprint != fingerprint {
+			return nil, ErrIdempotencyKeyReused
+		}
+		if existing.Completed() {
+			return replay(existing)
+		}
+
+		if !now.Before(deadline) {
+			return nil, ErrIdempotencyKeyInUse
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.config.PollInterval):
+		}
+	}
+}
+
+// run executes a reserved key's execution, storing its response or releasing the key if it fails
+func (s *IdempotencyService) run(ctx context.Context, subject string, record *models.IdempotencyRecord, scopedKey string, execute func(context.Context) (*models.ExecutionResponse, error)) (*models.ExecutionResponse, error) {
+	response, err := execute(execution.WithIdempotencyKey(ctx, scopedKey))
+	var document []byte
+	if err == nil {
+		document, err = json.Marshal(response)
+	}
+	if err != nil {
+		if releaseErr := s.repo.Release(ctx, subject, record.Key, record.Reservation); releaseErr != nil {
+			slog.Error("Failed to release idempotency key", "error", releaseErr)
+		}
+		return nil, err
+	}
+
+	// The execution already ran, a failure to store it only means a retry would run it again
+	if err := s.repo.Complete(ctx, subject, record.Key, record.Reservation, document, s.now().Add(s.config.Window)); err != nil {
+		slog.Error("Failed to store idempotent execution response", "error", err)
+	}
+	return response, nil
+}
+
+// scopedKey returns the key handed to nodes, unique to the workspace and caller so nodes can use it on its own
+func (s *IdempotencyService) scopedKey(ctx context.Context, subject, key string) (string, error) {
+	workspaceID, err := tenant.Workspace(ctx)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(workspaceID.String() + "\x00" + subject + "\x00" + key))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// replay returns the stored response of a completed execution
+func replay(record *models.IdempotencyRecord) (*models.ExecutionResponse, error) {
+	var response models.ExecutionResponse
+	if err := json.Unmarshal(record.Response, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal stored execution response: %w", err)
+	}
+	response.Replayed = true
+	return &response, nil
+}
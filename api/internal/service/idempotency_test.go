@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/auth"
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/tenant"
+)
+
+// memoryIdempotencyStore keeps idempotency records in memory, with the repository's reservation semantics
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+	// onGet is called with the number of Get calls so far, before answering
+	onGet func(calls int, records map[string]*models.IdempotencyRecord)
+	gets  int
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]*models.IdempotencyRecord)}
+}
+
+func (m *memoryIdempotencyStore) Reserve(_ context.Context, subject string, record *models.IdempotencyRecord, now, lockedUntil time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.records[subject+"/"+record.Key]; ok && existing.ExpiresAt.After(now) {
+		return false, nil
+	}
+	reserved := *record
+	reserved.CreatedAt, reserved.ExpiresAt, reserved.Response = now, lockedUntil, nil
+	m.records[subject+"/"+record.Key] = &reserved
+	return true, nil
+}
+
+func (m *memoryIdempotencyStore) Get(_ context.Context, subject, key string, now time.Time) (*models.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gets++
+	if m.onGet != nil {
+		m.onGet(m.gets, m.records)
+	}
+	record, ok := m.records[subject+"/"+key]
+	if !ok || !record.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (m *memoryIdempotencyStore) Complete(_ context.Context, subject, key string, reservation uuid.UUID, response []byte, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if record, ok := m.records[subject+"/"+key]; ok && record.Reservation == reservation && !record.Completed() {
+		record.Response, record.ExpiresAt = response, expiresAt
+	}
+	return nil
+}
+
+func (m *memoryIdempotencyStore) Release(_ context.Context, subject, key string, reservation uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if record, ok := m.records[subject+"/"+key]; ok && record.Reservation == reservation && !record.Completed() {
+		delete(m.records, subject+"/"+key)
+	}
+	return nil
+}
+
+func idempotencyTestContext() context.Context {
+	ctx := tenant.WithWorkspace(context.Background(), models.DefaultWorkspaceID)
+	return auth.WithIdentity(ctx, &auth.Identity{Subject: "alice"})
+}
+
+func TestIdempotencyService_Once(t *testing.T) {
+	workflowID := uuid.NewString()
+	request := func(city string) *models.ExecutionRequest {
+		return &models.ExecutionRequest{FormData: map[string]interface{}{"city": city}}
+	}
+
+	tests := []struct {
+		name string
+		// seed prepares the store before the call, given the fingerprint of the Sydney request
+		seed      func(store *memoryIdempotencyStore, fingerprint string)
+		config    func(config *IdempotencyConfig)
+		execute   func(ctx context.Context) (*models.ExecutionResponse, error)
+		req       *models.ExecutionRequest
+		wantErr   error
+		wantRuns  int
+		wantReply bool
+		// wantStored is whether the key holds a record after the call
+		wantStored bool
+	}{
+		{
+			name:       "runs and stores the response",
+			wantRuns:   1,
+			wantStored: true,
+		},
+		{
+			name: "replays a stored response",
+			seed: func(store *memoryIdempotencyStore, fingerprint string) {
+				store.records["alice/key-1"] = &models.IdempotencyRecord{
+					Key: "key-1", Fingerprint: fingerprint, Response: []byte(`{"status":"completed","steps":[]}`),
+					ExpiresAt: time.Now().Add(time.Hour), Reservation: uuid.New(),
+				}
+			},
+			wantReply:  true,
+			wantStored: true,
+		},
+		{
+			name: "key reused for another request",
+			seed: func(store *memoryIdempotencyStore, fingerprint string) {
+				store.records["alice/key-1"] = &models.IdempotencyRecord{
+					Key: "key-1", Fingerprint: fingerprint, Response: []byte(`{"status":"completed"}`),
+					ExpiresAt: time.Now().Add(time.Hour), Reservation: uuid.New(),
+				}
+			},
+			req:        request("Perth"),
+			wantErr:    ErrIdempotencyKeyReused,
+			wantStored: true,
+		},
+		{
+			name: "in flight without waiting",
+			seed: func(store *memoryIdempotencyStore, fingerprint string) {
+				store.records["alice/key-1"] = &models.IdempotencyRecord{
+					Key: "key-1", Fingerprint: fingerprint, ExpiresAt: time.Now().Add(time.Hour), Reservation: uuid.New(),
+				}
+			},
+			config:     func(config *IdempotencyConfig) { config.Wait = 0 },
+			wantErr:    ErrIdempotencyKeyInUse,
+			wantStored: true,
+		},
+		{
+			name: "waits for the in flight execution",
+			seed: func(store *memoryIdempotencyStore, fingerprint string) {
+				store.records["alice/key-1"] = &models.IdempotencyRecord{
+					Key: "key-1", Fingerprint: fingerprint, ExpiresAt: time.Now().Add(time.Hour), Reservation: uuid.New(),
+				}
+				// The running execution finishes while the duplicate polls
+				store.onGet = func(calls int, records map[string]*models.IdempotencyRecord) {
+					if calls == 3 {
+						records["alice/key-1"].Response = []byte(`{"status":"completed","steps":[]}`)
+					}
+				}
+			},
+			wantReply:  true,
+			wantStored: true,
+		},
+		{
+			name: "releases the key when the execution fails",
+			execute: func(ctx context.Context) (*models.ExecutionResponse, error) {
+				return nil, errors.New("engine unavailable")
+			},
+			wantErr:  errors.New("engine unavailable"),
+			wantRuns: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryIdempotencyStore()
+			fingerprint, err := models.ExecutionFingerprint(workflowID, request("Sydney"))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if tt.seed != nil {
+				tt.seed(store, fingerprint)
+			}
+			config := IdempotencyConfig{Window: time.Hour, Wait: time.Second, LockTimeout: time.Minute, PollInterval: time.Millisecond}
+			if tt.config != nil {
+				tt.config(&config)
+			}
+			service := NewIdempotencyService(store, config)
+
+			runs := 0
+			execute := func(ctx context.Context) (*models.ExecutionResponse, error) {
+				runs++
+				if tt.execute != nil {
+					return tt.execute(ctx)
+				}
+				return &models.ExecutionResponse{Status: "completed"}, nil
+			}
+			req := tt.req
+			if req == nil {
+				req = request("Sydney")
+			}
+
+			response, err := service.Once(idempotencyTestContext(), "key-1", workflowID, req, execute)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("Expected no error, got %v", err)
+			case tt.wantErr != nil && (err == nil || (!errors.Is(err, tt.wantErr) && err.Error() != tt.wantErr.Error())):
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("Expected %d runs, got %d", tt.wantRuns, runs)
+			}
+			if tt.wantErr == nil && response.Replayed != tt.wantReply {
+				t.Errorf("Expected replayed %v, got %v", tt.wantReply, response.Replayed)
+			}
+			if _, stored := store.records["alice/key-1"]; stored != tt.wantStored {
+				t.Errorf("Expected the key to be stored: %v, got %v", tt.wantStored, stored)
+			}
+		})
+	}
+}
+
+func TestIdempotencyService_Once_StoresUnderItsReservation(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	service := NewIdempotencyService(store, DefaultIdempotencyConfig())
+	ctx := idempotencyTestContext()
+	workflowID := uuid.NewString()
+	req := &models.ExecutionRequest{FormData: map[string]interface{}{"city": "Sydney"}}
+
+	// A retry takes the key over while the first execution is still running past its lock timeout
+	var takenOver uuid.UUID
+	execute := func(ctx context.Context) (*models.ExecutionResponse, error) {
+		record := store.records["alice/key-1"]
+		takenOver = uuid.New()
+		record.Reservation = takenOver
+		return &models.ExecutionResponse{Status: "completed"}, nil
+	}
+	if _, err := service.Once(ctx, "key-1", workflowID, req, execute); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	record := store.records["alice/key-1"]
+	if record == nil || record.Reservation != takenOver || record.Completed() {
+		t.Errorf("Expected the slow execution to leave the retry's reservation alone, got %+v", record)
+	}
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/repository"
+	"workflow-code-test/api/internal/secrets"
+	"workflow-code-test/api/internal/tenant"
+)
+
+var (
+	// ErrSecretsNotConfigured is returned when no master key is configured to encrypt secrets with
+	ErrSecretsNotConfigured = errors.New("secrets store is not configured, set SECRETS_MASTER_KEYS")
+	// ErrSecretNotFound is returned for secrets that don't exist
+	ErrSecretNotFound = errors.New("secret not found")
+)
+
+// SecretService manages encrypted secrets. Values go in but never come back out, except to the execution
+// engine through ResolveSecret.
+type SecretService struct {
+	repo    *repository.SecretRepository
+	keyring *secrets.Keyring
+	audit   AuditRecorder
+}
+
+// NewSecretService creates a secret service, keyring may be nil if no master key is configured
+func NewSecretService(repo *repository.SecretRepository, keyring *secrets.Keyring) *SecretService {
+	return &SecretService{
+		repo:    repo,
+		keyring: keyring,
+	}
+}
+
+// WithAuditLog records secret changes and every decryption of a secret in the audit log
+func (s *SecretService) WithAuditLog(audit AuditRecorder) *SecretService {
+	s.audit = audit
+	return s
+}
+
+// ListSecrets returns every secret, without values
+func (s *SecretService) ListSecrets(ctx context.Context) ([]models.Secret, error) {
+	return s.repo.ListSecrets(ctx)
+}
+
+// GetSecret returns a secret, without its value
+func (s *SecretService) GetSecret(ctx context.Context, name string) (*models.Secret, error) {
+	secret, err := s.repo.GetSecret(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, ErrSecretNotFound
+	}
+	return &secret.Secret, nil
+}
+
+// CreateSecret encrypts and stores a new secret
+func (s *SecretService) CreateSecret(ctx context.Context, req *models.SecretRequest) (*models.Secret, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if s.keyring == nil {
+		return nil, ErrSecretsNotConfigured
+	}
+
+	secret := &models.EncryptedSecret{Secret: models.Secret{Name: req.Name}}
+	if req.Description != nil {
+		secret.Description = *req.Description
+	}
+	if err := s.seal(ctx, secret, *req.Value); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateSecret(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	created, err := s.GetSecret(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.audit, models.AuditChange{
+		Action:       models.AuditSecretCreate,
+		ResourceType: models.AuditResourceSecret,
+		ResourceID:   created.Name,
+		After:        secretAuditSummary(created, true),
+	})
+	return created, nil
+}
+
+// UpdateSecret replaces the value and/or description of a secret
+func (s *SecretService) UpdateSecret(ctx context.Context, name string, req *models.SecretRequest) (*models.Secret, error) {
+	if err := req.ValidateUpdate(); err != nil {
+		return nil, err
+	}
+	if s.keyring == nil {
+		return nil, ErrSecretsNotConfigured
+	}
+
+	secret, err := s.repo.GetSecret(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, ErrSecretNotFound
+	}
+	before := secretAuditSummary(&secret.Secret, false)
+
+	if req.Description != nil {
+		secret.Description = *req.Description
+	}
+	if req.Value != nil {
+		if err := s.seal(ctx, secret, *req.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	found, err := s.repo.UpdateSecret(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrSecretNotFound
+	}
+
+	updated, err := s.GetSecret(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.audit, models.AuditChange{
+		Action:       models.AuditSecretUpdate,
+		ResourceType: models.AuditResourceSecret,
+		ResourceID:   name,
+		Before:       before,
+		After:        secretAuditSummary(updated, req.Value != nil),
+	})
+	return updated, nil
+}
+
+// DeleteSecret removes a secret
+func (s *SecretService) DeleteSecret(ctx context.Context, name string) error {
+	secret, err := s.GetSecret(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	found, err := s.repo.DeleteSecret(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrSecretNotFound
+	}
+
+	recordAudit(ctx, s.audit, models.AuditChange{
+		Action:       models.AuditSecretDelete,
+		ResourceType: models.AuditResourceSecret,
+		ResourceID:   name,
+		Before:       secretAuditSummary(secret, false),
+	})
+	return nil
+}
+
+// RotateSecrets rewraps the data key of every secret of the context's workspace not yet wrapped with the
+// primary master key, and reseals its secrets not yet bound to the workspace, returning how many were
+// rewrapped. Older master keys can only be removed from SECRETS_MASTER_KEYS once it has run for every
+// workspace.
+func (s *SecretService) RotateSecrets(ctx context.Context) (int, error) {
+	if s.keyring == nil {
+		return 0, ErrSecretsNotConfigured
+	}
+
+	stale, err := s.repo.ListEncryptedSecrets(ctx, s.keyring.PrimaryKeyID())
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, secret := range stale {
+		changed, err := s.rewrap(ctx, &secret)
+		if err != nil {
+			return rotated, fmt.Errorf("failed to rewrap secret %s: %w", secret.Name, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := s.repo.RewrapSecret(ctx, &secret); err != nil {
+			return rotated, err
+		}
+		rotated++
+	}
+
+	slog.Info("Rotated secrets", "count", rotated, "keyId", s.keyring.PrimaryKeyID())
+	recordAudit(ctx, s.audit, models.AuditChange{
+		Action:       models.AuditSecretRotate,
+		ResourceType: models.AuditResourceSecret,
+		After:        models.SecretRotationAuditSummary{Rotated: rotated, KeyID: s.keyring.PrimaryKeyID()},
+	})
+	return rotated, nil
+}
+
+// ResolveSecret decrypts a secret for the execution engine, implementing execution.SecretResolver
+func (s *SecretService) ResolveSecret(ctx context.Context, name string) (string, error) {
+	if s.keyring == nil {
+		return "", ErrSecretsNotConfigured
+	}
+
+	secret, err := s.repo.GetSecret(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if secret == nil {
+		return "", ErrSecretNotFound
+	}
+
+	associatedData, err := secretAssociatedData(ctx, secret)
+	if err != nil {
+		return "", err
+	}
+	value, err := s.keyring.Open(envelope(secret), associatedData)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	recordAudit(ctx, s.audit, models.AuditChange{
+		Action:       models.AuditSecretAccess,
+		ResourceType: models.AuditResourceSecret,
+		ResourceID:   name,
+	})
+	return string(value), nil
+}
+
+// seal encrypts value into the secret, bound to the secret's workspace and name
+func (s *SecretService) seal(ctx context.Context, secret *models.EncryptedSecret, value string) error {
+	secret.WorkspaceBound = true
+	associatedData, err := secretAssociatedData(ctx, secret)
+	if err != nil {
+		return err
+	}
+	sealed, err := s.keyring.Seal([]byte(value), associatedData)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	secret.KeyID = sealed.KeyID
+	secret.WrappedKey = sealed.WrappedKey
+	secret.Ciphertext = sealed.Ciphertext
+	return nil
+}
+
+// rewrap moves a secret onto the primary master key, resealing it bound to its workspace if it was sealed with
+// only its name. It returns false if the secret needed neither.
+func (s *SecretService) rewrap(ctx context.Context, secret *models.EncryptedSecret) (bool, error) {
+	associatedData, err := secretAssociatedData(ctx, secret)
+	if err != nil {
+		return false, err
+	}
+
+	if !secret.WorkspaceBound {
+		value, err := s.keyring.Open(envelope(secret), associatedData)
+		if err != nil {
+			return false, err
+		}
+		return true, s.seal(ctx, secret, string(value))
+	}
+
+	rewrapped, changed, err := s.keyring.Rewrap(envelope(secret), associatedData)
+	if err != nil || !changed {
+		return false, err
+	}
+	secret.KeyID = rewrapped.KeyID
+	secret.WrappedKey = rewrapped.WrappedKey
+	return true, nil
+}
+
+// secretAssociatedData returns what a secret's value is bound to: its workspace and name, or only its name for
+// values sealed before secrets belonged to workspaces. An envelope moved to another secret, in this workspace or
+// another, doesn't open.
+func secretAssociatedData(ctx context.Context, secret *models.EncryptedSecret) ([]byte, error) {
+	if !secret.WorkspaceBound {
+		return []byte(secret.Name), nil
+	}
+	workspaceID, err := tenant.Workspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(workspaceID.String() + "/" + secret.Name), nil
+}
+
+// secretAuditSummary describes a secret for the audit log
+func secretAuditSummary(secret *models.Secret, valueChanged bool) models.SecretAuditSummary {
+	return models.SecretAuditSummary{
+		Description:  secret.Description,
+		KeyID:        secret.KeyID,
+		ValueChanged: valueChanged,
+	}
+}
+
+// envelope returns the encrypted value of a secret as the keyring takes it
+func envelope(secret *models.EncryptedSecret) *secrets.Envelope {
+	return &secrets.Envelope{
+		KeyID:      secret.KeyID,
+		WrappedKey: secret.WrappedKey,
+		Ciphertext: secret.Ciphertext,
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/secrets"
+	"workflow-code-test/api/internal/tenant"
+)
+
+func TestSecretService_SealBindsWorkspace(t *testing.T) {
+	keyring, err := secrets.NewKeyring("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, secrets.MasterKeySize)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service := NewSecretService(nil, keyring)
+	workspaceA := tenant.WithWorkspace(context.Background(), uuid.New())
+	workspaceB := tenant.WithWorkspace(context.Background(), uuid.New())
+
+	secret := &models.EncryptedSecret{Secret: models.Secret{Name: "WEATHER_KEY"}}
+	if err := service.seal(workspaceA, secret, "api-key-123"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !secret.WorkspaceBound {
+		t.Error("Expected a sealed secret to be bound to its workspace")
+	}
+
+	open := func(ctx context.Context, secret *models.EncryptedSecret) (string, error) {
+		associatedData, err := secretAssociatedData(ctx, secret)
+		if err != nil {
+			return "", err
+		}
+		value, err := keyring.Open(envelope(secret), associatedData)
+		return string(value), err
+	}
+
+	if value, err := open(workspaceA, secret); err != nil || value != "api-key-123" {
+		t.Errorf("Expected the value to open in its workspace, got %q, %v", value, err)
+	}
+	// The same name in another workspace doesn't open the copied envelope
+	if _, err := open(workspaceB, secret); err == nil {
+		t.Error("Expected the envelope not to open in another workspace")
+	}
+
+	// Secrets sealed with only their name are resealed bound to the workspace
+	legacy := &models.EncryptedSecret{Secret: models.Secret{Name: "WEATHER_KEY"}}
+	sealed, _ := keyring.Seal([]byte("api-key-123"), []byte(legacy.Name))
+	legacy.KeyID, legacy.WrappedKey, legacy.Ciphertext = sealed.KeyID, sealed.WrappedKey, sealed.Ciphertext
+
+	changed, err := service.rewrap(workspaceA, legacy)
+	if err != nil || !changed || !legacy.WorkspaceBound {
+		t.Fatalf("Expected the legacy secret to be resealed, got %v, %v, bound %v", changed, err, legacy.WorkspaceBound)
+	}
+	if value, err := open(workspaceA, legacy); err != nil || value != "api-key-123" {
+		t.Errorf("Expected the resealed value to open in its workspace, got %q, %v", value, err)
+	}
+	if changed, err := service.rewrap(workspaceA, legacy); err != nil || changed {
+		t.Errorf("Expected nothing left to rewrap, got %v, %v", changed, err)
+	}
+}
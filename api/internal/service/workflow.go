@@ -3,9 +3,11 @@ package service
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/google/uuid"
 
+	"workflow-code-test/api/internal/auth"
 	"workflow-code-test/api/internal/execution"
 	"workflow-code-test/api/internal/models"
 	"workflow-code-test/api/internal/repository"
@@ -14,20 +16,49 @@ import (
 type WorkflowService struct {
 	repo            *repository.WorkflowRepository
 	executionEngine *execution.Engine
+	quota           ExecutionQuota
+	idempotency     ExecutionIdempotency
+	audit           AuditRecorder
 }
 
 func NewWorkflowService(repo *repository.WorkflowRepository) *WorkflowService {
 	// Create execution engine
-	executionEngine := execution.NewEngine()
+	return NewWorkflowServiceWithEngine(repo, execution.NewEngine())
+}
 
+// NewWorkflowServiceWithEngine creates a workflow service executing workflows with the given engine
+func NewWorkflowServiceWithEngine(repo *repository.WorkflowRepository, executionEngine *execution.Engine) *WorkflowService {
 	return &WorkflowService{
 		repo:            repo,
 		executionEngine: executionEngine,
 	}
 }
 
-// GetWorkflowWithNodesAndEdges retrieves a complete workflow with all its nodes and edges
+// WithExecutionQuota counts executions against their workspace's quota, refusing them once it is used up
+func (s *WorkflowService) WithExecutionQuota(quota ExecutionQuota) *WorkflowService {
+	s.quota = quota
+	return s
+}
+
+// WithIdempotency runs executions requested with the same idempotency key only once, replaying the response
+func (s *WorkflowService) WithIdempotency(idempotency ExecutionIdempotency) *WorkflowService {
+	s.idempotency = idempotency
+	return s
+}
+
+// WithAuditLog records saves, executions and sharing changes in the audit log
+func (s *WorkflowService) WithAuditLog(audit AuditRecorder) *WorkflowService {
+	s.audit = audit
+	return s
+}
+
+// GetWorkflowWithNodesAndEdges retrieves a complete workflow with all its nodes and edges, if the caller
+// may view it
 func (s *WorkflowService) GetWorkflowWithNodesAndEdges(ctx context.Context, workflowID uuid.UUID) (*models.WorkflowResponse, error) {
+	if err := s.authorize(ctx, workflowID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
 	// Get the workflow
 	workflow, err := s.repo.GetWorkflow(ctx, workflowID)
 	if err != nil {
@@ -58,16 +89,18 @@ func (s *WorkflowService) GetWorkflowWithNodesAndEdges(ctx context.Context, work
 	}
 
 	response := &models.WorkflowResponse{
-		ID:    workflow.ID.String(),
-		Name:  workflow.Name,
-		Nodes: nodeResponses,
-		Edges: edgeResponses,
+		ID:      workflow.ID.String(),
+		Name:    workflow.Name,
+		Version: workflow.Version(),
+		Nodes:   nodeResponses,
+		Edges:   edgeResponses,
 	}
 
 	return response, nil
 }
 
-// SaveWorkflowFromRequest saves a workflow from a frontend request
+// SaveWorkflowFromRequest saves a workflow from a frontend request. Existing workflows may only be saved by
+// their editors, new workflows are owned by the caller that creates them.
 func (s *WorkflowService) SaveWorkflowFromRequest(ctx context.Context, req *models.WorkflowRequest) error {
 	// Parse workflow ID
 	workflowID, err := uuid.Parse(req.ID)
@@ -77,7 +110,12 @@ func (s *WorkflowService) SaveWorkflowFromRequest(ctx context.Context, req *mode
 
 	// Validate nodes and edges
 	if err := s.validateWorkflowRequest(req); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+		return err
+	}
+
+	identity := auth.IdentityFromContext(ctx)
+	if identity == nil {
+		return fmt.Errorf("%w: no authenticated caller", ErrWorkflowForbidden)
 	}
 
 	// Create workflow entity
@@ -105,51 +143,195 @@ func (s *WorkflowService) SaveWorkflowFromRequest(ctx context.Context, req *mode
 		edges[i] = *edge
 	}
 
-	// Save to database
-	return s.repo.SaveWorkflow(ctx, workflow, nodes, edges)
-}
-
-// validateWorkflowRequest validates the workflow request
-func (s *WorkflowService) validateWorkflowRequest(req *models.WorkflowRequest) error {
-	// Validate nodes
-	for _, node := range req.Nodes {
-		if err := node.Validate(); err != nil {
-			return fmt.Errorf("invalid node %s: %w", node.ID, err)
+	// The summary before saving is only for the audit log, whether the workflow is new is decided by the save
+	var before *models.WorkflowAuditSummary
+	if s.audit != nil {
+		exists, err := s.repo.WorkflowExists(ctx, workflowID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			if before, err = s.workflowAuditSummary(ctx, workflowID); err != nil {
+				return err
+			}
 		}
 	}
 
-	// Validate edges
-	for _, edge := range req.Edges {
-		if err := edge.Validate(); err != nil {
-			return fmt.Errorf("invalid edge %s: %w", edge.ID, err)
+	// Save to database, new workflows are owned by the caller and existing ones need an editor
+	created, err := s.repo.SaveWorkflow(ctx, workflow, nodes, edges, identity.Subject, func(role string) error {
+		if identity.HasScope(models.ScopeAdmin) {
+			return nil
 		}
+		return checkRole(workflowID, identity.Subject, role, models.RoleEditor)
+	})
+	if err != nil {
+		return err
 	}
 
-	// Check that we have exactly one start node and one end node
-	startNodes := 0
-	endNodes := 0
+	action := models.AuditWorkflowUpdate
+	if created {
+		action = models.AuditWorkflowCreate
+		before = nil
+	}
 
-	for _, node := range req.Nodes {
-		switch node.Type {
-		case models.NodeTypeStart:
-			startNodes++
-		case models.NodeTypeEnd:
-			endNodes++
+	if s.audit != nil {
+		change := models.AuditChange{Action: action, ResourceType: models.AuditResourceWorkflow, ResourceID: workflowID.String()}
+		if before != nil {
+			change.Before = before
+		}
+		// The workflow is saved either way, so only the summary is lost if it can't be read back
+		if after, err := s.workflowAuditSummary(ctx, workflowID); err != nil {
+			slog.Error("Failed to summarize saved workflow", "workflowId", workflowID, "error", err)
+		} else {
+			change.After = after
 		}
+		s.audit.Record(ctx, change)
 	}
+	return nil
+}
 
-	if startNodes != 1 {
-		return fmt.Errorf("workflow must have exactly one start node, found %d", startNodes)
+// workflowAuditSummary summarizes a stored workflow for the audit log, nil if audit logging is off
+func (s *WorkflowService) workflowAuditSummary(ctx context.Context, workflowID uuid.UUID) (*models.WorkflowAuditSummary, error) {
+	if s.audit == nil {
+		return nil, nil
 	}
 
-	if endNodes != 1 {
-		return fmt.Errorf("workflow must have exactly one end node, found %d", endNodes)
+	workflow, err := s.repo.GetWorkflow(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow: %w", err)
 	}
+	nodes, err := s.repo.GetNodesByWorkflow(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
+	}
+	edges, err := s.repo.GetEdgesByWorkflow(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edges: %w", err)
+	}
+
+	return &models.WorkflowAuditSummary{
+		Name:    workflow.Name,
+		Version: workflow.Version(),
+		Nodes:   len(nodes),
+		Edges:   len(edges),
+	}, nil
+}
 
+// ValidateWorkflowRequest runs the full validation pipeline, returning errors and warnings
+func (s *WorkflowService) ValidateWorkflowRequest(req *models.WorkflowRequest) models.ValidationErrors {
+	return req.Lint()
+}
+
+// validateWorkflowRequest returns the blocking validation errors as a models.ValidationErrors error, or nil
+func (s *WorkflowService) validateWorkflowRequest(req *models.WorkflowRequest) error {
+	if errs := s.ValidateWorkflowRequest(req).Blocking(); len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
-// ExecuteWorkflow executes a workflow using the execution engine
+// ExecuteWorkflow validates a workflow and executes it using the execution engine. Stored workflows may only
+// be executed by their executors, unsaved ad-hoc definitions by anyone. Ad-hoc definitions can't resolve
+// secrets, as their caller chooses where the values are sent. Requests with an idempotency key replay the
+// response of an earlier request with the same key instead of executing again.
 func (s *WorkflowService) ExecuteWorkflow(ctx context.Context, workflow *models.WorkflowResponse, req *models.ExecutionRequest) (*models.ExecutionResponse, error) {
-	return s.executionEngine.ExecuteWorkflow(ctx, workflow, req)
+	if err := s.authorizeExecution(ctx, workflow.ID); err != nil {
+		return nil, err
+	}
+	// An ad-hoc definition reusing a stored workflow's ID is still the caller's definition, so it is never
+	// credited to the stored workflow
+	stored := req.GetMode() != models.ExecutionModeAdHoc
+	if !stored {
+		ctx = execution.WithoutSecrets(ctx)
+	}
+
+	if err := s.validateWorkflowRequest(workflow.ToRequest()); err != nil {
+		return nil, err
+	}
+
+	execute := func(ctx context.Context) (*models.ExecutionResponse, error) {
+		return s.execute(ctx, workflow, req, stored)
+	}
+	if req.IdempotencyKey != "" && s.idempotency != nil {
+		return s.idempotency.Once(ctx, req.IdempotencyKey, workflow.ID, req, execute)
+	}
+	return execute(ctx)
+}
+
+// execute runs a validated and authorized execution, replays don't get here
+func (s *WorkflowService) execute(ctx context.Context, workflow *models.WorkflowResponse, req *models.ExecutionRequest, stored bool) (*models.ExecutionResponse, error) {
+	// Only executions that actually run count against the quota
+	if s.quota != nil {
+		if err := s.quota.ConsumeExecution(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if stored {
+		ctx = execution.WithStoredWorkflow(ctx)
+	}
+	response, err := s.executionEngine.ExecuteWorkflow(ctx, workflow, req)
+
+	summary := &models.ExecutionAuditSummary{Status: "error", Stored: stored}
+	if response != nil {
+		summary.Status = response.Status
+		summary.Steps = len(response.Steps)
+	}
+	recordAudit(ctx, s.audit, models.AuditChange{
+		Action:       models.AuditWorkflowExecute,
+		ResourceType: models.AuditResourceWorkflow,
+		ResourceID:   workflow.ID,
+		After:        summary,
+	})
+
+	return response, err
+}
+
+// authorizeExecution checks the caller may execute the workflow with the given ID if it is stored
+func (s *WorkflowService) authorizeExecution(ctx context.Context, id string) error {
+	workflowID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid workflow ID: %w", err)
+	}
+
+	exists, err := s.repo.WorkflowExists(ctx, workflowID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	return s.authorize(ctx, workflowID, models.RoleExecutor)
+}
+
+// ExecuteAdHocWorkflow validates and executes the workflow definition sent with the request.
+// The definition is never persisted - use SaveWorkflowFromRequest for that.
+func (s *WorkflowService) ExecuteAdHocWorkflow(ctx context.Context, workflowID uuid.UUID, req *models.ExecutionRequest) (*models.ExecutionResponse, error) {
+	return s.ExecuteWorkflow(ctx, req.ToWorkflowRequest(workflowID.String()).ToResponse(), req)
+}
+
+// ListNodeTypes returns the descriptions of all registered node types for the editor palette
+func (s *WorkflowService) ListNodeTypes() []models.NodeTypeDescription {
+	nodeTypes := models.RegisteredNodeTypes()
+
+	descriptions := make([]models.NodeTypeDescription, len(nodeTypes))
+	for i, nodeType := range nodeTypes {
+		descriptions[i] = nodeType.Describe()
+	}
+
+	return descriptions
+}
+
+// GetNodeTypeSchema returns the JSON Schema of a node type's data, used by the editor to render config forms
+func (s *WorkflowService) GetNodeTypeSchema(nodeType string) (*models.Schema, error) {
+	if err := models.ValidateNodeType(nodeType); err != nil {
+		return nil, err
+	}
+
+	schema, ok := models.NodeDataSchema(nodeType)
+	if !ok {
+		return nil, fmt.Errorf("node type %s has no data schema", nodeType)
+	}
+
+	return schema, nil
 }
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/auth"
+	"workflow-code-test/api/internal/models"
+)
+
+var (
+	// ErrWorkflowNotFound is returned for workflows that don't exist or the caller has no role on, so
+	// callers can't probe for workflows they weren't shared
+	ErrWorkflowNotFound = errors.New("workflow not found")
+	// ErrWorkflowForbidden is returned when the caller's role on a workflow doesn't allow the action
+	ErrWorkflowForbidden = errors.New("workflow access denied")
+	// ErrPermissionNotFound is returned when removing a role a subject doesn't have
+	ErrPermissionNotFound = errors.New("workflow permission not found")
+	// ErrLastOwner is returned when removing or demoting the only owner of a workflow
+	ErrLastOwner = errors.New("a workflow must keep at least one owner")
+)
+
+// authorize checks the context's caller has at least the required role on a stored workflow. Admins are
+// allowed everything on any workflow.
+func (s *WorkflowService) authorize(ctx context.Context, workflowID uuid.UUID, required string) error {
+	identity := auth.IdentityFromContext(ctx)
+	if identity == nil {
+		return fmt.Errorf("%w: no authenticated caller", ErrWorkflowForbidden)
+	}
+
+	if identity.HasScope(models.ScopeAdmin) {
+		exists, err := s.repo.WorkflowExists(ctx, workflowID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrWorkflowNotFound
+		}
+		return nil
+	}
+
+	role, err := s.repo.GetRole(ctx, workflowID, identity.Subject)
+	if err != nil {
+		return err
+	}
+	return checkRole(workflowID, identity.Subject, role, required)
+}
+
+// checkRole checks a subject's role on a workflow, empty if it has none, is at least the required role
+func checkRole(workflowID uuid.UUID, subject, role, required string) error {
+	if role == "" {
+		return ErrWorkflowNotFound
+	}
+	if !models.RoleAllows(role, required) {
+		slog.Warn("Caller lacks workflow role", "workflowId", workflowID, "subject", subject, "role", role, "required", required)
+		return fmt.Errorf("%w: %s role required, caller is %s", ErrWorkflowForbidden, required, role)
+	}
+	return nil
+}
+
+// ListWorkflows returns the workflows the context's caller has a role on, admins get every workflow
+func (s *WorkflowService) ListWorkflows(ctx context.Context) ([]models.WorkflowSummary, error) {
+	identity := auth.IdentityFromContext(ctx)
+	if identity == nil {
+		return nil, fmt.Errorf("%w: no authenticated caller", ErrWorkflowForbidden)
+	}
+	return s.repo.ListWorkflows(ctx, identity.Subject, identity.HasScope(models.ScopeAdmin))
+}
+
+// ListPermissions returns who a workflow is shared with, only its owners may see that
+func (s *WorkflowService) ListPermissions(ctx context.Context, workflowID uuid.UUID) ([]models.WorkflowPermission, error) {
+	if err := s.authorize(ctx, workflowID, models.RoleOwner); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPermissions(ctx, workflowID)
+}
+
+// SetPermission shares a workflow with a subject, or changes the role it was shared with
+func (s *WorkflowService) SetPermission(ctx context.Context, workflowID uuid.UUID, subject string, req *models.WorkflowPermissionRequest) (*models.WorkflowPermission, error) {
+	if err := models.ValidatePermissionSubject(subject); err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, workflowID, models.RoleOwner); err != nil {
+		return nil, err
+	}
+	if req.Role != models.RoleOwner {
+		if err := s.checkOtherOwner(ctx, workflowID, subject); err != nil {
+			return nil, err
+		}
+	}
+
+	previousRole, err := s.repo.GetRole(ctx, workflowID, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	permission := &models.WorkflowPermission{
+		WorkflowID: workflowID,
+		Subject:    subject,
+		Role:       req.Role,
+		GrantedBy:  auth.IdentityFromContext(ctx).Subject,
+	}
+	if err := s.repo.SetPermission(ctx, permission); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Shared workflow", "workflowId", workflowID, "subject", subject, "role", req.Role, "grantedBy", permission.GrantedBy)
+	change := models.AuditChange{
+		Action:       models.AuditPermissionSet,
+		ResourceType: models.AuditResourceWorkflow,
+		ResourceID:   workflowID.String(),
+		After:        models.PermissionAuditSummary{Subject: subject, Role: req.Role},
+	}
+	if previousRole != "" {
+		change.Before = models.PermissionAuditSummary{Subject: subject, Role: previousRole}
+	}
+	recordAudit(ctx, s.audit, change)
+	return permission, nil
+}
+
+// DeletePermission stops sharing a workflow with a subject
+func (s *WorkflowService) DeletePermission(ctx context.Context, workflowID uuid.UUID, subject string) error {
+	if err := s.authorize(ctx, workflowID, models.RoleOwner); err != nil {
+		return err
+	}
+	if err := s.checkOtherOwner(ctx, workflowID, subject); err != nil {
+		return err
+	}
+	role, err := s.repo.GetRole(ctx, workflowID, subject)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.DeletePermission(ctx, workflowID, subject)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPermissionNotFound
+	}
+
+	slog.Info("Unshared workflow", "workflowId", workflowID, "subject", subject, "revokedBy", auth.IdentityFromContext(ctx).Subject)
+	recordAudit(ctx, s.audit, models.AuditChange{
+		Action:       models.AuditPermissionDelete,
+		ResourceType: models.AuditResourceWorkflow,
+		ResourceID:   workflowID.String(),
+		Before:       models.PermissionAuditSummary{Subject: subject, Role: role},
+	})
+	return nil
+}
+
+// checkOtherOwner returns ErrLastOwner if subject is the workflow's only owner
+func (s *WorkflowService) checkOtherOwner(ctx context.Context, workflowID uuid.UUID, subject string) error {
+	permissions, err := s.repo.ListPermissions(ctx, workflowID)
+	if err != nil {
+		return err
+	}
+
+	subjectIsOwner, otherOwners := false, 0
+	for _, permission := range permissions {
+		if permission.Role != models.RoleOwner {
+			continue
+		}
+		if permission.Subject == subject {
+			subjectIsOwner = true
+		} else {
+			otherOwners++
+		}
+	}
+	if subjectIsOwner && otherOwners == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
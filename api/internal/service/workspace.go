@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/repository"
+	"workflow-code-test/api/internal/tenant"
+)
+
+var (
+	// ErrWorkspaceNotFound is returned for workspaces that don't exist
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	// ErrExecutionQuotaExceeded is returned when a workspace has used up its executions for the day
+	ErrExecutionQuotaExceeded = errors.New("daily execution quota exceeded")
+)
+
+// ExecutionQuota limits how many executions the context's workspace may run
+type ExecutionQuota interface {
+	// ConsumeExecution counts an execution, or returns ErrExecutionQuotaExceeded if none are left
+	ConsumeExecution(ctx context.Context) error
+}
+
+// WorkspaceService manages workspaces and enforces their daily execution quotas
+type WorkspaceService struct {
+	repo *repository.WorkspaceRepository
+	now  func() time.Time
+}
+
+// NewWorkspaceService creates a workspace service
+func NewWorkspaceService(repo *repository.WorkspaceRepository) *WorkspaceService {
+	return &WorkspaceService{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// ListWorkspaces returns every workspace
+func (s *WorkspaceService) ListWorkspaces(ctx context.Context) ([]models.Workspace, error) {
+	return s.repo.ListWorkspaces(ctx)
+}
+
+// CreateWorkspace creates an empty workspace
+func (s *WorkspaceService) CreateWorkspace(ctx context.Context, req *models.WorkspaceRequest) (*models.Workspace, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	workspace := &models.Workspace{
+		ID:   uuid.New(),
+		Slug: req.Slug,
+		Name: req.Name,
+	}
+	if req.DailyExecutionQuota != nil {
+		workspace.DailyExecutionQuota = *req.DailyExecutionQuota
+	}
+
+	if err := s.repo.CreateWorkspace(ctx, workspace); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Created workspace", "id", workspace.ID, "slug", workspace.Slug, "dailyExecutionQuota", workspace.DailyExecutionQuota)
+	return s.getWorkspace(ctx, workspace.ID)
+}
+
+// UpdateWorkspace changes the name and/or daily execution quota of a workspace
+func (s *WorkspaceService) UpdateWorkspace(ctx context.Context, id uuid.UUID, req *models.WorkspaceRequest) (*models.Workspace, error) {
+	if err := req.ValidateUpdate(); err != nil {
+		return nil, err
+	}
+
+	workspace, err := s.getWorkspace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != "" {
+		workspace.Name = req.Name
+	}
+	if req.DailyExecutionQuota != nil {
+		workspace.DailyExecutionQuota = *req.DailyExecutionQuota
+	}
+
+	found, err := s.repo.UpdateWorkspace(ctx, workspace)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrWorkspaceNotFound
+	}
+
+	slog.Info("Updated workspace", "id", id, "dailyExecutionQuota", workspace.DailyExecutionQuota)
+	return s.getWorkspace(ctx, id)
+}
+
+// GetUsage returns the context's workspace with the executions it ran today
+func (s *WorkspaceService) GetUsage(ctx context.Context) (*models.WorkspaceUsage, error) {
+	workspaceID, err := tenant.Workspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	workspace, err := s.getWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	day := models.QuotaDay(s.now())
+	executions, err := s.repo.GetExecutionCount(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WorkspaceUsage{
+		Workspace:       *workspace,
+		ExecutionsToday: executions,
+		QuotaResetsAt:   day.AddDate(0, 0, 1),
+	}, nil
+}
+
+// WorkspaceExists returns true if the workspace exists, implementing auth.WorkspaceChecker
+func (s *WorkspaceService) WorkspaceExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	workspace, err := s.repo.GetWorkspace(ctx, id)
+	return workspace != nil, err
+}
+
+// ConsumeExecution counts an execution against the context's workspace quota for today, implementing
+// ExecutionQuota
+func (s *WorkspaceService) ConsumeExecution(ctx context.Context) error {
+	workspaceID, err := tenant.Workspace(ctx)
+	if err != nil {
+		return err
+	}
+	workspace, err := s.getWorkspace(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	executions, counted, err := s.repo.CountExecution(ctx, models.QuotaDay(s.now()), workspace.DailyExecutionQuota)
+	if err != nil {
+		return err
+	}
+	if !counted {
+		slog.Warn("Workspace exceeded its daily execution quota", "workspaceId", workspaceID, "quota", workspace.DailyExecutionQuota)
+		return fmt.Errorf("%w: %d executions per day", ErrExecutionQuotaExceeded, workspace.DailyExecutionQuota)
+	}
+
+	slog.Debug("Counted workspace execution", "workspaceId", workspaceID, "executionsToday", executions)
+	return nil
+}
+
+func (s *WorkspaceService) getWorkspace(ctx context.Context, id uuid.UUID) (*models.Workspace, error) {
+	workspace, err := s.repo.GetWorkspace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if workspace == nil {
+		return nil, ErrWorkspaceNotFound
+	}
+	return workspace, nil
+}
@@ -0,0 +1,36 @@
+// Package tenant carries the workspace a request acts in through its context, so repositories can scope
+// every query to it.
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoWorkspace is returned by Workspace for contexts that don't carry a workspace
+var ErrNoWorkspace = errors.New("no workspace in context")
+
+type workspaceKey struct{}
+
+// WithWorkspace returns a context acting in the given workspace
+func WithWorkspace(ctx context.Context, workspaceID uuid.UUID) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspaceID)
+}
+
+// WorkspaceFromContext returns the workspace the context acts in, false if it has none
+func WorkspaceFromContext(ctx context.Context) (uuid.UUID, bool) {
+	workspaceID, ok := ctx.Value(workspaceKey{}).(uuid.UUID)
+	return workspaceID, ok && workspaceID != uuid.Nil
+}
+
+// Workspace returns the workspace the context acts in, or ErrNoWorkspace. Queries must never fall back to
+// another workspace.
+func Workspace(ctx context.Context) (uuid.UUID, error) {
+	workspaceID, ok := WorkspaceFromContext(ctx)
+	if !ok {
+		return uuid.Nil, ErrNoWorkspace
+	}
+	return workspaceID, nil
+}
@@ -0,0 +1,27 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestWorkspace(t *testing.T) {
+	if _, err := Workspace(context.Background()); !errors.Is(err, ErrNoWorkspace) {
+		t.Errorf("Expected ErrNoWorkspace, got %v", err)
+	}
+	if _, err := Workspace(WithWorkspace(context.Background(), uuid.Nil)); !errors.Is(err, ErrNoWorkspace) {
+		t.Errorf("Expected ErrNoWorkspace for the nil workspace, got %v", err)
+	}
+
+	workspaceID := uuid.New()
+	got, err := Workspace(WithWorkspace(context.Background(), workspaceID))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got != workspaceID {
+		t.Errorf("Expected workspace %s, got %s", workspaceID, got)
+	}
+}
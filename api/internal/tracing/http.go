@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"workflow-code-test/api/internal/requestid"
+)
+
+// requestIDKey is the span attribute holding the request's X-Request-ID, to find its audit events by
+const requestIDKey = "http.request.id"
+
+// Middleware starts a server span for every request, continuing the trace of the caller's traceparent
+// header. Spans are named by route template, such as POST /api/v1/workflows/{id}/execute.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		name := r.Method
+		attributes := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLPath(r.URL.Path),
+		}
+		if route := routeTemplate(r); route != "" {
+			name = fmt.Sprintf("%s %s", r.Method, route)
+			attributes = append(attributes, semconv.HTTPRoute(route))
+		}
+		ctx, span := Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attributes...))
+		defer span.End()
+
+		captured := httpsnoop.CaptureMetrics(next, w, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(captured.Code))
+		if id := w.Header().Get(requestid.Header); id != "" {
+			span.SetAttributes(attribute.String(requestIDKey, id))
+		}
+		// Client errors are the caller's problem, only server errors fail the span
+		if captured.Code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(captured.Code))
+		}
+	})
+}
+
+// routeTemplate returns the template of the route the request matched, empty if it matched none
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+// Package tracing sets up OpenTelemetry tracing of API requests, executions, database queries and outbound
+// API calls, exported over OTLP or written to stdout or a file.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer every span of the API is started with
+const instrumentationName = "workflow-code-test/api"
+
+// defaultServiceName is the service spans are reported for unless OTEL_SERVICE_NAME says otherwise
+const defaultServiceName = "workflow-api"
+
+// Exporters selectable with OTEL_TRACES_EXPORTER
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Config selects where spans are exported to
+type Config struct {
+	// Exporter is one of the Exporter constants, ExporterNone disables tracing
+	Exporter string
+	// File is the file ExporterFile appends spans to, one JSON document per span
+	File string
+}
+
+// ConfigFromEnv reads the exporter from OTEL_TRACES_EXPORTER, none by default, and the file of the file
+// exporter from OTEL_TRACES_FILE. The OTLP exporter is configured by the standard OTEL_EXPORTER_OTLP_*
+// variables, such as OTEL_EXPORTER_OTLP_ENDPOINT.
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		Exporter: os.Getenv("OTEL_TRACES_EXPORTER"),
+		File:     os.Getenv("OTEL_TRACES_FILE"),
+	}
+	switch config.Exporter {
+	case "":
+		config.Exporter = ExporterNone
+	case ExporterNone, ExporterOTLP, ExporterStdout:
+	case ExporterFile:
+		if config.File == "" {
+			return Config{}, errors.New("OTEL_TRACES_FILE must be set for the file exporter")
+		}
+	default:
+		return Config{}, fmt.Errorf("invalid OTEL_TRACES_EXPORTER '%s', must be one of none, otlp, stdout or file", config.Exporter)
+	}
+	return config, nil
+}
+
+// Setup installs the global tracer provider and the W3C trace context propagator. The returned shutdown
+// flushes the spans not exported yet and must be called before the API exits. Without an exporter spans
+// aren't recorded, but incoming trace context is still passed on to outbound calls.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, closeOutput, err := newExporter(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(defaultServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		if closeOutput != nil {
+			closeOutput.Close()
+		}
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	// The sampler is read from OTEL_TRACES_SAMPLER, every trace by default
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeOutput != nil {
+			err = errors.Join(err, closeOutput.Close())
+		}
+		return err
+	}, nil
+}
+
+// newExporter creates the configured exporter, nil without one, along with the file it writes to if any
+func newExporter(ctx context.Context, config Config) (sdktrace.SpanExporter, io.Closer, error) {
+	switch config.Exporter {
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create stdout trace exporter: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterFile:
+		file, err := os.OpenFile(config.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("failed to create file trace exporter: %w", err)
+		}
+		return exporter, file, nil
+	default:
+		return nil, nil, nil
+	}
+}
+
+// Tracer returns the tracer spans of the API are started with, from the global tracer provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// RecordError marks a span failed with err, doing nothing for a nil error
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		file     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "disabled by default", expected: ExporterNone},
+		{name: "otlp", exporter: "otlp", expected: ExporterOTLP},
+		{name: "stdout", exporter: "stdout", expected: ExporterStdout},
+		{name: "file", exporter: "file", file: "traces.jsonl", expected: ExporterFile},
+		{name: "file without a path", exporter: "file", wantErr: true},
+		{name: "unknown exporter", exporter: "zipkin", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_TRACES_EXPORTER", tt.exporter)
+			t.Setenv("OTEL_TRACES_FILE", tt.file)
+
+			config, err := ConfigFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if config.Exporter != tt.expected {
+				t.Errorf("Expected exporter %s, got %s", tt.expected, config.Exporter)
+			}
+		})
+	}
+}
+
+func TestSetup_FileExporter(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterFile, File: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, span := Tracer().Start(context.Background(), "workflow.execute")
+	span.End()
+
+	// Shutting down flushes the batched span to the file
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read trace file: %v", err)
+	}
+	if !strings.Contains(string(written), `"Name":"workflow.execute"`) {
+		t.Errorf("Expected the span in the trace file, got %s", written)
+	}
+	if !strings.Contains(string(written), defaultServiceName) {
+		t.Errorf("Expected the service name %s in the trace file, got %s", defaultServiceName, written)
+	}
+}
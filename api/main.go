@@ -3,25 +3,59 @@ package main
 import (
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 
+	"workflow-code-test/api/internal/auth"
+	"workflow-code-test/api/internal/execution"
+	"workflow-code-test/api/internal/health"
+	"workflow-code-test/api/internal/metrics"
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/tracing"
 	"workflow-code-test/api/pkg/db"
 	"workflow-code-test/api/services/workflow"
 )
 
+// version is the build's version, set with -ldflags "-X main.version=..."
+var version = "dev"
+
+// addr is the address the API listens on
+const addr = ":8080"
+
 func main() {
-	// Configure structured logging
+	// Configure structured logging, with personal data and secrets masked
 	logHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	})
-	slog.SetDefault(slog.New(logHandler))
+	slog.SetDefault(slog.New(execution.NewRedactingLogHandler(logHandler, execution.DefaultSensitiveLogKeys...)))
+
+	// Trace requests, executions, queries and API calls to the exporter OTEL_TRACES_EXPORTER selects
+	traceConfig, err := tracing.ConfigFromEnv()
+	if err != nil {
+		slog.Error("Invalid tracing configuration", "error", err)
+		return
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), traceConfig)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
+		return
+	}
+	defer func() {
+		// Flush the spans not exported yet
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
 
 	dbConfig := db.DefaultConfig()
 	dbConfig.URI = os.Getenv("DATABASE_URL")
@@ -33,8 +67,36 @@ func main() {
 
 	defer db.Disconnect()
 
+	// The pgx pool's stats are served with the other metrics
+	if err := metrics.Register(metrics.NewPgxPoolCollector(db.GetPool())); err != nil {
+		slog.Error("Failed to register database pool metrics", "error", err)
+		return
+	}
+
+	// Readiness waits for the schema to reach the newest migration this build ships
+	migrationsPath := os.Getenv("MIGRATIONS_PATH")
+	if migrationsPath == "" {
+		migrationsPath = "./migrations"
+	}
+	expectedMigration, err := db.LatestMigrationVersion(migrationsPath)
+	if err != nil {
+		slog.Error("Failed to read migrations", "error", err, "migrationsPath", migrationsPath)
+		return
+	}
+
+	probes := health.NewProbes(version, configSummary(dbConfig, traceConfig, migrationsPath, expectedMigration))
+	probes.AddCheck("database", db.HealthCheck)
+	probes.AddCheck("migrations", health.MigrationCheck(expectedMigration, db.SchemaVersion))
+
 	// setup router
 	mainRouter := mux.NewRouter()
+	// Every request gets a span and is counted and timed by route, the metrics are served unauthenticated
+	// for Prometheus
+	mainRouter.Use(tracing.Middleware)
+	mainRouter.Use(metrics.Middleware)
+	mainRouter.Handle("/metrics", metrics.Handler()).Methods("GET")
+	// Probes for orchestration, unauthenticated too
+	probes.LoadRoutes(mainRouter)
 
 	apiRouter := mainRouter.PathPrefix("/api/v1").Subrouter()
 
@@ -53,28 +115,46 @@ func main() {
 	}
 
 	workflowService.LoadRoutes(apiRouter, false)
-
-	// Configure CORS
+	// The debug info names internal settings, so it is served to authenticated admins only
+	apiRouter.Handle("/admin/debug/info", auth.RequireScope(models.ScopeAdmin, http.HandlerFunc(probes.HandleDebugInfo))).Methods("GET")
+
+	// Configure CORS, the frontend URL by default
+	allowedOrigins := []string{"http://localhost:3003"}
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		allowedOrigins = nil
+		for _, origin := range strings.Split(origins, ",") {
+			allowedOrigins = append(allowedOrigins, strings.TrimSpace(origin))
+		}
+	}
 	corsHandler := handlers.CORS(
-		handlers.AllowedOrigins([]string{"http://localhost:3003"}), // Frontend URL
+		handlers.AllowedOrigins(allowedOrigins),
 		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-API-Key", "X-Admin-Token", "X-Workspace-ID", "X-Request-ID", "Idempotency-Key"}),
+		handlers.ExposedHeaders([]string{"X-Request-ID", "Idempotent-Replayed", "Retry-After"}),
 		handlers.AllowCredentials(),
 	)(mainRouter)
 
 	srv := &http.Server{
-		Addr:    ":8080",
+		Addr:    addr,
 		Handler: corsHandler,
 	}
 
+	// Listen before reporting ready, so traffic is only routed once connections are accepted
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		slog.Error("Failed to listen", "error", err, "addr", addr)
+		return
+	}
+
 	// Channel to listen for errors coming from the server
 	serverErrors := make(chan error, 1)
 
 	// Start the server in a goroutine
 	go func() {
-		slog.Info("Starting server on :8080")
-		serverErrors <- srv.ListenAndServe()
+		slog.Info("Starting server on " + addr)
+		serverErrors <- srv.Serve(listener)
 	}()
+	probes.SetStarted(true)
 
 	// Channel to listen for an interrupt or terminate signal from the OS
 	shutdown := make(chan os.Signal, 1)
@@ -87,6 +167,7 @@ func main() {
 
 	case sig := <-shutdown:
 		slog.Info("Shutdown signal received", "signal", sig)
+		probes.SetStarted(false)
 
 		// Give outstanding requests 5 seconds to complete
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -98,3 +179,27 @@ func main() {
 		}
 	}
 }
+
+// configSummary describes how the API is configured for the debug info, without the database URL or any
+// credentials
+func configSummary(dbConfig *db.Config, traceConfig tracing.Config, migrationsPath string, expectedMigration uint) map[string]any {
+	apiCacheStore := os.Getenv("API_CACHE_STORE")
+	if apiCacheStore == "" {
+		apiCacheStore = "memory"
+	}
+	return map[string]any{
+		"addr":                     addr,
+		"authDisabled":             os.Getenv("AUTH_DISABLED") == "true",
+		"workspaceRLS":             os.Getenv("WORKSPACE_RLS") == "true",
+		"apiCacheStore":            apiCacheStore,
+		"tracesExporter":           traceConfig.Exporter,
+		"migrationsPath":           migrationsPath,
+		"expectedMigrationVersion": expectedMigration,
+		"db": map[string]any{
+			"maxOpenConns":    dbConfig.MaxOpenConns,
+			"maxIdleConns":    dbConfig.MaxIdleConns,
+			"connMaxLifetime": dbConfig.ConnMaxLifetime.String(),
+			"queryTimeout":    dbConfig.QueryTimeout.String(),
+		},
+	}
+}
@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_api_response_cache_expires_at;
+
+-- Drop the api_response_cache table
+DROP TABLE IF EXISTS api_response_cache;
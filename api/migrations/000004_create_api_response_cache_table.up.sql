@@ -0,0 +1,12 @@
+-- Create api_response_cache table, the integration response cache shared by API replicas
+CREATE TABLE IF NOT EXISTS api_response_cache (
+    key VARCHAR(64) PRIMARY KEY,
+    url TEXT NOT NULL,
+    status_code INTEGER NOT NULL,
+    body JSONB NOT NULL,
+    stored_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_api_response_cache_expires_at ON api_response_cache(expires_at);
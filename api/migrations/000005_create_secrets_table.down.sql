@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_secrets_key_id;
+
+-- Drop the secrets table
+DROP TABLE IF EXISTS secrets;
@@ -0,0 +1,15 @@
+-- Create secrets table, integration credentials encrypted with envelope encryption
+CREATE TABLE IF NOT EXISTS secrets (
+    name VARCHAR(128) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    -- Value encrypted with the secret's own data key, base64 with the nonce first
+    ciphertext TEXT NOT NULL,
+    -- Data key encrypted with the master key named by key_id, base64 with the nonce first
+    wrapped_key TEXT NOT NULL,
+    key_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_secrets_key_id ON secrets(key_id);
@@ -0,0 +1,15 @@
+-- Create api_keys table, keys are only stored hashed
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    -- Random lookup prefix, the part of the key between wf_ and the secret
+    prefix VARCHAR(32) NOT NULL UNIQUE,
+    -- Hex SHA-256 of the full key
+    key_hash VARCHAR(64) NOT NULL,
+    -- Comma separated scopes, e.g. read,execute
+    scopes TEXT NOT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,8 @@
+-- Drop trigger
+DROP TRIGGER IF EXISTS update_workflow_permissions_updated_at ON workflow_permissions;
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_workflow_permissions_subject;
+
+-- Drop the workflow_permissions table
+DROP TABLE IF EXISTS workflow_permissions;
@@ -0,0 +1,21 @@
+-- Create workflow_permissions table, the role each caller has on a workflow
+CREATE TABLE IF NOT EXISTS workflow_permissions (
+    workflow_id UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    -- Caller identity subject, e.g. a JWT sub or api-key:<id>
+    subject VARCHAR(255) NOT NULL,
+    -- One of owner, editor, executor, viewer
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'editor', 'executor', 'viewer')),
+    granted_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workflow_id, subject)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_workflow_permissions_subject ON workflow_permissions(subject);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_workflow_permissions_updated_at
+    BEFORE UPDATE ON workflow_permissions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
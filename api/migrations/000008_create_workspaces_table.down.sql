@@ -0,0 +1,42 @@
+-- Drop row level security
+DROP POLICY IF EXISTS workspace_isolation ON workspace_execution_counts;
+DROP POLICY IF EXISTS workspace_isolation ON api_response_cache;
+ALTER TABLE api_response_cache DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON secrets;
+ALTER TABLE secrets DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON workflow_permissions;
+ALTER TABLE workflow_permissions DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON edges;
+ALTER TABLE edges DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON nodes;
+ALTER TABLE nodes DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON workflows;
+ALTER TABLE workflows DISABLE ROW LEVEL SECURITY;
+
+-- Drop the execution counts
+DROP TABLE IF EXISTS workspace_execution_counts;
+
+-- Cached responses go back to being shared, dropping them as they were fetched for one workspace
+DELETE FROM api_response_cache;
+ALTER TABLE api_response_cache DROP CONSTRAINT api_response_cache_pkey;
+ALTER TABLE api_response_cache ADD PRIMARY KEY (key);
+ALTER TABLE api_response_cache DROP COLUMN IF EXISTS workspace_id;
+
+-- Secrets sealed with their workspace can't be opened once the flag is gone, set them again after going back
+ALTER TABLE secrets DROP COLUMN IF EXISTS workspace_bound;
+
+-- Secret names go back to being unique across workspaces, which fails if two workspaces share one
+ALTER TABLE secrets DROP CONSTRAINT secrets_pkey;
+ALTER TABLE secrets ADD PRIMARY KEY (name);
+ALTER TABLE secrets DROP COLUMN IF EXISTS workspace_id;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS workspace_id;
+
+DROP INDEX IF EXISTS idx_workflows_workspace_id;
+ALTER TABLE workflows DROP COLUMN IF EXISTS workspace_id;
+
+-- Drop trigger
+DROP TRIGGER IF EXISTS update_workspaces_updated_at ON workspaces;
+
+-- Drop the workspaces table
+DROP TABLE IF EXISTS workspaces;
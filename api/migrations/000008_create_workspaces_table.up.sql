@@ -0,0 +1,83 @@
+-- Create workspaces table, every workflow, secret and API key belongs to one
+CREATE TABLE IF NOT EXISTS workspaces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug VARCHAR(64) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    -- Executions allowed per UTC day, 0 for no limit
+    daily_execution_quota INTEGER NOT NULL DEFAULT 0 CHECK (daily_execution_quota >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_workspaces_updated_at
+    BEFORE UPDATE ON workspaces
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Existing data moves to the default workspace
+INSERT INTO workspaces (id, slug, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Default')
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE workflows ADD COLUMN workspace_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES workspaces(id);
+ALTER TABLE workflows ALTER COLUMN workspace_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS idx_workflows_workspace_id ON workflows(workspace_id);
+
+ALTER TABLE api_keys ADD COLUMN workspace_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES workspaces(id);
+ALTER TABLE api_keys ALTER COLUMN workspace_id DROP DEFAULT;
+
+-- Secret names are unique per workspace
+ALTER TABLE secrets ADD COLUMN workspace_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES workspaces(id);
+ALTER TABLE secrets ALTER COLUMN workspace_id DROP DEFAULT;
+ALTER TABLE secrets DROP CONSTRAINT secrets_pkey;
+ALTER TABLE secrets ADD PRIMARY KEY (workspace_id, name);
+-- Values sealed so far only bind their name into the encryption, so an envelope copied to the same name in
+-- another workspace would still open. New values bind the workspace too, and POST /api/v1/admin/secrets/rotate
+-- reseals the older ones.
+ALTER TABLE secrets ADD COLUMN workspace_bound BOOLEAN NOT NULL DEFAULT false;
+
+-- Cached integration responses belong to the workspace whose execution fetched them. They are disposable, so
+-- they are dropped rather than moved to the default workspace.
+DELETE FROM api_response_cache;
+ALTER TABLE api_response_cache ADD COLUMN workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE api_response_cache DROP CONSTRAINT api_response_cache_pkey;
+ALTER TABLE api_response_cache ADD PRIMARY KEY (workspace_id, key);
+
+-- Executions per workspace and UTC day, for daily quotas
+CREATE TABLE IF NOT EXISTS workspace_execution_counts (
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    executions INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (workspace_id, day)
+);
+
+-- Row level security, only enforced for roles that don't own the tables. The API sets app.workspace_id on
+-- every connection when WORKSPACE_RLS=true.
+ALTER TABLE workflows ENABLE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON workflows
+    USING (workspace_id = NULLIF(current_setting('app.workspace_id', true), '')::uuid);
+
+ALTER TABLE nodes ENABLE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON nodes
+    USING (workflow_id IN (SELECT id FROM workflows));
+
+ALTER TABLE edges ENABLE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON edges
+    USING (workflow_id IN (SELECT id FROM workflows));
+
+ALTER TABLE workflow_permissions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON workflow_permissions
+    USING (workflow_id IN (SELECT id FROM workflows));
+
+ALTER TABLE secrets ENABLE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON secrets
+    USING (workspace_id = NULLIF(current_setting('app.workspace_id', true), '')::uuid);
+
+ALTER TABLE api_response_cache ENABLE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON api_response_cache
+    USING (workspace_id = NULLIF(current_setting('app.workspace_id', true), '')::uuid);
+
+ALTER TABLE workspace_execution_counts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON workspace_execution_counts
+    USING (workspace_id = NULLIF(current_setting('app.workspace_id', true), '')::uuid);
@@ -0,0 +1,3 @@
+-- Drop audit_events table, its triggers and policy go with it
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS reject_audit_event_changes();
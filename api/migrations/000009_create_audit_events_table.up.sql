@@ -0,0 +1,46 @@
+-- Create audit_events table, an append-only record of who changed or ran what
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    workspace_id UUID NOT NULL REFERENCES workspaces(id),
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    -- Caller identity subject and how it authenticated, e.g. api_key
+    actor VARCHAR(255) NOT NULL,
+    actor_method VARCHAR(32) NOT NULL DEFAULT '',
+    -- What happened, e.g. workflow.update, and to what
+    action VARCHAR(64) NOT NULL,
+    resource_type VARCHAR(32) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL DEFAULT '',
+    -- X-Request-ID of the request that caused the event
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    -- Summaries of the resource before and after the change, never secret values
+    before JSONB,
+    after JSONB
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_audit_events_workspace_occurred_at ON audit_events(workspace_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_resource ON audit_events(resource_type, resource_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor);
+
+-- Audit events are never changed or removed once written
+CREATE OR REPLACE FUNCTION reject_audit_event_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_audit_event_changes();
+
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION reject_audit_event_changes();
+
+-- Row level security, like the other workspace tables
+ALTER TABLE audit_events ENABLE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON audit_events
+    USING (workspace_id = NULLIF(current_setting('app.workspace_id', true), '')::uuid);
@@ -0,0 +1,2 @@
+-- Drop execution_idempotency_keys table
+DROP TABLE IF EXISTS execution_idempotency_keys;
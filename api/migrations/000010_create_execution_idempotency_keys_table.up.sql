@@ -0,0 +1,28 @@
+-- Create execution_idempotency_keys table, the results of executions requested with an Idempotency-Key, so
+-- retried requests replay them instead of running the workflow again
+CREATE TABLE IF NOT EXISTS execution_idempotency_keys (
+    workspace_id UUID NOT NULL REFERENCES workspaces(id),
+    -- Keys are scoped to the caller that sent them
+    subject VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    -- SHA-256 of the execution request, a key can't be reused for a different request
+    fingerprint VARCHAR(64) NOT NULL,
+    workflow_id UUID NOT NULL,
+    -- The execution response, NULL while the execution is running
+    response JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    -- Running executions expire after the lock timeout, completed ones after the replay window
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    -- Each reservation of the key gets its own token, so an execution that outlived the lock timeout can't
+    -- store its response into, or release, the reservation of the retry that took the key over
+    reservation UUID NOT NULL DEFAULT gen_random_uuid(),
+    PRIMARY KEY (workspace_id, subject, key)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_execution_idempotency_keys_expires_at ON execution_idempotency_keys(expires_at);
+
+-- Row level security, like the other workspace tables
+ALTER TABLE execution_idempotency_keys ENABLE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON execution_idempotency_keys
+    USING (workspace_id = NULLIF(current_setting('app.workspace_id', true), '')::uuid);
@@ -1,14 +1,19 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/lib/pq"
 )
 
@@ -88,3 +93,48 @@ func GetMigrationVersion(databaseURL, migrationsPath string) (uint, bool, error)
 
 	return version, dirty, nil
 }
+
+// undefinedTableCode is the Postgres error code for a missing table
+const undefinedTableCode = "42P01"
+
+// SchemaVersion returns the migration the schema is at and whether it is dirty, like GetMigrationVersion but
+// read from the migrations table through the pool, without the migration lock, so it honours ctx. It returns 0
+// if no migration ran yet.
+func SchemaVersion(ctx context.Context) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == undefinedTableCode) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to get migration version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
+
+// LatestMigrationVersion returns the version of the newest migration in the migrations directory, the
+// version the database is at once every migration ran
+func LatestMigrationVersion(migrationsPath string) (uint, error) {
+	driver, err := source.Open(fmt.Sprintf("file://%s", migrationsPath))
+	if err != nil {
+		return 0, fmt.Errorf("failed to open migrations: %w", err)
+	}
+	defer driver.Close()
+
+	version, err := driver.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	for {
+		next, err := driver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
+	}
+}
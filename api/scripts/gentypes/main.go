@@ -0,0 +1,170 @@
+// Command gentypes generates the TypeScript node data types for the web editor from the JSON Schemas
+// of the registered node types, so the frontend and the API validation share a single source.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"workflow-code-test/api/internal/models"
+)
+
+func main() {
+	output := flag.String("out", "../web/src/generated/nodeData.ts", "Path of the TypeScript file to write")
+	flag.Parse()
+
+	source, err := generate(models.RegisteredNodeTypes())
+	if err != nil {
+		log.Fatalf("Failed to generate types: %v", err)
+	}
+
+	if err := os.WriteFile(*output, source, 0o644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *output, err)
+	}
+
+	fmt.Printf("Wrote node data types to %s\n", *output)
+}
+
+// generator accumulates named TypeScript object types, one per titled struct schema
+type generator struct {
+	types map[string]string
+	order []string
+}
+
+func generate(nodeTypes []models.NodeType) ([]byte, error) {
+	g := &generator{types: make(map[string]string)}
+
+	dataTypes := make(map[string]string, len(nodeTypes))
+	names := make([]string, 0, len(nodeTypes))
+	for _, nodeType := range nodeTypes {
+		description := nodeType.Describe()
+		if description.DataSchema == nil || description.DataSchema.Title == "" {
+			return nil, fmt.Errorf("node type %s has no named data schema", description.Type)
+		}
+		dataTypes[description.Type] = g.typeOf(description.DataSchema)
+		names = append(names, description.Type)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by api/scripts/gentypes from the node data JSON Schemas. DO NOT EDIT.\n")
+
+	for _, name := range g.order {
+		buf.WriteString("\n")
+		buf.WriteString(g.types[name])
+	}
+
+	buf.WriteString("\nexport type NodeDataByType = {\n")
+	for _, name := range names {
+		fmt.Fprintf(&buf, "  %s: %s;\n", propertyName(name), dataTypes[name])
+	}
+	buf.WriteString("};\n")
+	buf.WriteString("\nexport type NodeType = keyof NodeDataByType;\n")
+
+	return buf.Bytes(), nil
+}
+
+// typeOf returns the TypeScript type of a schema, registering a named type for titled structs
+func (g *generator) typeOf(schema *models.Schema) string {
+	var types []string
+	for _, alternative := range schema.AnyOf {
+		types = append(types, g.typeOf(alternative))
+	}
+	for _, jsonType := range schema.Type {
+		types = append(types, g.typeOfJSONType(schema, jsonType))
+	}
+	if len(types) == 0 {
+		return "unknown"
+	}
+	return strings.Join(types, " | ")
+}
+
+func (g *generator) typeOfJSONType(schema *models.Schema, jsonType string) string {
+	switch jsonType {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "null":
+		return "null"
+	case "array":
+		if schema.Items == nil {
+			return "unknown[]"
+		}
+		itemType := g.typeOf(schema.Items)
+		if strings.Contains(itemType, " ") {
+			return "(" + itemType + ")[]"
+		}
+		return itemType + "[]"
+	case "object":
+		if valueSchema, ok := schema.AdditionalProperties.(*models.Schema); ok {
+			return "Record<string, " + g.typeOf(valueSchema) + ">"
+		}
+		if schema.Title == "" {
+			return "Record<string, unknown>"
+		}
+		g.addType(schema)
+		return schema.Title
+	default:
+		return "unknown"
+	}
+}
+
+// addType renders a struct schema as an exported type alias, once per title. Aliases rather than interfaces,
+// since only aliases are assignable to the Record<string, unknown> React Flow requires of node data.
+func (g *generator) addType(schema *models.Schema) {
+	if _, exists := g.types[schema.Title]; exists {
+		return
+	}
+	// Reserve the name first so recursive schemas terminate
+	g.types[schema.Title] = ""
+
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	properties := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		properties = append(properties, name)
+	}
+	sort.Slice(properties, func(i, j int) bool {
+		// Required properties first, then alphabetical
+		if required[properties[i]] != required[properties[j]] {
+			return required[properties[i]]
+		}
+		return properties[i] < properties[j]
+	})
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "export type %s = {\n", schema.Title)
+	for _, name := range properties {
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(&buf, "  %s%s: %s;\n", propertyName(name), optional, g.typeOf(schema.Properties[name]))
+	}
+	buf.WriteString("};\n")
+
+	g.types[schema.Title] = buf.String()
+	g.order = append(g.order, schema.Title)
+}
+
+// propertyName quotes property names that aren't valid TypeScript identifiers
+func propertyName(name string) string {
+	for i, r := range name {
+		isLetter := r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return "'" + name + "'"
+		}
+	}
+	return name
+}
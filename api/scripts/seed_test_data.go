@@ -10,6 +10,7 @@ import (
 
 	"workflow-code-test/api/internal/models"
 	"workflow-code-test/api/internal/repository"
+	"workflow-code-test/api/internal/tenant"
 	"workflow-code-test/api/pkg/db"
 )
 
@@ -173,9 +174,9 @@ func SeedTestData(databaseURL string) error {
 		}
 	}
 
-	// Save the workflow
-	ctx := context.Background()
-	if err := repo.SaveWorkflow(ctx, workflow, nodes, edges); err != nil {
+	// Save the workflow in the default workspace without an owner, only admins can see it until it is shared
+	ctx := tenant.WithWorkspace(context.Background(), models.DefaultWorkspaceID)
+	if _, err := repo.SaveWorkflow(ctx, workflow, nodes, edges, "", nil); err != nil {
 		return err
 	}
 
@@ -0,0 +1,21 @@
+package workflow
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+func (s *Service) HandleListCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Listing circuit breakers")
+
+	states := s.guardedClient.CircuitBreakerStates()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(states); err != nil {
+		slog.Error("Failed to encode circuit breakers", "error", err)
+		return
+	}
+}
@@ -0,0 +1,74 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/service"
+)
+
+func (s *Service) HandleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Listing API keys")
+
+	keys, err := s.apiKeyService.ListAPIKeys(r.Context())
+	if err != nil {
+		slog.Error("Failed to list API keys", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (s *Service) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var keyRequest models.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&keyRequest); err != nil {
+		slog.Error("Failed to decode request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	slog.Debug("Creating API key", "name", keyRequest.Name)
+
+	if err := keyRequest.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := s.apiKeyService.CreateAPIKey(r.Context(), &keyRequest)
+	if err != nil {
+		slog.Error("Failed to create API key", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, key)
+}
+
+func (s *Service) HandleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	slog.Debug("Revoking API key", "id", id)
+
+	keyID, err := uuid.Parse(id)
+	if err != nil {
+		http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.apiKeyService.RevokeAPIKey(r.Context(), keyID); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to revoke API key", "id", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,59 @@
+package workflow
+
+import (
+	"encoding/csv"
+	"log/slog"
+	"net/http"
+
+	"workflow-code-test/api/internal/models"
+)
+
+func (s *Service) HandleListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Listing audit events")
+
+	filter, err := models.AuditFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := s.auditService.ListEvents(r.Context(), filter)
+	if err != nil {
+		slog.Error("Failed to list audit events", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+// HandleExportAuditEvents streams every audit event matching the query as CSV, newest first
+func (s *Service) HandleExportAuditEvents(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Exporting audit events")
+
+	filter, err := models.AuditFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-events.csv"`)
+
+	// Once rows are written the status can't change, a failure part way is only logged and ends the file early
+	writer := csv.NewWriter(w)
+	if err := writer.Write(models.AuditCSVHeader); err != nil {
+		slog.Error("Failed to write audit export", "error", err)
+		return
+	}
+	err = s.auditService.ExportEvents(r.Context(), filter, func(event *models.AuditEvent) error {
+		return writer.Write(event.CSVRecord())
+	})
+	writer.Flush()
+	if err == nil {
+		err = writer.Error()
+	}
+	if err != nil {
+		slog.Error("Failed to export audit events", "error", err)
+	}
+}
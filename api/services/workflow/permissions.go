@@ -0,0 +1,137 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/repository"
+	"workflow-code-test/api/internal/service"
+)
+
+func (s *Service) HandleListWorkflows(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Listing workflows")
+
+	workflows, err := s.workflowService.ListWorkflows(r.Context())
+	if err != nil {
+		if writeWorkflowAccessError(w, err) {
+			return
+		}
+		slog.Error("Failed to list workflows", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, workflows)
+}
+
+func (s *Service) HandleListPermissions(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	slog.Debug("Listing workflow permissions", "id", id)
+
+	workflowID, err := uuid.Parse(id)
+	if err != nil {
+		http.Error(w, "Invalid workflow ID", http.StatusBadRequest)
+		return
+	}
+
+	permissions, err := s.workflowService.ListPermissions(r.Context(), workflowID)
+	if err != nil {
+		if writeWorkflowAccessError(w, err) {
+			return
+		}
+		slog.Error("Failed to list workflow permissions", "id", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, permissions)
+}
+
+func (s *Service) HandleSetPermission(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, subject := vars["id"], vars["subject"]
+
+	workflowID, err := uuid.Parse(id)
+	if err != nil {
+		http.Error(w, "Invalid workflow ID", http.StatusBadRequest)
+		return
+	}
+
+	var permissionRequest models.WorkflowPermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&permissionRequest); err != nil {
+		slog.Error("Failed to decode request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	slog.Debug("Sharing workflow", "id", id, "subject", subject, "role", permissionRequest.Role)
+
+	if err := models.ValidatePermissionSubject(subject); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := permissionRequest.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	permission, err := s.workflowService.SetPermission(r.Context(), workflowID, subject, &permissionRequest)
+	if err != nil {
+		if writeWorkflowAccessError(w, err) {
+			return
+		}
+		slog.Error("Failed to share workflow", "id", id, "subject", subject, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, permission)
+}
+
+func (s *Service) HandleDeletePermission(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, subject := vars["id"], vars["subject"]
+	slog.Debug("Unsharing workflow", "id", id, "subject", subject)
+
+	workflowID, err := uuid.Parse(id)
+	if err != nil {
+		http.Error(w, "Invalid workflow ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.workflowService.DeletePermission(r.Context(), workflowID, subject); err != nil {
+		if writeWorkflowAccessError(w, err) {
+			return
+		}
+		slog.Error("Failed to unshare workflow", "id", id, "subject", subject, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeWorkflowAccessError writes the response for workflow access and sharing errors, returning false
+// for any other error
+func writeWorkflowAccessError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrWorkflowNotFound):
+		http.Error(w, "Workflow not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrWorkflowForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrPermissionNotFound):
+		http.Error(w, "Workflow permission not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrLastOwner):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, repository.ErrWorkflowIDTaken):
+		http.Error(w, "Workflow ID is taken", http.StatusConflict)
+	default:
+		return false
+	}
+	return true
+}
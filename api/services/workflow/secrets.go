@@ -0,0 +1,149 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/repository"
+	"workflow-code-test/api/internal/service"
+)
+
+// None of the secret handlers ever return or log a secret's value
+
+func (s *Service) HandleListSecrets(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Listing secrets")
+
+	secrets, err := s.secretService.ListSecrets(r.Context())
+	if err != nil {
+		writeSecretError(w, "", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, secrets)
+}
+
+func (s *Service) HandleGetSecret(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	slog.Debug("Getting secret", "name", name)
+
+	secret, err := s.secretService.GetSecret(r.Context(), name)
+	if err != nil {
+		writeSecretError(w, name, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, secret)
+}
+
+func (s *Service) HandleCreateSecret(w http.ResponseWriter, r *http.Request) {
+	var secretRequest models.SecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&secretRequest); err != nil {
+		slog.Error("Failed to decode request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	slog.Debug("Creating secret", "name", secretRequest.Name)
+
+	if err := secretRequest.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	secret, err := s.secretService.CreateSecret(r.Context(), &secretRequest)
+	if err != nil {
+		writeSecretError(w, secretRequest.Name, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, secret)
+}
+
+func (s *Service) HandleUpdateSecret(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	slog.Debug("Updating secret", "name", name)
+
+	var secretRequest models.SecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&secretRequest); err != nil {
+		slog.Error("Failed to decode request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// The path is the source of truth for which secret is being updated, secrets can't be renamed
+	if secretRequest.Name != "" && secretRequest.Name != name {
+		http.Error(w, "Secret name in body does not match path", http.StatusBadRequest)
+		return
+	}
+	if err := secretRequest.ValidateUpdate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	secret, err := s.secretService.UpdateSecret(r.Context(), name, &secretRequest)
+	if err != nil {
+		writeSecretError(w, name, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, secret)
+}
+
+func (s *Service) HandleDeleteSecret(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	slog.Debug("Deleting secret", "name", name)
+
+	if err := s.secretService.DeleteSecret(r.Context(), name); err != nil {
+		writeSecretError(w, name, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Service) HandleRotateSecrets(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Rotating secrets")
+
+	rotated, err := s.secretService.RotateSecrets(r.Context())
+	if err != nil {
+		writeSecretError(w, "", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rotateSecretsResponse{Rotated: rotated})
+}
+
+// rotateSecretsResponse is the JSON body returned by the rotate endpoint
+type rotateSecretsResponse struct {
+	Rotated int `json:"rotated"`
+}
+
+// writeSecretError maps secret service errors to responses
+func writeSecretError(w http.ResponseWriter, name string, err error) {
+	switch {
+	case errors.Is(err, service.ErrSecretNotFound):
+		http.Error(w, "Secret not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrSecretExists):
+		http.Error(w, "Secret already exists", http.StatusConflict)
+	case errors.Is(err, service.ErrSecretsNotConfigured):
+		slog.Error("Secrets store not configured", "name", name)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		slog.Error("Secret operation failed", "name", name, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// writeJSON writes a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Error("Failed to encode response", "error", err)
+	}
+}
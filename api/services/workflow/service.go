@@ -2,20 +2,35 @@ package workflow
 
 import (
 	"database/sql"
+	"log/slog"
 	"net/http"
+	"os"
 
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 
+	"workflow-code-test/api/internal/auth"
+	"workflow-code-test/api/internal/execution"
+	"workflow-code-test/api/internal/metrics"
+	"workflow-code-test/api/internal/models"
 	"workflow-code-test/api/internal/repository"
+	"workflow-code-test/api/internal/requestid"
+	"workflow-code-test/api/internal/secrets"
 	"workflow-code-test/api/internal/service"
 	"workflow-code-test/api/pkg/db"
 )
 
 type Service struct {
-	db              *pgx.Conn
-	sqlDB           *sql.DB
-	workflowService *service.WorkflowService
+	db               *pgx.Conn
+	sqlDB            *sql.DB
+	workflowService  *service.WorkflowService
+	secretService    *service.SecretService
+	apiKeyService    *service.APIKeyService
+	workspaceService *service.WorkspaceService
+	auditService     *service.AuditService
+	guardedClient    *execution.GuardedAPIClient
+	authenticator    *auth.Authenticator
 }
 
 func NewService(conn *pgx.Conn, config *db.Config) (*Service, error) {
@@ -25,16 +40,89 @@ func NewService(conn *pgx.Conn, config *db.Config) (*Service, error) {
 		return nil, err
 	}
 
+	// Repositories query through this pool, so its stats are served with the other metrics
+	if err := metrics.Register(collectors.NewDBStatsCollector(sqlDB, "jet")); err != nil {
+		return nil, err
+	}
+
 	// Create repository using sql.DB
 	workflowRepo := repository.NewWorkflowRepository(sqlDB)
 
+	// Queries show up as spans of the request or execution they ran for
+	repository.EnableQueryTracing()
+
+	// Every query is scoped to the request's workspace, and with WORKSPACE_RLS the database enforces it too
+	if os.Getenv("WORKSPACE_RLS") == "true" {
+		repository.EnableRowLevelSecurity(true)
+		slog.Info("Workspace row level security enabled")
+	}
+	workspaceService := service.NewWorkspaceService(repository.NewWorkspaceRepository(sqlDB))
+	// Saves, executions, sharing, secret use and API keys are recorded in the workspace's audit log
+	auditService := service.NewAuditService(repository.NewAuditRepository(sqlDB))
+
+	// Cache integration responses in memory, and in Postgres too when replicas should share them
+	var responseCache execution.ResponseCache = execution.NewLRUResponseCache(execution.DefaultAPICacheSize)
+	if os.Getenv("API_CACHE_STORE") == "postgres" {
+		responseCache = execution.NewTieredResponseCache(responseCache, repository.NewAPIResponseCacheRepository(sqlDB))
+	}
+	// Integration endpoints are user editable, so only call the hosts and addresses the deployment allows
+	outboundPolicy, err := execution.OutboundPolicyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	guardConfig, err := execution.GuardConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	// Cache hits don't count against the circuit breakers and rate limits, so the cache goes outermost
+	// Calls are timed innermost, so the metrics only see calls that actually went out
+	httpClient := execution.NewInstrumentedAPIClient(execution.NewHTTPAPIClientWithPolicy(outboundPolicy))
+	guardedClient := execution.NewGuardedAPIClient(httpClient, guardConfig)
+	apiClient := execution.NewCachingAPIClient(guardedClient, responseCache, execution.DefaultAPICacheTTL)
+
+	// Secrets are encrypted with master keys from the environment, without them the store is unavailable
+	keyring, err := secrets.KeyringFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if keyring == nil {
+		slog.Warn("SECRETS_MASTER_KEYS is not set, secrets can't be stored or used")
+	}
+	secretService := service.NewSecretService(repository.NewSecretRepository(sqlDB), keyring).WithAuditLog(auditService)
+
+	// Callers authenticate with API keys, JWTs from the configured JWKS or the bootstrap admin token
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(sqlDB)).WithAuditLog(auditService)
+	authConfig, err := auth.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	authConfig.APIKeys = apiKeyService
+	authConfig.Workspaces = workspaceService
+
+	// Retried executions with the same Idempotency-Key replay the first response instead of running again
+	idempotencyConfig, err := service.IdempotencyConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(sqlDB), idempotencyConfig)
+
 	// Create service
-	workflowService := service.NewWorkflowService(workflowRepo)
+	engine := execution.NewEngineWithAPIClient(apiClient).WithSecretResolver(secretService)
+	workflowService := service.NewWorkflowServiceWithEngine(workflowRepo, engine).
+		WithExecutionQuota(workspaceService).
+		WithIdempotency(idempotencyService).
+		WithAuditLog(auditService)
 
 	return &Service{
-		db:              conn,
-		sqlDB:           sqlDB,
-		workflowService: workflowService,
+		db:               conn,
+		sqlDB:            sqlDB,
+		workflowService:  workflowService,
+		secretService:    secretService,
+		apiKeyService:    apiKeyService,
+		workspaceService: workspaceService,
+		auditService:     auditService,
+		guardedClient:    guardedClient,
+		authenticator:    auth.NewAuthenticator(authConfig),
 	}, nil
 }
 
@@ -46,12 +134,66 @@ func jsonMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// scoped wraps a handler so only callers granted scope reach it
+func scoped(scope string, handler http.HandlerFunc) http.Handler {
+	return auth.RequireScope(scope, handler)
+}
+
 func (s *Service) LoadRoutes(parentRouter *mux.Router, isProduction bool) {
+	// Every request gets an ID to trace it in the audit log by. Every route requires an authenticated caller,
+	// each route then requires its scope.
+	parentRouter.Use(requestid.Middleware)
+	parentRouter.Use(s.authenticator.Middleware)
+
+	parentRouter.Handle("/node-types", jsonMiddleware(scoped(models.ScopeRead, s.HandleListNodeTypes))).Methods("GET")
+	parentRouter.Handle("/node-types/{type}/schema", scoped(models.ScopeRead, s.HandleGetNodeTypeSchema)).Methods("GET")
+
 	router := parentRouter.PathPrefix("/workflows").Subrouter()
 	router.StrictSlash(false)
 	router.Use(jsonMiddleware)
 
-	router.HandleFunc("/{id}", s.HandleGetWorkflow).Methods("GET")
-	router.HandleFunc("/{id}/execute", s.HandleExecuteWorkflow).Methods("POST")
+	router.Handle("", scoped(models.ScopeRead, s.HandleListWorkflows)).Methods("GET")
+	router.Handle("/validate", scoped(models.ScopeRead, s.HandleValidateWorkflow)).Methods("POST")
+	router.Handle("/{id}", scoped(models.ScopeRead, s.HandleGetWorkflow)).Methods("GET")
+	router.Handle("/{id}", scoped(models.ScopeWrite, s.HandleSaveWorkflow)).Methods("PUT")
+	router.Handle("/{id}/execute", scoped(models.ScopeExecute, s.HandleExecuteWorkflow)).Methods("POST")
+	router.Handle("/{id}/permissions", scoped(models.ScopeRead, s.HandleListPermissions)).Methods("GET")
+	router.Handle("/{id}/permissions/{subject}", scoped(models.ScopeWrite, s.HandleSetPermission)).Methods("PUT")
+	router.Handle("/{id}/permissions/{subject}", scoped(models.ScopeWrite, s.HandleDeletePermission)).Methods("DELETE")
+
+	secretRouter := parentRouter.PathPrefix("/secrets").Subrouter()
+	secretRouter.Use(jsonMiddleware)
+
+	secretRouter.Handle("", scoped(models.ScopeRead, s.HandleListSecrets)).Methods("GET")
+	secretRouter.Handle("", scoped(models.ScopeWrite, s.HandleCreateSecret)).Methods("POST")
+	secretRouter.Handle("/{name}", scoped(models.ScopeRead, s.HandleGetSecret)).Methods("GET")
+	secretRouter.Handle("/{name}", scoped(models.ScopeWrite, s.HandleUpdateSecret)).Methods("PUT")
+	secretRouter.Handle("/{name}", scoped(models.ScopeWrite, s.HandleDeleteSecret)).Methods("DELETE")
+
+	apiKeyRouter := parentRouter.PathPrefix("/api-keys").Subrouter()
+	apiKeyRouter.Use(jsonMiddleware)
+
+	apiKeyRouter.Handle("", scoped(models.ScopeAdmin, s.HandleListAPIKeys)).Methods("GET")
+	apiKeyRouter.Handle("", scoped(models.ScopeAdmin, s.HandleCreateAPIKey)).Methods("POST")
+	apiKeyRouter.Handle("/{id}", scoped(models.ScopeAdmin, s.HandleRevokeAPIKey)).Methods("DELETE")
+
+	workspaceRouter := parentRouter.PathPrefix("/workspaces").Subrouter()
+	workspaceRouter.Use(jsonMiddleware)
+
+	workspaceRouter.Handle("", scoped(models.ScopeAdmin, s.HandleListWorkspaces)).Methods("GET")
+	workspaceRouter.Handle("", scoped(models.ScopeAdmin, s.HandleCreateWorkspace)).Methods("POST")
+	workspaceRouter.Handle("/current", scoped(models.ScopeRead, s.HandleGetCurrentWorkspace)).Methods("GET")
+	workspaceRouter.Handle("/{id}", scoped(models.ScopeAdmin, s.HandleUpdateWorkspace)).Methods("PUT")
+
+	auditRouter := parentRouter.PathPrefix("/audit").Subrouter()
+	auditRouter.Use(jsonMiddleware)
+
+	auditRouter.Handle("", scoped(models.ScopeAdmin, s.HandleListAuditEvents)).Methods("GET")
+	auditRouter.Handle("/export", scoped(models.ScopeAdmin, s.HandleExportAuditEvents)).Methods("GET")
+
+	adminRouter := parentRouter.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(jsonMiddleware)
 
+	adminRouter.Handle("/circuit-breakers", scoped(models.ScopeAdmin, s.HandleListCircuitBreakers)).Methods("GET")
+	adminRouter.Handle("/secrets/rotate", scoped(models.ScopeAdmin, s.HandleRotateSecrets)).Methods("POST")
 }
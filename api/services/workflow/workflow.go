@@ -2,13 +2,26 @@ package workflow
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 
+	"workflow-code-test/api/internal/auth"
+	"workflow-code-test/api/internal/execution"
 	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/service"
+)
+
+const (
+	// idempotencyKeyHeader carries the key that makes retried executions run only once
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader marks execution responses replayed for a retry
+	idempotentReplayedHeader = "Idempotent-Replayed"
 )
 
 func (s *Service) HandleGetWorkflow(w http.ResponseWriter, r *http.Request) {
@@ -27,6 +40,9 @@ func (s *Service) HandleGetWorkflow(w http.ResponseWriter, r *http.Request) {
 	workflow, err := s.workflowService.GetWorkflowWithNodesAndEdges(r.Context(), workflowID)
 	if err != nil {
 		slog.Error("Failed to get workflow", "id", id, "error", err)
+		if writeWorkflowAccessError(w, err) {
+			return
+		}
 		http.Error(w, "Workflow not found", http.StatusNotFound)
 		return
 	}
@@ -42,6 +58,66 @@ func (s *Service) HandleGetWorkflow(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Service) HandleSaveWorkflow(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	slog.Debug("Saving workflow definition for id", "id", id)
+
+	// Parse workflow ID
+	workflowID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("Invalid workflow ID", "id", id, "error", err)
+		http.Error(w, "Invalid workflow ID", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	var workflowRequest models.WorkflowRequest
+	if err := json.NewDecoder(r.Body).Decode(&workflowRequest); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// The path is the source of truth for which workflow is being saved
+	if workflowRequest.ID != "" && workflowRequest.ID != workflowID.String() {
+		slog.Error("Workflow ID mismatch", "id", id, "bodyId", workflowRequest.ID)
+		http.Error(w, "Workflow ID in body does not match path", http.StatusBadRequest)
+		return
+	}
+	workflowRequest.ID = workflowID.String()
+
+	if err := s.workflowService.SaveWorkflowFromRequest(r.Context(), &workflowRequest); err != nil {
+		var validationErrors models.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			slog.Debug("Workflow failed validation", "id", id, "errors", validationErrors)
+			writeValidationErrors(w, "Workflow validation failed", validationErrors)
+			return
+		}
+		if writeWorkflowAccessError(w, err) {
+			return
+		}
+
+		slog.Error("Failed to save workflow", "id", id, "error", err)
+		http.Error(w, "Failed to save workflow", http.StatusInternalServerError)
+		return
+	}
+
+	// Return the workflow as it is now stored
+	workflow, err := s.workflowService.GetWorkflowWithNodesAndEdges(r.Context(), workflowID)
+	if err != nil {
+		slog.Error("Failed to get saved workflow", "id", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(workflow); err != nil {
+		slog.Error("Failed to encode workflow response", "error", err)
+		return
+	}
+}
+
 func (s *Service) HandleExecuteWorkflow(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	slog.Debug("Handling workflow execution for id", "id", id)
@@ -57,48 +133,97 @@ func (s *Service) HandleExecuteWorkflow(w http.ResponseWriter, r *http.Request)
 	// Parse request body
 	var executeRequest models.ExecutionRequest
 	if err := json.NewDecoder(r.Body).Decode(&executeRequest); err != nil {
-		slog.Error("Failed to decode request body", "error", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeDecodeError(w, err)
 		return
 	}
 
-	// Get workflow definition from database
-	workflow, err := s.workflowService.GetWorkflowWithNodesAndEdges(r.Context(), workflowID)
-	if err != nil {
-		slog.Error("Failed to get workflow", "id", id, "error", err)
-		http.Error(w, "Workflow not found", http.StatusNotFound)
+	if err := executeRequest.Validate(); err != nil {
+		slog.Error("Invalid execution request", "id", id, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Execute workflow using the execution engine
-	executionResult, err := s.workflowService.ExecuteWorkflow(r.Context(), workflow, &executeRequest)
-	if err != nil {
-		slog.Error("Failed to execute workflow", "id", id, "error", err)
-		http.Error(w, "Workflow execution failed", http.StatusInternalServerError)
-		return
+	// Retries sending the same Idempotency-Key get the first request's result instead of executing again
+	if key := r.Header.Get(idempotencyKeyHeader); key != "" {
+		if err := models.ValidateIdempotencyKey(key); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		executeRequest.IdempotencyKey = key
 	}
 
-	// Save updated workflow positions if nodes and edges are provided in the request
-	if len(executeRequest.Nodes) > 0 || len(executeRequest.Edges) > 0 {
-		slog.Debug("Saving updated workflow positions", "nodeCount", len(executeRequest.Nodes), "edgeCount", len(executeRequest.Edges))
-		
-		workflowRequest := &models.WorkflowRequest{
-			ID:    id,
-			Name:  workflow.Name,
-			Nodes: executeRequest.Nodes,
-			Edges: executeRequest.Edges,
+	// Sensitive form values are masked in the response unless an admin asks to see them
+	ctx := r.Context()
+	if r.URL.Query().Get("reveal") == "true" {
+		identity := auth.IdentityFromContext(ctx)
+		if !identity.HasScope(models.ScopeAdmin) {
+			slog.Warn("Refused to reveal personal data", "id", id, "remoteAddr", r.RemoteAddr)
+			http.Error(w, "Revealing personal data requires the admin scope", http.StatusForbidden)
+			return
 		}
+		slog.Warn("Revealing personal data in execution response", "id", id, "subject", identity.Subject)
+		ctx = execution.WithPIIRevealed(ctx)
+	}
+
+	var executionResult *models.ExecutionResponse
 
-		if err := s.workflowService.SaveWorkflowFromRequest(r.Context(), workflowRequest); err != nil {
-			slog.Error("Failed to save updated workflow positions", "id", id, "error", err)
-			// Don't fail the execution if saving positions fails - just log it
-		} else {
-			slog.Debug("Successfully saved updated workflow positions", "id", id)
+	switch executeRequest.GetMode() {
+	case models.ExecutionModeAdHoc:
+		slog.Debug("Executing ad-hoc workflow definition", "id", id, "nodeCount", len(executeRequest.Nodes), "edgeCount", len(executeRequest.Edges))
+		executionResult, err = s.workflowService.ExecuteAdHocWorkflow(ctx, workflowID, &executeRequest)
+
+	default:
+		// Get workflow definition from database
+		workflow, getErr := s.workflowService.GetWorkflowWithNodesAndEdges(ctx, workflowID)
+		if getErr != nil {
+			slog.Error("Failed to get workflow", "id", id, "error", getErr)
+			if writeWorkflowAccessError(w, getErr) {
+				return
+			}
+			http.Error(w, "Workflow not found", http.StatusNotFound)
+			return
 		}
+
+		// Execute workflow using the execution engine
+		executionResult, err = s.workflowService.ExecuteWorkflow(ctx, workflow, &executeRequest)
+	}
+
+	if err != nil {
+		var validationErrors models.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			slog.Debug("Workflow failed validation", "id", id, "errors", validationErrors)
+			writeValidationErrors(w, "Workflow validation failed", validationErrors)
+			return
+		}
+
+		var formErr *models.FormValidationError
+		if errors.As(err, &formErr) {
+			slog.Debug("Form data failed validation", "id", id, "nodeId", formErr.NodeID, "errors", formErr.Errors)
+			writeFormValidationProblem(w, r, formErr)
+			return
+		}
+		if writeWorkflowAccessError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrExecutionQuotaExceeded) {
+			writeQuotaExceeded(w, err)
+			return
+		}
+		if writeIdempotencyError(w, err) {
+			return
+		}
+
+		slog.Error("Failed to execute workflow", "id", id, "error", err)
+		http.Error(w, "Workflow execution failed", http.StatusInternalServerError)
+		return
 	}
 
 	// Return execution result
 	w.Header().Set("Content-Type", "application/json")
+	if executionResult.Replayed {
+		slog.Debug("Replaying idempotent execution", "id", id)
+		w.Header().Set(idempotentReplayedHeader, "true")
+	}
 	w.WriteHeader(http.StatusOK)
 
 	if err := json.NewEncoder(w).Encode(executionResult); err != nil {
@@ -107,3 +232,172 @@ func (s *Service) HandleExecuteWorkflow(w http.ResponseWriter, r *http.Request)
 		return
 	}
 }
+
+func (s *Service) HandleValidateWorkflow(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Validating workflow definition")
+
+	// Parse request body
+	var workflowRequest models.WorkflowRequest
+	var validationErrors models.ValidationErrors
+	if err := json.NewDecoder(r.Body).Decode(&workflowRequest); err != nil {
+		// Node data that doesn't match its schema is reported like any other validation error
+		if !errors.As(err, &validationErrors) {
+			slog.Error("Failed to decode request body", "error", err)
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	} else {
+		validationErrors = s.workflowService.ValidateWorkflowRequest(&workflowRequest)
+	}
+	if validationErrors == nil {
+		validationErrors = models.ValidationErrors{}
+	}
+
+	// Return validation report, warnings included
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := validationReportResponse{
+		Valid:  len(validationErrors.Blocking()) == 0,
+		Errors: validationErrors,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Failed to encode validation report", "error", err)
+		return
+	}
+}
+
+func (s *Service) HandleListNodeTypes(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Listing node types")
+
+	nodeTypes := s.workflowService.ListNodeTypes()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(nodeTypes); err != nil {
+		slog.Error("Failed to encode node types", "error", err)
+		return
+	}
+}
+
+func (s *Service) HandleGetNodeTypeSchema(w http.ResponseWriter, r *http.Request) {
+	nodeType := mux.Vars(r)["type"]
+	slog.Debug("Getting node type schema", "type", nodeType)
+
+	schema, err := s.workflowService.GetNodeTypeSchema(nodeType)
+	if err != nil {
+		slog.Error("Failed to get node type schema", "type", nodeType, "error", err)
+		http.Error(w, "Node type not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(schema); err != nil {
+		slog.Error("Failed to encode node type schema", "error", err)
+		return
+	}
+}
+
+// validationReportResponse is the JSON body returned by the validate endpoint
+type validationReportResponse struct {
+	Valid  bool                     `json:"valid"`
+	Errors []models.ValidationError `json:"errors"`
+}
+
+// validationErrorResponse is the JSON body returned when a workflow definition fails validation
+type validationErrorResponse struct {
+	Message string                   `json:"message"`
+	Errors  []models.ValidationError `json:"errors"`
+}
+
+// writeValidationErrors writes a 400 response listing every validation error
+func writeValidationErrors(w http.ResponseWriter, message string, errs models.ValidationErrors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	if err := json.NewEncoder(w).Encode(validationErrorResponse{Message: message, Errors: errs}); err != nil {
+		slog.Error("Failed to encode validation errors", "error", err)
+	}
+}
+
+// writeDecodeError writes the response for a request body that couldn't be decoded. Node data that
+// doesn't match its schema is reported as validation errors with the offending paths.
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var validationErrors models.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		slog.Debug("Node data failed schema validation", "errors", validationErrors)
+		writeValidationErrors(w, "Invalid node data", validationErrors)
+		return
+	}
+
+	slog.Error("Failed to decode request body", "error", err)
+	http.Error(w, "Invalid request body", http.StatusBadRequest)
+}
+
+// writeIdempotencyError writes the response for an execution whose idempotency key can't be used, returning
+// false for any other error
+func writeIdempotencyError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrIdempotencyKeyInUse):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, service.ErrIdempotencyKeyRevealed):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		return false
+	}
+	return true
+}
+
+// formValidationProblemType identifies form validation problem responses
+const formValidationProblemType = "urn:workflow:problem:form-validation"
+
+// problemResponse is an RFC 7807 problem details body
+type problemResponse struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Extension members
+	NodeID string              `json:"nodeId,omitempty"`
+	Errors []models.FieldError `json:"errors,omitempty"`
+}
+
+// writeFormValidationProblem writes a 422 problem response listing every rejected form field,
+// with messages translated into the language requested by the Accept-Language header
+func writeFormValidationProblem(w http.ResponseWriter, r *http.Request, formErr *models.FormValidationError) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Content-Language", requestLocale(r))
+	w.WriteHeader(http.StatusUnprocessableEntity)
+
+	problem := problemResponse{
+		Type:     formValidationProblemType,
+		Title:    "Form data failed validation",
+		Status:   http.StatusUnprocessableEntity,
+		Detail:   fmt.Sprintf("%d form field(s) failed validation", len(formErr.Errors)),
+		Instance: r.URL.Path,
+		NodeID:   formErr.NodeID,
+		Errors:   formErr.Errors.Localize(requestLocale(r)),
+	}
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		slog.Error("Failed to encode problem response", "error", err)
+	}
+}
+
+// requestLocale picks the first language of the Accept-Language header that messages are translated into
+func requestLocale(r *http.Request) string {
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		language := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if models.SupportsLocale(language) {
+			return language
+		}
+	}
+	return models.DefaultLocale
+}
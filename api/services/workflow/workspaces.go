@@ -0,0 +1,115 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"workflow-code-test/api/internal/models"
+	"workflow-code-test/api/internal/repository"
+	"workflow-code-test/api/internal/service"
+)
+
+func (s *Service) HandleListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Listing workspaces")
+
+	workspaces, err := s.workspaceService.ListWorkspaces(r.Context())
+	if err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, workspaces)
+}
+
+func (s *Service) HandleCreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	var workspaceRequest models.WorkspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&workspaceRequest); err != nil {
+		slog.Error("Failed to decode request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	slog.Debug("Creating workspace", "slug", workspaceRequest.Slug)
+
+	if err := workspaceRequest.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workspace, err := s.workspaceService.CreateWorkspace(r.Context(), &workspaceRequest)
+	if err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, workspace)
+}
+
+func (s *Service) HandleUpdateWorkspace(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	slog.Debug("Updating workspace", "id", id)
+
+	workspaceID, err := uuid.Parse(id)
+	if err != nil {
+		http.Error(w, "Invalid workspace ID", http.StatusBadRequest)
+		return
+	}
+
+	var workspaceRequest models.WorkspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&workspaceRequest); err != nil {
+		slog.Error("Failed to decode request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := workspaceRequest.ValidateUpdate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workspace, err := s.workspaceService.UpdateWorkspace(r.Context(), workspaceID, &workspaceRequest)
+	if err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, workspace)
+}
+
+func (s *Service) HandleGetCurrentWorkspace(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Getting current workspace")
+
+	usage, err := s.workspaceService.GetUsage(r.Context())
+	if err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, usage)
+}
+
+// writeWorkspaceError maps workspace service errors to responses
+func writeWorkspaceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrWorkspaceNotFound):
+		http.Error(w, "Workspace not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrWorkspaceExists):
+		http.Error(w, "Workspace already exists", http.StatusConflict)
+	default:
+		slog.Error("Workspace operation failed", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// writeQuotaExceeded writes a 429 response telling the caller to retry once the daily quota resets
+func writeQuotaExceeded(w http.ResponseWriter, err error) {
+	now := time.Now()
+	retryAfter := models.QuotaDay(now).AddDate(0, 0, 1).Sub(now)
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+	http.Error(w, err.Error(), http.StatusTooManyRequests)
+}
@@ -30,6 +30,7 @@ function App() {
     execute,
     results: executionResults,
     loading: isExecuting,
+    fieldErrors,
     resetExecuteResult,
   } = useExecuteWorkflow(WORKFLOW_ID);
 
@@ -98,7 +99,11 @@ function App() {
           }}
         >
           {!executionResults ? (
-            <UserInputForm onExecute={handleExecute} isExecuting={isExecuting} />
+            <UserInputForm
+              onExecute={handleExecute}
+              isExecuting={isExecuting}
+              serverErrors={fieldErrors}
+            />
           ) : (
             <ExecutionResultsComponent results={executionResults} formData={formData} />
           )}
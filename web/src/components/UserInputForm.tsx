@@ -1,4 +1,4 @@
-import React from 'react';
+import React, { useEffect } from 'react';
 import { Controller, useForm } from 'react-hook-form';
 
 import { zodResolver } from '@hookform/resolvers/zod';
@@ -7,6 +7,8 @@ import { z } from 'zod/v4';
 import { InfoCircledIcon } from '@radix-ui/react-icons';
 import { Box, Button, Callout, Card, Code, Flex, Select, Text, TextField } from '@radix-ui/themes';
 
+import type { FieldError } from '../hooks/useExecuteWorkflow';
+
 const CITY_OPTIONS = [
   { value: 'Sydney', label: 'Sydney', flag: '🇦🇺' },
   { value: 'Melbourne', label: 'Melbourne', flag: '🇦🇺' },
@@ -45,15 +47,22 @@ type WorkflowFormData = z.infer<typeof workflowFormSchema>;
 interface UserInputFormProps {
   onExecute: (formData: WorkflowFormData) => Promise<void>;
   isExecuting: boolean;
+  // Field errors reported by the server after executing
+  serverErrors?: FieldError[];
 }
 
-export const UserInputForm: React.FC<UserInputFormProps> = ({ onExecute, isExecuting }) => {
+export const UserInputForm: React.FC<UserInputFormProps> = ({
+  onExecute,
+  isExecuting,
+  serverErrors = [],
+}) => {
   const {
     register,
     handleSubmit,
     formState: { errors },
     control,
     watch,
+    setError,
   } = useForm<WorkflowFormData>({
     resolver: zodResolver(workflowFormSchema),
     defaultValues: {
@@ -69,6 +78,17 @@ export const UserInputForm: React.FC<UserInputFormProps> = ({ onExecute, isExecu
 
   const watchedValues = watch();
 
+  useEffect(() => {
+    for (const fieldError of serverErrors) {
+      if (fieldError.field in workflowFormSchema.shape) {
+        setError(fieldError.field as keyof WorkflowFormData, {
+          type: fieldError.rule,
+          message: fieldError.message,
+        });
+      }
+    }
+  }, [serverErrors, setError]);
+
   const operatorLabels = {
     greater_than: 'is greater than',
     less_than: 'is less than',
@@ -142,6 +142,21 @@ export const WorkflowNode: React.FC<NodeProps> = ({ data, type, selected }) => {
           }}
         />
       )}
+
+      {/* Error edges are followed when the API call fails */}
+      {type === 'integration' && (
+        <Handle
+          type="source"
+          position={Position.Bottom}
+          id="error"
+          style={{
+            background: 'var(--red-9)',
+            border: 'none',
+            width: '8px',
+            height: '8px',
+          }}
+        />
+      )}
     </Box>
   );
 };
@@ -0,0 +1,148 @@
+// Code generated by api/scripts/gentypes from the node data JSON Schemas. DO NOT EDIT.
+
+export type HandleConfig = {
+  source: boolean;
+  target: boolean;
+};
+
+export type StartNodeMetadata = {
+  hasHandles: HandleConfig;
+  outputVariables?: string[] | null;
+  variableTypes?: Record<string, string> | null;
+};
+
+export type StartNodeData = {
+  description: string;
+  label: string;
+  metadata: StartNodeMetadata;
+};
+
+export type FormField = {
+  name: string;
+  default?: unknown;
+  helpText?: string;
+  label?: string;
+  options?: string[] | null;
+  placeholder?: string;
+  required?: boolean;
+  sensitive?: boolean;
+  type?: string;
+  validation?: string[] | null;
+};
+
+export type FormRuleCondition = {
+  equals: unknown;
+  field: string;
+};
+
+export type FormRule = {
+  fields: string[] | null;
+  rule: string;
+  when?: FormRuleCondition;
+};
+
+export type FormNodeMetadata = {
+  hasHandles: HandleConfig;
+  inputFields: (string | FormField)[] | null;
+  outputVariables: string[] | null;
+  rules?: FormRule[] | null;
+  variableTypes?: Record<string, string> | null;
+};
+
+export type FormNodeData = {
+  description: string;
+  label: string;
+  metadata: FormNodeMetadata;
+};
+
+export type LocationOption = {
+  city: string;
+  lat: number;
+  lon: number;
+};
+
+export type RetryPolicy = {
+  maxAttempts: number;
+  backoffMs?: number;
+};
+
+export type IntegrationNodeMetadata = {
+  apiEndpoint: string;
+  hasHandles: HandleConfig;
+  inputVariables: string[] | null;
+  options: LocationOption[] | null;
+  outputVariables: string[] | null;
+  cacheTtlSeconds?: number;
+  headers?: Record<string, string> | null;
+  retry?: RetryPolicy;
+  temperatureUnit?: string;
+  variableTypes?: Record<string, string> | null;
+  windSpeedUnit?: string;
+};
+
+export type IntegrationNodeData = {
+  description: string;
+  label: string;
+  metadata: IntegrationNodeMetadata;
+};
+
+export type HandleConfigWithBranches = {
+  source: string[] | null;
+  target: boolean;
+};
+
+export type ConditionNodeMetadata = {
+  conditionExpression: string;
+  hasHandles: HandleConfigWithBranches;
+  outputVariables: string[] | null;
+  inputVariables?: string[] | null;
+  variableTypes?: Record<string, string> | null;
+};
+
+export type ConditionNodeData = {
+  description: string;
+  label: string;
+  metadata: ConditionNodeMetadata;
+};
+
+export type EmailTemplate = {
+  body: string;
+  subject: string;
+};
+
+export type EmailNodeMetadata = {
+  emailTemplate: EmailTemplate;
+  hasHandles: HandleConfig;
+  inputVariables: string[] | null;
+  outputVariables: string[] | null;
+  variableTypes?: Record<string, string> | null;
+};
+
+export type EmailNodeData = {
+  description: string;
+  label: string;
+  metadata: EmailNodeMetadata;
+};
+
+export type EndNodeMetadata = {
+  hasHandles: HandleConfig;
+  inputVariables?: string[] | null;
+  variableTypes?: Record<string, string> | null;
+};
+
+export type EndNodeData = {
+  description: string;
+  label: string;
+  metadata: EndNodeMetadata;
+};
+
+export type NodeDataByType = {
+  condition: ConditionNodeData;
+  email: EmailNodeData;
+  end: EndNodeData;
+  form: FormNodeData;
+  integration: IntegrationNodeData;
+  start: StartNodeData;
+};
+
+export type NodeType = keyof NodeDataByType;
@@ -6,29 +6,61 @@ interface ExecuteError {
   message: string;
 }
 
+// A rejected form field from a 422 problem response
+export interface FieldError {
+  field: string;
+  // Every field involved in a cross-field rule
+  fields?: string[];
+  rule: string;
+  message: string;
+  value: unknown;
+}
+
+interface ProblemDetails {
+  title: string;
+  detail?: string;
+  errors?: FieldError[];
+}
+
 export function useExecuteWorkflow(id: string) {
   const [results, setResults] = useState<ExecutionResults | null>(null);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
+  const [fieldErrors, setFieldErrors] = useState<FieldError[]>([]);
 
   async function execute(formData: WorkflowFormData, nodes: WorkflowNode[], edges: WorkflowEdge[]) {
     setLoading(true);
     setError(null);
+    setFieldErrors([]);
     setResults(null);
 
+    // One key per run, so a retried request replays the run instead of sending its emails again
+    const idempotencyKey = crypto.randomUUID();
+
     try {
       const res = await fetch(`/api/v1/workflows/${id}/execute`, {
         method: 'POST',
-        headers: { 'Content-Type': 'application/json' },
+        headers: {
+          'Content-Type': 'application/json',
+          'Accept-Language': navigator.language,
+          'Idempotency-Key': idempotencyKey,
+        },
         body: JSON.stringify({
+          // Run exactly what is on the canvas without persisting it
+          mode: 'adhoc',
           formData,
           condition: { operator: formData.operator, threshold: formData.threshold },
           nodes,
           edges,
         }),
       });
+      if (res.status === 422) {
+        const problem = (await res.json().catch(() => ({}))) as Partial<ProblemDetails>;
+        setFieldErrors(problem.errors ?? []);
+        throw new Error(problem.detail || problem.title || 'Form data failed validation');
+      }
       if (!res.ok) {
-        const errBody = (await res.json()) as ExecuteError;
+        const errBody = (await res.json().catch(() => ({}))) as Partial<ExecuteError>;
         throw new Error(errBody.message || `Execute failed (${res.status})`);
       }
       const data = (await res.json()) as ExecutionResults;
@@ -44,5 +76,12 @@ export function useExecuteWorkflow(id: string) {
     }
   }
 
-  return { execute, results, loading, error, resetExecuteResult: () => setResults(null) };
+  return {
+    execute,
+    results,
+    loading,
+    error,
+    fieldErrors,
+    resetExecuteResult: () => setResults(null),
+  };
 }
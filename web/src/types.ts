@@ -1,3 +1,5 @@
+import type { NodeDataByType, NodeType } from './generated/nodeData';
+
 export interface WorkflowFormData {
   name: string;
   email: string;
@@ -11,19 +13,15 @@ export interface WorkflowFormData {
   threshold: number;
 }
 
-interface NodeData {
-  label: string;
-  description: string;
-  type?: string;
-  [key: string]: unknown;
-}
-
-export interface WorkflowNode {
-  id: string;
-  type: string;
-  position: { x: number; y: number };
-  data: NodeData;
-}
+// A node of each type carries that type's generated data, so the editor and the API validate the same shape
+export type WorkflowNode = {
+  [T in NodeType]: {
+    id: string;
+    type: T;
+    position: { x: number; y: number };
+    data: NodeDataByType[T];
+  };
+}[NodeType];
 
 export interface WorkflowEdge {
   id: string;